@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"solivra-go/backend/internal/config"
+	"solivra-go/backend/internal/database"
+	"solivra-go/backend/internal/logging"
+	"solivra-go/backend/internal/services"
+	"solivra-go/backend/internal/store"
+)
+
+// runBackfill membangun ulang koleksi streaksummaries untuk semua habit yang punya streak.
+// Aman dijalankan berulang kali: setiap ringkasan di-upsert dari data relapse terbaru.
+func runBackfill(stores *store.Stores) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	habits, err := stores.Habits.ListWithStreak(ctx)
+	if err != nil {
+		logging.Fatal("failed to list habits with streak", "err", err)
+	}
+
+	refreshed, failed := 0, 0
+	for _, habit := range habits {
+		habitCtx, habitCancel := context.WithTimeout(ctx, 10*time.Second)
+		err := stores.Tx.WithTransaction(habitCtx, func(txCtx context.Context) error {
+			return services.RefreshStreakSummary(txCtx, stores, habit.UserID, habit.ID)
+		})
+		habitCancel()
+		if err != nil {
+			slog.Error("failed to backfill streak summary", "habit_id", habit.ID.Hex(), "err", err)
+			failed++
+			continue
+		}
+		refreshed++
+	}
+
+	slog.Info("streak summaries backfilled", "refreshed", refreshed, "failed", failed)
+}
+
+func main() {
+	// 1. Init Config
+	cfg := config.Load()
+	logging.Setup(cfg.LogFormat, cfg.LogLevel)
+
+	// 2. Connect Database
+	db := database.ConnectDB(cfg.MongoURI, cfg.MongoDBName)
+
+	// 3. Run Backfill
+	runBackfill(store.NewMongo(db))
+}
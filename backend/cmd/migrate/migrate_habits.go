@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"solivra-go/backend/internal/config"
+	"solivra-go/backend/internal/database"
+	"solivra-go/backend/internal/logging"
+	"solivra-go/backend/internal/services"
+	"solivra-go/backend/internal/store"
+)
+
+// runMigrateHabits mengubah streak lama setiap user (users.streak_start_date dan
+// longest_streak_seconds) menjadi habit default, memasangkan relapse lama ke habit
+// tersebut, lalu membuang ringkasan streak format lama (satu per user).
+// Aman dijalankan berulang kali: user yang sudah punya habit default dilewati.
+func runMigrateHabits(stores *store.Stores) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	users, err := stores.Users.List(ctx)
+	if err != nil {
+		logging.Fatal("failed to list users", "err", err)
+	}
+
+	migrated, failed := 0, 0
+	for i := range users {
+		user := &users[i]
+		userCtx, userCancel := context.WithTimeout(ctx, 10*time.Second)
+		err := stores.Tx.WithTransaction(userCtx, func(txCtx context.Context) error {
+			habit, err := services.EnsureDefaultHabit(txCtx, stores, user)
+			if err != nil {
+				return err
+			}
+			// Relapse yang dibuat di antara deploy dan migrasi juga ikut dipasangkan
+			if _, err := stores.Relapses.AssignHabit(txCtx, user.ID, habit.ID); err != nil {
+				return err
+			}
+			return services.RefreshStreakSummary(txCtx, stores, user.ID, habit.ID)
+		})
+		userCancel()
+		if err != nil {
+			slog.Error("failed to migrate habits", "user_id", user.ID.Hex(), "username", user.Username, "err", err)
+			failed++
+			continue
+		}
+		migrated++
+	}
+
+	removed, err := stores.StreakSummaries.DeleteLegacy(ctx)
+	if err != nil {
+		logging.Fatal("failed to delete legacy streak summaries", "err", err)
+	}
+
+	slog.Info("habits migrated", "migrated", migrated, "failed", failed, "legacy_summaries_removed", removed)
+}
+
+func main() {
+	// 1. Init Config
+	cfg := config.Load()
+	logging.Setup(cfg.LogFormat, cfg.LogLevel)
+
+	// 2. Connect Database
+	db := database.ConnectDB(cfg.MongoURI, cfg.MongoDBName)
+
+	// 3. Run Migration
+	runMigrateHabits(store.NewMongo(db))
+}
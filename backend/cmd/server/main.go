@@ -1,145 +1,76 @@
 package main
 
 import (
-	"log"
-	"time"
-
-	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/helmet"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
-	"github.com/gofiber/fiber/v2/middleware/logger"
-	"github.com/gofiber/fiber/v2/middleware/recover"
+	"context"
+	"log/slog"
+	"net/http"
+	_ "time/tzdata" // Image alpine tidak membawa zoneinfo; dibutuhkan untuk impor dengan timezone
 
+	"solivra-go/backend/internal/captcha"
 	"solivra-go/backend/internal/config"
 	"solivra-go/backend/internal/database"
-	"solivra-go/backend/internal/handlers"
-	"solivra-go/backend/internal/middleware"
-	"solivra-go/backend/pkg/utils"
+	"solivra-go/backend/internal/jobs"
+	"solivra-go/backend/internal/logging"
+	"solivra-go/backend/internal/metrics"
+	"solivra-go/backend/internal/server"
+	"solivra-go/backend/internal/storage"
+	"solivra-go/backend/internal/store"
 )
 
 func main() {
 	// 1. Init Config
 	cfg := config.Load() // FIXED: cfg sekarang menerima return dari Load()
+	logging.Setup(cfg.LogFormat, cfg.LogLevel)
 
 	// 2. Connect Database
-	database.ConnectDB(cfg.MongoURI, cfg.MongoDBName) // FIXED: ConnectDB sekarang huruf kapital
+	db := database.ConnectDB(cfg.MongoURI, cfg.MongoDBName) // FIXED: ConnectDB sekarang huruf kapital
 
-	// 3. Init Cloudinary (Wajib untuk upload file)
-	utils.InitCloudinary(cfg.CloudinaryCloudName, cfg.CloudinaryAPIKey, cfg.CloudinaryAPISecret)
+	// 3. Init storage foto profil (Cloudinary, disk lokal, atau S3 sesuai STORAGE_DRIVER)
+	files, err := storage.New(cfg)
+	if err != nil {
+		logging.Fatal("failed to initialize storage", "driver", cfg.StorageDriver, "err", err)
+	}
+	slog.Info("storage initialized", "driver", cfg.StorageDriver)
+
+	// 4. Daftarkan job terjadwal (purge, evaluasi badge, pembersihan log/sesi)
+	stores := store.NewMongo(db)
+	runner := jobs.NewRunner(stores.Jobs, jobs.InstanceID())
+	for _, job := range jobs.Builtin(stores, cfg) {
+		if err := runner.Register(job); err != nil {
+			logging.Fatal("failed to register job", "err", err)
+		}
+	}
 
-	// 4. Setup Fiber App
-	app := fiber.New(fiber.Config{
-		AppName:   "Solivra Go Backend",
-		BodyLimit: 10 * 1024 * 1024,
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			code := fiber.StatusInternalServerError
-			if e, ok := err.(*fiber.Error); ok {
-				code = e.Code
-			}
-			// PASTIKAN MENGEMBALIKAN "msg" UNTUK KONSISTENSI DENGAN NODE.JS
-			return c.Status(code).JSON(fiber.Map{
-				"ok":  false,
-				"msg": err.Error(),
-			})
-		},
+	// 5. Setup Fiber App (middleware + routes ada di internal/server)
+	app := server.New(cfg, server.Deps{
+		Stores:  stores,
+		Captcha: captcha.FromConfig(cfg),
+		Jobs:    runner,
+		Storage: files,
+		Mongo:   db,
 	})
 
-	// 5. Middlewares Global
-	app.Use(logger.New())
-	app.Use(recover.New())
-	app.Use(helmet.New())
-
-	app.Use(cors.New(cors.Config{
-		AllowOrigins:     cfg.CorsAllowedOrigins,
-		AllowCredentials: true,
-		AllowMethods:     "GET,POST,HEAD,PUT,DELETE,PATCH,OPTIONS",
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-Requested-With, X-Session-Token",
-	}))
-
-	// Rate Limiting (can be disabled or configured via env vars)
-	if cfg.RateLimitMax > 0 {
-		app.Use(limiter.New(limiter.Config{
-			Max:        cfg.RateLimitMax,
-			Expiration: time.Duration(cfg.RateLimitExpiration) * time.Minute,
-			KeyGenerator: func(c *fiber.Ctx) string {
-				return utils.GetClientIP(c)
-			},
-			LimitReached: func(c *fiber.Ctx) error {
-				return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-					"ok":  false,
-					"msg": "Too many requests, please try again later.",
-				})
-			},
-		}))
+	// 6. Jalankan scheduler; hanya satu replica (pemegang lease) yang mengeksekusi job
+	if cfg.DisableJobs {
+		slog.Info("job scheduler disabled (DISABLE_JOBS)")
 	} else {
-		log.Println("⚠️  Rate limiting is DISABLED (RATE_LIMIT_MAX=0)")
+		go runner.Start(context.Background())
 	}
 
-	app.Use(middleware.ActivityLoggerMiddleware)
-
-	// 6. Routes
-	api := app.Group("/api")
-
-	// Health Check
-	api.Get("/health", handlers.HealthCheck)
-	api.Post("/track-visit", handlers.TrackVisit)
-
-	// Auth Routes
-	auth := api.Group("/auth")
-	auth.Post("/login", handlers.Login)
-	auth.Post("/register", handlers.Register)
-	auth.Post("/refresh", handlers.Refresh)
-	auth.Post("/logout", handlers.Logout)
-
-	// Honeypot (Public)
-	honeypot := api.Group("/honeypot")
-	honeypot.Post("/admin-login", handlers.FakeAdminLogin) // FIXED: Nama handler Kapital
-	honeypot.Get("/admin-access", handlers.LogAdminAccess) // FIXED: Nama handler Kapital
-
-	// Public user utilities
-	publicUsers := api.Group("/users")
-	publicUsers.Get("/check-username/:username", handlers.CheckUsername)
-
-	// Protected Routes (Need Valid Session)
-	api.Use(middleware.Protected())
-
-	// User Routes
-	users := api.Group("/users")
-	users.Get("/me", handlers.GetMe)
-	users.Put("/profile", handlers.UpdateProfile)
-	users.Put("/password", handlers.UpdatePassword)
-	users.Put("/language", handlers.UpdateLanguage)
-	users.Delete("/profile-picture", handlers.RemoveProfilePicture)
-	users.Delete("/", handlers.DeleteAccount)
-	users.Get("/sessions", handlers.GetSessions)
-	users.Delete("/sessions/:id", handlers.RevokeSession)
-	users.Post("/start-streak", handlers.StartStreak)
-
-	// Relapse Routes
-	relapses := api.Group("/relapses")
-	relapses.Get("/", handlers.GetRelapses)
-	relapses.Post("/", handlers.CreateRelapse)
-	relapses.Post("/sync", handlers.SyncRelapse)
-	relapses.Put("/:id", handlers.UpdateRelapse)
-	relapses.Delete("/:id", handlers.DeleteRelapse)
-	relapses.Delete("/", handlers.DeleteAllRelapses)
-
-	// Stats Routes
-	stats := api.Group("/stats")
-	stats.Get("/", handlers.GetStats)
-	stats.Get("/rankings", handlers.GetRankings)
-
-	// Admin Routes (Protected + Admin Role Check)
-	admin := api.Group("/admin", middleware.AdminOnly())
-	admin.Get("/dashboard", handlers.GetAdminDashboard)
-	admin.Get("/logs", handlers.GetAdminLogs)
-	admin.Get("/rankings", handlers.GetAdminRankings)
-
-	// Honeypot Stats (Admin only)
-	honeypotAdmin := api.Group("/honeypot", middleware.AdminOnly())
-	honeypotAdmin.Get("/stats", handlers.GetHoneypotStats)
+	// 7. Listener metrik terpisah (hanya untuk jaringan internal, tanpa token)
+	if cfg.MetricsAddr != "" {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", metrics.Handler())
+			slog.Info("metrics listener started", "addr", cfg.MetricsAddr)
+			if err := http.ListenAndServe(cfg.MetricsAddr, mux); err != nil {
+				logging.Fatal("metrics listener stopped", "addr", cfg.MetricsAddr, "err", err)
+			}
+		}()
+	}
 
-	// 7. Start Server
-	log.Fatal(app.Listen(":" + cfg.Port))
+	// 8. Start Server
+	if err := app.Listen(":" + cfg.Port); err != nil {
+		logging.Fatal("server stopped", "err", err)
+	}
 }
@@ -10,14 +10,15 @@ import (
 	"solivra-go/backend/internal/database"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // runDowngrade mengandung logika utama skrip
-func runDowngrade(cfg *config.Config) {
+func runDowngrade(cfg *config.Config, db *mongo.Database) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	usersColl := database.GetCollection("users")
+	usersColl := db.Collection("users")
 
 	// Filter logika dari backend/scripts/downgrade_admins.js
 	whitelist := cfg.AdminEmails
@@ -50,8 +51,8 @@ func main() {
 	cfg := config.Load()
 
 	// 2. Connect Database
-	database.ConnectDB(cfg.MongoURI, cfg.MongoDBName)
+	db := database.ConnectDB(cfg.MongoURI, cfg.MongoDBName)
 
 	// 3. Run Downgrade
-	runDowngrade(cfg)
+	runDowngrade(cfg, db)
 }
@@ -0,0 +1,43 @@
+// internal/captcha/captcha.go
+package captcha
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"solivra-go/backend/internal/config"
+)
+
+var (
+	// ErrMissingToken dikembalikan jika klien tidak mengirim token captcha.
+	ErrMissingToken = errors.New("captcha token missing")
+	// ErrInvalidToken dikembalikan jika token ditolak oleh penyedia captcha.
+	ErrInvalidToken = errors.New("captcha token invalid")
+)
+
+// Verifier memverifikasi token captcha yang dikirim klien.
+// Error selain ErrMissingToken/ErrInvalidToken berarti penyedia captcha tidak bisa dihubungi.
+type Verifier interface {
+	Verify(ctx context.Context, token, remoteIP string) error
+}
+
+// FromConfig memilih verifier berdasarkan CAPTCHA_PROVIDER.
+// Mengembalikan nil jika captcha dinonaktifkan.
+func FromConfig(cfg *config.Config) Verifier {
+	switch strings.ToLower(cfg.CaptchaProvider) {
+	case "turnstile":
+		if cfg.CloudflareTurnstileSecret == "" {
+			slog.Warn("CAPTCHA_PROVIDER=turnstile but CLOUDFLARE_TURNSTILE_SECRET_KEY is empty, captcha DISABLED")
+			return nil
+		}
+		return NewTurnstile(cfg.CloudflareTurnstileSecret)
+	case "fake":
+		slog.Warn("captcha is using the FAKE verifier (dev/test only)")
+		return &FakeVerifier{}
+	default:
+		slog.Warn("captcha verification is DISABLED (CAPTCHA_PROVIDER=none)")
+		return nil
+	}
+}
@@ -0,0 +1,23 @@
+// internal/captcha/fake.go
+package captcha
+
+import "context"
+
+// FakeRejectToken adalah token yang selalu ditolak oleh FakeVerifier.
+const FakeRejectToken = "fail"
+
+// FakeVerifier menerima semua token yang tidak kosong kecuali FakeRejectToken.
+// Dipakai untuk pengujian tanpa akses ke Cloudflare. Frontend tanpa site key tidak
+// mengirim token, jadi untuk pengembangan lokal tanpa captcha pakai provider none.
+type FakeVerifier struct{}
+
+// Verify mensimulasikan verifikasi tanpa panggilan jaringan.
+func (FakeVerifier) Verify(_ context.Context, token, _ string) error {
+	switch token {
+	case "":
+		return ErrMissingToken
+	case FakeRejectToken:
+		return ErrInvalidToken
+	}
+	return nil
+}
@@ -1,12 +1,15 @@
 package config
 
 import (
-	"log"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+
+	"solivra-go/backend/internal/logging"
 )
 
 // Config holds all environment variables
@@ -22,10 +25,30 @@ type Config struct {
 	CloudinaryAPISecret       string
 	CloudflareTurnstileKey    string
 	CloudflareTurnstileSecret string
+	CaptchaProvider           string // turnstile | fake | none (default: turnstile jika secret diisi)
 	AdminEmails               []string
 	DisableIPLockout          bool   // Disable IP lockout for testing/benchmark
 	RateLimitMax              int    // Max requests per minute (0 = disable, default: 200)
 	RateLimitExpiration       int    // Expiration in minutes (default: 1)
+	ExportDir                 string // Folder arsip ekspor data user, harus volume bersama jika lebih dari satu replica (default: <tmp>/solivra-exports)
+	RelapseRetentionDays      int    // Lama relapse terhapus bisa dipulihkan sebelum dihapus permanen (default: 30)
+	DisableJobs               bool   // Jangan jalankan job terjadwal di replica ini
+	ActivityLogRetentionDays  int    // Umur maksimal activity log sebelum dihapus job (default: 365)
+	AuthAttemptRetentionDays  int    // Umur maksimal login/registration attempt (default: 90)
+	SessionRetentionDays      int    // Lama sesi yang di-revoke disimpan sebelum dihapus (default: 30)
+	StorageDriver             string // cloudinary | local | s3 (default: cloudinary jika CLOUDINARY_CLOUD_NAME diisi, selain itu local)
+	StorageLocalDir           string // Folder file driver local (default: uploads)
+	StoragePublicURL          string // Base URL publik file local/s3 (default local: http://localhost:<PORT>)
+	S3Endpoint                string // Host S3-compatible tanpa skema, misal s3.amazonaws.com
+	S3Region                  string
+	S3Bucket                  string
+	S3AccessKeyID             string
+	S3SecretAccessKey         string
+	S3UseSSL                  bool   // default: true, set S3_USE_SSL=false untuk MinIO lokal
+	LogFormat                 string // text | json (default: json jika NODE_ENV=production, selain itu text)
+	LogLevel                  string // debug | info | warn | error (default: info)
+	MetricsToken              string // Bearer token untuk /metrics di port utama (kosong = tidak disajikan)
+	MetricsAddr               string // Listener terpisah khusus /metrics tanpa token, misal :9100 (kosong = nonaktif)
 }
 
 var appConfig *Config
@@ -74,6 +97,45 @@ func Load() *Config {
 		}
 	}
 
+	// Parse RELAPSE_RETENTION_DAYS (default: 30)
+	relapseRetentionDays := 30
+	if envRetention := os.Getenv("RELAPSE_RETENTION_DAYS"); envRetention != "" {
+		if parsed, err := strconv.Atoi(envRetention); err == nil && parsed > 0 {
+			relapseRetentionDays = parsed
+		}
+	}
+
+	// Parse DISABLE_JOBS (default: false)
+	disableJobs := os.Getenv("DISABLE_JOBS")
+	disableJobsBool := disableJobs == "true" || disableJobs == "1"
+
+	// Parse ACTIVITY_LOG_RETENTION_DAYS (default: 365)
+	activityLogRetentionDays := 365
+	if envRetention := os.Getenv("ACTIVITY_LOG_RETENTION_DAYS"); envRetention != "" {
+		if parsed, err := strconv.Atoi(envRetention); err == nil && parsed > 0 {
+			activityLogRetentionDays = parsed
+		}
+	}
+
+	// Parse AUTH_ATTEMPT_RETENTION_DAYS (default: 90)
+	authAttemptRetentionDays := 90
+	if envRetention := os.Getenv("AUTH_ATTEMPT_RETENTION_DAYS"); envRetention != "" {
+		if parsed, err := strconv.Atoi(envRetention); err == nil && parsed > 0 {
+			authAttemptRetentionDays = parsed
+		}
+	}
+
+	// Parse SESSION_RETENTION_DAYS (default: 30)
+	sessionRetentionDays := 30
+	if envRetention := os.Getenv("SESSION_RETENTION_DAYS"); envRetention != "" {
+		if parsed, err := strconv.Atoi(envRetention); err == nil && parsed > 0 {
+			sessionRetentionDays = parsed
+		}
+	}
+
+	// Parse S3_USE_SSL (default: true)
+	s3UseSSL := os.Getenv("S3_USE_SSL") != "false" && os.Getenv("S3_USE_SSL") != "0"
+
 	cfg := &Config{
 		Port:                      os.Getenv("PORT"),
 		MongoURI:                  mongoURI,
@@ -86,19 +148,76 @@ func Load() *Config {
 		CloudinaryAPISecret:       os.Getenv("CLOUDINARY_API_SECRET"),
 		CloudflareTurnstileKey:    os.Getenv("CLOUDFLARE_TURNSTILE_SITE_KEY"),
 		CloudflareTurnstileSecret: os.Getenv("CLOUDFLARE_TURNSTILE_SECRET_KEY"),
+		CaptchaProvider:           os.Getenv("CAPTCHA_PROVIDER"),
 		DisableIPLockout:          disableIPLockoutBool,
 		RateLimitMax:              rateLimitMax,
 		RateLimitExpiration:       rateLimitExpiration,
+		ExportDir:                 os.Getenv("EXPORT_DIR"),
+		RelapseRetentionDays:      relapseRetentionDays,
+		DisableJobs:               disableJobsBool,
+		ActivityLogRetentionDays:  activityLogRetentionDays,
+		AuthAttemptRetentionDays:  authAttemptRetentionDays,
+		SessionRetentionDays:      sessionRetentionDays,
+		StorageDriver:             strings.ToLower(os.Getenv("STORAGE_DRIVER")),
+		StorageLocalDir:           os.Getenv("STORAGE_LOCAL_DIR"),
+		StoragePublicURL:          os.Getenv("STORAGE_PUBLIC_URL"),
+		S3Endpoint:                os.Getenv("S3_ENDPOINT"),
+		S3Region:                  os.Getenv("S3_REGION"),
+		S3Bucket:                  os.Getenv("S3_BUCKET"),
+		S3AccessKeyID:             os.Getenv("S3_ACCESS_KEY_ID"),
+		S3SecretAccessKey:         os.Getenv("S3_SECRET_ACCESS_KEY"),
+		S3UseSSL:                  s3UseSSL,
+		LogFormat:                 strings.ToLower(os.Getenv("LOG_FORMAT")),
+		LogLevel:                  strings.ToLower(os.Getenv("LOG_LEVEL")),
+		MetricsToken:              os.Getenv("METRICS_TOKEN"),
+		MetricsAddr:               os.Getenv("METRICS_ADDR"),
 	}
 
 	if cfg.Port == "" {
 		cfg.Port = "5000"
 	}
 
+	if cfg.ExportDir == "" {
+		cfg.ExportDir = filepath.Join(os.TempDir(), "solivra-exports")
+	}
+
+	if cfg.StorageDriver == "" {
+		cfg.StorageDriver = "local"
+		if cfg.CloudinaryCloudName != "" {
+			cfg.StorageDriver = "cloudinary"
+		}
+	}
+
+	if cfg.StorageLocalDir == "" {
+		cfg.StorageLocalDir = "uploads"
+	}
+
+	if cfg.StoragePublicURL == "" && cfg.StorageDriver == "local" {
+		cfg.StoragePublicURL = "http://localhost:" + cfg.Port
+	}
+
+	if cfg.LogFormat == "" {
+		cfg.LogFormat = "text"
+		if IsProd() {
+			cfg.LogFormat = "json"
+		}
+	}
+
+	if cfg.LogLevel == "" {
+		cfg.LogLevel = "info"
+	}
+
+	if cfg.CaptchaProvider == "" {
+		cfg.CaptchaProvider = "none"
+		if cfg.CloudflareTurnstileSecret != "" {
+			cfg.CaptchaProvider = "turnstile"
+		}
+	}
+
 	// Pengecekan Kritis
 	if cfg.MongoURI == "" {
 		// FIXED: Pesan error yang lebih jelas.
-		log.Fatal("FATAL: Variabel MONGO_URI/MONGODB_URI TIDAK DITEMUKAN. Pastikan file .env ada di direktori /backend/ dan variabel tersebut diisi.")
+		logging.Fatal("MONGO_URI/MONGODB_URI is not set. Make sure the .env file exists in /backend/ and the variable is filled in.")
 	}
 
 	// Setup Admin Emails (MERN Logic)
@@ -120,6 +239,63 @@ func Get() *Config {
 	return appConfig
 }
 
+// RelapseRetention mengembalikan masa pemulihan relapse yang di-soft delete.
+func (c *Config) RelapseRetention() time.Duration {
+	return time.Duration(c.RelapseRetentionDays) * 24 * time.Hour
+}
+
+// ActivityLogRetention mengembalikan umur maksimal activity log.
+func (c *Config) ActivityLogRetention() time.Duration {
+	return time.Duration(c.ActivityLogRetentionDays) * 24 * time.Hour
+}
+
+// AuthAttemptRetention mengembalikan umur maksimal login dan registration attempt.
+func (c *Config) AuthAttemptRetention() time.Duration {
+	return time.Duration(c.AuthAttemptRetentionDays) * 24 * time.Hour
+}
+
+// SessionRetention mengembalikan lama sesi yang di-revoke disimpan.
+func (c *Config) SessionRetention() time.Duration {
+	return time.Duration(c.SessionRetentionDays) * 24 * time.Hour
+}
+
+// Problems mengembalikan daftar konfigurasi yang belum lengkap untuk readiness check.
+// Kosong berarti konfigurasi siap dipakai.
+func (c *Config) Problems() []string {
+	var problems []string
+	if c.MongoURI == "" {
+		problems = append(problems, "MONGO_URI belum diisi")
+	}
+	if c.JWTAccessSecret == "" {
+		problems = append(problems, "JWT_ACCESS_SECRET belum diisi")
+	}
+	if c.JWTRefreshSecret == "" {
+		problems = append(problems, "JWT_REFRESH_SECRET belum diisi")
+	}
+	if IsProd() && c.CorsAllowedOrigins == "" {
+		problems = append(problems, "CORS_ALLOWED_ORIGINS belum diisi")
+	}
+
+	switch c.StorageDriver {
+	case "cloudinary":
+		if c.CloudinaryCloudName == "" || c.CloudinaryAPIKey == "" || c.CloudinaryAPISecret == "" {
+			problems = append(problems, "STORAGE_DRIVER=cloudinary membutuhkan CLOUDINARY_CLOUD_NAME, CLOUDINARY_API_KEY, dan CLOUDINARY_API_SECRET")
+		}
+	case "s3":
+		if c.S3Endpoint == "" || c.S3Bucket == "" || c.S3AccessKeyID == "" || c.S3SecretAccessKey == "" {
+			problems = append(problems, "STORAGE_DRIVER=s3 membutuhkan S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY_ID, dan S3_SECRET_ACCESS_KEY")
+		}
+	case "local":
+	default:
+		problems = append(problems, "STORAGE_DRIVER tidak dikenal: "+c.StorageDriver)
+	}
+
+	if c.CaptchaProvider == "turnstile" && c.CloudflareTurnstileSecret == "" {
+		problems = append(problems, "CAPTCHA_PROVIDER=turnstile membutuhkan CLOUDFLARE_TURNSTILE_SECRET_KEY")
+	}
+	return problems
+}
+
 // IsProd checks if environment is production
 func IsProd() bool {
 	return os.Getenv("NODE_ENV") == "production"
@@ -0,0 +1,201 @@
+// Package export menyusun arsip data pribadi user (takeout): dokumen user tanpa
+// rahasia, habit, relapse, sesi, percobaan login, activity log, dan foto profil.
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/storage"
+	"solivra-go/backend/internal/store"
+)
+
+// maxPictureBytes membatasi ukuran foto profil yang diunduh ke dalam arsip.
+const maxPictureBytes = 10 * 1024 * 1024
+
+// defaultProfilePicture adalah foto bawaan; tidak ikut diekspor.
+const defaultProfilePicture = "/default.png"
+
+// UserDocument adalah dokumen user di dalam arsip. Password, refresh token, dan
+// rahasia 2FA sudah disembunyikan oleh tag json models.User.
+type UserDocument struct {
+	*models.User
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
+}
+
+// Bundle adalah seluruh data milik satu user yang siap ditulis.
+type Bundle struct {
+	GeneratedAt    time.Time                `json:"generated_at"`
+	Timezone       string                   `json:"timezone"`
+	User           UserDocument             `json:"user"`
+	Habits         []models.Habit           `json:"habits"`
+	Relapses       []models.RelapseLog      `json:"relapses"`
+	RelapseHistory []models.RelapseRevision `json:"relapse_history"`
+	Achievements   []models.Achievement     `json:"achievements"`
+	Sessions       []models.UserSession     `json:"sessions"`
+	LoginAttempts  []models.LoginAttempt    `json:"login_attempts"`
+	ActivityLogs   []models.ActivityLog     `json:"activity_logs"`
+
+	// Foto profil hanya disertakan di ZIP; versi JSON cukup memuat URL-nya.
+	ProfilePicture     []byte `json:"-"`
+	ProfilePictureName string `json:"-"`
+}
+
+// Collect membaca semua data user dari store. relapse_time ditulis dengan offset zona
+// waktu user agar mudah dibaca. Foto profil dibaca dari files bila bukan foto bawaan;
+// files nil atau kegagalan membaca foto tidak menggagalkan ekspor.
+func Collect(ctx context.Context, s *store.Stores, files storage.Storage, user *models.User, withPicture bool) (*Bundle, error) {
+	// Visibilitas kosong (akun lama) diekspor sebagai nilai efektifnya
+	doc := *user
+	doc.RankingVisibility = user.Visibility()
+
+	b := &Bundle{
+		GeneratedAt: time.Now().UTC(),
+		Timezone:    user.TimezoneName(),
+		User:        UserDocument{User: &doc, TwoFactorEnabled: user.TwoFactor.Enabled},
+	}
+
+	var err error
+	if b.Habits, err = s.Habits.ListByUser(ctx, user.ID); err != nil {
+		return nil, fmt.Errorf("list habits: %w", err)
+	}
+	if b.Relapses, err = s.Relapses.ListByUser(ctx, user.ID, true); err != nil {
+		return nil, fmt.Errorf("list relapses: %w", err)
+	}
+	if b.RelapseHistory, err = s.Relapses.ListRevisionsByUser(ctx, user.ID); err != nil {
+		return nil, fmt.Errorf("list relapse history: %w", err)
+	}
+	if b.Achievements, err = s.Achievements.ListByUser(ctx, user.ID); err != nil {
+		return nil, fmt.Errorf("list achievements: %w", err)
+	}
+	if b.Sessions, err = s.Sessions.ListByUser(ctx, user.ID); err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	if b.LoginAttempts, err = s.LoginAttempts.ListByUser(ctx, user.ID); err != nil {
+		return nil, fmt.Errorf("list login attempts: %w", err)
+	}
+	if b.ActivityLogs, err = s.ActivityLogs.ListByUser(ctx, user.ID); err != nil {
+		return nil, fmt.Errorf("list activity logs: %w", err)
+	}
+	if b.Relapses == nil {
+		b.Relapses = []models.RelapseLog{}
+	}
+	if b.RelapseHistory == nil {
+		b.RelapseHistory = []models.RelapseRevision{}
+	}
+	loc := user.Location()
+	for i := range b.Relapses {
+		b.Relapses[i].RelapseTime = b.Relapses[i].RelapseTime.In(loc)
+	}
+	for i := range b.RelapseHistory {
+		b.RelapseHistory[i].RelapseTime = b.RelapseHistory[i].RelapseTime.In(loc)
+	}
+
+	if withPicture && files != nil && user.ProfilePicture != "" && user.ProfilePicture != defaultProfilePicture {
+		if data, name, err := fetchPicture(ctx, files, user.ProfilePicture); err == nil {
+			b.ProfilePicture, b.ProfilePictureName = data, name
+		}
+	}
+	return b, nil
+}
+
+// fetchPicture membaca foto profil lewat storage yang menyimpannya.
+func fetchPicture(ctx context.Context, files storage.Storage, url string) ([]byte, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	r, err := files.Open(ctx, url)
+	if err != nil {
+		return nil, "", err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(io.LimitReader(r, maxPictureBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > maxPictureBytes {
+		return nil, "", fmt.Errorf("picture too large")
+	}
+
+	ext := path.Ext(strings.SplitN(url, "?", 2)[0])
+	if ext == "" || len(ext) > 5 {
+		ext = ".jpg"
+	}
+	return data, "profile_picture" + strings.ToLower(ext), nil
+}
+
+// WriteZip menulis bundle sebagai arsip ZIP: satu file JSON per koleksi, manifest
+// berisi jumlah data, dan foto profil (jika ada).
+func WriteZip(w io.Writer, b *Bundle) error {
+	zw := zip.NewWriter(w)
+
+	manifest := map[string]interface{}{
+		"generated_at": b.GeneratedAt,
+		"user_id":      b.User.ID,
+		"timezone":     b.Timezone,
+		"counts": map[string]int{
+			"habits":          len(b.Habits),
+			"relapses":        len(b.Relapses),
+			"relapse_history": len(b.RelapseHistory),
+			"achievements":    len(b.Achievements),
+			"sessions":        len(b.Sessions),
+			"login_attempts":  len(b.LoginAttempts),
+			"activity_logs":   len(b.ActivityLogs),
+		},
+		"profile_picture": b.ProfilePictureName,
+	}
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"manifest.json", manifest},
+		{"user.json", b.User},
+		{"habits.json", b.Habits},
+		{"relapses.json", b.Relapses},
+		{"relapse_history.json", b.RelapseHistory},
+		{"achievements.json", b.Achievements},
+		{"sessions.json", b.Sessions},
+		{"login_attempts.json", b.LoginAttempts},
+		{"activity_logs.json", b.ActivityLogs},
+	}
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			return fmt.Errorf("write %s: %w", f.name, err)
+		}
+	}
+
+	if len(b.ProfilePicture) > 0 {
+		fw, err := zw.Create(b.ProfilePictureName)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(b.ProfilePicture); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// FileName mengembalikan nama file unduhan untuk user pada waktu t. Tanggal diambil
+// dari zona t, jadi ubah t ke zona waktu user lebih dulu.
+func FileName(userID primitive.ObjectID, t time.Time, ext string) string {
+	return fmt.Sprintf("solivra-export-%s-%s.%s", userID.Hex(), t.Format("20060102"), ext)
+}
@@ -0,0 +1,63 @@
+package export
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/storage"
+	"solivra-go/backend/internal/store"
+)
+
+func TestCollectPicture(t *testing.T) {
+	ctx := context.Background()
+	local, err := storage.NewLocal(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, err := local.Put(ctx, "profile_pictures", strings.NewReader("avatar"), 6, "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(url, storage.LocalURLPrefix+"/") {
+		t.Fatalf("url = %q, want a relative local url", url)
+	}
+
+	tests := []struct {
+		name        string
+		files       storage.Storage
+		picture     string
+		withPicture bool
+		wantName    string
+	}{
+		{name: "relative local url", files: local, picture: url, withPicture: true, wantName: "profile_picture.png"},
+		{name: "without picture", files: local, picture: url},
+		{name: "no storage", picture: url, withPicture: true},
+		{name: "default picture", files: local, picture: defaultProfilePicture, withPicture: true},
+		{name: "missing file", files: local, picture: storage.LocalURLPrefix + "/profile_pictures/nope.png", withPicture: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewMemory()
+			user := &models.User{ID: primitive.NewObjectID(), Username: "alice", ProfilePicture: tt.picture}
+			if err := s.Users.Create(ctx, user); err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := Collect(ctx, s, tt.files, user, tt.withPicture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.ProfilePictureName != tt.wantName {
+				t.Errorf("ProfilePictureName = %q, want %q", b.ProfilePictureName, tt.wantName)
+			}
+			if tt.wantName != "" && string(b.ProfilePicture) != "avatar" {
+				t.Errorf("ProfilePicture = %q, want %q", b.ProfilePicture, "avatar")
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+// internal/export/job.go
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/storage"
+	"solivra-go/backend/internal/store"
+)
+
+// TTL adalah lama arsip ekspor asinkron (dan link unduhannya) berlaku. Catatan job yang
+// gagal juga disimpan selama TTL agar statusnya bisa dibaca sebelum dibersihkan.
+const TTL = 24 * time.Hour
+
+// RunTimeout adalah batas waktu job sejak dibuat, termasuk waktu menunggu di antrean.
+// Job yang masih pending setelah itu dianggap mati (misal replica restart) dan ditandai
+// gagal oleh FailStale.
+const RunTimeout = 10 * time.Minute
+
+// failedReason adalah pesan error job gagal yang ditampilkan ke user.
+const failedReason = "Gagal membuat arsip ekspor."
+
+// Run membuat arsip sesuai job.Format (ZIP atau JSON) di folder dir lalu menandai job
+// ready atau failed.
+// Dipanggil oleh worker Queue; memakai context sendiri yang berakhir RunTimeout setelah
+// job dibuat.
+func Run(s *store.Stores, files storage.Storage, dir string, job models.DataExport) {
+	ctx, cancel := context.WithDeadline(context.Background(), job.CreatedAt.Add(RunTimeout))
+	defer cancel()
+
+	filePath, size, err := build(ctx, s, files, dir, job)
+
+	// Status tetap harus tersimpan meskipun ctx sudah habis karena timeout
+	markCtx, markCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer markCancel()
+	now := time.Now()
+	if err != nil {
+		slog.Error("data export failed", "export_id", job.ID.Hex(), "user_id", job.UserID.Hex(), "err", err)
+		if filePath != "" {
+			os.Remove(filePath)
+		}
+		if err := s.DataExports.MarkFailed(markCtx, job.ID, failedReason, now.Add(TTL), now); err != nil {
+			slog.Error("data export: failed to mark failed", "export_id", job.ID.Hex(), "err", err)
+		}
+		return
+	}
+
+	if err := s.DataExports.MarkReady(markCtx, job.ID, filePath, size, now.Add(TTL), now); err != nil {
+		slog.Error("data export: failed to mark ready", "export_id", job.ID.Hex(), "err", err)
+		os.Remove(filePath)
+	}
+}
+
+// Stale melaporkan apakah job pending sudah melewati RunTimeout pada waktu now.
+func Stale(job *models.DataExport, now time.Time) bool {
+	return job.Status == models.DataExportPending && !job.CreatedAt.Add(RunTimeout).After(now)
+}
+
+// MarkStale menandai gagal satu job pending yang sudah Stale.
+func MarkStale(ctx context.Context, s *store.Stores, job *models.DataExport, now time.Time) error {
+	return s.DataExports.MarkFailed(ctx, job.ID, failedReason, now.Add(TTL), now)
+}
+
+func build(ctx context.Context, s *store.Stores, files storage.Storage, dir string, job models.DataExport) (string, int64, error) {
+	// Job yang menunggu di antrean melewati RunTimeout tidak dikerjakan lagi
+	if err := ctx.Err(); err != nil {
+		return "", 0, err
+	}
+	user, err := s.Users.FindByID(ctx, job.UserID)
+	if err != nil {
+		return "", 0, err
+	}
+	format := job.FileFormat()
+	// Foto profil hanya bisa disertakan di arsip ZIP
+	bundle, err := Collect(ctx, s, files, user, format == models.DataExportFormatZip)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", 0, err
+	}
+	filePath := filepath.Join(dir, job.ID.Hex()+"."+format)
+	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return "", 0, err
+	}
+	if format == models.DataExportFormatJSON {
+		err = json.NewEncoder(f).Encode(bundle)
+	} else {
+		err = WriteZip(f, bundle)
+	}
+	if err != nil {
+		f.Close()
+		return filePath, 0, err
+	}
+	if err := f.Close(); err != nil {
+		return filePath, 0, err
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return filePath, 0, err
+	}
+	return filePath, info.Size(), nil
+}
+
+// Remove menghapus arsip dan catatan job.
+func Remove(ctx context.Context, s *store.Stores, export *models.DataExport) error {
+	if export.FilePath != "" {
+		if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return s.DataExports.Delete(ctx, export.ID)
+}
+
+// RemoveForUser menghapus semua arsip dan job milik user (dipakai saat akun dihapus).
+func RemoveForUser(ctx context.Context, s *store.Stores, userID primitive.ObjectID) error {
+	exports, err := s.DataExports.ListByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for i := range exports {
+		if exports[i].FilePath != "" {
+			os.Remove(exports[i].FilePath)
+		}
+	}
+	return s.DataExports.DeleteByUser(ctx, userID)
+}
+
+// PurgeExpired menandai gagal job yang macet (lihat RunTimeout), lalu menghapus arsip dan
+// job yang sudah kedaluwarsa dan mengembalikan jumlah yang dihapus.
+func PurgeExpired(ctx context.Context, s *store.Stores, now time.Time) (int, error) {
+	stale, err := s.DataExports.FailStale(ctx, now.Add(-RunTimeout), failedReason, now.Add(TTL), now)
+	if err != nil {
+		return 0, err
+	}
+	if stale > 0 {
+		slog.WarnContext(ctx, "marked stale data exports as failed", "count", stale)
+	}
+
+	expired, err := s.DataExports.ListExpired(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for i := range expired {
+		if err := Remove(ctx, s, &expired[i]); err != nil {
+			slog.ErrorContext(ctx, "failed to remove expired export", "export_id", expired[i].ID.Hex(), "err", err)
+			continue
+		}
+		removed++
+	}
+	return removed, nil
+}
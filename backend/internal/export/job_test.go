@@ -0,0 +1,160 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/store"
+)
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		format string
+		age    time.Duration // Umur job saat worker mulai
+		status string
+		file   string // Ekstensi arsip yang ditulis
+	}{
+		{name: "fresh job", format: models.DataExportFormatZip, age: time.Second, status: models.DataExportReady, file: "zip"},
+		{name: "job without format", age: time.Second, status: models.DataExportReady, file: "zip"},
+		{name: "json job", format: models.DataExportFormatJSON, age: time.Second, status: models.DataExportReady, file: "json"},
+		{name: "waited past run timeout", format: models.DataExportFormatZip, age: RunTimeout + time.Minute, status: models.DataExportFailed, file: "zip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewMemory()
+			dir := t.TempDir()
+			user := &models.User{ID: primitive.NewObjectID(), Username: "alice"}
+			if err := s.Users.Create(ctx, user); err != nil {
+				t.Fatal(err)
+			}
+			job := models.DataExport{ID: primitive.NewObjectID(), UserID: user.ID, Status: models.DataExportPending, Format: tt.format, CreatedAt: time.Now().Add(-tt.age)}
+			if err := s.DataExports.Create(ctx, &job); err != nil {
+				t.Fatal(err)
+			}
+
+			Run(s, nil, dir, job)
+
+			got, err := s.DataExports.Get(ctx, job.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Status != tt.status {
+				t.Fatalf("status = %q, want %q (error %q)", got.Status, tt.status, got.Error)
+			}
+			// Job selesai, baik ready maupun failed, selalu punya ExpiresAt agar dibersihkan
+			if got.ExpiresAt == nil {
+				t.Error("ExpiresAt not set")
+			}
+			archive := filepath.Join(dir, job.ID.Hex()+"."+tt.file)
+			_, statErr := os.Stat(archive)
+			if exists := statErr == nil; exists != (tt.status == models.DataExportReady) {
+				t.Errorf("archive exists = %v for status %q", exists, got.Status)
+			}
+			if tt.status == models.DataExportReady && got.FilePath != archive {
+				t.Errorf("FilePath = %q, want %q", got.FilePath, archive)
+			}
+			if tt.file == "json" && statErr == nil {
+				raw, err := os.ReadFile(archive)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var bundle Bundle
+				if err := json.Unmarshal(raw, &bundle); err != nil {
+					t.Fatalf("json archive does not decode: %v", err)
+				}
+				if bundle.User.Username != "alice" {
+					t.Errorf("json archive user = %q, want alice", bundle.User.Username)
+				}
+			}
+		})
+	}
+}
+
+func TestPurgeExpired(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemory()
+	now := time.Now()
+	userID := primitive.NewObjectID()
+
+	archive := filepath.Join(t.TempDir(), "expired.zip")
+	if err := os.WriteFile(archive, []byte("zip"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	expiredAt := now.Add(-time.Minute)
+
+	jobs := map[string]*models.DataExport{
+		"stale":   {Status: models.DataExportPending, CreatedAt: now.Add(-RunTimeout - time.Minute)},
+		"running": {Status: models.DataExportPending, CreatedAt: now.Add(-time.Minute)},
+		"expired": {Status: models.DataExportReady, CreatedAt: now.Add(-TTL - time.Hour), FilePath: archive, ExpiresAt: &expiredAt},
+	}
+	for _, job := range jobs {
+		job.UserID = userID
+		if err := s.DataExports.Create(ctx, job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := PurgeExpired(ctx, s, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Errorf("removed = %d, want 1", removed)
+	}
+	if _, err := os.Stat(archive); !os.IsNotExist(err) {
+		t.Errorf("expired archive still on disk (stat err %v)", err)
+	}
+	if _, err := s.DataExports.Get(ctx, jobs["expired"].ID); err != store.ErrNotFound {
+		t.Errorf("expired job still stored (err %v)", err)
+	}
+
+	stale, err := s.DataExports.Get(ctx, jobs["stale"].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stale.Status != models.DataExportFailed || stale.ExpiresAt == nil {
+		t.Fatalf("stale job = %+v, want failed with ExpiresAt", stale)
+	}
+
+	// Job yang masih dalam RunTimeout tetap pending dan masih memblokir ekspor baru
+	pending, err := s.DataExports.FindPending(ctx, userID)
+	if err != nil || pending.ID != jobs["running"].ID {
+		t.Fatalf("FindPending = %v, %v; want the running job", pending, err)
+	}
+
+	// Catatan job gagal dihapus setelah TTL
+	if _, err := PurgeExpired(ctx, s, stale.ExpiresAt.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.DataExports.Get(ctx, stale.ID); err != store.ErrNotFound {
+		t.Errorf("failed job still stored after TTL (err %v)", err)
+	}
+}
+
+func TestStale(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		job  models.DataExport
+		want bool
+	}{
+		{name: "recent pending", job: models.DataExport{Status: models.DataExportPending, CreatedAt: now.Add(-time.Minute)}, want: false},
+		{name: "old pending", job: models.DataExport{Status: models.DataExportPending, CreatedAt: now.Add(-RunTimeout)}, want: true},
+		{name: "old ready", job: models.DataExport{Status: models.DataExportReady, CreatedAt: now.Add(-time.Hour)}, want: false},
+	}
+	for _, tt := range tests {
+		if got := Stale(&tt.job, now); got != tt.want {
+			t.Errorf("%s: Stale = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
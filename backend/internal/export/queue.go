@@ -0,0 +1,60 @@
+// internal/export/queue.go
+package export
+
+import (
+	"sync"
+
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/storage"
+	"solivra-go/backend/internal/store"
+)
+
+const (
+	// queueWorkers adalah jumlah ekspor asinkron yang diproses bersamaan per replica.
+	queueWorkers = 2
+	// queueSize adalah jumlah job yang boleh menunggu worker.
+	queueSize = 32
+)
+
+// Queue menjalankan job ekspor asinkron dengan jumlah worker terbatas agar lonjakan
+// permintaan tidak menghabiskan memori dan koneksi database. Antrean hanya ada di memori:
+// job yang hilang karena restart ditandai gagal oleh PurgeExpired setelah RunTimeout.
+//
+// Arsip ditulis ke folder lokal dir, sedangkan link unduhan dan PurgeExpired (job leader)
+// bisa berjalan di replica lain, jadi dir harus berupa volume bersama jika ada lebih
+// dari satu replica.
+type Queue struct {
+	s       *store.Stores
+	files   storage.Storage
+	dir     string
+	pending chan models.DataExport
+	start   sync.Once
+}
+
+// NewQueue membuat Queue yang menulis arsip ke dir. Worker baru dijalankan saat job
+// pertama masuk.
+func NewQueue(s *store.Stores, files storage.Storage, dir string) *Queue {
+	return &Queue{s: s, files: files, dir: dir, pending: make(chan models.DataExport, queueSize)}
+}
+
+// Enqueue menjadwalkan job. false berarti antrean penuh dan job tidak akan diproses;
+// pemanggil harus membatalkan job tersebut.
+func (q *Queue) Enqueue(job models.DataExport) bool {
+	q.start.Do(func() {
+		for i := 0; i < queueWorkers; i++ {
+			go q.work()
+		}
+	})
+	select {
+	case q.pending <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+func (q *Queue) work() {
+	for job := range q.pending {
+		Run(q.s, q.files, q.dir, job)
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/logging"
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/services"
+	"solivra-go/backend/pkg/utils"
+)
+
+// evaluateAchievements memberikan badge yang baru tercapai setelah streak berubah. Kegagalan
+// hanya dicatat di log: badge yang terlewat akan diberikan oleh evaluasi berkala.
+func (h *Handler) evaluateAchievements(ctx context.Context, userID primitive.ObjectID) []models.Achievement {
+	awarded, err := services.EvaluateAchievements(ctx, h.store, userID, time.Now())
+	if err != nil {
+		slog.ErrorContext(ctx, "error evaluating achievements", "err", err, "user_id", userID.Hex())
+	}
+	return awarded
+}
+
+// GetAchievements handles GET /api/users/achievements
+// Mengembalikan badge milik user (terlama lebih dulu) beserta katalog semua badge.
+func (h *Handler) GetAchievements(c *fiber.Ctx) error {
+	userID := c.Locals("userObjectID").(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	achievements, err := h.store.Achievements.ListByUser(ctx, userID)
+	if err != nil {
+		logging.For(c).Error("error fetching achievements", "err", err)
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal memuat achievement.")
+	}
+
+	return c.JSON(fiber.Map{
+		"achievements": achievements,
+		"catalog":      models.AchievementCatalog,
+	})
+}
@@ -2,85 +2,56 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"math"
-	"sort"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
 
-	"solivra-go/backend/internal/database"
 	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/store"
 	"solivra-go/backend/pkg/utils"
 )
 
 // GetDashboardStats returns admin dashboard statistics
-func GetDashboardStats(c *fiber.Ctx) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// relapse_by_hour dikelompokkan pada zona ?timezone=... (default: zona waktu admin).
+func (h *Handler) GetDashboardStats(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
 	defer cancel()
 
-	usersColl := database.GetCollection("users")
-	relapsesColl := database.GetCollection("relapselogs")
+	admin, err := h.store.Users.FindByID(ctx, c.Locals("userObjectID").(primitive.ObjectID))
+	if err != nil {
+		admin = &models.User{}
+	}
+	loc, err := requestLocation(c, admin)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
 
 	twentyFourHoursAgo := time.Now().Add(-24 * time.Hour)
 
 	// Total Users
-	totalUsers, _ := usersColl.CountDocuments(ctx, bson.M{})
+	totalUsers, _ := h.store.Users.Count(ctx)
 
 	// New Registrations (Last 24h)
-	newRegistrations, _ := usersColl.CountDocuments(ctx, bson.M{
-		"created_at": bson.M{"$gte": twentyFourHoursAgo},
-	})
+	newRegistrations, _ := h.store.Users.CountCreatedSince(ctx, twentyFourHoursAgo)
 
 	// Total Relapses (Last 24h)
-	totalRelapses24h, _ := relapsesColl.CountDocuments(ctx, bson.M{
-		"relapse_time": bson.M{"$gte": twentyFourHoursAgo},
-	})
+	totalRelapses24h, _ := h.store.Relapses.CountSince(ctx, twentyFourHoursAgo)
 
 	// Relapse By Hour
-	relapseByHourPipeline := []bson.M{
-		{"$group": bson.M{"_id": bson.M{"$hour": "$relapse_time"}, "count": bson.M{"$sum": 1}}},
-		{"$sort": bson.M{"_id": 1}},
-	}
-	cursor, err := relapsesColl.Aggregate(ctx, relapseByHourPipeline)
+	formattedRelapseByHour, err := h.store.Relapses.CountByHour(ctx, loc)
 	if err != nil {
 		return utils.ErrorResponse(c, 500, "Failed to aggregate relapse by hour")
 	}
-	defer cursor.Close(ctx)
-
-	var rawRelapseByHour []bson.M
-	if err = cursor.All(ctx, &rawRelapseByHour); err != nil {
-		return utils.ErrorResponse(c, 500, "Failed to decode relapse by hour")
-	}
-
-	formattedRelapseByHour := make([]int, 24)
-	for _, item := range rawRelapseByHour {
-		if hour, ok := item["_id"].(int32); ok {
-			if hour >= 0 && hour < 24 {
-				formattedRelapseByHour[hour] = int(item["count"].(int32))
-			}
-		}
-	}
 
 	// Language Distribution
-	languageDistributionPipeline := []bson.M{
-		{"$group": bson.M{"_id": "$language_pref", "count": bson.M{"$sum": 1}}},
-		{"$project": bson.M{"language_pref": "$_id", "user_count": "$count", "_id": 0}},
-	}
-	cursor, err = usersColl.Aggregate(ctx, languageDistributionPipeline)
+	languageDistribution, err := h.store.Users.LanguageDistribution(ctx)
 	if err != nil {
 		return utils.ErrorResponse(c, 500, "Failed to aggregate language distribution")
 	}
-	defer cursor.Close(ctx)
-
-	var languageDistribution []bson.M
-	if err = cursor.All(ctx, &languageDistribution); err != nil {
-		return utils.ErrorResponse(c, 500, "Failed to decode language distribution")
-	}
 
 	return c.JSON(fiber.Map{
 		"summary": fiber.Map{
@@ -95,13 +66,14 @@ func GetDashboardStats(c *fiber.Ctx) error {
 		},
 		"patterns": fiber.Map{
 			"relapse_by_hour": formattedRelapseByHour,
+			"timezone":        loc.String(),
 		},
 	})
 }
 
 // GetAdminLogs returns paginated activity logs
-func GetAdminLogs(c *fiber.Ctx) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (h *Handler) GetAdminLogs(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
 	defer cancel()
 
 	// 1. Ambil Query Params
@@ -119,46 +91,25 @@ func GetAdminLogs(c *fiber.Ctx) error {
 	}
 
 	// 2. Buat Query Filter
-	query := bson.M{
-		"action": bson.M{"$ne": "api_call"},
+	query := store.ActivityLogQuery{
+		ExcludeAction:  "api_call",
+		UsernameSearch: searchRaw,
+		// 3. Sort Order
+		Ascending: sortOrder == "oldest",
+		Skip:      int64((page - 1) * limit),
+		Limit:     int64(limit),
 	}
 
 	if filter != "all" {
-		query["action"] = filter
-	}
-
-	if searchRaw != "" {
-		query["username"] = bson.M{"$regex": searchRaw, "$options": "i"}
+		query.Action = filter
 	}
 
-	// 3. Buat Sort Order
-	mongoSortOrder := -1
-	if sortOrder == "oldest" {
-		mongoSortOrder = 1
-	}
-	sortBSON := bson.D{{Key: "timestamp", Value: mongoSortOrder}}
-
-	// 4. Hitung Total Dokumen
-	logsColl := database.GetCollection("activitylogs")
-	count, _ := logsColl.CountDocuments(ctx, query)
-	totalPages := int(math.Ceil(float64(count) / float64(limit)))
-
-	// 5. Ambil Log
-	opts := options.Find().
-		SetSort(sortBSON).
-		SetLimit(int64(limit)).
-		SetSkip(int64((page - 1) * limit))
-
-	cursor, err := logsColl.Find(ctx, query, opts)
+	// 4. Ambil Log beserta Total Dokumen
+	logs, count, err := h.store.ActivityLogs.List(ctx, query)
 	if err != nil {
 		return utils.ErrorResponse(c, 500, "Failed to fetch logs")
 	}
-	defer cursor.Close(ctx)
-
-	var logs []models.ActivityLog
-	if err = cursor.All(ctx, &logs); err != nil {
-		return utils.ErrorResponse(c, 500, "Failed to decode logs")
-	}
+	totalPages := int(math.Ceil(float64(count) / float64(limit)))
 
 	return c.JSON(fiber.Map{
 		"logs":        logs,
@@ -167,25 +118,26 @@ func GetAdminLogs(c *fiber.Ctx) error {
 	})
 }
 
-// GetAdminRankings returns full user rankings for admin and public (called from stats.go)
-func GetAdminRankings(c *fiber.Ctx) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+// GetAdminRankings returns full user rankings for admin.
+// Ranking publik dilayani GetRankings yang menyembunyikan user sesuai privasinya.
+func (h *Handler) GetAdminRankings(c *fiber.Ctx) error {
+	q, err := parseRankingQuery(c)
+	if err != nil {
+		return utils.ErrorResponse(c, 400, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
 	defer cancel()
 
-	usersColl := database.GetCollection("users")
-	relapsesColl := database.GetCollection("relapselogs")
+	// NOTE: Pengecekan Locals("userObjectID") ini aman karena fungsi ini dipanggil setelah middleware Protected()
+	currentUserID := c.Locals("userObjectID").(primitive.ObjectID)
 
-	// 1. Ambil semua user yang memiliki streak_start_date
-	cursor, err := usersColl.Find(ctx, bson.M{"streak_start_date": bson.M{"$exists": true}})
+	// 1. Ambil satu halaman ranking sesuai sort/window/cursor, termasuk user yang tersembunyi
+	page, err := h.loadRankingPage(ctx, q, currentUserID, store.RankingFilter{})
 	if err != nil {
 		return utils.ErrorResponse(c, 500, "Failed to fetch users for rankings")
 	}
 
-	var users []models.User
-	if err = cursor.All(ctx, &users); err != nil {
-		return utils.ErrorResponse(c, 500, "Failed to decode users")
-	}
-
 	type RankEntry struct {
 		UserID          primitive.ObjectID `json:"user_id"`
 		Username        string             `json:"username"`
@@ -196,157 +148,56 @@ func GetAdminRankings(c *fiber.Ctx) error {
 		AverageStreak   int64              `json:"average_streak"`
 		StreakStartDate *time.Time         `json:"streak_start_date,omitempty"`
 		CreatedAt       time.Time          `json:"created_at"`
+		Visibility      string             `json:"ranking_visibility"`
 		IsCurrentUser   bool               `json:"is_current_user"`
 		Rank            int                `json:"rank"`
 	}
 
-	var rankings []RankEntry
-	now := time.Now()
-	// NOTE: Pengecekan Locals("userObjectID") ini aman karena fungsi ini dipanggil setelah middleware Protected()
-	currentUserID := c.Locals("userObjectID").(primitive.ObjectID)
+	rankings := []RankEntry{}
 
-	// 2. Loop Users dan Hitung Streak secara Manual (Mirip Logic MERN)
-	for _, user := range users {
-		if user.StreakStartDate == nil {
-			// User belum memulai streak - skip agar tidak panic ketika dereference
+	// 2. Susun baris ranking; peringkat mengikuti urutan leaderboard
+	for i, entry := range page.Entries {
+		user, ok := page.Users[entry.UserID]
+		if !ok {
 			continue
 		}
-		streakStartDate := *user.StreakStartDate
-
-		// Ambil Relapses user
-		relapseCursor, _ := relapsesColl.Find(ctx, bson.M{"user": user.ID}, options.Find().SetSort(bson.D{{Key: "relapse_time", Value: 1}}))
-		var userRelapses []models.RelapseLog
-		relapseCursor.All(ctx, &userRelapses)
-
-		totalRelapses := len(userRelapses)
-
-		var currentStreakSeconds int64
-		var computedLongestStreak int64
-		var averageStreakSeconds int64
-
-		if totalRelapses == 0 {
-			currentStreakSeconds = int64(now.Sub(streakStartDate).Seconds())
-			computedLongestStreak = currentStreakSeconds
-			averageStreakSeconds = computedLongestStreak
-		} else {
-			timeline := []time.Time{streakStartDate}
-			for _, r := range userRelapses {
-				timeline = append(timeline, r.RelapseTime)
-			}
-			sort.Slice(timeline, func(i, j int) bool {
-				return timeline[i].Before(timeline[j])
-			})
-
-			var streaks []int64
-			for i := 0; i < len(timeline)-1; i++ {
-				diff := int64(timeline[i+1].Sub(timeline[i]).Seconds())
-				streaks = append(streaks, diff)
-			}
-
-			// Current streak from last relapse to now
-			lastRelapseDate := userRelapses[totalRelapses-1].RelapseTime
-			currentStreakSeconds = int64(now.Sub(lastRelapseDate).Seconds())
-
-			// Find longest streak
-			allStreaks := append(streaks, currentStreakSeconds)
-			computedLongestStreak = 0
-			for _, s := range allStreaks {
-				if s > computedLongestStreak {
-					computedLongestStreak = s
-				}
-			}
-
-			// Calculate average
-			if len(streaks) > 0 {
-				var sum int64
-				for _, s := range streaks {
-					sum += s
-				}
-				averageStreakSeconds = sum / int64(len(streaks))
-			}
-		}
-
-		// Final longest is max of stored and computed
-		finalLongest := int64(math.Max(float64(user.LongestStreakSeconds), float64(computedLongestStreak)))
+		r := buildRankingUser(entry, user, currentUserID, true)
 
 		rankings = append(rankings, RankEntry{
 			UserID:          user.ID,
 			Username:        user.Username,
 			Nickname:        user.Nickname,
-			CurrentStreak:   currentStreakSeconds,
-			LongestStreak:   finalLongest,
-			TotalRelapses:   totalRelapses,
-			AverageStreak:   averageStreakSeconds,
+			CurrentStreak:   r.CurrentStreak,
+			LongestStreak:   r.LongestStreak,
+			TotalRelapses:   r.TotalRelapses,
+			AverageStreak:   r.AverageStreak,
 			StreakStartDate: user.StreakStartDate,
 			CreatedAt:       user.CreatedAt,
-			IsCurrentUser:   user.ID == currentUserID,
-		})
-	}
-
-	// 3. Sort dan Beri Peringkat
-	sort.Slice(rankings, func(i, j int) bool {
-		return rankings[i].CurrentStreak > rankings[j].CurrentStreak
-	})
-
-	for i := range rankings {
-		rankings[i].Rank = i + 1
-	}
-
-	// 4. Filter Ranking Publik (Jika dipanggil dari /api/stats/rankings)
-	isPublicCall := strings.Contains(c.Path(), "/stats/rankings")
-
-	if isPublicCall {
-		// Public call should only return anonymized/limited data
-		var publicRankings []fiber.Map
-		var currentUserRank int
-		for _, r := range rankings {
-			if r.IsCurrentUser {
-				currentUserRank = r.Rank
-			}
-			publicRankings = append(publicRankings, fiber.Map{
-				"username":        r.Username,
-				"nickname":        r.Nickname,
-				"current_streak":  r.CurrentStreak,
-				"longest_streak":  r.LongestStreak,
-				"total_relapses":  r.TotalRelapses,
-				"is_current_user": r.IsCurrentUser,
-				"rank":            r.Rank,
-			})
-		}
-
-		return c.JSON(fiber.Map{
-			"rankings":          publicRankings,
-			"total_users":       len(users),
-			"current_user_rank": currentUserRank,
+			Visibility:      user.Visibility(),
+			IsCurrentUser:   r.IsCurrentUser,
+			Rank:            page.Offset + i + 1,
 		})
 	}
 
-	// Admin call returns all detailed data
 	return c.JSON(fiber.Map{
 		"rankings":    rankings,
-		"total_users": len(users),
+		"total_users": page.Total,
+		"sort":        q.Sort,
+		"window":      q.Window,
+		"habit_kind":  q.HabitKind,
+		"next_cursor": page.NextCursor,
 	})
 }
 
 // GetAllUsers returns a list of all users (admin only)
-func GetAllUsers(c *fiber.Ctx) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (h *Handler) GetAllUsers(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
 	defer cancel()
 
-	usersColl := database.GetCollection("users")
-
-	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
-
-	cursor, err := usersColl.Find(ctx, bson.M{}, opts)
+	users, err := h.store.Users.List(ctx)
 	if err != nil {
 		return utils.ErrorResponse(c, 500, "Failed to fetch users")
 	}
-	defer cursor.Close(ctx)
-
-	var users []models.User
-	if err = cursor.All(ctx, &users); err != nil {
-		return utils.ErrorResponse(c, 500, "Failed to decode users")
-	}
 
 	// Convert to public format
 	publicUsers := make([]models.UserPublic, len(users))
@@ -358,10 +209,10 @@ func GetAllUsers(c *fiber.Ctx) error {
 }
 
 // DeleteUser deletes a user (admin only)
-func DeleteUser(c *fiber.Ctx) error {
+func (h *Handler) DeleteUser(c *fiber.Ctx) error {
 	targetUserID := c.Params("id")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
 	defer cancel()
 
 	oid, err := primitive.ObjectIDFromHex(targetUserID)
@@ -369,24 +220,40 @@ func DeleteUser(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, 400, "Invalid user ID")
 	}
 
-	usersColl := database.GetCollection("users")
+	// Admin tidak bisa menghapus akunnya sendiri (pakai DELETE /api/users) atau admin lain
+	if oid == c.Locals("userObjectID").(primitive.ObjectID) {
+		return utils.ErrorResponse(c, 400, "Cannot delete your own account from the admin panel")
+	}
 
-	// Delete user
-	result, err := usersColl.DeleteOne(ctx, bson.M{"_id": oid})
+	user, err := h.store.Users.FindByID(ctx, oid)
+	if errors.Is(err, store.ErrNotFound) {
+		return utils.ErrorResponse(c, 404, "User not found")
+	}
 	if err != nil {
 		return utils.ErrorResponse(c, 500, "Failed to delete user")
 	}
+	if user.Role == "admin" {
+		return utils.ErrorResponse(c, 403, "Cannot delete another admin")
+	}
 
-	if result.DeletedCount == 0 {
-		return utils.ErrorResponse(c, 404, "User not found")
+	// Clean-up data milik user (sama seperti DeleteAccount)
+	removed, err := h.deleteUserData(c, ctx, user)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Failed to delete user")
 	}
 
-	// Tambahkan logging dan clean-up jika perlu (relapses, sessions)
+	utils.LogActivity(c, "admin_user_deleted", fiber.Map{
+		"target_user_id":          targetUserID,
+		"removed_profile_picture": removed.ProfilePicture,
+		"relapses_deleted":        removed.Relapses,
+		"habits_deleted":          removed.Habits,
+		"sessions_revoked":        removed.Sessions,
+	}, nil)
 
 	return c.JSON(fiber.Map{"msg": "User deleted successfully"})
 }
 
 // GetAdminDashboard returns key admin stats (Placeholder)
-func GetAdminDashboard(c *fiber.Ctx) error {
-	return GetDashboardStats(c)
+func (h *Handler) GetAdminDashboard(c *fiber.Ctx) error {
+	return h.GetDashboardStats(c)
 }
@@ -7,12 +7,9 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"solivra-go/backend/internal/config"
-	"solivra-go/backend/internal/database"
 	"solivra-go/backend/internal/models"
 	"solivra-go/backend/internal/services"
 	"solivra-go/backend/pkg/utils"
@@ -35,13 +32,13 @@ type RegisterRequest struct {
 }
 
 // Login handles user login
-func Login(c *fiber.Ctx) error {
+func (h *Handler) Login(c *fiber.Ctx) error {
 	var req LoginRequest
 	if err := c.BodyParser(&req); err != nil {
 		return utils.ErrorResponse(c, 400, "Invalid request body")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
 	defer cancel()
 
 	ip := utils.GetClientIP(c)
@@ -63,16 +60,10 @@ func Login(c *fiber.Ctx) error {
 
 	// 1. Cek IP Lock (Brute Force Protection) - Skip jika disabled untuk testing
 	cfg := config.Get()
-	loginAttemptsColl := database.GetCollection("loginattempts")
-	
-	if !cfg.DisableIPLockout {
-		var activeIpLock models.LoginAttempt
-		err := loginAttemptsColl.FindOne(ctx, bson.M{
-			"ip_address":    ip,
-			"outcome":       "ip_locked",
-			"lockout_until": bson.M{"$gt": now},
-		}, options.FindOne().SetSort(bson.D{{Key: "attempt_time", Value: -1}})).Decode(&activeIpLock)
+	loginAttempts := h.store.LoginAttempts
 
+	if !cfg.DisableIPLockout {
+		activeIpLock, err := loginAttempts.FindActiveIPLock(ctx, ip, now)
 		if err == nil {
 			utils.LogActivity(c, "security_ip_lock", map[string]interface{}{
 				"lockout_until": activeIpLock.LockoutUntil,
@@ -91,18 +82,13 @@ func Login(c *fiber.Ctx) error {
 	}
 
 	// 2. Cari User di Database
-	usersColl := database.GetCollection("users")
-	var user models.User
-	var err error
-
-	err = usersColl.FindOne(ctx, bson.M{
-		"username": username, // Username sudah di-lowercase
-	}).Decode(&user)
+	// Username sudah di-lowercase
+	user, err := h.store.Users.FindByUsername(ctx, username)
 
 	// Jika User Tidak Ditemukan
 	if err != nil {
 		// Catat kegagalan
-		loginAttemptsColl.InsertOne(ctx, models.LoginAttempt{
+		loginAttempts.Insert(ctx, &models.LoginAttempt{
 			Username:    username,
 			IPAddress:   ip,
 			UserAgent:   userAgent,
@@ -113,15 +99,11 @@ func Login(c *fiber.Ctx) error {
 		// Cek apakah IP ini sering mencoba user acak (IP Lock Logic) - Skip jika disabled
 		if !cfg.DisableIPLockout {
 			oneHourAgo := now.Add(-1 * time.Hour)
-			unknownAttempts, _ := loginAttemptsColl.CountDocuments(ctx, bson.M{
-				"ip_address":   ip,
-				"outcome":      "user_not_found",
-				"attempt_time": bson.M{"$gte": oneHourAgo},
-			})
+			unknownAttempts, _ := loginAttempts.CountByIP(ctx, ip, oneHourAgo, "user_not_found", "captcha_failed")
 
 			if unknownAttempts >= 5 {
 				lockoutUntil := now.Add(1 * time.Hour)
-				loginAttemptsColl.InsertOne(ctx, models.LoginAttempt{
+				loginAttempts.Insert(ctx, &models.LoginAttempt{
 					Username:     username,
 					IPAddress:    ip,
 					UserAgent:    userAgent,
@@ -156,7 +138,7 @@ func Login(c *fiber.Ctx) error {
 
 	// 3. Cek User Lock (Jika akun dikunci karena terlalu banyak gagal password)
 	if user.LockoutUntil != nil && user.LockoutUntil.After(now) {
-		loginAttemptsColl.InsertOne(ctx, models.LoginAttempt{
+		loginAttempts.Insert(ctx, &models.LoginAttempt{
 			UserID:       &user.ID,
 			Username:     user.Username,
 			IPAddress:    ip,
@@ -183,81 +165,100 @@ func Login(c *fiber.Ctx) error {
 
 	// 4. Cek Password
 	if !utils.CheckPasswordHash(password, user.Password) {
-		user.FailedLoginAttempts++
-		attemptsRemaining := 10 - user.FailedLoginAttempts
-		if attemptsRemaining < 0 {
-			attemptsRemaining = 0
-		}
+		return h.failLoginAttempt(c, ctx, user, "invalid_password", "Username atau password salah.", now)
+	}
 
-		var lockoutUntil *time.Time
-		statusCode := 401
-		msg := "Username atau password salah."
-
-		// Jika gagal 10x, kunci akun 10 menit
-		if user.FailedLoginAttempts >= 10 {
-			t := now.Add(10 * time.Minute)
-			lockoutUntil = &t
-			user.LockoutUntil = lockoutUntil
-			user.FailedLoginAttempts = 0
-			attemptsRemaining = 0
-			statusCode = 423
-			msg = "Akun dikunci selama 10 menit karena terlalu banyak percobaan gagal."
+	// 5. Jika 2FA aktif, jangan buat sesi dulu: kirim challenge token untuk langkah kedua.
+	// Counter gagal tidak di-reset di sini agar kode 2FA yang salah tetap ikut terhitung.
+	if user.TwoFactor.Enabled {
+		challengeToken, err := utils.GenerateTwoFactorChallenge(user.ID, req.RememberMe)
+		if err != nil {
+			return utils.ErrorResponse(c, 500, "Failed to generate 2FA challenge")
 		}
 
-		// Update user di DB
-		usersColl.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{
-			"$set": bson.M{
-				"failed_login_attempts": user.FailedLoginAttempts,
-				"lockout_until":         user.LockoutUntil,
-			},
-		})
-
-		// Log attempt
-		loginAttemptsColl.InsertOne(ctx, models.LoginAttempt{
-			UserID:            &user.ID,
-			Username:          user.Username,
-			IPAddress:         ip,
-			UserAgent:         userAgent,
-			Outcome:           "invalid_password",
-			LockoutUntil:      lockoutUntil,
-			AttemptsRemaining: &attemptsRemaining,
-			AttemptTime:       now,
-		})
-
-		utils.LogActivity(c, "auth_login_failed", map[string]interface{}{
-			"reason":             "invalid_password",
-			"attempts_remaining": attemptsRemaining,
-			"locked_until":       lockoutUntil,
+		utils.LogActivity(c, "auth_2fa_challenge", map[string]interface{}{
+			"ip_address": ip,
+			"user_agent": userAgent,
 		}, map[string]interface{}{"userId": user.ID.Hex(), "username": user.Username})
 
-		return c.Status(statusCode).JSON(fiber.Map{
-			"ok":                false,
-			"msg":               msg,
-			"attemptsRemaining": attemptsRemaining,
-			"lockout":           lockoutUntil,
+		return c.JSON(fiber.Map{
+			"ok":                  true,
+			"msg":                 "Masukkan kode autentikasi dua faktor",
+			"two_factor_required": true,
+			"challenge_token":     challengeToken,
 		})
 	}
 
-	// 5. Login Sukses
-	// Reset failed attempts
-	usersColl.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{
-		"$set": bson.M{
-			"failed_login_attempts": 0,
-			"lockout_until":         nil,
-		},
+	return h.completeLogin(c, ctx, user, req.RememberMe, "password", now)
+}
+
+// failLoginAttempt menaikkan FailedLoginAttempts user dan mengunci akun 10 menit setelah 10x gagal.
+// Dipakai untuk password salah maupun kode 2FA salah.
+func (h *Handler) failLoginAttempt(c *fiber.Ctx, ctx context.Context, user *models.User, outcome, msg string, now time.Time) error {
+	user.FailedLoginAttempts++
+	attemptsRemaining := 10 - user.FailedLoginAttempts
+	if attemptsRemaining < 0 {
+		attemptsRemaining = 0
+	}
+
+	var lockoutUntil *time.Time
+	statusCode := 401
+
+	// Jika gagal 10x, kunci akun 10 menit
+	if user.FailedLoginAttempts >= 10 {
+		t := now.Add(10 * time.Minute)
+		lockoutUntil = &t
+		user.LockoutUntil = lockoutUntil
+		user.FailedLoginAttempts = 0
+		attemptsRemaining = 0
+		statusCode = 423
+		msg = "Akun dikunci selama 10 menit karena terlalu banyak percobaan gagal."
+	}
+
+	// Update user di DB
+	h.store.Users.SetLoginState(ctx, user.ID, user.FailedLoginAttempts, user.LockoutUntil)
+
+	// Log attempt
+	h.store.LoginAttempts.Insert(ctx, &models.LoginAttempt{
+		UserID:            &user.ID,
+		Username:          user.Username,
+		IPAddress:         utils.GetClientIP(c),
+		UserAgent:         c.Get("User-Agent"),
+		Outcome:           outcome,
+		LockoutUntil:      lockoutUntil,
+		AttemptsRemaining: &attemptsRemaining,
+		AttemptTime:       now,
 	})
 
+	utils.LogActivity(c, "auth_login_failed", map[string]interface{}{
+		"reason":             outcome,
+		"attempts_remaining": attemptsRemaining,
+		"locked_until":       lockoutUntil,
+	}, map[string]interface{}{"userId": user.ID.Hex(), "username": user.Username})
+
+	return c.Status(statusCode).JSON(fiber.Map{
+		"ok":                false,
+		"msg":               msg,
+		"attemptsRemaining": attemptsRemaining,
+		"lockout":           lockoutUntil,
+	})
+}
+
+// completeLogin membuat sesi baru, menerbitkan token, dan mengirim respons login sukses.
+// method mencatat faktor terakhir yang dipakai: password, totp, atau recovery_code.
+func (h *Handler) completeLogin(c *fiber.Ctx, ctx context.Context, user *models.User, rememberMe bool, method string, now time.Time) error {
+	ip := utils.GetClientIP(c)
+	userAgent := c.Get("User-Agent")
+
+	// Reset failed attempts
+	h.store.Users.SetLoginState(ctx, user.ID, 0, nil)
+
 	// Generate tokens
-	accessToken, err := utils.GenerateAccessToken(&user)
+	accessToken, err := utils.GenerateAccessToken(user)
 	if err != nil {
 		return utils.ErrorResponse(c, 500, "Failed to generate access token")
 	}
 
-	refreshToken, err := utils.GenerateRefreshToken(user.ID, req.RememberMe)
-	if err != nil {
-		return utils.ErrorResponse(c, 500, "Failed to generate refresh token")
-	}
-
 	// Generate Session Token
 	sessionToken, err := utils.GenerateRandomToken(48)
 	if err != nil {
@@ -265,7 +266,6 @@ func Login(c *fiber.Ctx) error {
 	}
 
 	// Simpan Sesi ke DB
-	sessionsColl := database.GetCollection("usersessions")
 	session := models.UserSession{
 		UserID:         user.ID,
 		Token:          utils.HashToken(sessionToken),
@@ -275,13 +275,21 @@ func Login(c *fiber.Ctx) error {
 		LastActiveTime: now,
 	}
 
-	insertResult, err := sessionsColl.InsertOne(ctx, session)
-	if err != nil {
+	if err := h.store.Sessions.Create(ctx, &session); err != nil {
 		return utils.ErrorResponse(c, 500, "Failed to save session")
 	}
 
+	// Refresh token terikat ke sesi, JTI disimpan agar bisa di-rotate
+	refreshToken, refreshEntry, err := utils.GenerateRefreshToken(user.ID, session.ID, rememberMe)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Failed to generate refresh token")
+	}
+	if err := h.store.Users.AddRefreshToken(ctx, user.ID, *refreshEntry, now); err != nil {
+		return utils.ErrorResponse(c, 500, "Failed to save refresh token")
+	}
+
 	// Log sukses di LoginAttempt
-	loginAttemptsColl.InsertOne(ctx, models.LoginAttempt{
+	h.store.LoginAttempts.Insert(ctx, &models.LoginAttempt{
 		UserID:      &user.ID,
 		Username:    user.Username,
 		IPAddress:   ip,
@@ -291,14 +299,15 @@ func Login(c *fiber.Ctx) error {
 	})
 
 	// Set Cookies di Browser
-	services.SetAuthCookies(c, accessToken, refreshToken, sessionToken, req.RememberMe)
+	services.SetAuthCookies(c, accessToken, refreshToken, sessionToken, rememberMe)
 
 	// Log aktivitas umum
 	utils.LogActivity(c, "auth_login_success", map[string]interface{}{
-		"session_id":  insertResult.InsertedID.(primitive.ObjectID).Hex(),
+		"session_id":  session.ID.Hex(),
 		"ip_address":  ip,
 		"user_agent":  userAgent,
-		"remember_me": req.RememberMe,
+		"remember_me": rememberMe,
+		"method":      method,
 	}, map[string]interface{}{
 		"userId":   user.ID.Hex(),
 		"username": user.Username,
@@ -321,13 +330,13 @@ func Login(c *fiber.Ctx) error {
 }
 
 // Register handles user registration
-func Register(c *fiber.Ctx) error {
+func (h *Handler) Register(c *fiber.Ctx) error {
 	var req RegisterRequest
 	if err := c.BodyParser(&req); err != nil {
 		return utils.ErrorResponse(c, 400, "Invalid request body")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
 	defer cancel()
 
 	ip := utils.GetClientIP(c)
@@ -355,14 +364,8 @@ func Register(c *fiber.Ctx) error {
 	}
 
 	// 1. Cek Blokir Registrasi (IP Lock)
-	regAttemptsColl := database.GetCollection("registrationattempts")
-	var block models.RegistrationAttempt
-	err := regAttemptsColl.FindOne(ctx, bson.M{
-		"ip_address":    ip,
-		"status":        "blocked",
-		"blocked_until": bson.M{"$gt": now},
-	}, options.FindOne().SetSort(bson.D{{Key: "attempt_time", Value: -1}})).Decode(&block)
-
+	regAttempts := h.store.RegistrationAttempts
+	block, err := regAttempts.FindActiveBlock(ctx, ip, now)
 	if err == nil {
 		return c.Status(429).JSON(fiber.Map{
 			"ok":  false,
@@ -376,15 +379,11 @@ func Register(c *fiber.Ctx) error {
 
 	// 2. Cek Batas Harian (Daily Limit)
 	dayAgo := now.Add(-24 * time.Hour)
-	recentRegistrations, _ := regAttemptsColl.CountDocuments(ctx, bson.M{
-		"ip_address":   ip,
-		"status":       "success",
-		"attempt_time": bson.M{"$gte": dayAgo},
-	})
+	recentRegistrations, _ := regAttempts.CountByIP(ctx, ip, dayAgo, "success")
 
 	if recentRegistrations >= 5 {
 		lockoutUntil := now.Add(24 * time.Hour)
-		regAttemptsColl.InsertOne(ctx, models.RegistrationAttempt{
+		regAttempts.Insert(ctx, &models.RegistrationAttempt{
 			IPAddress:    ip,
 			Username:     username,
 			AttemptTime:  now,
@@ -408,15 +407,12 @@ func Register(c *fiber.Ctx) error {
 	}
 
 	// 3. Cek Username Tersedia
-	usersColl := database.GetCollection("users")
-	count, err := usersColl.CountDocuments(ctx, bson.M{
-		"username": username,
-	})
+	taken, err := h.store.Users.UsernameTaken(ctx, username, primitive.NilObjectID)
 	if err != nil {
 		return utils.ErrorResponse(c, 500, "Server error checking username")
 	}
 
-	if count > 0 {
+	if taken {
 		return utils.ErrorResponse(c, 400, "Username sudah digunakan")
 	}
 
@@ -443,13 +439,12 @@ func Register(c *fiber.Ctx) error {
 		UpdatedAt:      now,
 	}
 
-	result, err := usersColl.InsertOne(ctx, user)
-	if err != nil {
+	if err := h.store.Users.Create(ctx, &user); err != nil {
 		return utils.ErrorResponse(c, 500, "Failed to create user")
 	}
 
 	// Catat Registrasi Sukses
-	regAttemptsColl.InsertOne(ctx, models.RegistrationAttempt{
+	regAttempts.Insert(ctx, &models.RegistrationAttempt{
 		IPAddress:   ip,
 		Username:    username,
 		AttemptTime: now,
@@ -462,7 +457,7 @@ func Register(c *fiber.Ctx) error {
 		"ip_address": ip,
 		"user_agent": userAgent,
 	}, map[string]interface{}{
-		"userId":   result.InsertedID.(primitive.ObjectID).Hex(),
+		"userId":   user.ID.Hex(),
 		"username": username,
 	})
 
@@ -473,7 +468,7 @@ func Register(c *fiber.Ctx) error {
 }
 
 // Refresh handles token refresh
-func Refresh(c *fiber.Ctx) error {
+func (h *Handler) Refresh(c *fiber.Ctx) error {
 	refreshToken := c.Cookies("refresh_token")
 	if refreshToken == "" {
 		refreshToken = c.Get("X-Refresh-Token")
@@ -487,7 +482,7 @@ func Refresh(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, 401, "Refresh token tidak valid")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
 	defer cancel()
 
 	userID, err := primitive.ObjectIDFromHex(claims.UserID)
@@ -496,13 +491,31 @@ func Refresh(c *fiber.Ctx) error {
 	}
 
 	// Cek User
-	usersColl := database.GetCollection("users")
-	var user models.User
-	err = usersColl.FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	user, err := h.store.Users.FindByID(ctx, userID)
 	if err != nil {
 		return utils.ErrorResponse(c, 401, "Pengguna tidak ditemukan")
 	}
 
+	now := time.Now()
+
+	// Token tanpa JTI (diterbitkan sebelum rotasi ada) tidak bisa dicatat sebagai terpakai,
+	// jadi ditolak dan user harus login ulang.
+	if claims.ID == "" {
+		services.ClearAuthCookies(c)
+		return utils.ErrorResponse(c, 401, "Refresh token kedaluwarsa, silakan login ulang")
+	}
+
+	// Cek JTI: token yang sudah pernah di-rotate berarti refresh token lama dipakai ulang,
+	// kecuali masih dalam masa tenggang (refresh bersamaan dari tab lain).
+	entry := user.FindRefreshToken(claims.ID)
+	if entry == nil {
+		services.ClearAuthCookies(c)
+		return utils.ErrorResponse(c, 401, "Refresh token tidak valid")
+	}
+	if entry.RotatedAt != nil && !entry.InReuseGrace(now) {
+		return h.handleRefreshReuse(c, ctx, user, entry)
+	}
+
 	// Cek Validitas Session
 	sessionToken := c.Cookies("session_token")
 	if sessionToken == "" {
@@ -514,35 +527,48 @@ func Refresh(c *fiber.Ctx) error {
 	}
 
 	sessionHash := utils.HashToken(sessionToken)
-	sessionsColl := database.GetCollection("usersessions")
-
-	var session models.UserSession
-	err = sessionsColl.FindOne(ctx, bson.M{
-		"user":       user.ID,
-		"token":      sessionHash,
-		"revoked_at": nil, // Session harus belum revoked
-	}).Decode(&session)
 
-	if err != nil {
+	// Session harus belum revoked dan harus sesi yang sama dengan pemilik refresh token
+	session, err := h.store.Sessions.FindActive(ctx, user.ID, sessionHash)
+	if err != nil || (claims.SessionID != "" && claims.SessionID != session.ID.Hex()) {
 		services.ClearAuthCookies(c)
 		return utils.ErrorResponse(c, 401, "Session sudah tidak berlaku (Revoked or Invalid)")
 	}
 
+	// Rotasi: tandai JTI lama sebagai terpakai. Gagal berarti token ini baru saja dipakai
+	// request lain; itu hanya reuse jika rotasinya sudah lewat masa tenggang.
+	if entry.RotatedAt == nil {
+		rotated, err := h.store.Users.RotateRefreshToken(ctx, user.ID, claims.ID, now)
+		if err != nil {
+			return utils.ErrorResponse(c, 500, "Failed to rotate refresh token")
+		}
+		if !rotated {
+			current, err := h.store.Users.FindByID(ctx, user.ID)
+			if err != nil {
+				return utils.ErrorResponse(c, 500, "Failed to rotate refresh token")
+			}
+			if entry = current.FindRefreshToken(claims.ID); entry == nil || !entry.InReuseGrace(now) {
+				return h.handleRefreshReuse(c, ctx, user, &models.RefreshTokenSchema{JTI: claims.ID, SessionID: session.ID})
+			}
+		}
+	}
+
 	// Generate Token Baru
-	newAccessToken, err := utils.GenerateAccessToken(&user)
+	newAccessToken, err := utils.GenerateAccessToken(user)
 	if err != nil {
 		return utils.ErrorResponse(c, 500, "Failed to generate access token")
 	}
 
-	newRefreshToken, err := utils.GenerateRefreshToken(user.ID, claims.RememberMe)
+	newRefreshToken, refreshEntry, err := utils.GenerateRefreshToken(user.ID, session.ID, claims.RememberMe)
 	if err != nil {
 		return utils.ErrorResponse(c, 500, "Failed to generate refresh token")
 	}
+	if err := h.store.Users.AddRefreshToken(ctx, user.ID, *refreshEntry, now); err != nil {
+		return utils.ErrorResponse(c, 500, "Failed to save refresh token")
+	}
 
 	// Update Activity Session
-	sessionsColl.UpdateOne(ctx, bson.M{"_id": session.ID}, bson.M{
-		"$set": bson.M{"last_active_time": time.Now()},
-	})
+	h.store.Sessions.Touch(ctx, session.ID, now)
 
 	services.SetAuthCookies(c, newAccessToken, newRefreshToken, sessionToken, claims.RememberMe)
 
@@ -553,49 +579,42 @@ func Refresh(c *fiber.Ctx) error {
 	})
 }
 
+// handleRefreshReuse mencabut seluruh keluarga token (sesi beserta semua refresh token-nya)
+// ketika refresh token yang sudah di-rotate dipakai lagi.
+func (h *Handler) handleRefreshReuse(c *fiber.Ctx, ctx context.Context, user *models.User, entry *models.RefreshTokenSchema) error {
+	now := time.Now()
+	h.store.Sessions.Revoke(ctx, entry.SessionID, now)
+	h.store.Users.RemoveSessionRefreshTokens(ctx, user.ID, entry.SessionID)
+	services.ClearAuthCookies(c)
+
+	utils.LogActivity(c, "security_refresh_reuse", map[string]interface{}{
+		"session_id": entry.SessionID.Hex(),
+		"jti":        entry.JTI,
+		"rotated_at": entry.RotatedAt,
+		"ip_address": utils.GetClientIP(c),
+		"user_agent": c.Get("User-Agent"),
+	}, map[string]interface{}{
+		"userId":   user.ID.Hex(),
+		"username": user.Username,
+	})
+
+	return utils.ErrorResponse(c, 401, "Refresh token sudah pernah dipakai. Sesi dicabut, silakan login kembali.")
+}
+
 // Logout handles user logout
-func Logout(c *fiber.Ctx) error {
+func (h *Handler) Logout(c *fiber.Ctx) error {
 	sessionToken := c.Cookies("session_token")
+	if sessionToken == "" {
+		// Support header session token fallback
+		sessionToken = c.Get("X-Session-Token")
+	}
 
 	if sessionToken != "" {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
 		defer cancel()
 
-		sessionHash := utils.HashToken(sessionToken)
-		sessionsColl := database.GetCollection("usersessions")
-
 		// Tandai sesi sebagai revoked
-		now := time.Now()
-		sessionsColl.UpdateOne(ctx, bson.M{
-			"token":      sessionHash,
-			"revoked_at": nil,
-		}, bson.M{
-			"$set": bson.M{
-				"revoked_at":       now,
-				"last_active_time": now,
-			},
-		})
-	} else {
-		// Support header session token fallback
-		if headerToken := c.Get("X-Session-Token"); headerToken != "" {
-			sessionToken = headerToken
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-			defer cancel()
-
-			sessionHash := utils.HashToken(sessionToken)
-			sessionsColl := database.GetCollection("usersessions")
-
-			now := time.Now()
-			sessionsColl.UpdateOne(ctx, bson.M{
-				"token":      sessionHash,
-				"revoked_at": nil,
-			}, bson.M{
-				"$set": bson.M{
-					"revoked_at":       now,
-					"last_active_time": now,
-				},
-			})
-		}
+		h.store.Sessions.RevokeByToken(ctx, utils.HashToken(sessionToken), time.Now())
 	}
 
 	userID := c.Locals("userID")
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"solivra-go/backend/internal/models"
+)
+
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		name   string
+		user   func(*models.User) // nil: user "alice" biasa
+		body   string
+		status int
+		check  func(t *testing.T, env *testEnv, user *models.User, body map[string]interface{})
+	}{
+		{
+			name:   "invalid body",
+			body:   `{"username":`,
+			status: 400,
+		},
+		{
+			name:   "missing password",
+			body:   `{"username":"alice"}`,
+			status: 400,
+		},
+		{
+			name:   "unknown user",
+			body:   `{"username":"bob","password":"password123"}`,
+			status: 401,
+		},
+		{
+			name:   "wrong password",
+			body:   `{"username":"alice","password":"wrong-password"}`,
+			status: 401,
+			check: func(t *testing.T, env *testEnv, user *models.User, body map[string]interface{}) {
+				if got := body["attemptsRemaining"]; got != float64(9) {
+					t.Errorf("attemptsRemaining = %v, want 9", got)
+				}
+				stored, _ := env.store.Users.FindByID(context.Background(), user.ID)
+				if stored.FailedLoginAttempts != 1 {
+					t.Errorf("FailedLoginAttempts = %d, want 1", stored.FailedLoginAttempts)
+				}
+			},
+		},
+		{
+			name:   "tenth wrong password locks account",
+			user:   func(u *models.User) { u.FailedLoginAttempts = 9 },
+			body:   `{"username":"alice","password":"wrong-password"}`,
+			status: 423,
+			check: func(t *testing.T, env *testEnv, user *models.User, body map[string]interface{}) {
+				stored, _ := env.store.Users.FindByID(context.Background(), user.ID)
+				if stored.LockoutUntil == nil || !stored.LockoutUntil.After(time.Now()) {
+					t.Errorf("LockoutUntil = %v, want in the future", stored.LockoutUntil)
+				}
+			},
+		},
+		{
+			name: "locked account",
+			user: func(u *models.User) {
+				until := time.Now().Add(5 * time.Minute)
+				u.LockoutUntil = &until
+			},
+			body:   `{"username":"alice","password":"password123"}`,
+			status: 423,
+		},
+		{
+			name:   "two factor challenge",
+			user:   func(u *models.User) { u.TwoFactor.Enabled = true },
+			body:   `{"username":"alice","password":"password123"}`,
+			status: 200,
+			check: func(t *testing.T, env *testEnv, user *models.User, body map[string]interface{}) {
+				if body["two_factor_required"] != true || body["challenge_token"] == "" {
+					t.Errorf("body = %v, want a two factor challenge", body)
+				}
+				if _, ok := body["access_token"]; ok {
+					t.Error("access_token issued before the second factor")
+				}
+			},
+		},
+		{
+			name:   "success with uppercase username",
+			user:   func(u *models.User) { u.FailedLoginAttempts = 3 },
+			body:   `{"username":"ALICE","password":"password123"}`,
+			status: 200,
+			check: func(t *testing.T, env *testEnv, user *models.User, body map[string]interface{}) {
+				for _, key := range []string{"access_token", "refresh_token", "session_token"} {
+					if s, _ := body[key].(string); s == "" {
+						t.Errorf("%s missing from response", key)
+					}
+				}
+				ctx := context.Background()
+				stored, _ := env.store.Users.FindByID(ctx, user.ID)
+				if stored.FailedLoginAttempts != 0 {
+					t.Errorf("FailedLoginAttempts = %d, want reset to 0", stored.FailedLoginAttempts)
+				}
+				if len(stored.RefreshTokens) != 1 {
+					t.Errorf("stored %d refresh tokens, want 1", len(stored.RefreshTokens))
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			user := env.addUser("alice", tt.user)
+
+			status, body := env.do("POST", "/login", user.ID, tt.body)
+			if status != tt.status {
+				t.Fatalf("status = %d, want %d (body %v)", status, tt.status, body)
+			}
+			if tt.check != nil {
+				tt.check(t, env, user, body)
+			}
+		})
+	}
+}
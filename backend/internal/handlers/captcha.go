@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"solivra-go/backend/internal/config"
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/pkg/utils"
+)
+
+// captchaFailureThreshold adalah jumlah kegagalan per IP dalam 1 jam sebelum form dikunci.
+const captchaFailureThreshold = 5
+
+// LoginCaptchaFailed dipanggil middleware Captcha ketika token captcha login tidak valid.
+// Kegagalan dicatat di loginattempts dan ikut dihitung ke IP lock bersama user_not_found.
+func (h *Handler) LoginCaptchaFailed(c *fiber.Ctx, reason string) error {
+	var req LoginRequest
+	_ = c.BodyParser(&req)
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	ip := utils.GetClientIP(c)
+	now := time.Now()
+	username := strings.ToLower(utils.SanitizeString(req.Username, 120))
+	cfg := config.Get()
+	loginAttempts := h.store.LoginAttempts
+
+	if !cfg.DisableIPLockout {
+		if activeIpLock, err := loginAttempts.FindActiveIPLock(ctx, ip, now); err == nil {
+			return c.Status(429).JSON(fiber.Map{
+				"ok":  false,
+				"msg": "Form login sementara dinonaktifkan untuk IP ini. Coba lagi nanti.",
+				"lockout": fiber.Map{
+					"type":  "ip",
+					"until": activeIpLock.LockoutUntil,
+				},
+			})
+		}
+	}
+
+	loginAttempts.Insert(ctx, &models.LoginAttempt{
+		Username:    username,
+		IPAddress:   ip,
+		UserAgent:   c.Get("User-Agent"),
+		Outcome:     "captcha_failed",
+		AttemptTime: now,
+	})
+
+	utils.LogActivity(c, "auth_captcha_failed", map[string]interface{}{
+		"endpoint":        "login",
+		"reason":          reason,
+		"usernameAttempt": username,
+	}, map[string]interface{}{"username": "guest"})
+
+	if !cfg.DisableIPLockout {
+		oneHourAgo := now.Add(-1 * time.Hour)
+		failedAttempts, _ := loginAttempts.CountByIP(ctx, ip, oneHourAgo, "user_not_found", "captcha_failed")
+
+		if failedAttempts >= captchaFailureThreshold {
+			lockoutUntil := now.Add(1 * time.Hour)
+			loginAttempts.Insert(ctx, &models.LoginAttempt{
+				Username:     username,
+				IPAddress:    ip,
+				UserAgent:    c.Get("User-Agent"),
+				Outcome:      "ip_locked",
+				LockoutUntil: &lockoutUntil,
+				AttemptTime:  now,
+			})
+
+			utils.LogActivity(c, "security_ip_lock", map[string]interface{}{
+				"lockout_until": lockoutUntil,
+				"attempts":      failedAttempts,
+			}, map[string]interface{}{"username": "guest"})
+
+			return c.Status(429).JSON(fiber.Map{
+				"ok":  false,
+				"msg": "Form login dinonaktifkan selama 1 jam untuk IP ini.",
+				"lockout": fiber.Map{
+					"type":  "ip",
+					"until": lockoutUntil,
+				},
+			})
+		}
+	}
+
+	return utils.ErrorResponse(c, 400, "Verifikasi captcha gagal. Silakan coba lagi.")
+}
+
+// RegisterCaptchaFailed dipanggil middleware Captcha ketika token captcha registrasi tidak valid.
+// Kegagalan dicatat di registrationattempts dan memblokir IP setelah terlalu sering gagal.
+func (h *Handler) RegisterCaptchaFailed(c *fiber.Ctx, reason string) error {
+	var req RegisterRequest
+	_ = c.BodyParser(&req)
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	ip := utils.GetClientIP(c)
+	now := time.Now()
+	username := strings.ToLower(utils.SanitizeString(req.Username, 30))
+	regAttempts := h.store.RegistrationAttempts
+
+	if block, err := regAttempts.FindActiveBlock(ctx, ip, now); err == nil {
+		return c.Status(429).JSON(fiber.Map{
+			"ok":  false,
+			"msg": "Form registrasi dinonaktifkan sementara untuk IP ini.",
+			"lockout": fiber.Map{
+				"type":  "registration",
+				"until": block.BlockedUntil,
+			},
+		})
+	}
+
+	regAttempts.Insert(ctx, &models.RegistrationAttempt{
+		IPAddress:   ip,
+		Username:    username,
+		AttemptTime: now,
+		Status:      "captcha_failed",
+	})
+
+	utils.LogActivity(c, "auth_captcha_failed", map[string]interface{}{
+		"endpoint":        "register",
+		"reason":          reason,
+		"usernameAttempt": username,
+	}, map[string]interface{}{"username": "guest"})
+
+	oneHourAgo := now.Add(-1 * time.Hour)
+	failedAttempts, _ := regAttempts.CountByIP(ctx, ip, oneHourAgo, "captcha_failed")
+
+	if failedAttempts >= captchaFailureThreshold {
+		lockoutUntil := now.Add(1 * time.Hour)
+		regAttempts.Insert(ctx, &models.RegistrationAttempt{
+			IPAddress:    ip,
+			Username:     username,
+			AttemptTime:  now,
+			Status:       "blocked",
+			BlockedUntil: &lockoutUntil,
+		})
+
+		utils.LogActivity(c, "security_registration_block", map[string]interface{}{
+			"lockout_until": lockoutUntil,
+			"attempts":      failedAttempts,
+			"reason":        "captcha_failed",
+		}, map[string]interface{}{"username": "guest"})
+
+		return c.Status(429).JSON(fiber.Map{
+			"ok":  false,
+			"msg": "Form registrasi dinonaktifkan sementara untuk IP ini.",
+			"lockout": fiber.Map{
+				"type":  "registration",
+				"until": lockoutUntil,
+			},
+		})
+	}
+
+	return utils.ErrorResponse(c, 400, "Verifikasi captcha gagal. Silakan coba lagi.")
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/export"
+	"solivra-go/backend/internal/logging"
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/store"
+	"solivra-go/backend/pkg/utils"
+)
+
+// exportSyncMaxLogs adalah batas activity log untuk ekspor langsung; akun yang lebih
+// besar diekspor di background dan diunduh lewat link yang kedaluwarsa.
+const exportSyncMaxLogs = 2000
+
+// dataExportResponse menyusun status job ekspor; download_url hanya ada jika arsip siap.
+func dataExportResponse(e *models.DataExport) fiber.Map {
+	response := fiber.Map{
+		"_id":          e.ID,
+		"status":       e.Status,
+		"format":       e.FileFormat(),
+		"created_at":   e.CreatedAt,
+		"completed_at": e.CompletedAt,
+		"expires_at":   e.ExpiresAt,
+		"size_bytes":   e.SizeBytes,
+		"download_url": nil,
+	}
+	if e.Status == models.DataExportFailed {
+		response["error"] = e.Error
+	}
+	if e.Status == models.DataExportReady && e.ExpiresAt != nil && e.ExpiresAt.After(time.Now()) {
+		if token, err := utils.GenerateExportDownloadToken(e.ID, *e.ExpiresAt); err == nil {
+			response["download_url"] = "/api/exports/" + e.ID.Hex() + "/download?token=" + token
+		}
+	}
+	return response
+}
+
+// ExportData handles GET /api/users/export
+// format=zip (default) atau json. Akun besar (atau async=true) diproses di background
+// dengan format yang sama: respons 202 berisi job yang statusnya bisa dipantau di
+// GET /api/users/export/:id.
+func (h *Handler) ExportData(c *fiber.Ctx) error {
+	format := c.Query("format", models.DataExportFormatZip)
+	if format != models.DataExportFormatZip && format != models.DataExportFormatJSON {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Format ekspor tidak valid.")
+	}
+
+	userID := c.Locals("userObjectID").(primitive.ObjectID)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 30*time.Second)
+	defer cancel()
+
+	user, err := h.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "User not found")
+	}
+
+	// Satu job berjalan per user. Job yang macet (worker mati sebelum selesai) ditandai
+	// gagal agar user bisa memulai ekspor baru.
+	if pending, err := h.store.DataExports.FindPending(ctx, userID); err == nil {
+		if !export.Stale(pending, time.Now()) {
+			return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+				"export": dataExportResponse(pending),
+				"msg":    "Ekspor data sedang diproses.",
+			})
+		}
+		if err := export.MarkStale(ctx, h.store, pending, time.Now()); err != nil {
+			logging.For(c).Error("error failing stale data export", "export_id", pending.ID.Hex(), "err", err)
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal memproses ekspor data.")
+		}
+	} else if !errors.Is(err, store.ErrNotFound) {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal memproses ekspor data.")
+	}
+
+	logCount, err := h.store.ActivityLogs.CountByUser(ctx, userID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal memproses ekspor data.")
+	}
+
+	if c.QueryBool("async") || logCount > exportSyncMaxLogs {
+		job := models.DataExport{
+			ID:        primitive.NewObjectID(),
+			UserID:    userID,
+			Status:    models.DataExportPending,
+			Format:    format,
+			CreatedAt: time.Now(),
+		}
+		if err := h.store.DataExports.Create(ctx, &job); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal memproses ekspor data.")
+		}
+
+		if !h.exports.Enqueue(job) {
+			if err := h.store.DataExports.Delete(ctx, job.ID); err != nil {
+				logging.For(c).Error("error deleting unqueued data export", "export_id", job.ID.Hex(), "err", err)
+			}
+			return utils.ErrorResponse(c, fiber.StatusServiceUnavailable, "Terlalu banyak ekspor data sedang diproses. Coba lagi nanti.")
+		}
+
+		utils.LogActivity(c, "user_data_export_requested", fiber.Map{
+			"export_id": job.ID.Hex(),
+			"mode":      "async",
+			"format":    format,
+		}, nil)
+
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"export": dataExportResponse(&job),
+			"msg":    "Ekspor data sedang diproses. Link unduhan akan tersedia setelah selesai.",
+		})
+	}
+
+	bundle, err := export.Collect(ctx, h.store, h.files, user, format == models.DataExportFormatZip)
+	if err != nil {
+		logging.For(c).Error("error collecting export data", "err", err)
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal memproses ekspor data.")
+	}
+
+	utils.LogActivity(c, "user_data_exported", fiber.Map{
+		"mode":   "sync",
+		"format": format,
+	}, nil)
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	if format == models.DataExportFormatJSON {
+		c.Attachment(export.FileName(userID, bundle.GeneratedAt.In(user.Location()), format))
+		return c.JSON(bundle)
+	}
+
+	c.Attachment(export.FileName(userID, bundle.GeneratedAt.In(user.Location()), format))
+	c.Set(fiber.HeaderContentType, "application/zip")
+	logger := logging.For(c)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := export.WriteZip(w, bundle); err != nil {
+			logger.Error("error streaming export zip", "err", err)
+		}
+		w.Flush()
+	})
+	return nil
+}
+
+// GetDataExport handles GET /api/users/export/:id
+func (h *Handler) GetDataExport(c *fiber.Ctx) error {
+	exportID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Ekspor tidak ditemukan.")
+	}
+
+	userID := c.Locals("userObjectID").(primitive.ObjectID)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	job, err := h.store.DataExports.FindByID(ctx, exportID, userID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Ekspor tidak ditemukan.")
+	}
+
+	return c.JSON(fiber.Map{"export": dataExportResponse(job)})
+}
+
+// DownloadDataExport handles GET /api/exports/:id/download?token=...
+// Tidak memerlukan sesi: token bertanda tangan di URL sudah membuktikan kepemilikan.
+func (h *Handler) DownloadDataExport(c *fiber.Ctx) error {
+	claims, err := utils.ValidateExportDownloadToken(c.Query("token"))
+	if err != nil || claims.ExportID != c.Params("id") {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, "Link unduhan tidak valid atau sudah kedaluwarsa.")
+	}
+	exportID, err := primitive.ObjectIDFromHex(claims.ExportID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, "Link unduhan tidak valid atau sudah kedaluwarsa.")
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	job, err := h.store.DataExports.Get(ctx, exportID)
+	if err != nil || job.Status != models.DataExportReady || job.ExpiresAt == nil || !job.ExpiresAt.After(time.Now()) {
+		return utils.ErrorResponse(c, fiber.StatusGone, "Arsip ekspor tidak tersedia lagi.")
+	}
+	if _, err := os.Stat(job.FilePath); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusGone, "Arsip ekspor tidak tersedia lagi.")
+	}
+
+	utils.LogActivity(c, "user_data_export_downloaded", fiber.Map{
+		"export_id": job.ID.Hex(),
+	}, map[string]interface{}{"userId": job.UserID.Hex()})
+
+	completedAt := *job.CompletedAt
+	if owner, err := h.store.Users.FindByID(ctx, job.UserID); err == nil {
+		completedAt = completedAt.In(owner.Location())
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Download(job.FilePath, export.FileName(job.UserID, completedAt, job.FileFormat()))
+}
@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/logging"
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/services"
+	"solivra-go/backend/internal/store"
+	"solivra-go/backend/pkg/utils"
+)
+
+const maxHabitsPerUser = 10
+
+var habitColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// resolveHabit mengembalikan habit milik user dengan ID rawID, atau habit default jika
+// rawID kosong (dibuat dari streak lama user bila belum ada). ID yang tidak valid atau
+// milik user lain menghasilkan store.ErrNotFound.
+func (h *Handler) resolveHabit(ctx context.Context, user *models.User, rawID string) (*models.Habit, error) {
+	if rawID == "" {
+		return services.EnsureDefaultHabit(ctx, h.store, user)
+	}
+	habitID, err := primitive.ObjectIDFromHex(rawID)
+	if err != nil {
+		return nil, store.ErrNotFound
+	}
+	return h.store.Habits.FindByID(ctx, habitID, user.ID)
+}
+
+// userHabit sama seperti resolveHabit, tetapi membaca user dari DB terlebih dahulu.
+func (h *Handler) userHabit(ctx context.Context, userID primitive.ObjectID, rawID string) (*models.Habit, error) {
+	user, err := h.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return h.resolveHabit(ctx, user, rawID)
+}
+
+// habitError menerjemahkan error resolveHabit menjadi respons HTTP
+func habitError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, store.ErrNotFound) {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Habit tidak ditemukan.")
+	}
+	logging.For(c).Error("error resolving habit", "err", err)
+	return c.Status(fiber.StatusInternalServerError).SendString("Server Error")
+}
+
+// habitResponse menggabungkan habit dengan ringkasan streak-nya (jika sudah dimulai)
+func (h *Handler) habitResponse(ctx context.Context, habit *models.Habit, now time.Time) fiber.Map {
+	response := fiber.Map{
+		"_id":                    habit.ID,
+		"name":                   habit.Name,
+		"kind":                   habit.Kind,
+		"is_default":             habit.IsDefault,
+		"settings":               habit.Settings,
+		"streak_start_date":      habit.StreakStartDate,
+		"longest_streak_seconds": habit.LongestStreakSeconds,
+		"current_streak":         int64(0),
+		"longest_streak":         habit.LongestStreakSeconds,
+		"total_relapses":         0,
+		"created_at":             habit.CreatedAt,
+		"updated_at":             habit.UpdatedAt,
+	}
+	if summary, err := h.store.StreakSummaries.Get(ctx, habit.ID); err == nil {
+		response["current_streak"] = summary.CurrentSeconds(now)
+		response["longest_streak"] = summary.LongestSeconds(now)
+		response["total_relapses"] = summary.TotalRelapses
+	}
+	return response
+}
+
+// habitPayload adalah body POST/PUT /api/habits. Field nil tidak diubah saat update.
+type habitPayload struct {
+	Name           *string `json:"name"`
+	Kind           *string `json:"kind"`
+	Color          *string `json:"color"`
+	Icon           *string `json:"icon"`
+	ShowInRankings *bool   `json:"show_in_rankings"`
+	StartTime      string  `json:"start_time"` // Hanya saat membuat habit
+}
+
+// validate membersihkan field teks dan mengembalikan pesan error yang aman untuk client
+func (p *habitPayload) validate() string {
+	if p.Name != nil {
+		name := utils.SanitizeString(*p.Name, 50)
+		if name == "" {
+			return "Nama habit wajib diisi (maksimal 50 karakter)."
+		}
+		p.Name = &name
+	}
+	if p.Color != nil {
+		color := strings.TrimSpace(*p.Color)
+		if color != "" && !habitColorPattern.MatchString(color) {
+			return "Warna habit harus berformat #RRGGBB."
+		}
+		p.Color = &color
+	}
+	if p.Icon != nil {
+		icon := utils.SanitizeString(*p.Icon, 32)
+		p.Icon = &icon
+	}
+	return ""
+}
+
+// ListHabits handles GET /api/habits
+func (h *Handler) ListHabits(c *fiber.Ctx) error {
+	userID := c.Locals("userObjectID").(primitive.ObjectID)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	// Pastikan habit default ada sebelum daftar dibaca
+	if _, err := h.userHabit(ctx, userID, ""); err != nil {
+		return habitError(c, err)
+	}
+
+	habits, err := h.store.Habits.ListByUser(ctx, userID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal memuat habit.")
+	}
+
+	now := time.Now()
+	result := make([]fiber.Map, 0, len(habits))
+	for i := range habits {
+		result = append(result, h.habitResponse(ctx, &habits[i], now))
+	}
+
+	return c.JSON(fiber.Map{"habits": result, "kinds": models.HabitKinds})
+}
+
+// CreateHabit handles POST /api/habits
+func (h *Handler) CreateHabit(c *fiber.Ctx) error {
+	var req habitPayload
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Input tidak valid.")
+	}
+	if req.Name == nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Nama habit wajib diisi (maksimal 50 karakter).")
+	}
+	if msg := req.validate(); msg != "" {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, msg)
+	}
+
+	kind := models.HabitKindCustom
+	if req.Kind != nil && *req.Kind != "" {
+		kind = strings.ToLower(strings.TrimSpace(*req.Kind))
+	}
+	// Jenis general hanya dipakai habit default
+	if !models.ValidHabitKind(kind) || kind == models.HabitKindGeneral {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Jenis habit tidak valid.")
+	}
+
+	now := time.Now()
+	var startDate *time.Time
+	if req.StartTime != "" {
+		parsed, err := time.Parse(time.RFC3339, req.StartTime)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Format waktu tidak valid.")
+		}
+		if parsed.After(now.Add(time.Second)) {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Waktu mulai tidak boleh di masa depan.")
+		}
+		startDate = &parsed
+	}
+
+	userID := c.Locals("userObjectID").(primitive.ObjectID)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	// Habit default dibuat lebih dulu agar ikut terhitung dalam batas jumlah habit
+	if _, err := h.userHabit(ctx, userID, ""); err != nil {
+		return habitError(c, err)
+	}
+
+	count, err := h.store.Habits.CountByUser(ctx, userID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal membuat habit.")
+	}
+	if count >= maxHabitsPerUser {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Jumlah habit sudah mencapai batas maksimal.")
+	}
+
+	// Selain custom, satu user hanya boleh punya satu habit per jenis (dipakai ranking per jenis)
+	if kind != models.HabitKindCustom {
+		_, err := h.store.Habits.FindByKind(ctx, userID, kind)
+		if err == nil {
+			return utils.ErrorResponse(c, fiber.StatusConflict, "Habit dengan jenis ini sudah ada.")
+		}
+		if !errors.Is(err, store.ErrNotFound) {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal membuat habit.")
+		}
+	}
+
+	habit := models.Habit{
+		ID:              primitive.NewObjectID(),
+		UserID:          userID,
+		Name:            *req.Name,
+		Kind:            kind,
+		StreakStartDate: startDate,
+		Settings:        models.HabitSettings{ShowInRankings: true},
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if req.Color != nil {
+		habit.Settings.Color = *req.Color
+	}
+	if req.Icon != nil {
+		habit.Settings.Icon = *req.Icon
+	}
+	if req.ShowInRankings != nil {
+		habit.Settings.ShowInRankings = *req.ShowInRankings
+	}
+
+	err = h.withStreakUpdate(ctx, userID, habit.ID, func(ctx context.Context) error {
+		return h.store.Habits.Create(ctx, &habit)
+	})
+	if err != nil {
+		logging.For(c).Error("error creating habit", "err", err)
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal membuat habit.")
+	}
+
+	utils.LogActivity(c, "habit_created", fiber.Map{
+		"habit_id":          habit.ID.Hex(),
+		"name":              habit.Name,
+		"kind":              habit.Kind,
+		"streak_start_date": habit.StreakStartDate,
+	}, nil)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"habit": h.habitResponse(ctx, &habit, now)})
+}
+
+// GetHabit handles GET /api/habits/:id
+func (h *Handler) GetHabit(c *fiber.Ctx) error {
+	userID := c.Locals("userObjectID").(primitive.ObjectID)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	habit, err := h.userHabit(ctx, userID, c.Params("id"))
+	if err != nil {
+		return habitError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"habit": h.habitResponse(ctx, habit, time.Now())})
+}
+
+// GetHabitStats handles GET /api/habits/:id/stats (format sama dengan GET /api/stats)
+func (h *Handler) GetHabitStats(c *fiber.Ctx) error {
+	userID := c.Locals("userObjectID").(primitive.ObjectID)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"msg": "User not found"})
+	}
+	loc, err := requestLocation(c, user)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"msg": err.Error()})
+	}
+
+	habit, err := h.userHabit(ctx, userID, c.Params("id"))
+	if err != nil {
+		return habitError(c, err)
+	}
+
+	return h.habitStats(c, ctx, habit, loc)
+}
+
+// UpdateHabit handles PUT /api/habits/:id
+// name, color, icon, dan show_in_rankings bisa diubah; kind tidak.
+func (h *Handler) UpdateHabit(c *fiber.Ctx) error {
+	var req habitPayload
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Input tidak valid.")
+	}
+	if msg := req.validate(); msg != "" {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, msg)
+	}
+
+	userID := c.Locals("userObjectID").(primitive.ObjectID)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	habit, err := h.userHabit(ctx, userID, c.Params("id"))
+	if err != nil {
+		return habitError(c, err)
+	}
+
+	if req.Kind != nil && *req.Kind != habit.Kind {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Jenis habit tidak bisa diubah.")
+	}
+
+	update := store.HabitUpdate{
+		Name:           req.Name,
+		Color:          req.Color,
+		Icon:           req.Icon,
+		ShowInRankings: req.ShowInRankings,
+	}
+
+	// Ringkasan streak ikut diperbarui karena show_in_rankings menentukan visibilitas di ranking
+	err = h.withStreakUpdate(ctx, userID, habit.ID, func(ctx context.Context) error {
+		return h.store.Habits.Update(ctx, habit.ID, userID, update, time.Now())
+	})
+	if err != nil {
+		logging.For(c).Error("error updating habit", "err", err)
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal memperbarui habit.")
+	}
+
+	updated, err := h.store.Habits.FindByID(ctx, habit.ID, userID)
+	if err != nil {
+		return habitError(c, err)
+	}
+
+	utils.LogActivity(c, "habit_updated", fiber.Map{
+		"habit_id": habit.ID.Hex(),
+		"old_name": habit.Name,
+		"new_name": updated.Name,
+		"settings": updated.Settings,
+	}, nil)
+
+	return c.JSON(fiber.Map{"habit": h.habitResponse(ctx, updated, time.Now()), "msg": "Habit berhasil diperbarui."})
+}
+
+// DeleteHabit handles DELETE /api/habits/:id
+// Habit default tidak bisa dihapus; relapse dan ringkasan streak habit ikut dihapus.
+func (h *Handler) DeleteHabit(c *fiber.Ctx) error {
+	userID := c.Locals("userObjectID").(primitive.ObjectID)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	habit, err := h.userHabit(ctx, userID, c.Params("id"))
+	if err != nil {
+		return habitError(c, err)
+	}
+	if habit.IsDefault {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Habit utama tidak bisa dihapus.")
+	}
+
+	var relapsesRemoved int
+	err = h.store.Tx.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		relapsesRemoved, err = h.store.Relapses.DeleteAllByHabit(ctx, habit.ID, time.Now())
+		if err != nil {
+			return err
+		}
+		deleted, err := h.store.Habits.Delete(ctx, habit.ID, userID)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			return store.ErrNotFound
+		}
+		if err := h.store.Achievements.DeleteByHabit(ctx, habit.ID); err != nil {
+			return err
+		}
+		return h.store.StreakSummaries.Delete(ctx, habit.ID)
+	})
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return habitError(c, err)
+		}
+		logging.For(c).Error("error deleting habit", "err", err)
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal menghapus habit.")
+	}
+
+	utils.LogActivity(c, "habit_deleted", fiber.Map{
+		"habit_id":         habit.ID.Hex(),
+		"name":             habit.Name,
+		"kind":             habit.Kind,
+		"relapses_deleted": relapsesRemoved,
+	}, nil)
+
+	return c.JSON(fiber.Map{"msg": "Habit berhasil dihapus."})
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/services"
+	"solivra-go/backend/internal/store"
+)
+
+// habitNames mengembalikan nama habit dari respons GET /habits sesuai urutannya.
+func habitNames(t *testing.T, body map[string]interface{}) []string {
+	t.Helper()
+	habits, ok := body["habits"].([]interface{})
+	if !ok {
+		t.Fatalf("habits missing from body %v", body)
+	}
+	var names []string
+	for _, h := range habits {
+		names = append(names, h.(map[string]interface{})["name"].(string))
+	}
+	return names
+}
+
+func TestCreateAndListHabits(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("alice", nil)
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	past := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{name: "invalid body", body: `{"name":`, status: 400},
+		{name: "missing name", body: `{"kind":"smoking"}`, status: 400},
+		{name: "blank name", body: `{"name":"   "}`, status: 400},
+		{name: "general kind", body: `{"name":"Main","kind":"general"}`, status: 400},
+		{name: "unknown kind", body: `{"name":"Chess","kind":"chess"}`, status: 400},
+		{name: "bad color", body: `{"name":"Soda","color":"red"}`, status: 400},
+		{name: "future start", body: `{"name":"Soda","start_time":"` + future + `"}`, status: 400},
+		{name: "smoking", body: `{"name":"No cigarettes","kind":"smoking","start_time":"` + past + `"}`, status: 201},
+		{name: "duplicate kind", body: `{"name":"Still no cigarettes","kind":"smoking"}`, status: 409},
+		{name: "custom", body: `{"name":"Soda","color":"#FF0000"}`, status: 201},
+	}
+	for _, tt := range tests {
+		status, body := env.do("POST", "/habits", user.ID, tt.body)
+		if status != tt.status {
+			t.Errorf("%s: status = %d, want %d (body %v)", tt.name, status, tt.status, body)
+		}
+	}
+
+	status, body := env.do("GET", "/habits", user.ID, "")
+	if status != 200 {
+		t.Fatalf("list: status %d, body %v", status, body)
+	}
+	// Habit default dibuat otomatis dan selalu di urutan pertama
+	names := habitNames(t, body)
+	if len(names) != 3 || names[1] != "No cigarettes" || names[2] != "Soda" {
+		t.Fatalf("habits = %v, want default, No cigarettes, Soda", names)
+	}
+	first := body["habits"].([]interface{})[0].(map[string]interface{})
+	if first["is_default"] != true {
+		t.Errorf("first habit is not the default: %v", first)
+	}
+
+	// User lain tidak melihat habit alice
+	bob := env.addUser("bob", nil)
+	_, body = env.do("GET", "/habits", bob.ID, "")
+	if names := habitNames(t, body); len(names) != 1 {
+		t.Errorf("bob sees habits %v, want only his default", names)
+	}
+}
+
+func TestCreateHabitLimit(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("alice", nil)
+
+	// Habit default ikut terhitung dalam batas
+	for i := 1; i < maxHabitsPerUser; i++ {
+		if status, body := env.do("POST", "/habits", user.ID, `{"name":"Habit"}`); status != 201 {
+			t.Fatalf("habit %d: status %d, body %v", i, status, body)
+		}
+	}
+	if status, _ := env.do("POST", "/habits", user.ID, `{"name":"One too many"}`); status != 400 {
+		t.Errorf("status = %d past the limit, want 400", status)
+	}
+}
+
+func TestUpdateHabit(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("alice", nil)
+	_, body := env.do("POST", "/habits", user.ID, `{"name":"Soda"}`)
+	id := body["habit"].(map[string]interface{})["_id"].(string)
+
+	tests := []struct {
+		name   string
+		user   primitive.ObjectID
+		id     string
+		body   string
+		status int
+	}{
+		{name: "kind change", user: user.ID, id: id, body: `{"kind":"smoking"}`, status: 400},
+		{name: "bad color", user: user.ID, id: id, body: `{"color":"#12"}`, status: 400},
+		{name: "unknown habit", user: user.ID, id: primitive.NewObjectID().Hex(), body: `{"name":"Tea"}`, status: 404},
+		{name: "invalid id", user: user.ID, id: "nope", body: `{"name":"Tea"}`, status: 404},
+		{name: "other user", user: env.addUser("bob", nil).ID, id: id, body: `{"name":"Tea"}`, status: 404},
+		{name: "rename", user: user.ID, id: id, body: `{"name":"Sugary drinks","show_in_rankings":false}`, status: 200},
+	}
+	for _, tt := range tests {
+		status, body := env.do("PUT", "/habits/"+tt.id, tt.user, tt.body)
+		if status != tt.status {
+			t.Errorf("%s: status = %d, want %d (body %v)", tt.name, status, tt.status, body)
+		}
+	}
+
+	habitID, _ := primitive.ObjectIDFromHex(id)
+	habit, err := env.store.Habits.FindByID(context.Background(), habitID, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if habit.Name != "Sugary drinks" || habit.Settings.ShowInRankings || habit.Kind != models.HabitKindCustom {
+		t.Errorf("habit after update = %q, show_in_rankings %v, kind %q", habit.Name, habit.Settings.ShowInRankings, habit.Kind)
+	}
+}
+
+func TestDeleteDefaultHabit(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("alice", nil)
+	ctx := context.Background()
+	habit, err := services.EnsureDefaultHabit(ctx, env.store, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, body := env.do("DELETE", "/habits/"+habit.ID.Hex(), user.ID, "")
+	if status != 400 {
+		t.Fatalf("status = %d, want 400 (body %v)", status, body)
+	}
+	if _, err := env.store.Habits.FindByID(ctx, habit.ID, user.ID); err != nil {
+		t.Errorf("default habit gone after rejected delete: %v", err)
+	}
+}
+
+func TestDeleteHabitCascade(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("alice", nil)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	_, body := env.do("POST", "/habits", user.ID, `{"name":"Soda","start_time":"`+now.Add(-10*24*time.Hour).Format(time.RFC3339)+`"}`)
+	habitID, _ := primitive.ObjectIDFromHex(body["habit"].(map[string]interface{})["_id"].(string))
+	for _, d := range []time.Duration{-72 * time.Hour, -24 * time.Hour} {
+		status, body := env.do("POST", "/relapses", user.ID, `{"relapse_time":"`+now.Add(d).Format(time.RFC3339)+`","habit_id":"`+habitID.Hex()+`"}`)
+		if status != 201 {
+			t.Fatalf("create relapse: status %d, body %v", status, body)
+		}
+	}
+	// Relapse dan achievement habit default tidak boleh ikut terhapus
+	env.relapse(user.ID, now.Add(-time.Hour))
+	defaultHabit, err := services.EnsureDefaultHabit(ctx, env.store, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []primitive.ObjectID{habitID, defaultHabit.ID} {
+		a := &models.Achievement{ID: primitive.NewObjectID(), UserID: user.ID, HabitID: id, Code: models.AchievementFirstWeek, Category: models.AchievementCategoryMilestone, AwardedAt: now}
+		if _, err := env.store.Achievements.Award(ctx, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := env.store.StreakSummaries.Get(ctx, habitID); err != nil {
+		t.Fatalf("streak summary missing before delete: %v", err)
+	}
+
+	status, body := env.do("DELETE", "/habits/"+habitID.Hex(), user.ID, "")
+	if status != 200 {
+		t.Fatalf("delete: status %d, body %v", status, body)
+	}
+
+	if _, err := env.store.Habits.FindByID(ctx, habitID, user.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("habit lookup after delete: err = %v, want ErrNotFound", err)
+	}
+	if relapses, _ := env.store.Relapses.ListByHabit(ctx, habitID, true); len(relapses) != 0 {
+		t.Errorf("%d relapses left for deleted habit", len(relapses))
+	}
+	if _, err := env.store.StreakSummaries.Get(ctx, habitID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("streak summary after delete: err = %v, want ErrNotFound", err)
+	}
+	achievements, err := env.store.Achievements.ListByUser(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(achievements) != 1 || achievements[0].HabitID != defaultHabit.ID {
+		t.Errorf("achievements after delete = %v, want only the default habit's", achievements)
+	}
+	if relapses, _ := env.store.Relapses.ListByHabit(ctx, defaultHabit.ID, true); len(relapses) != 1 {
+		t.Errorf("default habit has %d relapses, want 1", len(relapses))
+	}
+
+	// Menghapus ulang menghasilkan 404
+	if status, _ := env.do("DELETE", "/habits/"+habitID.Hex(), user.ID, ""); status != 404 {
+		t.Errorf("second delete: status = %d, want 404", status)
+	}
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"solivra-go/backend/internal/export"
+	"solivra-go/backend/internal/health"
+	"solivra-go/backend/internal/jobs"
+	"solivra-go/backend/internal/storage"
+	"solivra-go/backend/internal/store"
+)
+
+// Handler menampung dependensi yang dibutuhkan seluruh handler HTTP.
+// Semua akses data lewat store sehingga handler bisa dijalankan dengan store in-memory.
+type Handler struct {
+	store *store.Stores
+	// jobs dipakai endpoint admin job; nil jika aplikasi dibuat tanpa scheduler.
+	jobs *jobs.Runner
+	// files menyimpan foto profil; nil berarti upload foto profil tidak tersedia.
+	files storage.Storage
+	// exports memproses ekspor data asinkron dengan worker terbatas.
+	exports *export.Queue
+	// checks dijalankan oleh endpoint readiness.
+	checks []health.Check
+}
+
+// New membuat Handler dengan store, scheduler job, storage file, folder arsip ekspor,
+// dan readiness check yang diberikan.
+func New(s *store.Stores, runner *jobs.Runner, files storage.Storage, exportDir string, checks []health.Check) *Handler {
+	return &Handler{
+		store:   s,
+		jobs:    runner,
+		files:   files,
+		exports: export.NewQueue(s, files, exportDir),
+		checks:  checks,
+	}
+}
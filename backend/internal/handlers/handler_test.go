@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/config"
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/store"
+	"solivra-go/backend/pkg/utils"
+)
+
+const testPassword = "password123"
+
+func TestMain(m *testing.M) {
+	os.Setenv("MONGO_URI", "mongodb://localhost:27017/solivra-test")
+	os.Setenv("JWT_ACCESS_SECRET", "test-access-secret")
+	os.Setenv("JWT_REFRESH_SECRET", "test-refresh-secret")
+	config.Load()
+	os.Exit(m.Run())
+}
+
+// testEnv menjalankan handler di atas store in-memory. Middleware autentikasi diganti
+// header X-Test-User (ID user) dan X-Test-Role.
+type testEnv struct {
+	t     *testing.T
+	store *store.Stores
+	app   *fiber.App
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	st := store.NewMemory()
+	h := New(st, nil, nil, t.TempDir(), nil)
+
+	auth := func(c *fiber.Ctx) error {
+		id, err := primitive.ObjectIDFromHex(c.Get("X-Test-User"))
+		if err != nil {
+			return c.SendStatus(fiber.StatusUnauthorized)
+		}
+		c.Locals("userId", id.Hex())
+		c.Locals("userObjectID", id)
+		c.Locals("role", c.Get("X-Test-Role"))
+		return c.Next()
+	}
+
+	app := fiber.New()
+	app.Post("/login", h.Login)
+	app.Post("/relapses", auth, h.CreateRelapse)
+	app.Post("/relapses/restore", auth, h.RestoreRelapses)
+	app.Get("/habits", auth, h.ListHabits)
+	app.Post("/habits", auth, h.CreateHabit)
+	app.Put("/habits/:id", auth, h.UpdateHabit)
+	app.Delete("/habits/:id", auth, h.DeleteHabit)
+	app.Get("/stats", auth, h.GetStats)
+	app.Get("/rankings", auth, h.GetRankings)
+	app.Get("/admin/rankings", auth, h.GetAdminRankings)
+	return &testEnv{t: t, store: st, app: app}
+}
+
+// do mengirim request sebagai user (NilObjectID: tanpa autentikasi) dan mengembalikan status serta body JSON.
+func (e *testEnv) do(method, path string, user primitive.ObjectID, body string) (int, map[string]interface{}) {
+	e.t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if !user.IsZero() {
+		req.Header.Set("X-Test-User", user.Hex())
+	}
+	resp, err := e.app.Test(req, -1)
+	if err != nil {
+		e.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+	var decoded map[string]interface{}
+	json.Unmarshal(raw, &decoded)
+	return resp.StatusCode, decoded
+}
+
+// addUser menyimpan user dengan password testPassword. mutate (opsional) dijalankan sebelum disimpan.
+func (e *testEnv) addUser(username string, mutate func(*models.User)) *models.User {
+	e.t.Helper()
+	hashed, err := utils.HashPassword(testPassword)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	now := time.Now()
+	user := &models.User{
+		ID:             primitive.NewObjectID(),
+		Nickname:       username,
+		Username:       username,
+		Password:       hashed,
+		Role:           "user",
+		ProfilePicture: "/default.png",
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if mutate != nil {
+		mutate(user)
+	}
+	if err := e.store.Users.Create(context.Background(), user); err != nil {
+		e.t.Fatal(err)
+	}
+	return user
+}
+
+// relapse mencatat relapse lewat POST /relapses dan menggagalkan test jika tidak 201.
+func (e *testEnv) relapse(user primitive.ObjectID, at time.Time) {
+	e.t.Helper()
+	status, body := e.do("POST", "/relapses", user, `{"relapse_time":"`+at.UTC().Format(time.RFC3339)+`"}`)
+	if status != fiber.StatusCreated {
+		e.t.Fatalf("create relapse: status %d, body %v", status, body)
+	}
+}
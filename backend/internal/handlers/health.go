@@ -1,14 +1,18 @@
 package handlers
 
 import (
+	"context"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+
+	"solivra-go/backend/internal/health"
+	"solivra-go/backend/internal/logging"
 )
 
 // HealthCheck handles GET /api/health
 // Mirip dengan backend/server.js health check
-func HealthCheck(c *fiber.Ctx) error {
+func (h *Handler) HealthCheck(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{
 		"status":    "ok",
 		"timestamp": time.Now().Format(time.RFC3339),
@@ -20,4 +24,40 @@ func HealthCheck(c *fiber.Ctx) error {
 			"admin":    "/api/admin",
 		},
 	})
-}
\ No newline at end of file
+}
+
+// LiveCheck handles GET /api/health/live
+// Liveness: hanya memastikan proses masih melayani request, tanpa memeriksa dependensi,
+// agar orchestrator tidak me-restart container saat MongoDB sedang bermasalah.
+func (h *Handler) LiveCheck(c *fiber.Ctx) error {
+	version, commit := health.Build()
+	return c.JSON(fiber.Map{
+		"status":         health.StatusOK,
+		"version":        version,
+		"commit":         commit,
+		"uptime_seconds": int64(health.Uptime().Seconds()),
+		"timestamp":      time.Now().Format(time.RFC3339),
+	})
+}
+
+// ReadyCheck handles GET /api/health/ready
+// Readiness: memeriksa MongoDB, storage, dan kelengkapan konfigurasi. Mengembalikan 503
+// beserta status per dependensi jika ada yang tidak sehat. Endpoint ini publik, jadi
+// pesan error (nama secret yang kosong, alamat database, dll.) hanya ditulis ke log.
+func (h *Handler) ReadyCheck(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	report := health.Run(ctx, h.checks)
+	for name, result := range report.Checks {
+		if result.Status != health.StatusOK {
+			logging.For(c).Warn("readiness check failed", "check", name, "err", result.Error)
+		}
+		result.Error = ""
+		report.Checks[name] = result
+	}
+	if !report.Healthy() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(report)
+	}
+	return c.JSON(report)
+}
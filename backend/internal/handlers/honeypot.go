@@ -6,9 +6,8 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson"
 
-	"solivra-go/backend/internal/database"
+	"solivra-go/backend/internal/logging"
 	"solivra-go/backend/internal/models"
 	"solivra-go/backend/pkg/utils"
 )
@@ -30,7 +29,7 @@ var FAKE_CREDENTIALS = map[string]string{
 }
 
 // logHoneypotIncident records the incident to DB
-func logHoneypotIncident(c *fiber.Ctx, typeName string, data map[string]interface{}) {
+func (h *Handler) logHoneypotIncident(c *fiber.Ctx, typeName string, data map[string]interface{}) {
 	ip := utils.GetClientIP(c)
 	userAgent := c.Get("User-Agent")
 
@@ -42,13 +41,11 @@ func logHoneypotIncident(c *fiber.Ctx, typeName string, data map[string]interfac
 		SubmittedData: data,
 	}
 
-	honeypotColl := database.GetCollection("honeypotlogs")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
-	_, err := honeypotColl.InsertOne(ctx, incident)
-	if err != nil {
-		c.Context().Logger().Printf("Failed to log honeypot incident: %v", err)
+	if err := h.store.Honeypots.Insert(ctx, &incident); err != nil {
+		logging.For(c).Error("failed to log honeypot incident", "err", err)
 	}
 
 	// Log to main activity log (MERN Logic)
@@ -60,14 +57,14 @@ func logHoneypotIncident(c *fiber.Ctx, typeName string, data map[string]interfac
 
 // FakeAdminLogin handles fake admin login attempts
 // FIXED: Nama fungsi diubah menjadi Kapital (FakeAdminLogin)
-func FakeAdminLogin(c *fiber.Ctx) error {
+func (h *Handler) FakeAdminLogin(c *fiber.Ctx) error {
 	var req struct {
 		Username string `json:"username"`
 		Password string `json:"password"`
 	}
 	if err := c.BodyParser(&req); err != nil {
 		// Log attempt even if parsing fails
-		logHoneypotIncident(c, "fake_admin_login_attempt", map[string]interface{}{
+		h.logHoneypotIncident(c, "fake_admin_login_attempt", map[string]interface{}{
 			"error": "invalid_body",
 		})
 		return utils.ErrorResponse(c, 400, "Invalid request body")
@@ -77,7 +74,7 @@ func FakeAdminLogin(c *fiber.Ctx) error {
 	password := utils.SanitizeString(req.Password, 100)
 
 	// Log the honeypot incident
-	logHoneypotIncident(c, "fake_admin_login_attempt", map[string]interface{}{
+	h.logHoneypotIncident(c, "fake_admin_login_attempt", map[string]interface{}{
 		"username": username,
 		"password": password,
 	})
@@ -105,8 +102,8 @@ func FakeAdminLogin(c *fiber.Ctx) error {
 
 // LogAdminAccess handles logging unauthorized admin page access
 // FIXED: Nama fungsi diubah menjadi Kapital (LogAdminAccess)
-func LogAdminAccess(c *fiber.Ctx) error {
-	logHoneypotIncident(c, "admin_page_access_attempt", map[string]interface{}{
+func (h *Handler) LogAdminAccess(c *fiber.Ctx) error {
+	h.logHoneypotIncident(c, "admin_page_access_attempt", map[string]interface{}{
 		"referrer": c.Get("Referer"),
 	})
 
@@ -117,39 +114,22 @@ func LogAdminAccess(c *fiber.Ctx) error {
 }
 
 // GetHoneypotStats returns honeypot statistics (Admin only)
-func GetHoneypotStats(c *fiber.Ctx) error {
-	honeypotColl := database.GetCollection("honeypotlogs")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (h *Handler) GetHoneypotStats(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
 	defer cancel()
 
-	totalAttempts, _ := honeypotColl.CountDocuments(ctx, bson.M{})
-	loginAttempts, _ := honeypotColl.CountDocuments(ctx, bson.M{"honeypot_type": "fake_admin_login_attempt"})
-	accessAttempts, _ := honeypotColl.CountDocuments(ctx, bson.M{"honeypot_type": "admin_page_access_attempt"})
-
 	yesterday := time.Now().Add(-24 * time.Hour)
-	recentAttempts, _ := honeypotColl.CountDocuments(ctx, bson.M{"incident_time": bson.M{"$gte": yesterday}})
-
-	uniqueIPs, _ := honeypotColl.Distinct(ctx, "ip_address", bson.M{})
-
-	// Get top attempted usernames (Aggregation)
-	topUsernamesPipeline := []bson.M{
-		{"$match": bson.M{"honeypot_type": "fake_admin_login_attempt"}},
-		{"$group": bson.M{"_id": "$submitted_data.username", "count": bson.M{"$sum": 1}}},
-		{"$sort": bson.M{"count": -1}},
-		{"$limit": 10},
-	}
-	cursor, err := honeypotColl.Aggregate(ctx, topUsernamesPipeline)
-	var topUsernames []bson.M
-	if err == nil {
-		cursor.All(ctx, &topUsernames)
+	stats, err := h.store.Honeypots.Stats(ctx, yesterday)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Failed to fetch honeypot stats")
 	}
 
 	return c.JSON(fiber.Map{
-		"totalAttempts":    totalAttempts,
-		"loginAttempts":    loginAttempts,
-		"accessAttempts":   accessAttempts,
-		"recentAttempts":   recentAttempts,
-		"uniqueIPs":        len(uniqueIPs),
-		"topUsernames":     topUsernames,
+		"totalAttempts":  stats.TotalAttempts,
+		"loginAttempts":  stats.LoginAttempts,
+		"accessAttempts": stats.AccessAttempts,
+		"recentAttempts": stats.RecentAttempts,
+		"uniqueIPs":      stats.UniqueIPs,
+		"topUsernames":   stats.TopUsernames,
 	})
 }
\ No newline at end of file
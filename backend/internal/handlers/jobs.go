@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"solivra-go/backend/pkg/utils"
+)
+
+// GetAdminJobs mengembalikan job terjadwal beserta eksekusi terakhir dan jadwal berikutnya.
+func (h *Handler) GetAdminJobs(c *fiber.Ctx) error {
+	if h.jobs == nil {
+		return utils.ErrorResponse(c, fiber.StatusServiceUnavailable, "Scheduler job tidak aktif.")
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	status, err := h.jobs.Status(ctx, time.Now())
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal memuat status job.")
+	}
+	return c.JSON(status)
+}
+
+// GetAdminJobRuns mengembalikan riwayat eksekusi satu job, terbaru lebih dulu (?limit=, maks 100).
+func (h *Handler) GetAdminJobRuns(c *fiber.Ctx) error {
+	if h.jobs == nil {
+		return utils.ErrorResponse(c, fiber.StatusServiceUnavailable, "Scheduler job tidak aktif.")
+	}
+	name := c.Params("name")
+	if !h.jobs.Has(name) {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Job tidak ditemukan.")
+	}
+
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	runs, err := h.store.Jobs.ListRuns(ctx, name, int64(limit))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal memuat riwayat job.")
+	}
+	return c.JSON(fiber.Map{"job": name, "runs": runs})
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/store"
+	"solivra-go/backend/pkg/utils"
+)
+
+// RelapseDetailsPayload adalah metadata terstruktur relapse di body POST/PUT. Field nil
+// tidak diubah saat update; 0, "" atau [] mengosongkan field.
+type RelapseDetailsPayload struct {
+	Triggers   *[]string `json:"triggers"`
+	MoodBefore *int      `json:"mood_before"`
+	MoodAfter  *int      `json:"mood_after"`
+	Intensity  *int      `json:"intensity"`
+	Location   *string   `json:"location"`
+	Tags       *[]string `json:"tags"`
+}
+
+// IsZero melaporkan apakah tidak ada field metadata yang dikirim.
+func (p RelapseDetailsPayload) IsZero() bool {
+	return p == RelapseDetailsPayload{}
+}
+
+// apply memvalidasi payload lalu menerapkannya pada d. Error yang dikembalikan aman
+// ditampilkan ke client.
+func (p RelapseDetailsPayload) apply(d *models.RelapseDetails) error {
+	if p.Triggers != nil {
+		triggers, err := normalizeTriggers(*p.Triggers)
+		if err != nil {
+			return err
+		}
+		d.Triggers = triggers
+	}
+	if p.MoodBefore != nil {
+		if !validScale(*p.MoodBefore, models.RelapseMoodMin, models.RelapseMoodMax) {
+			return fmt.Errorf("Mood sebelum relapse harus %d-%d.", models.RelapseMoodMin, models.RelapseMoodMax)
+		}
+		d.MoodBefore = *p.MoodBefore
+	}
+	if p.MoodAfter != nil {
+		if !validScale(*p.MoodAfter, models.RelapseMoodMin, models.RelapseMoodMax) {
+			return fmt.Errorf("Mood setelah relapse harus %d-%d.", models.RelapseMoodMin, models.RelapseMoodMax)
+		}
+		d.MoodAfter = *p.MoodAfter
+	}
+	if p.Intensity != nil {
+		if !validScale(*p.Intensity, models.RelapseIntensityMin, models.RelapseIntensityMax) {
+			return fmt.Errorf("Intensitas harus %d-%d.", models.RelapseIntensityMin, models.RelapseIntensityMax)
+		}
+		d.Intensity = *p.Intensity
+	}
+	if p.Location != nil {
+		location := strings.ToLower(strings.TrimSpace(*p.Location))
+		if location != "" && !models.ValidRelapseLocation(location) {
+			return errors.New("Lokasi relapse tidak dikenal.")
+		}
+		d.Location = location
+	}
+	if p.Tags != nil {
+		tags, err := normalizeTags(*p.Tags)
+		if err != nil {
+			return err
+		}
+		d.Tags = tags
+	}
+	return nil
+}
+
+// validScale menerima 0 (kosongkan) atau nilai di antara min dan max.
+func validScale(v, min, max int) bool {
+	return v == 0 || (v >= min && v <= max)
+}
+
+// normalizeTriggers memvalidasi kategori pemicu dan membuang duplikat (urutan dipertahankan).
+func normalizeTriggers(raw []string) ([]string, error) {
+	triggers := make([]string, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for _, t := range raw {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if !models.ValidRelapseTrigger(t) {
+			return nil, fmt.Errorf("Pemicu relapse tidak dikenal: %q.", utils.SanitizeString(t, 32))
+		}
+		if !seen[t] {
+			seen[t] = true
+			triggers = append(triggers, t)
+		}
+	}
+	if len(triggers) > models.MaxRelapseTriggers {
+		return nil, fmt.Errorf("Maksimal %d pemicu per relapse.", models.MaxRelapseTriggers)
+	}
+	return triggers, nil
+}
+
+// normalizeTags membersihkan tag buatan user: huruf kecil, spasi di tengah menjadi "-",
+// tanpa duplikat. Tag kosong diabaikan.
+func normalizeTags(raw []string) ([]string, error) {
+	tags := make([]string, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for _, t := range raw {
+		t = strings.Join(strings.Fields(strings.ToLower(utils.SanitizeString(t, 100))), "-")
+		if t == "" || seen[t] {
+			continue
+		}
+		if len([]rune(t)) > models.MaxRelapseTagLength {
+			return nil, fmt.Errorf("Tag maksimal %d karakter.", models.MaxRelapseTagLength)
+		}
+		seen[t] = true
+		tags = append(tags, t)
+	}
+	if len(tags) > models.MaxRelapseTags {
+		return nil, fmt.Errorf("Maksimal %d tag per relapse.", models.MaxRelapseTags)
+	}
+	return tags, nil
+}
+
+// parseRelapseFilter membaca filter GET /api/relapses: trigger, tag, location,
+// mood_before, mood_after, min_intensity, dan max_intensity.
+func parseRelapseFilter(c *fiber.Ctx) (store.RelapseFilter, error) {
+	f := store.RelapseFilter{
+		Trigger:  strings.ToLower(strings.TrimSpace(c.Query("trigger"))),
+		Location: strings.ToLower(strings.TrimSpace(c.Query("location"))),
+	}
+	if f.Trigger != "" && !models.ValidRelapseTrigger(f.Trigger) {
+		return f, errors.New("Parameter trigger tidak valid.")
+	}
+	if f.Location != "" && !models.ValidRelapseLocation(f.Location) {
+		return f, errors.New("Parameter location tidak valid.")
+	}
+	if tag := c.Query("tag"); tag != "" {
+		tags, _ := normalizeTags([]string{tag})
+		if len(tags) == 0 {
+			return f, errors.New("Parameter tag tidak valid.")
+		}
+		f.Tag = tags[0]
+	}
+
+	scales := []struct {
+		key      string
+		min, max int
+		dst      *int
+	}{
+		{"mood_before", models.RelapseMoodMin, models.RelapseMoodMax, &f.MoodBefore},
+		{"mood_after", models.RelapseMoodMin, models.RelapseMoodMax, &f.MoodAfter},
+		{"min_intensity", models.RelapseIntensityMin, models.RelapseIntensityMax, &f.MinIntensity},
+		{"max_intensity", models.RelapseIntensityMin, models.RelapseIntensityMax, &f.MaxIntensity},
+	}
+	for _, s := range scales {
+		raw := c.Query(s.key)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < s.min || v > s.max {
+			return f, fmt.Errorf("Parameter %s harus %d-%d.", s.key, s.min, s.max)
+		}
+		*s.dst = v
+	}
+	if f.MinIntensity != 0 && f.MaxIntensity != 0 && f.MinIntensity > f.MaxIntensity {
+		return f, errors.New("min_intensity tidak boleh lebih besar dari max_intensity.")
+	}
+	return f, nil
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/importer"
+	"solivra-go/backend/internal/logging"
+	"solivra-go/backend/internal/metrics"
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/services"
+	"solivra-go/backend/internal/store"
+	"solivra-go/backend/pkg/utils"
+)
+
+// importPreviewLimit membatasi jumlah entri yang dikembalikan pada dry run.
+const importPreviewLimit = 200
+
+// importParam membaca parameter impor dari query string atau field multipart.
+func importParam(c *fiber.Ctx, key string) string {
+	if v := c.Query(key); v != "" {
+		return strings.TrimSpace(v)
+	}
+	return strings.TrimSpace(c.FormValue(key))
+}
+
+// importSource mengembalikan isi file impor: field multipart "file" atau body mentah.
+func importSource(c *fiber.Ctx) (io.Reader, error) {
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(data), nil
+	}
+	if len(c.Body()) == 0 || strings.HasPrefix(string(c.Request().Header.ContentType()), fiber.MIMEMultipartForm) {
+		return nil, errors.New("file impor wajib diisi")
+	}
+	return bytes.NewReader(c.Body()), nil
+}
+
+// ImportRelapses handles POST /api/relapses/import
+// Menerima file (multipart "file" atau body mentah) dengan parameter format
+// (csv|loop|habitbull|solivra), timezone (IANA, default zona waktu user), habit_id, source_habit,
+// dan dry_run. Relapse yang sudah ada dilewati; streak dihitung ulang sekali di akhir.
+func (h *Handler) ImportRelapses(c *fiber.Ctx) error {
+	format := importer.Format(strings.ToLower(importParam(c, "format")))
+	if format == "" {
+		format = importer.FormatCSV
+	}
+	if !format.Valid() {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Format impor tidak didukung.")
+	}
+
+	userID := c.Locals("userObjectID").(primitive.ObjectID)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 30*time.Second)
+	defer cancel()
+
+	user, err := h.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "User not found")
+	}
+	loc := user.Location()
+	if timezone := importParam(c, "timezone"); timezone != "" {
+		if loc, err = models.LoadTimezone(timezone); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Zona waktu tidak valid.")
+		}
+	}
+
+	dryRun := importParam(c, "dry_run") == "true" || importParam(c, "dry_run") == "1"
+
+	source, err := importSource(c)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "File impor wajib diisi.")
+	}
+
+	entries, rowErrs, err := importer.Parse(source, format, importer.Options{
+		Location:    loc,
+		SourceHabit: utils.SanitizeString(importParam(c, "source_habit"), 100),
+	})
+	if err != nil {
+		var multi *importer.MultipleHabitsError
+		if errors.As(err, &multi) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"msg":    "File berisi beberapa habit. Pilih salah satu lewat source_habit.",
+				"habits": multi.Habits,
+			})
+		}
+		if errors.Is(err, importer.ErrTooManyEntries) {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest,
+				fmt.Sprintf("Maksimal %d relapse per impor.", importer.MaxEntries))
+		}
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "File impor tidak valid: "+err.Error())
+	}
+
+	habit, err := h.userHabit(ctx, userID, importParam(c, "habit_id"))
+	if err != nil {
+		return habitError(c, err)
+	}
+
+	// Duplikat hanya dicari di rentang tanggal yang dicakup file
+	var existing []models.RelapseLog
+	if from, to, ok := importer.Span(entries, loc); ok {
+		existing, err = h.store.Relapses.ListByHabitBetween(ctx, habit.ID, from, to)
+		if err != nil {
+			logging.For(c).Error("error listing relapses for import", "err", err)
+			return c.Status(fiber.StatusInternalServerError).SendString("Server Error")
+		}
+	}
+
+	plan := importer.Build(entries, relapseTimes(existing), loc, time.Now())
+	rejected := append(rowErrs, plan.Rejected...)
+	if rejected == nil {
+		rejected = []importer.RowError{}
+	}
+
+	if dryRun {
+		preview := plan.New
+		if len(preview) > importPreviewLimit {
+			preview = preview[:importPreviewLimit]
+		}
+		return c.JSON(fiber.Map{
+			"dry_run":    true,
+			"habit_id":   habit.ID,
+			"format":     format,
+			"timezone":   loc.String(),
+			"to_import":  len(plan.New),
+			"duplicates": len(plan.Duplicates),
+			"rejected":   rejected,
+			"preview":    preview,
+		})
+	}
+
+	if len(plan.New) == 0 {
+		return c.JSON(fiber.Map{
+			"imported":   0,
+			"duplicates": len(plan.Duplicates),
+			"rejected":   rejected,
+			"msg":        "Tidak ada relapse baru untuk diimpor.",
+		})
+	}
+
+	now := time.Now()
+	relapses := make([]models.RelapseLog, len(plan.New))
+	for i, e := range plan.New {
+		relapses[i] = models.RelapseLog{
+			ID:          primitive.NewObjectID(),
+			UserID:      userID,
+			HabitID:     habit.ID,
+			RelapseTime: e.Time,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if note := utils.SanitizeString(e.Note, 2048); note != "" {
+			relapses[i].RelapseNote = &note
+		}
+	}
+
+	// Berbeda dengan CreateRelapse, impor tidak menghapus relapse setelahnya:
+	// semua relapse disimpan sekaligus lalu streak dihitung ulang satu kali.
+	var (
+		imported int
+		updated  *models.Habit
+	)
+	err = h.withStreakUpdate(ctx, userID, habit.ID, func(ctx context.Context) error {
+		var err error
+		imported, err = h.store.Relapses.CreateMany(ctx, relapses)
+		if err != nil {
+			return fmt.Errorf("insert relapses: %w", err)
+		}
+		updated, err = services.RecomputeStreak(ctx, h.store, userID, habit.ID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return habitError(c, err)
+		}
+		logging.For(c).Error("error importing relapses", "err", err)
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengimpor relapse.")
+	}
+
+	metrics.RecordEvents("relapse_recorded", imported)
+	utils.LogActivity(c, "relapses_imported", fiber.Map{
+		"habit_id":   habit.ID.Hex(),
+		"format":     format,
+		"timezone":   loc.String(),
+		"imported":   imported,
+		"duplicates": len(plan.Duplicates),
+		"rejected":   len(rejected),
+		"first":      plan.New[0].Time,
+		"last":       plan.New[len(plan.New)-1].Time,
+	}, nil)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"imported":               imported,
+		"duplicates":             len(plan.Duplicates),
+		"rejected":               rejected,
+		"habit_id":               habit.ID,
+		"streak_start_date":      updated.StreakStartDate,
+		"longest_streak_seconds": updated.LongestStreakSeconds,
+		"msg":                    fmt.Sprintf("%d relapse berhasil diimpor.", imported),
+	})
+}
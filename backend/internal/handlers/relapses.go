@@ -4,32 +4,55 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"time"
 
-	"solivra-go/backend/internal/database"
+	"solivra-go/backend/internal/config"
+	"solivra-go/backend/internal/logging"
+	"solivra-go/backend/internal/metrics"
 	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/relapsesync"
+	"solivra-go/backend/internal/services"
+	"solivra-go/backend/internal/store"
 	"solivra-go/backend/pkg/utils"
 
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // RelapsePayload mirrors the expected input for creating/syncing a relapse
 type RelapsePayload struct {
 	RelapseTimeRaw string `json:"relapse_time"`
 	RelapseNoteRaw string `json:"relapse_note"`
+	HabitID        string `json:"habit_id"` // Opsional; kosong berarti habit default
+	RelapseDetailsPayload
 }
 
-// recordRelapse encapsulates the logic for logging the relapse and activity
-func recordRelapse(c *fiber.Ctx, user *models.User, relapseDate time.Time, note string, source string, purgedCount int, streakStartUpdated bool) (*models.RelapseLog, error) {
-	relapseColl := database.GetCollection("relapselogs")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// details memvalidasi metadata relapse di payload. Error aman ditampilkan ke client.
+func (p *RelapsePayload) details() (models.RelapseDetails, error) {
+	var details models.RelapseDetails
+	err := p.RelapseDetailsPayload.apply(&details)
+	return details, err
+}
+
+// withStreakUpdate menjalankan mutasi relapse/streak lalu membangun ulang ringkasan streak
+// habit di dalam satu transaksi, sehingga ranking tidak pernah melihat data setengah jadi.
+// Setelah transaksi berhasil, achievement user dievaluasi dengan ringkasan yang baru.
+func (h *Handler) withStreakUpdate(ctx context.Context, userID, habitID primitive.ObjectID, fn func(ctx context.Context) error) error {
+	err := h.store.Tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := fn(ctx); err != nil {
+			return err
+		}
+		return services.RefreshStreakSummary(ctx, h.store, userID, habitID)
+	})
+	if err == nil {
+		h.evaluateAchievements(ctx, userID)
+	}
+	return err
+}
 
+// recordRelapse mencatat relapse baru pada habit: menghapus relapse habit setelah relapseDate,
+// memundurkan streak_start_date bila perlu, menyimpan relapse, lalu mencatat activity log.
+func (h *Handler) recordRelapse(c *fiber.Ctx, ctx context.Context, habit *models.Habit, relapseDate time.Time, note string, details models.RelapseDetails, source string) (*models.RelapseLog, error) {
 	sanitizedNote := utils.SanitizeString(note, 2048)
 	var relapseNote *string
 	if sanitizedNote != "" {
@@ -37,36 +60,75 @@ func recordRelapse(c *fiber.Ctx, user *models.User, relapseDate time.Time, note
 		relapseNote = &noteCopy
 	}
 
-	relapse := models.RelapseLog{
-		ID:          primitive.NewObjectID(),
-		UserID:      user.ID,
-		RelapseTime: relapseDate,
-		RelapseNote: relapseNote,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-	}
+	shouldUpdateStreakStart := habit.StreakStartDate == nil || habit.StreakStartDate.After(relapseDate)
+
+	var (
+		relapse            models.RelapseLog
+		purgedCount        int
+		deletionID         = primitive.NewObjectID()
+		streakStartUpdated bool
+	)
+
+	err := h.withStreakUpdate(ctx, habit.UserID, habit.ID, func(ctx context.Context) error {
+		var err error
+
+		// Soft delete relapse yang tercatat setelah relapse yang baru. Semuanya memakai
+		// deletionID yang sama sehingga bisa dipulihkan sekaligus lewat POST /api/relapses/restore.
+		purgedCount, err = h.store.Relapses.SoftDeleteAfter(ctx, habit.ID, deletionID, relapseDate, time.Now())
+		if err != nil {
+			return fmt.Errorf("purge relapses: %w", err)
+		}
 
-	_, err := relapseColl.InsertOne(ctx, relapse)
+		// Update streak_start_date jika perlu
+		streakStartUpdated = false
+		if shouldUpdateStreakStart {
+			streakStartUpdated, err = h.store.Habits.UpdateStreakStartIfLater(ctx, habit.ID, relapseDate)
+			if err != nil {
+				return fmt.Errorf("update streak start date: %w", err)
+			}
+		}
+
+		relapse = models.RelapseLog{
+			ID:             primitive.NewObjectID(),
+			UserID:         habit.UserID,
+			HabitID:        habit.ID,
+			RelapseTime:    relapseDate,
+			RelapseNote:    relapseNote,
+			RelapseDetails: details,
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+		}
+		return h.store.Relapses.Create(ctx, &relapse)
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	if streakStartUpdated {
+		habit.StreakStartDate = &relapseDate
+	}
+
 	// Log Activity (MERN logic replication)
 	logDetails := fiber.Map{
 		"relapse_id":             relapse.ID.Hex(),
+		"habit_id":               habit.ID.Hex(),
 		"relapse_time":           relapseDate,
 		"relapse_note":           relapseNote,
+		"details":                details,
 		"source":                 source,
 		"streak_start_updated":   streakStartUpdated,
 		"purged_future_relapses": purgedCount,
 	}
+	if purgedCount > 0 {
+		logDetails["deletion_id"] = deletionID.Hex()
+	}
 
 	utils.LogActivity(c, "relapse_recorded", logDetails, nil)
 	return &relapse, nil
 }
 
 // CreateRelapse handles POST /api/relapses
-func CreateRelapse(c *fiber.Ctx) error {
+func (h *Handler) CreateRelapse(c *fiber.Ctx) error {
 	var payload RelapsePayload
 	if err := c.BodyParser(&payload); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"msg": "Input tidak valid."})
@@ -87,67 +149,35 @@ func CreateRelapse(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"msg": "Waktu relapse tidak boleh di masa depan."})
 	}
 
+	details, err := payload.details()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"msg": err.Error()})
+	}
+
 	userIDHex := c.Locals("userId").(string)
 	userID, _ := primitive.ObjectIDFromHex(userIDHex)
 
-	userColl := database.GetCollection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
-	var user models.User
-	if err := userColl.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
+	user, err := h.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"msg": "User not found"})
 		}
-		log.Printf("Error fetching user: %v", err)
+		logging.For(c).Error("error fetching user", "err", err)
 		return c.Status(fiber.StatusInternalServerError).SendString("Server Error")
 	}
 
-	var currentStart *time.Time = nil
-	if user.StreakStartDate != nil {
-		currentStart = user.StreakStartDate
-	}
-
-	shouldUpdateStreakStart := currentStart == nil || currentStart.After(relapseDate)
-
-	// Hapus relapse yang tercatat di masa depan setelah relapse yang baru
-	relapseColl := database.GetCollection("relapselogs")
-	purgeResult, err := relapseColl.DeleteMany(ctx, bson.M{
-		"user":         user.ID,
-		"relapse_time": bson.M{"$gt": relapseDate},
-	})
+	habit, err := h.resolveHabit(ctx, user, payload.HabitID)
 	if err != nil {
-		log.Printf("Error purging relapses: %v", err)
-	}
-	purgedCount := int(purgeResult.DeletedCount)
-
-	// Update streak_start_date jika perlu
-	streakStartUpdated := false
-	if shouldUpdateStreakStart {
-		update := bson.M{"$set": bson.M{"streak_start_date": relapseDate}}
-		filter := bson.M{
-			"_id": user.ID,
-			"$or": bson.A{
-				bson.M{"streak_start_date": bson.M{"$exists": false}},
-				bson.M{"streak_start_date": nil},
-				bson.M{"streak_start_date": bson.M{"$gt": relapseDate}},
-			},
-		}
-
-		updateResult, err := userColl.UpdateOne(ctx, filter, update)
-		if err != nil {
-			log.Printf("Error updating streak start date: %v", err)
-		}
-		if updateResult.ModifiedCount > 0 {
-			streakStartUpdated = true
-			user.StreakStartDate = &relapseDate
-		}
+		return habitError(c, err)
 	}
 
 	// Catat relapse baru
-	relapse, err := recordRelapse(c, &user, relapseDate, payload.RelapseNoteRaw, "manual", purgedCount, streakStartUpdated)
+	relapse, err := h.recordRelapse(c, ctx, habit, relapseDate, payload.RelapseNoteRaw, details, "manual")
 	if err != nil {
-		log.Printf("Error recording relapse: %v", err)
+		logging.For(c).Error("error recording relapse", "err", err)
 		return c.Status(fiber.StatusInternalServerError).SendString("Server Error")
 	}
 
@@ -155,7 +185,9 @@ func CreateRelapse(c *fiber.Ctx) error {
 }
 
 // SyncRelapse handles POST /api/relapses/sync
-func SyncRelapse(c *fiber.Ctx) error {
+// Deprecated: seperti CreateRelapse, relapse setelah relapse yang dikirim ikut dihapus.
+// Klien offline sebaiknya memakai POST /api/relapses/sync/batch.
+func (h *Handler) SyncRelapse(c *fiber.Ctx) error {
 	var payload RelapsePayload
 	if err := c.BodyParser(&payload); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"msg": "Input tidak valid."})
@@ -175,88 +207,173 @@ func SyncRelapse(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"msg": "Data relapse tidak valid."})
 	}
 
+	details, err := payload.details()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"msg": err.Error()})
+	}
+
 	userIDHex := c.Locals("userId").(string)
 	userID, _ := primitive.ObjectIDFromHex(userIDHex)
 
-	userColl := database.GetCollection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
-	var user models.User
-	if err := userColl.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
+	user, err := h.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"msg": "User not found"})
 		}
-		log.Printf("Error fetching user: %v", err)
+		logging.For(c).Error("error fetching user", "err", err)
 		return c.Status(fiber.StatusInternalServerError).SendString("Server Error")
 	}
 
-	var currentStart *time.Time = nil
-	if user.StreakStartDate != nil {
-		currentStart = user.StreakStartDate
+	habit, err := h.resolveHabit(ctx, user, payload.HabitID)
+	if err != nil {
+		return habitError(c, err)
 	}
-	shouldUpdateStreakStart := currentStart == nil || currentStart.After(relapseDate)
 
-	relapseColl := database.GetCollection("relapselogs")
-	purgeResult, err := relapseColl.DeleteMany(ctx, bson.M{
-		"user":         user.ID,
-		"relapse_time": bson.M{"$gt": relapseDate},
-	})
+	_, err = h.recordRelapse(c, ctx, habit, relapseDate, payload.RelapseNoteRaw, details, "sync")
 	if err != nil {
-		log.Printf("Error purging relapses during sync: %v", err)
+		logging.For(c).Error("error recording sync relapse", "err", err)
+		return c.Status(fiber.StatusInternalServerError).SendString("Server Error")
 	}
-	purgedCount := int(purgeResult.DeletedCount)
 
-	streakStartUpdated := false
-	if shouldUpdateStreakStart {
-		update := bson.M{"$set": bson.M{"streak_start_date": relapseDate}}
-		filter := bson.M{
-			"_id": user.ID,
-			"$or": bson.A{
-				bson.M{"streak_start_date": bson.M{"$exists": false}},
-				bson.M{"streak_start_date": nil},
-				bson.M{"streak_start_date": bson.M{"$gt": relapseDate}},
-			},
-		}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"ok": true, "msg": "Data relapse berhasil disinkronisasi."})
+}
 
-		updateResult, err := userColl.UpdateOne(ctx, filter, update)
+// syncBatchPayload adalah body POST /api/relapses/sync/batch
+type syncBatchPayload struct {
+	Operations []relapsesync.Operation `json:"operations"`
+	Cursor     string                  `json:"cursor"`
+	Limit      int                     `json:"limit"`
+}
+
+// SyncRelapsesBatch handles POST /api/relapses/sync/batch
+// Menerapkan operasi offline klien secara idempoten (aturan konflik: lihat paket
+// relapsesync), menghitung ulang streak habit yang berubah sekali, lalu mengembalikan
+// perubahan server sejak cursor klien.
+func (h *Handler) SyncRelapsesBatch(c *fiber.Ctx) error {
+	var payload syncBatchPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"msg": "Input tidak valid."})
+	}
+	if len(payload.Operations) > relapsesync.MaxOperations {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"msg": fmt.Sprintf("Maksimal %d operasi per sinkronisasi.", relapsesync.MaxOperations),
+		})
+	}
+	since, err := relapsesync.DecodeCursor(payload.Cursor)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"msg": "Cursor sinkronisasi tidak valid."})
+	}
+	if payload.Limit <= 0 || payload.Limit > 1000 {
+		payload.Limit = 500
+	}
+
+	userID := c.Locals("userObjectID").(primitive.ObjectID)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 30*time.Second)
+	defer cancel()
+
+	// Habit default disiapkan di luar transaksi (bisa membuat habit baru)
+	defaultHabit, err := h.userHabit(ctx, userID, "")
+	if err != nil {
+		return habitError(c, err)
+	}
+	resolve := func(ctx context.Context, rawID string) (*models.Habit, error) {
+		if rawID == "" || rawID == defaultHabit.ID.Hex() {
+			return defaultHabit, nil
+		}
+		habitID, err := primitive.ObjectIDFromHex(rawID)
+		if err != nil {
+			return nil, store.ErrNotFound
+		}
+		return h.store.Habits.FindByID(ctx, habitID, userID)
+	}
+
+	var batch *relapsesync.Batch
+	if len(payload.Operations) > 0 {
+		err = h.store.Tx.WithTransaction(ctx, func(ctx context.Context) error {
+			var err error
+			batch, err = relapsesync.Apply(ctx, h.store, userID, payload.Operations, resolve, time.Now())
+			if err != nil {
+				return err
+			}
+			for _, habitID := range batch.Habits {
+				if _, err := services.RecomputeStreak(ctx, h.store, userID, habitID); err != nil {
+					return err
+				}
+				if err := services.RefreshStreakSummary(ctx, h.store, userID, habitID); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
 		if err != nil {
-			log.Printf("Error updating streak start date during sync: %v", err)
+			logging.For(c).Error("error applying sync batch", "err", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"msg": "Gagal menyinkronkan relapse."})
 		}
-		if updateResult.ModifiedCount > 0 {
-			streakStartUpdated = true
-			user.StreakStartDate = &relapseDate
+		if len(batch.Habits) > 0 {
+			h.evaluateAchievements(ctx, userID)
 		}
+	} else {
+		batch = &relapsesync.Batch{Results: []relapsesync.Result{}}
 	}
 
-	_, err = recordRelapse(c, &user, relapseDate, payload.RelapseNoteRaw, "sync", purgedCount, streakStartUpdated)
+	changes, err := relapsesync.ChangesSince(ctx, h.store, userID, since, payload.Limit, time.Now())
 	if err != nil {
-		log.Printf("Error recording sync relapse: %v", err)
-		return c.Status(fiber.StatusInternalServerError).SendString("Server Error")
+		logging.For(c).Error("error listing sync changes", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"msg": "Gagal menyinkronkan relapse."})
+	}
+
+	if len(payload.Operations) > 0 {
+		counts := make(map[relapsesync.Status]int)
+		created := 0
+		for _, r := range batch.Results {
+			counts[r.Status]++
+			if r.Op == relapsesync.OpCreate && r.Status == relapsesync.StatusApplied {
+				created++
+			}
+		}
+		metrics.RecordEvents("relapse_recorded", created)
+		utils.LogActivity(c, "relapses_synced", fiber.Map{
+			"operations":     len(payload.Operations),
+			"results":        counts,
+			"habits_updated": len(batch.Habits),
+		}, nil)
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"ok": true, "msg": "Data relapse berhasil disinkronisasi."})
+	return c.JSON(fiber.Map{
+		"results":     batch.Results,
+		"changes":     fiber.Map{"relapses": changes.Relapses, "deleted": changes.Deleted},
+		"cursor":      changes.Cursor,
+		"has_more":    changes.HasMore,
+		"server_time": time.Now().UTC(),
+	})
 }
 
-// GetRelapses handles GET /api/relapses
-func GetRelapses(c *fiber.Ctx) error {
+// GetRelapses handles GET /api/relapses (opsional ?habit_id=..., default: habit default)
+// Bisa disaring berdasarkan metadata: trigger, tag, location, mood_before, mood_after,
+// min_intensity, max_intensity.
+func (h *Handler) GetRelapses(c *fiber.Ctx) error {
 	userIDHex := c.Locals("userId").(string)
 	userID, _ := primitive.ObjectIDFromHex(userIDHex)
 
-	relapseColl := database.GetCollection("relapselogs")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	filter, err := parseRelapseFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"msg": err.Error()})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
-	opts := options.Find().SetSort(bson.D{{Key: "relapse_time", Value: -1}})
-	cursor, err := relapseColl.Find(ctx, bson.M{"user": userID}, opts)
+	habit, err := h.userHabit(ctx, userID, c.Query("habit_id"))
 	if err != nil {
-		log.Printf("Error getting relapses: %v", err)
-		return c.Status(fiber.StatusInternalServerError).SendString("Server Error")
+		return habitError(c, err)
 	}
-	defer cursor.Close(ctx)
 
-	var relapses []models.RelapseLog
-	if err := cursor.All(ctx, &relapses); err != nil {
+	relapses, err := h.store.Relapses.ListFiltered(ctx, habit.ID, filter, false)
+	if err != nil {
+		logging.For(c).Error("error getting relapses", "err", err)
 		return c.Status(fiber.StatusInternalServerError).SendString("Server Error")
 	}
 
@@ -264,7 +381,11 @@ func GetRelapses(c *fiber.Ctx) error {
 }
 
 // UpdateRelapse handles PUT /api/relapses/:id
-func UpdateRelapse(c *fiber.Ctx) error {
+// Mengubah relapse_note, metadata (lihat RelapseDetailsPayload), dan/atau mengoreksi
+// relapse_time; field yang tidak dikirim tidak disentuh. Setiap perubahan dicatat ke
+// riwayat edit (GET /api/relapses/:id/history).
+// Koreksi waktu menghitung ulang streak habit tanpa menghapus relapse lain.
+func (h *Handler) UpdateRelapse(c *fiber.Ctx) error {
 	relapseIDHex := c.Params("id")
 	relapseID, err := primitive.ObjectIDFromHex(relapseIDHex)
 	if err != nil {
@@ -275,67 +396,194 @@ func UpdateRelapse(c *fiber.Ctx) error {
 	userID, _ := primitive.ObjectIDFromHex(userIDHex)
 
 	var payload struct {
-		RelapseNoteRaw string `json:"relapse_note"`
+		RelapseNoteRaw *string `json:"relapse_note"`
+		RelapseTimeRaw string  `json:"relapse_time"`
+		RelapseDetailsPayload
 	}
 	if err := c.BodyParser(&payload); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"msg": "Input tidak valid."})
 	}
+	if payload.RelapseNoteRaw == nil && payload.RelapseTimeRaw == "" && payload.RelapseDetailsPayload.IsZero() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"msg": "Tidak ada perubahan yang dikirim."})
+	}
 
-	note := utils.SanitizeString(payload.RelapseNoteRaw, 2048)
+	now := time.Now()
+	var relapseTime *time.Time
+	if payload.RelapseTimeRaw != "" {
+		parsed, err := time.Parse(time.RFC3339, payload.RelapseTimeRaw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"msg": "Format waktu relapse tidak valid."})
+		}
+		if parsed.After(now.Add(time.Second)) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"msg": "Waktu relapse tidak boleh di masa depan."})
+		}
+		parsed = parsed.UTC()
+		relapseTime = &parsed
+	}
 
-	relapseColl := database.GetCollection("relapselogs")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
-	var existingRelapse models.RelapseLog
-	filter := bson.M{"_id": relapseID, "user": userID}
-	if err := relapseColl.FindOne(ctx, filter).Decode(&existingRelapse); err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
+	existingRelapse, err := h.store.Relapses.FindByID(ctx, relapseID, userID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"msg": "Relapse tidak ditemukan."})
 		}
-		log.Printf("Error finding relapse for update: %v", err)
+		logging.For(c).Error("error finding relapse for update", "err", err)
 		return c.Status(fiber.StatusInternalServerError).SendString("Server Error")
 	}
 
-	oldNote := existingRelapse.RelapseNote
-
-	var noteValue interface{}
-	if note == "" {
+	noteValue := existingRelapse.RelapseNote
+	noteChanged := false
+	if payload.RelapseNoteRaw != nil {
 		noteValue = nil
-	} else {
-		noteValue = note
+		if note := utils.SanitizeString(*payload.RelapseNoteRaw, 2048); note != "" {
+			noteValue = &note
+		}
+		noteChanged = !sameRelapseNote(noteValue, existingRelapse.RelapseNote)
 	}
+	timeChanged := relapseTime != nil && !relapseTime.Equal(existingRelapse.RelapseTime)
 
-	update := bson.M{
-		"$set": bson.M{
-			"relapse_note": noteValue,
-			"updatedAt":    time.Now(),
-		},
+	details := existingRelapse.RelapseDetails
+	if err := payload.RelapseDetailsPayload.apply(&details); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"msg": err.Error()})
 	}
+	detailsChanged := !details.Equal(existingRelapse.RelapseDetails)
 
-	updateResult, err := relapseColl.UpdateOne(ctx, filter, update)
-	if err != nil || updateResult.ModifiedCount == 0 {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"msg": "Gagal memperbarui catatan relapse."})
+	if !noteChanged && !timeChanged && !detailsChanged {
+		return c.JSON(fiber.Map{"relapse": existingRelapse, "msg": "Tidak ada perubahan pada relapse."})
 	}
 
-	// Fetch updated relapse (to return to client)
-	var updatedRelapse models.RelapseLog
-	// FindOneAndUpdate is more efficient but this is clearer.
-	relapseColl.FindOne(ctx, filter).Decode(&updatedRelapse)
+	// Relapse lama (sebelum habit) dipasangkan ke habit default saat habit default dibuat
+	habitID := existingRelapse.HabitID
+	if habitID.IsZero() {
+		habit, err := h.userHabit(ctx, userID, "")
+		if err != nil {
+			return habitError(c, err)
+		}
+		habitID = habit.ID
+	}
+
+	var (
+		updatedRelapse = existingRelapse
+		habit          *models.Habit
+	)
+	err = h.withStreakUpdate(ctx, userID, habitID, func(ctx context.Context) error {
+		var err error
+		if noteChanged {
+			if updatedRelapse, err = h.store.Relapses.UpdateNote(ctx, relapseID, userID, noteValue, now, now); err != nil {
+				return err
+			}
+		}
+		if detailsChanged {
+			if updatedRelapse, err = h.store.Relapses.UpdateDetails(ctx, relapseID, userID, details, now, now); err != nil {
+				return err
+			}
+		}
+		if timeChanged {
+			if updatedRelapse, err = h.store.Relapses.UpdateTime(ctx, relapseID, userID, *relapseTime, now, now); err != nil {
+				return err
+			}
+		}
+		if err := services.RecordRelapseRevision(ctx, h.store, existingRelapse, updatedRelapse, models.RevisionSourceAPI, now); err != nil {
+			return err
+		}
+		if timeChanged {
+			habit, err = services.RecomputeStreak(ctx, h.store, userID, habitID)
+		}
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"msg": "Relapse tidak ditemukan."})
+		}
+		logging.For(c).Error("error updating relapse", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"msg": "Gagal memperbarui relapse."})
+	}
 
 	// Log Activity
 	logDetails := fiber.Map{
 		"relapse_id": relapseIDHex,
-		"old_note":   oldNote,
-		"new_note":   noteValue,
+	}
+	if noteChanged {
+		logDetails["old_note"] = existingRelapse.RelapseNote
+		logDetails["new_note"] = noteValue
+	}
+	if timeChanged {
+		logDetails["old_time"] = existingRelapse.RelapseTime
+		logDetails["new_time"] = updatedRelapse.RelapseTime
+	}
+	if detailsChanged {
+		logDetails["old_details"] = existingRelapse.RelapseDetails
+		logDetails["new_details"] = details
 	}
 	utils.LogActivity(c, "relapse_updated", logDetails, nil)
 
-	return c.JSON(fiber.Map{"relapse": updatedRelapse, "msg": "Catatan relapse berhasil diperbarui."})
+	if !timeChanged {
+		msg := "Relapse berhasil diperbarui."
+		if !detailsChanged {
+			msg = "Catatan relapse berhasil diperbarui."
+		}
+		return c.JSON(fiber.Map{"relapse": updatedRelapse, "msg": msg})
+	}
+	return c.JSON(fiber.Map{
+		"relapse":                updatedRelapse,
+		"streak_start_date":      habit.StreakStartDate,
+		"longest_streak_seconds": habit.LongestStreakSeconds,
+		"msg":                    "Relapse berhasil diperbarui.",
+	})
+}
+
+func sameRelapseNote(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// GetRelapseHistory handles GET /api/relapses/:id/history
+// Mengembalikan semua versi relapse (catatan dan waktu), versi terlama lebih dulu.
+func (h *Handler) GetRelapseHistory(c *fiber.Ctx) error {
+	relapseID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"msg": "ID Relapse tidak valid."})
+	}
+	userID := c.Locals("userObjectID").(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	relapse, err := h.store.Relapses.FindByID(ctx, relapseID, userID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"msg": "Relapse tidak ditemukan."})
+		}
+		logging.For(c).Error("error finding relapse for history", "err", err)
+		return c.Status(fiber.StatusInternalServerError).SendString("Server Error")
+	}
+
+	history, err := services.RelapseHistory(ctx, h.store, relapse)
+	if err != nil {
+		logging.For(c).Error("error listing relapse history", "err", err)
+		return c.Status(fiber.StatusInternalServerError).SendString("Server Error")
+	}
+
+	return c.JSON(fiber.Map{
+		"relapse":         relapse,
+		"current_version": history[len(history)-1].Version,
+		"history":         history,
+	})
+}
+
+// relapseRestoreWindow mengembalikan batas waktu hapus tertua yang masih bisa dipulihkan.
+func relapseRestoreWindow(now time.Time) time.Time {
+	return now.Add(-config.Get().RelapseRetention())
 }
 
 // DeleteRelapse handles DELETE /api/relapses/:id
-func DeleteRelapse(c *fiber.Ctx) error {
+// Relapse hanya ditandai terhapus (soft delete) dan bisa dipulihkan lewat
+// POST /api/relapses/:id/restore selama masa retensi. Streak habit tidak di-reset.
+func (h *Handler) DeleteRelapse(c *fiber.Ctx) error {
 	relapseIDHex := c.Params("id")
 	relapseID, err := primitive.ObjectIDFromHex(relapseIDHex)
 	if err != nil {
@@ -345,70 +593,131 @@ func DeleteRelapse(c *fiber.Ctx) error {
 	userIDHex := c.Locals("userId").(string)
 	userID, _ := primitive.ObjectIDFromHex(userIDHex)
 
-	relapseColl := database.GetCollection("relapselogs")
-	userColl := database.GetCollection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
-	var relapseToDelete models.RelapseLog
-	filter := bson.M{"_id": relapseID, "user": userID}
-	if err := relapseColl.FindOne(ctx, filter).Decode(&relapseToDelete); err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
+	relapseToDelete, err := h.store.Relapses.FindByID(ctx, relapseID, userID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"msg": "Relapse tidak ditemukan."})
 		}
-		log.Printf("Error finding relapse for delete: %v", err)
+		logging.For(c).Error("error finding relapse for delete", "err", err)
 		return c.Status(fiber.StatusInternalServerError).SendString("Server Error")
 	}
 
-	deleteResult, err := relapseColl.DeleteOne(ctx, filter)
-	if err != nil || deleteResult.DeletedCount == 0 {
+	// Relapse lama (sebelum habit) dipasangkan ke habit default saat habit default dibuat
+	habitID := relapseToDelete.HabitID
+	if habitID.IsZero() {
+		habit, err := h.userHabit(ctx, userID, "")
+		if err != nil {
+			return habitError(c, err)
+		}
+		habitID = habit.ID
+	}
+
+	now := time.Now()
+	err = h.withStreakUpdate(ctx, userID, habitID, func(ctx context.Context) error {
+		deleted, err := h.store.Relapses.SoftDelete(ctx, relapseID, userID, now)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			return store.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"msg": "Relapse tidak ditemukan."})
+		}
+		logging.For(c).Error("error deleting relapse", "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"msg": "Gagal menghapus relapse."})
 	}
 
-	// Check if this was the last relapse
-	remainingCount, err := relapseColl.CountDocuments(ctx, bson.M{"user": userID})
+	restoreUntil := now.Add(config.Get().RelapseRetention())
+
+	// Log Activity
+	logDetails := fiber.Map{
+		"relapse_id":    relapseIDHex,
+		"habit_id":      habitID.Hex(),
+		"relapse_time":  relapseToDelete.RelapseTime,
+		"relapse_note":  relapseToDelete.RelapseNote,
+		"restore_until": restoreUntil,
+	}
+	utils.LogActivity(c, "relapse_deleted", logDetails, nil)
+
+	return c.JSON(fiber.Map{
+		"msg":           "Relapse berhasil dihapus.",
+		"restore_until": restoreUntil,
+	})
+}
+
+// RestoreRelapse handles POST /api/relapses/:id/restore
+func (h *Handler) RestoreRelapse(c *fiber.Ctx) error {
+	relapseIDHex := c.Params("id")
+	relapseID, err := primitive.ObjectIDFromHex(relapseIDHex)
 	if err != nil {
-		log.Printf("Error counting relapses: %v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"msg": "ID Relapse tidak valid."})
 	}
 
-	streakReset := false
-	if remainingCount == 0 {
-		// Reset streak_start_date dan longest_streak_seconds
-		_, err := userColl.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{
-			// $set: nil tidak bekerja. Kita set ke null BSON untuk menghapus/mereset field.
-			"$set": bson.M{"streak_start_date": nil, "longest_streak_seconds": 0},
-		})
+	userID := c.Locals("userObjectID").(primitive.ObjectID)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	var restored *models.RelapseLog
+	err = h.store.Tx.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		restored, err = h.store.Relapses.Restore(ctx, relapseID, userID, relapseRestoreWindow(now), now)
 		if err != nil {
-			log.Printf("Error resetting user streak data: %v", err)
+			return err
+		}
+		habitID := restored.HabitID
+		if habitID.IsZero() {
+			habit, err := h.store.Habits.FindDefault(ctx, userID)
+			if err != nil {
+				return err
+			}
+			habitID = habit.ID
 		}
-		streakReset = true
+		return services.RefreshStreakSummary(ctx, h.store, userID, habitID)
+	})
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"msg": "Relapse tidak ditemukan atau masa pemulihan sudah lewat."})
+		}
+		logging.For(c).Error("error restoring relapse", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"msg": "Gagal memulihkan relapse."})
 	}
+	h.evaluateAchievements(ctx, userID)
 
-	// Log Activity
-	logDetails := fiber.Map{
+	utils.LogActivity(c, "relapse_restored", fiber.Map{
 		"relapse_id":   relapseIDHex,
-		"relapse_time": relapseToDelete.RelapseTime,
-		"relapse_note": relapseToDelete.RelapseNote,
-		"streak_reset": streakReset,
-	}
-	utils.LogActivity(c, "relapse_deleted", logDetails, nil)
+		"habit_id":     restored.HabitID.Hex(),
+		"relapse_time": restored.RelapseTime,
+	}, nil)
 
-	return c.JSON(fiber.Map{"msg": "Relapse berhasil dihapus."})
+	return c.JSON(fiber.Map{"relapse": restored, "msg": "Relapse berhasil dipulihkan."})
 }
 
-// DeleteAllRelapses handles DELETE /api/relapses
-func DeleteAllRelapses(c *fiber.Ctx) error {
+// DeleteAllRelapses handles DELETE /api/relapses (opsional ?habit_id=..., default: habit default)
+// Semua relapse habit di-soft delete dengan satu deletion_id sehingga bisa dipulihkan
+// sekaligus lewat POST /api/relapses/restore. Streak habit tidak di-reset.
+func (h *Handler) DeleteAllRelapses(c *fiber.Ctx) error {
 	userIDHex := c.Locals("userId").(string)
 	userID, _ := primitive.ObjectIDFromHex(userIDHex)
 
-	relapseColl := database.GetCollection("relapselogs")
-	userColl := database.GetCollection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
-	relapseCount, err := relapseColl.CountDocuments(ctx, bson.M{"user": userID})
+	habit, err := h.userHabit(ctx, userID, c.Query("habit_id"))
 	if err != nil {
-		log.Printf("Error counting relapses: %v", err)
+		return habitError(c, err)
+	}
+
+	relapseCount, err := h.store.Relapses.CountByHabit(ctx, habit.ID)
+	if err != nil {
+		logging.For(c).Error("error counting relapses", "err", err)
 		return c.Status(fiber.StatusInternalServerError).SendString("Server Error")
 	}
 
@@ -416,30 +725,103 @@ func DeleteAllRelapses(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"msg": "Tidak ada riwayat relapse yang ditemukan."})
 	}
 
-	deleteResult, err := relapseColl.DeleteMany(ctx, bson.M{"user": userID})
+	now := time.Now()
+	deletionID := primitive.NewObjectID()
+	var deletedCount int
+	err = h.withStreakUpdate(ctx, userID, habit.ID, func(ctx context.Context) error {
+		var err error
+		deletedCount, err = h.store.Relapses.SoftDeleteByHabit(ctx, habit.ID, deletionID, now)
+		return err
+	})
 	if err != nil {
-		log.Printf("Error deleting all relapses: %v", err)
+		logging.For(c).Error("error deleting all relapses", "err", err)
 		return c.Status(fiber.StatusInternalServerError).SendString("Server Error")
 	}
 
-	// Reset streak_start_date dan longest_streak_seconds
-	_, err = userColl.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{
-		"$set": bson.M{"streak_start_date": nil, "longest_streak_seconds": 0},
-	})
-	if err != nil {
-		log.Printf("Error resetting user streak data after mass delete: %v", err)
-	}
+	restoreUntil := now.Add(config.Get().RelapseRetention())
 
 	// Log Activity
 	logDetails := fiber.Map{
-		"deleted_count": deleteResult.DeletedCount,
-		"streak_reset":  true,
+		"habit_id":      habit.ID.Hex(),
+		"deleted_count": deletedCount,
+		"deletion_id":   deletionID.Hex(),
+		"restore_until": restoreUntil,
 	}
 	utils.LogActivity(c, "all_relapses_deleted", logDetails, nil)
 
 	return c.JSON(fiber.Map{
 		// Match MERN's dynamic message format
-		"msg":           fmt.Sprintf("%d riwayat relapse berhasil dihapus.", deleteResult.DeletedCount),
-		"deleted_count": deleteResult.DeletedCount,
+		"msg":           fmt.Sprintf("%d riwayat relapse berhasil dihapus.", deletedCount),
+		"deleted_count": deletedCount,
+		"deletion_id":   deletionID,
+		"restore_until": restoreUntil,
+	})
+}
+
+// RestoreRelapses handles POST /api/relapses/restore (opsional ?habit_id=..., default: habit default)
+// Memulihkan hasil DeleteAllRelapses. Body {"deletion_id": "..."} opsional; jika kosong,
+// penghapusan massal terbaru yang masih dalam masa retensi yang dipulihkan.
+func (h *Handler) RestoreRelapses(c *fiber.Ctx) error {
+	var payload struct {
+		DeletionID string `json:"deletion_id"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&payload); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"msg": "Input tidak valid."})
+		}
+	}
+
+	userID := c.Locals("userObjectID").(primitive.ObjectID)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	habit, err := h.userHabit(ctx, userID, c.Query("habit_id"))
+	if err != nil {
+		return habitError(c, err)
+	}
+
+	now := time.Now()
+	since := relapseRestoreWindow(now)
+
+	var deletionID primitive.ObjectID
+	if payload.DeletionID != "" {
+		deletionID, err = primitive.ObjectIDFromHex(payload.DeletionID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"msg": "deletion_id tidak valid."})
+		}
+	} else {
+		deletionID, err = h.store.Relapses.LastDeletion(ctx, habit.ID, since)
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"msg": "Tidak ada riwayat relapse yang bisa dipulihkan."})
+		}
+		if err != nil {
+			logging.For(c).Error("error finding last relapse deletion", "err", err)
+			return c.Status(fiber.StatusInternalServerError).SendString("Server Error")
+		}
+	}
+
+	var restoredCount int
+	err = h.withStreakUpdate(ctx, userID, habit.ID, func(ctx context.Context) error {
+		var err error
+		restoredCount, err = h.store.Relapses.RestoreDeletion(ctx, habit.ID, deletionID, since, now)
+		return err
+	})
+	if err != nil {
+		logging.For(c).Error("error restoring relapses", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"msg": "Gagal memulihkan relapse."})
+	}
+	if restoredCount == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"msg": "Tidak ada riwayat relapse yang bisa dipulihkan."})
+	}
+
+	utils.LogActivity(c, "all_relapses_restored", fiber.Map{
+		"habit_id":       habit.ID.Hex(),
+		"deletion_id":    deletionID.Hex(),
+		"restored_count": restoredCount,
+	}, nil)
+
+	return c.JSON(fiber.Map{
+		"msg":            fmt.Sprintf("%d riwayat relapse berhasil dipulihkan.", restoredCount),
+		"restored_count": restoredCount,
 	})
 }
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/services"
+)
+
+func TestCreateRelapse(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	ts := func(d time.Duration) string { return now.Add(d).Format(time.RFC3339) }
+
+	tests := []struct {
+		name   string
+		before []time.Duration // Relapse yang sudah ada, relatif terhadap now
+		body   string
+		status int
+		active int // Jumlah relapse aktif setelah request
+	}{
+		{name: "invalid body", body: `{"relapse_time":`, status: 400},
+		{name: "missing time", body: `{}`, status: 400},
+		{name: "bad time format", body: `{"relapse_time":"2024-01-01 10:00"}`, status: 400},
+		{name: "future time", body: `{"relapse_time":"` + ts(time.Hour) + `"}`, status: 400},
+		{name: "invalid mood", body: `{"relapse_time":"` + ts(-time.Hour) + `","mood_before":42}`, status: 400},
+		{name: "unknown habit", body: `{"relapse_time":"` + ts(-time.Hour) + `","habit_id":"` + primitive.NewObjectID().Hex() + `"}`, status: 404},
+		{name: "first relapse", body: `{"relapse_time":"` + ts(-time.Hour) + `","relapse_note":"note"}`, status: 201, active: 1},
+		{
+			name:   "after existing relapses",
+			before: []time.Duration{-72 * time.Hour, -48 * time.Hour},
+			body:   `{"relapse_time":"` + ts(-time.Hour) + `"}`,
+			status: 201,
+			active: 3,
+		},
+		{
+			name:   "backdated removes later relapses",
+			before: []time.Duration{-72 * time.Hour, -24 * time.Hour, -2 * time.Hour},
+			body:   `{"relapse_time":"` + ts(-48*time.Hour) + `"}`,
+			status: 201,
+			active: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			user := env.addUser("alice", nil)
+			for _, d := range tt.before {
+				env.relapse(user.ID, now.Add(d))
+			}
+
+			status, body := env.do("POST", "/relapses", user.ID, tt.body)
+			if status != tt.status {
+				t.Fatalf("status = %d, want %d (body %v)", status, tt.status, body)
+			}
+
+			ctx := context.Background()
+			habit, err := services.EnsureDefaultHabit(ctx, env.store, user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			active, err := env.store.Relapses.ListByHabit(ctx, habit.ID, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(active) != tt.active {
+				t.Errorf("%d active relapses, want %d", len(active), tt.active)
+			}
+			if tt.status != 201 {
+				return
+			}
+
+			summary, err := env.store.StreakSummaries.Get(ctx, habit.ID)
+			if err != nil {
+				t.Fatalf("streak summary not refreshed: %v", err)
+			}
+			if summary.TotalRelapses != tt.active {
+				t.Errorf("summary.TotalRelapses = %d, want %d", summary.TotalRelapses, tt.active)
+			}
+		})
+	}
+}
+
+func TestCreateRelapseBackdatedCanBeRestored(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.addUser("alice", nil)
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, d := range []time.Duration{-72 * time.Hour, -24 * time.Hour, -2 * time.Hour} {
+		env.relapse(user.ID, now.Add(d))
+	}
+
+	env.relapse(user.ID, now.Add(-48*time.Hour))
+
+	status, body := env.do("POST", "/relapses/restore", user.ID, "")
+	if status != 200 {
+		t.Fatalf("restore: status %d, body %v", status, body)
+	}
+	if got := body["restored_count"]; got != float64(2) {
+		t.Errorf("restored_count = %v, want 2", got)
+	}
+}
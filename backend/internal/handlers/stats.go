@@ -2,139 +2,141 @@ package handlers
 
 import (
 	"context"
-	"log"
+	"errors"
 	"math"
 	"sort"
+	"strconv"
 	"time"
 
-	"solivra-go/backend/internal/database"
+	"solivra-go/backend/internal/leaderboard"
+	"solivra-go/backend/internal/logging"
 	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/services"
+	"solivra-go/backend/internal/store"
+	"solivra-go/backend/internal/streaks"
 
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// Helper function to calculate streak durations
-// Replicates logic from backend/routes/stats.js
-func calculateStreaks(streakStart time.Time, relapses []models.RelapseLog, now time.Time) (currentStreak int64, computedLongest int64, historicalStreaks []int64) {
-
-	relapseTimes := make([]time.Time, len(relapses))
+// relapseTimes mengambil relapse_time dari daftar relapse untuk paket streaks
+func relapseTimes(relapses []models.RelapseLog) []time.Time {
+	times := make([]time.Time, len(relapses))
 	for i, r := range relapses {
-		relapseTimes[i] = r.RelapseTime
+		times[i] = r.RelapseTime
 	}
+	return times
+}
 
-	var currentStreakStartTime time.Time
-	if len(relapseTimes) > 0 {
-		// Waktu mulai adalah waktu relapse PALING BARU (MAX)
-		latestRelapse := relapseTimes[0]
-		for _, t := range relapseTimes {
-			if t.After(latestRelapse) {
-				latestRelapse = t
-			}
-		}
-		currentStreakStartTime = latestRelapse
-	} else {
-		// Jika belum ada relapse, waktu mulai adalah tanggal streak dimulai
-		currentStreakStartTime = streakStart
-	}
-
-	// Current Streak (in seconds)
-	duration := now.Sub(currentStreakStartTime)
-	currentStreak = int64(math.Max(0, duration.Seconds()))
-
-	// Historical Streaks (using timeline)
-	timeline := []time.Time{streakStart}
-	timeline = append(timeline, relapseTimes...)
+// GetStats handles GET /api/stats (opsional ?habit_id=..., default: habit default)
+func (h *Handler) GetStats(c *fiber.Ctx) error {
+	userIDHex := c.Locals("userId").(string)
+	userID, _ := primitive.ObjectIDFromHex(userIDHex)
 
-	// Sort timeline
-	sort.Slice(timeline, func(i, j int) bool {
-		return timeline[i].Before(timeline[j])
-	})
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
 
-	for i := 0; i < len(timeline)-1; i++ {
-		duration := timeline[i+1].Sub(timeline[i])
-		historicalStreaks = append(historicalStreaks, int64(duration.Seconds()))
+	user, err := h.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"msg": "User not found"})
 	}
 
-	// Find Longest Streak
-	allStreaksForLongest := make([]int64, 0, len(historicalStreaks)+1)
-	allStreaksForLongest = append(allStreaksForLongest, historicalStreaks...)
-	allStreaksForLongest = append(allStreaksForLongest, currentStreak)
+	loc, err := requestLocation(c, user)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"msg": err.Error()})
+	}
 
-	computedLongest = 0
-	if len(allStreaksForLongest) > 0 {
-		for _, streak := range allStreaksForLongest {
-			if streak > computedLongest {
-				computedLongest = streak
-			}
-		}
+	habit, err := h.resolveHabit(ctx, user, c.Query("habit_id"))
+	if err != nil {
+		return habitError(c, err)
 	}
 
-	return currentStreak, computedLongest, historicalStreaks
+	return h.habitStats(c, ctx, habit, loc)
 }
 
-// GetStats handles GET /api/stats
-func GetStats(c *fiber.Ctx) error {
+// GetInsights handles GET /api/stats/insights (opsional ?habit_id=... dan ?timezone=...)
+// Analitik pribadi: panjang streak, frekuensi relapse, heatmap, tren, dan persentil komunitas.
+func (h *Handler) GetInsights(c *fiber.Ctx) error {
 	userIDHex := c.Locals("userId").(string)
 	userID, _ := primitive.ObjectIDFromHex(userIDHex)
 
-	userColl := database.GetCollection("users")
-	relapseColl := database.GetCollection("relapselogs")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
 	defer cancel()
 
-	var user models.User
-	if err := userColl.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+	user, err := h.store.Users.FindByID(ctx, userID)
+	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"msg": "User not found"})
 	}
 
-	storedLongest := user.LongestStreakSeconds
+	loc, err := requestLocation(c, user)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"msg": err.Error()})
+	}
+
+	habit, err := h.resolveHabit(ctx, user, c.Query("habit_id"))
+	if err != nil {
+		return habitError(c, err)
+	}
+
+	insights, err := services.BuildInsights(ctx, h.store, habit, loc, time.Now())
+	if err != nil {
+		logging.For(c).Error("error building insights", "err", err)
+		return c.Status(fiber.StatusInternalServerError).SendString("Server Error")
+	}
+	return c.JSON(insights)
+}
+
+// habitStats menyusun respons statistik streak satu habit (dipakai GET /api/stats dan /api/habits/:id/stats).
+// relapse_dates dikelompokkan per tanggal kalender pada zona loc.
+func (h *Handler) habitStats(c *fiber.Ctx, ctx context.Context, habit *models.Habit, loc *time.Location) error {
+	storedLongest := habit.LongestStreakSeconds
 
 	// JIKA PENGGUNA BELUM MEMULAI STREAK
-	if user.StreakStartDate == nil {
+	if habit.StreakStartDate == nil {
 		return c.JSON(fiber.Map{
-			"currentStreak": int64(0),
-			"longestStreak": storedLongest,
-			"relapse_dates": []string{},
-			"streakStarted": false, // Flag penting untuk frontend
+			"habit_id":          habit.ID,
+			"currentStreak":     int64(0),
+			"longestStreak":     storedLongest,
+			"relapse_dates":     []string{},
+			"streakStarted":     false, // Flag penting untuk frontend
+			"relapse_breakdown": services.BuildRelapseBreakdown(nil),
+			"timezone":          loc.String(),
 		})
 	}
 
-	streakStartDate := *user.StreakStartDate
+	streakStartDate := *habit.StreakStartDate
 	now := time.Now()
 
-	// Ambil semua relapse logs, urutkan berdasarkan relapse_time (asc)
-	var relapseLogs []models.RelapseLog
-	opts := options.Find().SetSort(bson.D{{Key: "relapse_time", Value: 1}})
-	cursor, err := relapseColl.Find(ctx, bson.M{"user": userID}, opts)
+	// Ambil semua relapse logs habit ini, urutkan berdasarkan relapse_time (asc)
+	relapseLogs, err := h.store.Relapses.ListByHabit(ctx, habit.ID, true)
 	if err != nil {
-		log.Printf("Error fetching relapse logs: %v", err)
-		return c.Status(fiber.StatusInternalServerError).SendString("Server Error")
-	}
-	defer cursor.Close(ctx)
-	if err := cursor.All(ctx, &relapseLogs); err != nil {
+		logging.For(c).Error("error fetching relapse logs", "err", err)
 		return c.Status(fiber.StatusInternalServerError).SendString("Server Error")
 	}
 
-	currentStreak, computedLongest, _ := calculateStreaks(streakStartDate, relapseLogs, now)
-	finalLongest := int64(math.Max(float64(storedLongest), float64(computedLongest)))
+	summary := streaks.Compute(streakStartDate, relapseTimes(relapseLogs), now)
+	currentStreak := summary.Current
+	finalLongest := int64(math.Max(float64(storedLongest), float64(summary.Longest)))
 
 	// Update longest_streak_seconds jika computedLongest > storedLongest
+	// (withStreakUpdate sekaligus mengevaluasi achievement; jika tidak, evaluasi di sini)
 	if finalLongest > storedLongest {
-		_, err := userColl.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": bson.M{"longest_streak_seconds": finalLongest}})
+		err := h.withStreakUpdate(ctx, habit.UserID, habit.ID, func(ctx context.Context) error {
+			return h.store.Habits.SetLongestStreak(ctx, habit.ID, finalLongest)
+		})
 		if err != nil {
-			log.Printf("Error updating longest streak: %v", err)
+			logging.For(c).Error("error updating longest streak", "err", err)
 			// Lanjut eksekusi, ini bukan kegagalan fatal
 		}
+	} else {
+		h.evaluateAchievements(ctx, habit.UserID)
 	}
 
-	// Format relapse dates ke YYYY-MM-DD UTC (Sesuai output MERN: new Date(...).toISOString().split("T")[0])
+	// Format relapse dates ke YYYY-MM-DD pada zona waktu user (MERN selalu memakai UTC)
 	relapseDatesSet := make(map[string]struct{}, len(relapseLogs))
 	relapseDates := make([]string, 0, len(relapseLogs))
 	for _, r := range relapseLogs {
-		dateKey := r.RelapseTime.UTC().Format("2006-01-02")
+		dateKey := r.RelapseTime.In(loc).Format("2006-01-02")
 		if _, exists := relapseDatesSet[dateKey]; exists {
 			continue
 		}
@@ -143,10 +145,14 @@ func GetStats(c *fiber.Ctx) error {
 	}
 
 	return c.JSON(fiber.Map{
+		"habit_id":      habit.ID,
 		"currentStreak": currentStreak,
 		"longestStreak": finalLongest,
 		"relapse_dates": relapseDates,
 		"streakStarted": true,
+		// Distribusi pemicu, mood, intensitas, lokasi, dan tag dari metadata relapse
+		"relapse_breakdown": services.BuildRelapseBreakdown(relapseLogs),
+		"timezone":          loc.String(),
 	})
 }
 
@@ -162,188 +168,364 @@ type RankingUser struct {
 	StreakStartDate *time.Time         `json:"streak_start_date,omitempty"`
 	LanguagePref    string             `json:"language_pref,omitempty"`
 	CreatedAt       time.Time          `json:"created_at,omitempty"`
+	IsAnonymous     bool               `json:"is_anonymous,omitempty"`
 	IsCurrentUser   bool               `json:"is_current_user"`
 	Rank            int                `json:"rank"`
 }
 
-// processUserRankings calculates all streak metrics for a single user.
-// Replicates logic from both backend/routes/stats.js and backend/routes/admin.js ranking logic
-func processUserRankings(ctx context.Context, user models.User, currentUserID primitive.ObjectID, isAdmin bool) (RankingUser, error) {
-	relapseColl := database.GetCollection("relapselogs")
-	now := time.Now()
+// rankingQuery adalah parameter ranking yang dibaca dari query string:
+// limit, cursor, sort (current|longest|average|fewest_relapses), window (all|week|month),
+// habit_kind untuk membandingkan satu jenis habit antar user (kosong: habit default),
+// serta mode=neighbours dengan radius untuk mengambil user di sekitar peringkat pemanggil.
+type rankingQuery struct {
+	Sort      leaderboard.Sort
+	Window    leaderboard.Window
+	HabitKind string
+	Limit     int
+	// After dan AfterRank berasal dari cursor: posisi dan peringkat baris terakhir halaman sebelumnya.
+	After      *leaderboard.Key
+	AfterRank  int
+	Neighbours bool
+	Radius     int
+}
 
-	ranking := RankingUser{
-		Username:      user.Username,
-		Nickname:      user.Nickname,
-		LongestStreak: user.LongestStreakSeconds,
-		IsCurrentUser: user.ID == currentUserID,
+// parseRankingQuery memvalidasi query ranking. Error yang dikembalikan aman ditampilkan ke client.
+func parseRankingQuery(c *fiber.Ctx) (rankingQuery, error) {
+	q := rankingQuery{}
+
+	var ok bool
+	if q.Sort, ok = leaderboard.ParseSort(c.Query("sort")); !ok {
+		return q, errors.New("Parameter sort tidak valid.")
+	}
+	if q.Window, ok = leaderboard.ParseWindow(c.Query("window")); !ok {
+		return q, errors.New("Parameter window tidak valid.")
+	}
+	if kind := c.Query("habit_kind"); kind != "" {
+		// Habit custom tidak bisa dibandingkan antar user
+		if !models.ValidHabitKind(kind) || kind == models.HabitKindCustom {
+			return q, errors.New("Parameter habit_kind tidak valid.")
+		}
+		q.HabitKind = kind
 	}
 
-	if isAdmin {
-		ranking.ID = user.ID
-		ranking.StreakStartDate = user.StreakStartDate
-		ranking.LanguagePref = user.LanguagePref
-		ranking.CreatedAt = user.CreatedAt
+	q.Limit, _ = strconv.Atoi(c.Query("limit", "50"))
+	if q.Limit < 1 || q.Limit > 100 {
+		q.Limit = 50
 	}
 
-	if user.StreakStartDate == nil {
-		ranking.CurrentStreak = 0
-		ranking.TotalRelapses = 0
-		ranking.AverageStreak = 0
-		return ranking, nil
+	switch c.Query("mode") {
+	case "", "list":
+	case "neighbours", "neighbors":
+		q.Neighbours = true
+		q.Radius, _ = strconv.Atoi(c.Query("radius", "5"))
+		if q.Radius < 1 || q.Radius > 25 {
+			q.Radius = 5
+		}
+		return q, nil
+	default:
+		return q, errors.New("Parameter mode tidak valid.")
 	}
 
-	streakStartDate := *user.StreakStartDate
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := leaderboard.DecodeCursor(raw, q.Sort, q.Window, q.HabitKind)
+		if err != nil {
+			return q, errors.New("Cursor tidak valid.")
+		}
+		q.After, q.AfterRank = &cursor.After, cursor.Rank
+	}
+	return q, nil
+}
 
-	// Ambil semua relapse logs, urutkan berdasarkan relapse_time (asc)
-	var relapses []models.RelapseLog
-	opts := options.Find().SetSort(bson.D{{Key: "relapse_time", Value: 1}})
-	cursor, err := relapseColl.Find(ctx, bson.M{"user": user.ID}, opts)
-	if err != nil {
-		log.Printf("Error fetching relapses for ranking user %s: %v", user.Username, err)
-		return ranking, err
+// rankingPage adalah satu halaman ranking beserta data user-nya
+type rankingPage struct {
+	Entries    []leaderboard.Entry
+	Users      map[primitive.ObjectID]models.User
+	Total      int64
+	Offset     int   // Peringkat entry pertama = Offset + 1
+	CallerRank int64 // 0 jika pemanggil tidak masuk ranking
+	NextCursor *string
+}
+
+// loadRankingPage membaca satu halaman ranking dari koleksi streaksummaries.
+// Ranking sepanjang masa diurutkan dan dipaginasi oleh database untuk setiap sort.
+// Untuk window, habit yang streak berjalannya dimulai sebelum awal jendela punya metrik
+// jendela yang sama dan selalu berada di atas habit yang berubah di dalam jendela, jadi
+// bagian itu juga dibaca dari database; hanya ringkasan dan relapse habit yang berubah
+// di dalam jendela yang dihitung di memori. filter menentukan apakah user dengan
+// visibilitas "hidden" ikut dihitung (hanya untuk tampilan admin); jenis habit yang
+// diranking diambil dari q.HabitKind.
+func (h *Handler) loadRankingPage(ctx context.Context, q rankingQuery, currentUserID primitive.ObjectID, filter store.RankingFilter) (*rankingPage, error) {
+	now := time.Now()
+	page := &rankingPage{}
+	filter.HabitKind = q.HabitKind
+
+	// Habit pemanggil yang ikut ranking ini (jika ada) untuk current_user_rank
+	var callerHabit *models.Habit
+	var err error
+	if q.HabitKind == "" {
+		callerHabit, err = h.store.Habits.FindDefault(ctx, currentUserID)
+	} else {
+		callerHabit, err = h.store.Habits.FindByKind(ctx, currentUserID, q.HabitKind)
+	}
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, err
 	}
-	defer cursor.Close(ctx)
-	if err := cursor.All(ctx, &relapses); err != nil {
-		log.Printf("Error decoding relapses for ranking user %s: %v", user.Username, err)
-		return ranking, err
+	callerHabitID := primitive.NilObjectID
+	if callerHabit != nil {
+		callerHabitID = callerHabit.ID
 	}
 
-	ranking.TotalRelapses = len(relapses)
+	// Mode list membaca satu baris lebih untuk mengetahui apakah masih ada halaman berikutnya
+	offset, limit := 0, q.Limit+1
+	// pageBounds menyesuaikan offset/limit untuk mode neighbours setelah peringkat pemanggil diketahui
+	pageBounds := func() bool {
+		if !q.Neighbours {
+			return true
+		}
+		if page.CallerRank == 0 {
+			return false
+		}
+		offset = int(page.CallerRank) - 1 - q.Radius
+		if offset < 0 {
+			offset = 0
+		}
+		limit = 2*q.Radius + 1
+		return true
+	}
 
-	currentStreak := int64(0)
-	longestStreak := user.LongestStreakSeconds
-	averageStreak := float64(0)
+	// ranked membaca total, peringkat pemanggil, dan halaman [offset, offset+limit) setelah
+	// filter.After dari database; halaman dibaca hanya jika bounds true.
+	ranked := func(filter store.RankingFilter, bounds func() bool, entry func(models.StreakSummary) leaderboard.Entry) error {
+		total, err := h.store.StreakSummaries.Count(ctx, filter)
+		if err != nil {
+			return err
+		}
+		page.Total = total
 
-	if len(relapses) == 0 {
-		currentStreak = int64(math.Max(0, now.Sub(streakStartDate).Seconds()))
-		longestStreak = int64(math.Max(float64(longestStreak), float64(currentStreak)))
-		averageStreak = float64(currentStreak)
+		if !callerHabitID.IsZero() {
+			rank, err := h.store.StreakSummaries.Rank(ctx, filter, callerHabitID)
+			if err != nil && !errors.Is(err, store.ErrNotFound) {
+				return err
+			}
+			page.CallerRank = rank
+		}
+
+		if bounds() && int64(offset) < total {
+			summaries, err := h.store.StreakSummaries.ListRanked(ctx, filter, int64(offset), int64(limit))
+			if err != nil {
+				return err
+			}
+			for _, summary := range summaries {
+				page.Entries = append(page.Entries, entry(summary))
+			}
+		}
+		return nil
+	}
+
+	windowStart, windowed := q.Window.Start(now)
+	if !windowed {
+		filter.Sort, filter.Now, filter.After = q.Sort, now, q.After
+		err := ranked(filter, pageBounds, func(summary models.StreakSummary) leaderboard.Entry {
+			return leaderboard.FromSummary(summary, now)
+		})
+		if err != nil {
+			return nil, err
+		}
 	} else {
-		// Calculate streaks between relapses
-		streaks := make([]int64, 0)
-		streakStart := streakStartDate
-		var totalDuration int64 = 0
-
-		for i := 0; i < len(relapses); i++ {
-			relapseDate := relapses[i].RelapseTime
-			streakDuration := int64(math.Max(0, relapseDate.Sub(streakStart).Seconds()))
-			streaks = append(streaks, streakDuration)
-			totalDuration += streakDuration
-			streakStart = relapseDate
+		// Relapse tidak pernah sebelum streak_start_date, jadi current_streak_start sebelum
+		// awal jendela berarti tidak ada relapse di dalam jendela.
+		quiet := filter
+		quiet.CurrentStartBefore = windowStart
+		changed := filter
+		changed.CurrentStartFrom = windowStart
+
+		// Hanya habit jenis ini yang berubah di dalam jendela, beserta waktu relapse-nya
+		// di dalam jendela, yang dibaca untuk dihitung ulang.
+		summaries, err := h.store.StreakSummaries.ListRanked(ctx, changed, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		habitIDs := make([]primitive.ObjectID, len(summaries))
+		for i, summary := range summaries {
+			habitIDs[i] = summary.HabitID
 		}
+		windowRelapses, err := h.store.Relapses.ListTimesSince(ctx, habitIDs, windowStart)
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]leaderboard.Entry, 0, len(summaries))
+		for _, summary := range summaries {
+			entries = append(entries, leaderboard.FromWindow(summary, windowRelapses[summary.HabitID], windowStart, now))
+		}
+		leaderboard.SortEntries(entries, q.Sort)
 
-		// Current streak from last relapse to now
-		lastRelapseDate := relapses[len(relapses)-1].RelapseTime
-		currentStreak = int64(math.Max(0, now.Sub(lastRelapseDate).Seconds()))
+		var callerIndex int64
+		for i, entry := range entries {
+			if !callerHabitID.IsZero() && entry.HabitID == callerHabitID {
+				callerIndex = int64(i) + 1
+				break
+			}
+		}
+
+		// Bagian yang tidak berubah dibaca dari database, lalu sisa halaman diisi dari entries.
+		// Cursor yang menunjuk ke bagian yang berubah melewati seluruh bagian yang tidak berubah.
+		afterChanged := q.After != nil && !q.After.CurrentStart.Before(windowStart)
+		if q.After != nil && !afterChanged {
+			quiet.After = q.After
+		}
+		var inPage bool
+		bounds := func() bool {
+			if callerIndex > 0 {
+				page.CallerRank = page.Total + callerIndex
+			}
+			inPage = pageBounds() && !afterChanged
+			return inPage
+		}
+		err = ranked(quiet, bounds, func(summary models.StreakSummary) leaderboard.Entry {
+			return leaderboard.FromWindow(summary, nil, windowStart, now)
+		})
+		if err != nil {
+			return nil, err
+		}
 
-		// Find longest streak
-		computedLongest := currentStreak
-		for _, s := range streaks {
-			if s > computedLongest {
-				computedLongest = s
+		quietTotal := page.Total
+		page.Total += int64(len(entries))
+		from := int64(-1)
+		switch {
+		case q.Neighbours:
+			if inPage {
+				from = int64(offset+len(page.Entries)) - quietTotal
+			}
+		case afterChanged:
+			from = int64(sort.Search(len(entries), func(i int) bool { return q.After.Before(entries[i], q.Sort) }))
+		default:
+			// Halaman yang belum penuh berarti bagian yang tidak berubah sudah habis
+			from = 0
+		}
+		if from >= 0 && from < int64(len(entries)) && len(page.Entries) < limit {
+			end := from + int64(limit-len(page.Entries))
+			if end > int64(len(entries)) {
+				end = int64(len(entries))
 			}
+			page.Entries = append(page.Entries, entries[from:end]...)
 		}
-		longestStreak = int64(math.Max(float64(user.LongestStreakSeconds), float64(computedLongest)))
+	}
 
-		// Calculate average streak
-		if len(streaks) > 0 {
-			averageStreak = float64(totalDuration) / float64(len(streaks))
+	page.Offset = offset
+	if !q.Neighbours {
+		page.Offset = q.AfterRank
+		if len(page.Entries) > q.Limit {
+			page.Entries = page.Entries[:q.Limit]
+			next := leaderboard.Cursor{
+				Sort:      q.Sort,
+				Window:    q.Window,
+				HabitKind: q.HabitKind,
+				After:     leaderboard.KeyOf(page.Entries[q.Limit-1], q.Sort),
+				Rank:      page.Offset + q.Limit,
+			}.Encode()
+			page.NextCursor = &next
 		}
 	}
 
-	ranking.CurrentStreak = currentStreak
-	ranking.LongestStreak = longestStreak
-	// Admin ranking stores floor(averageStreak)
-	ranking.AverageStreak = int64(math.Floor(averageStreak))
+	ids := make([]primitive.ObjectID, len(page.Entries))
+	for i, entry := range page.Entries {
+		ids[i] = entry.UserID
+	}
+	users, err := h.store.Users.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	page.Users = make(map[primitive.ObjectID]models.User, len(users))
+	for _, u := range users {
+		page.Users[u.ID] = u
+	}
+	return page, nil
+}
+
+// buildRankingUser menyusun satu baris ranking dari metrik leaderboard dan data user.
+// Field yang tampil dibatasi sesuai role (isAdmin); di tampilan publik user "anonymous"
+// ditampilkan dengan alias, kecuali kepada dirinya sendiri.
+func buildRankingUser(entry leaderboard.Entry, user models.User, currentUserID primitive.ObjectID, isAdmin bool) RankingUser {
+	ranking := RankingUser{
+		Username:      user.Username,
+		Nickname:      user.Nickname,
+		CurrentStreak: entry.Current,
+		LongestStreak: entry.Longest,
+		TotalRelapses: entry.Relapses,
+		AverageStreak: entry.Average,
+		IsCurrentUser: user.ID == currentUserID,
+	}
 
-	return ranking, nil
+	if isAdmin {
+		ranking.ID = user.ID
+		ranking.StreakStartDate = user.StreakStartDate
+		ranking.LanguagePref = user.LanguagePref
+		ranking.CreatedAt = user.CreatedAt
+	} else if user.Visibility() == models.RankingVisibilityAnonymous && !ranking.IsCurrentUser {
+		alias := models.AnonymousAlias(user.ID)
+		ranking.Username = alias
+		ranking.Nickname = alias
+		ranking.IsAnonymous = true
+	}
+	return ranking
 }
 
 // GetRankings handles GET /api/stats/rankings (Public)
-func GetRankings(c *fiber.Ctx) error {
-	return getRankingsHandler(c, false)
+func (h *Handler) GetRankings(c *fiber.Ctx) error {
+	return h.getRankingsHandler(c, false)
 }
 
 // getRankingsHandler is the core logic for both public and admin rankings
-func getRankingsHandler(c *fiber.Ctx, isAdmin bool) error {
+func (h *Handler) getRankingsHandler(c *fiber.Ctx, isAdmin bool) error {
 	userIDHex := c.Locals("userId").(string)
 	currentUserID, _ := primitive.ObjectIDFromHex(userIDHex)
 
-	userColl := database.GetCollection("users")
-	// Diberi batas waktu yang lebih panjang karena operasi ini berat
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-	defer cancel()
-
-	// Select fields based on access role
-	projection := bson.M{
-		"username":               1,
-		"nickname":               1,
-		"streak_start_date":      1,
-		"longest_streak_seconds": 1,
-	}
-	if isAdmin {
-		projection["language_pref"] = 1
-		projection["created_at"] = 1
+	q, err := parseRankingQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"msg": err.Error()})
 	}
 
-	filter := bson.M{"streak_start_date": bson.M{"$ne": nil}}
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
 
-	opts := options.Find().SetProjection(projection)
-	cursor, err := userColl.Find(ctx, filter, opts)
+	page, err := h.loadRankingPage(ctx, q, currentUserID, store.RankingFilter{ExcludeHidden: !isAdmin})
 	if err != nil {
-		log.Printf("Error fetching users for rankings: %v", err)
-		return c.Status(fiber.StatusInternalServerError).SendString("Server Error")
-	}
-	defer cursor.Close(ctx)
-
-	var users []models.User
-	if err := cursor.All(ctx, &users); err != nil {
-		log.Printf("Error decoding users for rankings: %v", err)
+		logging.For(c).Error("error fetching rankings", "err", err)
 		return c.Status(fiber.StatusInternalServerError).SendString("Server Error")
 	}
 
-	rankings := make([]RankingUser, 0, len(users))
-
-	// Perhitungan metrik loop: N+1 query pattern yang sama dengan MERN
-	for _, user := range users {
-		ranking, err := processUserRankings(ctx, user, currentUserID, isAdmin)
-		if err != nil {
-			log.Printf("Skipping user %s in ranking due to error: %v", user.Username, err)
+	rankings := make([]RankingUser, 0, len(page.Entries))
+	for i, entry := range page.Entries {
+		user, ok := page.Users[entry.UserID]
+		if !ok {
+			// Ringkasan yatim (user sudah dihapus) - lewati
+			continue
+		}
+		if !isAdmin && user.Visibility() == models.RankingVisibilityHidden {
+			// Ringkasan belum ikut diperbarui setelah user menyembunyikan diri
 			continue
 		}
+		ranking := buildRankingUser(entry, user, currentUserID, isAdmin)
+		ranking.Rank = page.Offset + i + 1
 		rankings = append(rankings, ranking)
 	}
 
-	// Sort by current streak descending
-	sort.Slice(rankings, func(i, j int) bool {
-		return rankings[i].CurrentStreak > rankings[j].CurrentStreak
-	})
-
-	// Add ranking positions
-	for i := range rankings {
-		rankings[i].Rank = i + 1
-	}
-
 	response := fiber.Map{
 		"rankings":    rankings,
-		"total_users": len(rankings),
+		"total_users": page.Total,
+		"sort":        q.Sort,
+		"window":      q.Window,
+		"habit_kind":  q.HabitKind,
+		"next_cursor": page.NextCursor,
 	}
 
 	if isAdmin {
 		response["generated_at"] = time.Now()
 	} else {
-		// Logika mencari peringkat user saat ini (untuk publik)
+		// Peringkat user saat ini dihitung dari seluruh ranking, meskipun tidak ada di halaman ini
 		response["current_user_rank"] = nil
-		currentUserRank := -1
-		for _, r := range rankings {
-			if r.IsCurrentUser {
-				currentUserRank = r.Rank
-				break
-			}
-		}
-		if currentUserRank != -1 {
-			response["current_user_rank"] = currentUserRank
+		if page.CallerRank > 0 {
+			response["current_user_rank"] = page.CallerRank
 		}
 	}
 
@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/leaderboard"
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/store"
+)
+
+func TestGetStats(t *testing.T) {
+	// 23:30 UTC dan 00:30 UTC keesokan harinya jatuh di tanggal yang sama di Asia/Jakarta (UTC+7)
+	late := time.Date(2024, 3, 10, 23, 30, 0, 0, time.UTC)
+	early := time.Date(2024, 3, 11, 0, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		user     primitive.ObjectID // NilObjectID: user yang dibuat test
+		relapses []time.Time
+		query    string
+		status   int
+		started  bool
+		dates    []interface{}
+	}{
+		{name: "unknown user", user: primitive.NewObjectID(), status: 404},
+		{name: "invalid timezone", query: "?timezone=Mars/Olympus", status: 400},
+		{name: "unknown habit", query: "?habit_id=" + primitive.NewObjectID().Hex(), status: 404},
+		{name: "streak not started", status: 200, dates: []interface{}{}},
+		{
+			name:     "dates in utc",
+			relapses: []time.Time{late, early},
+			query:    "?timezone=UTC",
+			status:   200,
+			started:  true,
+			dates:    []interface{}{"2024-03-10", "2024-03-11"},
+		},
+		{
+			name:     "dates grouped in request timezone",
+			relapses: []time.Time{late, early},
+			query:    "?timezone=Asia/Jakarta",
+			status:   200,
+			started:  true,
+			dates:    []interface{}{"2024-03-11"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			user := env.addUser("alice", nil)
+			for _, at := range tt.relapses {
+				env.relapse(user.ID, at)
+			}
+			caller := user.ID
+			if !tt.user.IsZero() {
+				caller = tt.user
+			}
+
+			status, body := env.do("GET", "/stats"+tt.query, caller, "")
+			if status != tt.status {
+				t.Fatalf("status = %d, want %d (body %v)", status, tt.status, body)
+			}
+			if status != 200 {
+				return
+			}
+			if body["streakStarted"] != tt.started {
+				t.Errorf("streakStarted = %v, want %v", body["streakStarted"], tt.started)
+			}
+			if !reflect.DeepEqual(body["relapse_dates"], tt.dates) {
+				t.Errorf("relapse_dates = %v, want %v", body["relapse_dates"], tt.dates)
+			}
+			if tt.started {
+				want := time.Since(early).Seconds()
+				if got, _ := body["currentStreak"].(float64); got < want-5 || got > want+5 {
+					t.Errorf("currentStreak = %v, want about %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRankingQuery(t *testing.T) {
+	key := leaderboard.Key{Value: 3600, CurrentStart: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), HabitID: primitive.NewObjectID()}
+	tests := []struct {
+		query   string
+		want    rankingQuery
+		wantErr bool
+	}{
+		{query: "", want: rankingQuery{Sort: leaderboard.SortCurrent, Window: leaderboard.WindowAll, Limit: 50}},
+		{query: "sort=longest&window=week&limit=10", want: rankingQuery{Sort: leaderboard.SortLongest, Window: leaderboard.WindowWeek, Limit: 10}},
+		{query: "limit=0", want: rankingQuery{Sort: leaderboard.SortCurrent, Window: leaderboard.WindowAll, Limit: 50}},
+		{query: "limit=101", want: rankingQuery{Sort: leaderboard.SortCurrent, Window: leaderboard.WindowAll, Limit: 50}},
+		{query: "habit_kind=" + models.HabitKindGeneral, want: rankingQuery{Sort: leaderboard.SortCurrent, Window: leaderboard.WindowAll, HabitKind: models.HabitKindGeneral, Limit: 50}},
+		{query: "mode=neighbours", want: rankingQuery{Sort: leaderboard.SortCurrent, Window: leaderboard.WindowAll, Limit: 50, Neighbours: true, Radius: 5}},
+		{query: "mode=neighbors&radius=2", want: rankingQuery{Sort: leaderboard.SortCurrent, Window: leaderboard.WindowAll, Limit: 50, Neighbours: true, Radius: 2}},
+		{query: "mode=neighbours&radius=26", want: rankingQuery{Sort: leaderboard.SortCurrent, Window: leaderboard.WindowAll, Limit: 50, Neighbours: true, Radius: 5}},
+		{
+			query: "sort=average&cursor=" + leaderboard.Cursor{Sort: leaderboard.SortAverage, Window: leaderboard.WindowAll, After: key, Rank: 40}.Encode(),
+			want:  rankingQuery{Sort: leaderboard.SortAverage, Window: leaderboard.WindowAll, Limit: 50, After: &key, AfterRank: 40},
+		},
+		{query: "sort=average&cursor=" + leaderboard.Cursor{Sort: leaderboard.SortCurrent, Window: leaderboard.WindowAll, After: key, Rank: 40}.Encode(), wantErr: true},
+		{
+			query: "habit_kind=" + models.HabitKindGeneral + "&cursor=" + leaderboard.Cursor{Sort: leaderboard.SortCurrent, Window: leaderboard.WindowAll, HabitKind: models.HabitKindGeneral, After: key, Rank: 20}.Encode(),
+			want:  rankingQuery{Sort: leaderboard.SortCurrent, Window: leaderboard.WindowAll, HabitKind: models.HabitKindGeneral, Limit: 50, After: &key, AfterRank: 20},
+		},
+		{query: "cursor=" + leaderboard.Cursor{Sort: leaderboard.SortCurrent, Window: leaderboard.WindowAll, HabitKind: models.HabitKindGeneral, After: key, Rank: 20}.Encode(), wantErr: true},
+		{query: "habit_kind=" + models.HabitKindGeneral + "&cursor=" + leaderboard.Cursor{Sort: leaderboard.SortCurrent, Window: leaderboard.WindowAll, After: key, Rank: 20}.Encode(), wantErr: true},
+		{query: "cursor=" + leaderboard.Cursor{Sort: leaderboard.SortCurrent, Window: leaderboard.WindowAll, Rank: 20}.Encode(), wantErr: true},
+		{query: "cursor=" + leaderboard.Cursor{Sort: leaderboard.SortCurrent, Window: leaderboard.WindowAll, After: key}.Encode(), wantErr: true},
+		{query: "cursor=not-a-cursor", wantErr: true},
+		{query: "sort=best", wantErr: true},
+		{query: "window=year", wantErr: true},
+		{query: "habit_kind=" + models.HabitKindCustom, wantErr: true},
+		{query: "habit_kind=unknown", wantErr: true},
+		{query: "mode=top", wantErr: true},
+	}
+
+	app := fiber.New()
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			c := app.AcquireCtx(&fasthttp.RequestCtx{})
+			defer app.ReleaseCtx(c)
+			c.Request().SetRequestURI("/rankings?" + tt.query)
+
+			got, err := parseRankingQuery(c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRankings(t *testing.T) {
+	env := newTestEnv(t)
+	now := time.Now()
+	days := func(n int) time.Time { return now.Add(-time.Duration(n) * 24 * time.Hour) }
+
+	alice := env.addUser("alice", nil)
+	env.relapse(alice.ID, days(10))
+	bob := env.addUser("bob", nil)
+	env.relapse(bob.ID, days(20))
+	env.relapse(bob.ID, days(2))
+	carol := env.addUser("carol", func(u *models.User) { u.RankingVisibility = models.RankingVisibilityAnonymous })
+	env.relapse(carol.ID, days(5))
+	dave := env.addUser("dave", func(u *models.User) { u.RankingVisibility = models.RankingVisibilityHidden })
+	env.relapse(dave.ID, days(7))
+
+	carolAlias := models.AnonymousAlias(carol.ID)
+	carolHabit, err := env.store.Habits.FindDefault(context.Background(), carol.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	carolKey := leaderboard.Key{CurrentStart: days(5), HabitID: carolHabit.ID}
+
+	tests := []struct {
+		name   string
+		path   string
+		caller primitive.ObjectID
+		status int
+		want   []string // Username sesuai urutan
+		offset int      // Peringkat baris pertama - 1
+		total  float64
+		rank   interface{} // current_user_rank (hanya ranking publik)
+		next   bool
+	}{
+		{name: "default order", path: "/rankings", caller: alice.ID, status: 200, want: []string{"alice", carolAlias, "bob"}, total: 3, rank: float64(1)},
+		{name: "anonymous user sees own name", path: "/rankings", caller: carol.ID, status: 200, want: []string{"alice", "carol", "bob"}, total: 3, rank: float64(2)},
+		{name: "hidden user has no rank", path: "/rankings", caller: dave.ID, status: 200, want: []string{"alice", carolAlias, "bob"}, total: 3, rank: nil},
+		{name: "longest", path: "/rankings?sort=longest", caller: alice.ID, status: 200, want: []string{"bob", "alice", carolAlias}, total: 3, rank: float64(2)},
+		{name: "average", path: "/rankings?sort=average", caller: alice.ID, status: 200, want: []string{"bob", "alice", carolAlias}, total: 3, rank: float64(2)},
+		{name: "fewest relapses", path: "/rankings?sort=fewest_relapses", caller: alice.ID, status: 200, want: []string{"alice", carolAlias, "bob"}, total: 3, rank: float64(1)},
+		{name: "first page", path: "/rankings?limit=2", caller: bob.ID, status: 200, want: []string{"alice", carolAlias}, total: 3, rank: float64(3), next: true},
+		{
+			name:   "second page",
+			path:   "/rankings?limit=2&cursor=" + leaderboard.Cursor{Sort: leaderboard.SortCurrent, Window: leaderboard.WindowAll, After: carolKey, Rank: 2}.Encode(),
+			caller: bob.ID,
+			status: 200,
+			want:   []string{"bob"},
+			offset: 2,
+			total:  3,
+			rank:   float64(3),
+		},
+		{name: "neighbours", path: "/rankings?mode=neighbours&radius=1", caller: bob.ID, status: 200, want: []string{carolAlias, "bob"}, offset: 1, total: 3, rank: float64(3)},
+		{name: "invalid sort", path: "/rankings?sort=best", caller: alice.ID, status: 400},
+		{name: "admin includes hidden", path: "/admin/rankings", caller: alice.ID, status: 200, want: []string{"alice", "dave", "carol", "bob"}, total: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := env.do("GET", tt.path, tt.caller, "")
+			if status != tt.status {
+				t.Fatalf("status = %d, want %d (body %v)", status, tt.status, body)
+			}
+			if status != 200 {
+				return
+			}
+
+			rows, _ := body["rankings"].([]interface{})
+			got := make([]string, len(rows))
+			for i, row := range rows {
+				r := row.(map[string]interface{})
+				got[i], _ = r["username"].(string)
+				if r["rank"] != float64(tt.offset+i+1) {
+					t.Errorf("row %d rank = %v", i, r["rank"])
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rankings = %v, want %v", got, tt.want)
+			}
+			if body["total_users"] != tt.total {
+				t.Errorf("total_users = %v, want %v", body["total_users"], tt.total)
+			}
+			if _, admin := body["generated_at"]; !admin && body["current_user_rank"] != tt.rank {
+				t.Errorf("current_user_rank = %v, want %v", body["current_user_rank"], tt.rank)
+			}
+			if hasNext := body["next_cursor"] != nil; hasNext != tt.next {
+				t.Errorf("next_cursor = %v, want present %v", body["next_cursor"], tt.next)
+			}
+		})
+	}
+}
+
+// TestRankingPagesMatchFullSort memastikan halaman dan peringkat dari database sama dengan
+// urutan yang dihitung dari seluruh ringkasan di memori, untuk setiap sort dan window.
+func TestRankingPagesMatchFullSort(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	// Jam relapse per user, dari yang paling lama
+	relapseHours := [][]int{{1000}, {900, 40}, {500, 300, 100}, {200}, {30}, {2}, {700, 600}, {50, 20, 5}}
+	usernames := make(map[primitive.ObjectID]string)
+	var users []*models.User
+	for i, hours := range relapseHours {
+		user := env.addUser(fmt.Sprintf("user%d", i), nil)
+		for _, h := range hours {
+			env.relapse(user.ID, now.Add(-time.Duration(h)*time.Hour))
+		}
+		usernames[user.ID] = user.Username
+		users = append(users, user)
+	}
+
+	summaries, err := env.store.StreakSummaries.ListRanked(ctx, store.RankingFilter{ExcludeHidden: true}, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, window := range []leaderboard.Window{leaderboard.WindowAll, leaderboard.WindowWeek, leaderboard.WindowMonth} {
+		for _, by := range []leaderboard.Sort{leaderboard.SortCurrent, leaderboard.SortLongest, leaderboard.SortAverage, leaderboard.SortFewestRelapses} {
+			t.Run(string(window)+" "+string(by), func(t *testing.T) {
+				windowStart, windowed := window.Start(now)
+				habitIDs := make([]primitive.ObjectID, len(summaries))
+				for i, summary := range summaries {
+					habitIDs[i] = summary.HabitID
+				}
+				windowRelapses, err := env.store.Relapses.ListTimesSince(ctx, habitIDs, windowStart)
+				if err != nil {
+					t.Fatal(err)
+				}
+				entries := make([]leaderboard.Entry, 0, len(summaries))
+				for _, summary := range summaries {
+					if windowed {
+						entries = append(entries, leaderboard.FromWindow(summary, windowRelapses[summary.HabitID], windowStart, now))
+					} else {
+						entries = append(entries, leaderboard.FromSummary(summary, now))
+					}
+				}
+				leaderboard.SortEntries(entries, by)
+				want := make([]string, len(entries))
+				for i, entry := range entries {
+					want[i] = usernames[entry.UserID]
+				}
+
+				var got []string
+				path := fmt.Sprintf("/rankings?sort=%s&window=%s&limit=3", by, window)
+				for next := path; next != ""; {
+					status, body := env.do("GET", next, users[0].ID, "")
+					if status != 200 {
+						t.Fatalf("status = %d (body %v)", status, body)
+					}
+					rows, _ := body["rankings"].([]interface{})
+					for _, row := range rows {
+						got = append(got, row.(map[string]interface{})["username"].(string))
+					}
+					next = ""
+					if cursor, ok := body["next_cursor"].(string); ok {
+						next = path + "&cursor=" + cursor
+					}
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("rankings = %v, want %v", got, want)
+				}
+
+				for _, user := range users {
+					_, body := env.do("GET", path, user.ID, "")
+					wantRank := float64(0)
+					for i, name := range want {
+						if name == user.Username {
+							wantRank = float64(i + 1)
+						}
+					}
+					if body["current_user_rank"] != wantRank {
+						t.Errorf("%s current_user_rank = %v, want %v", user.Username, body["current_user_rank"], wantRank)
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestRankingCursorSurvivesChanges memastikan cursor menunjuk ke baris terakhir, bukan ke
+// offset: streak yang berubah di antara dua halaman tidak membuat baris terlewat atau terulang.
+func TestRankingCursorSurvivesChanges(t *testing.T) {
+	env := newTestEnv(t)
+	now := time.Now()
+	var users []*models.User
+	for i, days := range []int{40, 30, 20, 10} {
+		user := env.addUser(fmt.Sprintf("user%d", i), nil)
+		env.relapse(user.ID, now.Add(-time.Duration(days)*24*time.Hour))
+		users = append(users, user)
+	}
+
+	page := func(path string) ([]string, string) {
+		t.Helper()
+		status, body := env.do("GET", path, users[0].ID, "")
+		if status != 200 {
+			t.Fatalf("status = %d (body %v)", status, body)
+		}
+		var names []string
+		for _, row := range body["rankings"].([]interface{}) {
+			names = append(names, row.(map[string]interface{})["username"].(string))
+		}
+		cursor, _ := body["next_cursor"].(string)
+		return names, cursor
+	}
+
+	first, cursor := page("/rankings?limit=2")
+	if want := []string{"user0", "user1"}; !reflect.DeepEqual(first, want) {
+		t.Fatalf("first page = %v, want %v", first, want)
+	}
+
+	// user0 relapse: pindah dari halaman pertama ke posisi terakhir
+	env.relapse(users[0].ID, now)
+
+	second, cursor := page("/rankings?limit=2&cursor=" + cursor)
+	if want := []string{"user2", "user3"}; !reflect.DeepEqual(second, want) {
+		t.Errorf("second page = %v, want %v", second, want)
+	}
+	third, cursor := page("/rankings?limit=2&cursor=" + cursor)
+	if want := []string{"user0"}; !reflect.DeepEqual(third, want) || cursor != "" {
+		t.Errorf("third page = %v (next %q), want %v without next", third, cursor, want)
+	}
+}
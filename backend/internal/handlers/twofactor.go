@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/pkg/utils"
+)
+
+const (
+	twoFactorIssuer   = "Solivra"
+	recoveryCodeCount = 10
+)
+
+// hashRecoveryCodes mengubah recovery code mentah menjadi hash yang disimpan di DB
+func hashRecoveryCodes(codes []string) []string {
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.HashToken(utils.NormalizeRecoveryCode(code))
+	}
+	return hashes
+}
+
+// GetTwoFactorStatus handles GET /api/users/2fa
+func (h *Handler) GetTwoFactorStatus(c *fiber.Ctx) error {
+	userID := c.Locals("userObjectID").(primitive.ObjectID)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "User not found")
+	}
+
+	return c.JSON(fiber.Map{
+		"enabled":                  user.TwoFactor.Enabled,
+		"enabled_at":               user.TwoFactor.EnabledAt,
+		"recovery_codes_remaining": len(user.TwoFactor.RecoveryCodes),
+	})
+}
+
+// SetupTwoFactor handles POST /api/users/2fa/setup
+// Membuat secret baru (belum aktif) dan mengembalikan otpauth URI untuk QR code.
+func (h *Handler) SetupTwoFactor(c *fiber.Ctx) error {
+	userID := c.Locals("userObjectID").(primitive.ObjectID)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "User not found")
+	}
+
+	if user.TwoFactor.Enabled {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Autentikasi dua faktor sudah aktif.")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal membuat secret 2FA.")
+	}
+
+	settings := user.TwoFactor
+	settings.PendingSecret = secret
+	if err := h.store.Users.SetTwoFactor(ctx, userID, settings, time.Now()); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal menyimpan secret 2FA.")
+	}
+
+	utils.LogActivity(c, "user_2fa_setup", nil, nil)
+
+	return c.JSON(fiber.Map{
+		"secret":      secret,
+		"otpauth_uri": utils.TOTPURI(twoFactorIssuer, user.Username, secret),
+	})
+}
+
+// ConfirmTwoFactor handles POST /api/users/2fa/confirm
+// Mengaktifkan 2FA setelah kode dari aplikasi authenticator terverifikasi.
+// Recovery code hanya dikembalikan sekali di respons ini.
+func (h *Handler) ConfirmTwoFactor(c *fiber.Ctx) error {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Input tidak valid.")
+	}
+
+	userID := c.Locals("userObjectID").(primitive.ObjectID)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "User not found")
+	}
+
+	if user.TwoFactor.Enabled {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Autentikasi dua faktor sudah aktif.")
+	}
+	if user.TwoFactor.PendingSecret == "" {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Mulai setup 2FA terlebih dahulu.")
+	}
+
+	now := time.Now()
+	step, ok := utils.ValidateTOTP(user.TwoFactor.PendingSecret, req.Code, now)
+	if !ok {
+		utils.LogActivity(c, "user_2fa_confirm_failed", fiber.Map{"reason": "invalid_code"}, nil)
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Kode autentikasi salah.")
+	}
+
+	recoveryCodes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal membuat recovery code.")
+	}
+
+	settings := models.TwoFactorSettings{
+		Enabled:       true,
+		Secret:        user.TwoFactor.PendingSecret,
+		RecoveryCodes: hashRecoveryCodes(recoveryCodes),
+		LastUsedStep:  step,
+		EnabledAt:     &now,
+	}
+	if err := h.store.Users.SetTwoFactor(ctx, userID, settings, now); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengaktifkan 2FA.")
+	}
+
+	utils.LogActivity(c, "user_2fa_enabled", nil, nil)
+
+	return c.JSON(fiber.Map{
+		"msg":            "Autentikasi dua faktor berhasil diaktifkan.",
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+// DisableTwoFactor handles POST /api/users/2fa/disable
+func (h *Handler) DisableTwoFactor(c *fiber.Ctx) error {
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Input tidak valid.")
+	}
+
+	userID := c.Locals("userObjectID").(primitive.ObjectID)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "User not found")
+	}
+
+	if !user.TwoFactor.Enabled {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Autentikasi dua faktor belum aktif.")
+	}
+
+	if !utils.CheckPasswordHash(req.Password, user.Password) {
+		utils.LogActivity(c, "user_2fa_disable_failed", fiber.Map{"reason": "invalid_password"}, nil)
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Password saat ini salah.")
+	}
+
+	if err := h.store.Users.SetTwoFactor(ctx, userID, models.TwoFactorSettings{}, time.Now()); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal menonaktifkan 2FA.")
+	}
+
+	utils.LogActivity(c, "user_2fa_disabled", nil, nil)
+
+	return c.JSON(fiber.Map{"msg": "Autentikasi dua faktor dinonaktifkan."})
+}
+
+// VerifyTwoFactorLogin handles POST /api/auth/login/2fa
+// Langkah kedua login: menukar challenge token + kode TOTP (atau recovery code) dengan sesi.
+func (h *Handler) VerifyTwoFactorLogin(c *fiber.Ctx) error {
+	var req struct {
+		ChallengeToken string `json:"challenge_token"`
+		Code           string `json:"code"`
+		RecoveryCode   string `json:"recovery_code"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid request body")
+	}
+
+	if req.Code == "" && req.RecoveryCode == "" {
+		return utils.ErrorResponse(c, 400, "Kode autentikasi wajib diisi")
+	}
+
+	claims, err := utils.ValidateTwoFactorChallenge(req.ChallengeToken)
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Sesi verifikasi kedaluwarsa. Silakan login kembali.")
+	}
+
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Invalid user ID")
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.store.Users.FindByID(ctx, userID)
+	if err != nil || !user.TwoFactor.Enabled {
+		return utils.ErrorResponse(c, 401, "Sesi verifikasi tidak valid. Silakan login kembali.")
+	}
+
+	now := time.Now()
+
+	// Akun yang sedang dikunci tidak boleh mencoba kode
+	if user.LockoutUntil != nil && user.LockoutUntil.After(now) {
+		return c.Status(423).JSON(fiber.Map{
+			"ok":  false,
+			"msg": "Akun Anda sedang dikunci. Coba lagi beberapa menit lagi.",
+			"lockout": fiber.Map{
+				"type":  "user",
+				"until": user.LockoutUntil,
+			},
+		})
+	}
+
+	method := "totp"
+	if req.RecoveryCode != "" {
+		method = "recovery_code"
+		codeHash := utils.HashToken(utils.NormalizeRecoveryCode(req.RecoveryCode))
+		consumed, err := h.store.Users.ConsumeRecoveryCode(ctx, user.ID, codeHash)
+		if err != nil {
+			return utils.ErrorResponse(c, 500, "Failed to verify recovery code")
+		}
+		if !consumed {
+			return h.failLoginAttempt(c, ctx, user, "invalid_recovery_code", "Recovery code salah atau sudah dipakai.", now)
+		}
+	} else {
+		step, ok := utils.ValidateTOTP(user.TwoFactor.Secret, req.Code, now)
+		if !ok {
+			return h.failLoginAttempt(c, ctx, user, "invalid_2fa_code", "Kode autentikasi salah.", now)
+		}
+		// Kode yang sama tidak boleh dipakai dua kali
+		fresh, err := h.store.Users.UseTwoFactorStep(ctx, user.ID, step)
+		if err != nil {
+			return utils.ErrorResponse(c, 500, "Failed to verify code")
+		}
+		if !fresh {
+			return h.failLoginAttempt(c, ctx, user, "reused_2fa_code", "Kode autentikasi sudah dipakai. Tunggu kode berikutnya.", now)
+		}
+	}
+
+	return h.completeLogin(c, ctx, user, claims.RememberMe, method, now)
+}
@@ -1,43 +1,41 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
-	
-	"solivra-go/backend/internal/database"
+
+	"solivra-go/backend/internal/export"
+	"solivra-go/backend/internal/imaging"
+	"solivra-go/backend/internal/logging"
 	"solivra-go/backend/internal/models"
 	"solivra-go/backend/internal/services" // Digunakan untuk ClearAuthCookies
+	"solivra-go/backend/internal/store"
 	"solivra-go/backend/pkg/utils"
 )
 
 // GetMe returns current user profile (Sudah Ada)
-func GetMe(c *fiber.Ctx) error {
+func (h *Handler) GetMe(c *fiber.Ctx) error {
 	userID := c.Locals("userObjectID").(primitive.ObjectID)
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
 	defer cancel()
 
 	// 1. Get User
-	usersColl := database.GetCollection("users")
-	var user models.User
-	err := usersColl.FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	user, err := h.store.Users.FindByID(ctx, userID)
 	if err != nil {
 		return utils.ErrorResponse(c, 404, "User not found")
 	}
 
-	// 2. Get Relapses 
-	relapsesColl := database.GetCollection("relapselogs")
-	cursor, err := relapsesColl.Find(ctx, bson.M{"user": userID})
+	// 2. Get Relapses (habit default; habit lain lewat /api/habits)
 	var relapses []models.RelapseLog
-	if err == nil {
-		cursor.All(ctx, &relapses)
+	if habit, err := services.EnsureDefaultHabit(ctx, h.store, user); err == nil {
+		relapses, _ = h.store.Relapses.ListByHabit(ctx, habit.ID, true)
 	}
 	if relapses == nil {
 		relapses = []models.RelapseLog{}
@@ -51,8 +49,11 @@ func GetMe(c *fiber.Ctx) error {
 		"role":                   user.Role,
 		"language_pref":          user.LanguagePref,
 		"profile_picture":        user.ProfilePicture,
+		"profile_picture_small":  user.ProfilePictureSmall,
 		"streak_start_date":      user.StreakStartDate,
 		"longest_streak_seconds": user.LongestStreakSeconds,
+		"ranking_visibility":     user.Visibility(),
+		"timezone":               user.TimezoneName(),
 		"created_at":             user.CreatedAt,
 		"relapses":               relapses, // Include relapses in user object
 	}
@@ -61,7 +62,7 @@ func GetMe(c *fiber.Ctx) error {
 }
 
 // CheckUsername checks availability (Sudah Ada)
-func CheckUsername(c *fiber.Ctx) error {
+func (h *Handler) CheckUsername(c *fiber.Ctx) error {
 	rawUsername := c.Params("username")
 	username := strings.ToLower(utils.SanitizeString(rawUsername, 30))
 
@@ -69,17 +70,15 @@ func CheckUsername(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{"available": false, "message": "Username hanya boleh mengandung huruf latin kecil, angka, titik, underscore, atau @ (1-30 karakter)."})
 	}
 	
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
-	usersColl := database.GetCollection("users")
-	count, err := usersColl.CountDocuments(ctx, bson.M{"username": username})
-	
+	taken, err := h.store.Users.UsernameTaken(ctx, username, primitive.NilObjectID)
 	if err != nil {
 		return utils.ErrorResponse(c, 500, "Error saat memeriksa username.")
 	}
 
-	if count > 0 {
+	if taken {
 		return c.JSON(fiber.Map{"available": false, "message": "Username sudah digunakan."})
 	}
 
@@ -87,18 +86,17 @@ func CheckUsername(c *fiber.Ctx) error {
 }
 
 // UpdateProfile handles profile updates including image upload (Sudah Ada)
-func UpdateProfile(c *fiber.Ctx) error {
+func (h *Handler) UpdateProfile(c *fiber.Ctx) error {
 	userID := c.Locals("userObjectID").(primitive.ObjectID)
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second) // Longer timeout for upload
+	ctx, cancel := context.WithTimeout(c.UserContext(), 30*time.Second) // Longer timeout for upload
 	defer cancel()
 
-	usersColl := database.GetCollection("users")
-	var user models.User
-	if err := usersColl.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+	user, err := h.store.Users.FindByID(ctx, userID)
+	if err != nil {
 		return utils.ErrorResponse(c, 404, "User not found")
 	}
 
-	updateFields := bson.M{"updated_at": time.Now()}
+	var updateFields store.UserProfileUpdate
 	changes := fiber.Map{} // Menyimpan perubahan untuk log
 	
 	// Handle form values (text fields)
@@ -108,7 +106,7 @@ func UpdateProfile(c *fiber.Ctx) error {
 	if nicknameInput := c.FormValue("nickname"); nicknameInput != "" {
 		nickname := utils.SanitizeString(nicknameInput, 120)
 		if nickname != user.Nickname {
-			updateFields["nickname"] = nickname
+			updateFields.Nickname = &nickname
 			changes["nickname"] = fiber.Map{"from": user.Nickname, "to": nickname}
 		}
 	}
@@ -122,54 +120,68 @@ func UpdateProfile(c *fiber.Ctx) error {
 			}
 			
 			// Check uniqueness
-			count, _ := usersColl.CountDocuments(ctx, bson.M{"username": newUsername, "_id": bson.M{"$ne": userID}})
-			if count > 0 {
+			taken, _ := h.store.Users.UsernameTaken(ctx, newUsername, userID)
+			if taken {
 				return utils.ErrorResponse(c, 400, "Username sudah digunakan pengguna lain.")
 			}
-			updateFields["username"] = newUsername
+			updateFields.Username = &newUsername
 			changes["username"] = fiber.Map{"from": user.Username, "to": newUsername}
 		}
 	}
 	
 	// PROFILE PICTURE
 	if form != nil && len(form.File["profile_picture"]) > 0 {
+		if h.files == nil {
+			return utils.ErrorResponse(c, fiber.StatusServiceUnavailable, "Upload foto profil tidak tersedia.")
+		}
 		fileHeader := form.File["profile_picture"][0]
 		file, err := fileHeader.Open() // file sekarang bertipe io.Reader/multipart.File
 		if err == nil {
 			defer file.Close()
 			
-			// 1. Upload to Cloudinary
-			url, uploadErr := utils.UploadToCloudinary(file, "profile_pictures") 
+			// 1. Validasi & proses gambar: format asli, ukuran, crop persegi, buang EXIF
+			if fileHeader.Size > imaging.MaxUploadBytes {
+				return rejectProfilePicture(c, imaging.ErrTooLarge, fileHeader.Size)
+			}
+			avatar, procErr := imaging.ProcessAvatar(file)
+			if procErr != nil {
+				return rejectProfilePicture(c, procErr, fileHeader.Size)
+			}
+
+			// 2. Upload ke storage (Cloudinary/local/S3 sesuai config)
+			url, smallURL, uploadErr := h.storeAvatar(c, avatar)
 			if uploadErr == nil {
-				updateFields["profile_picture"] = url
+				updateFields.ProfilePicture = &url
+				updateFields.ProfilePictureSmall = &smallURL
 				changes["profile_picture"] = fiber.Map{"from": user.ProfilePicture, "to": url}
 				
-				// 2. Delete old image (if not default)
+				// 3. Delete old image (if not default)
 				if user.ProfilePicture != "/default.png" {
-					utils.DeleteFromCloudinary(user.ProfilePicture)
+					h.deleteStoredFile(c, user.ProfilePicture)
+					h.deleteStoredFile(c, user.ProfilePictureSmall)
 				}
 			} else {
 				// Handle case where upload fails
-				return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengunggah foto profil ke Cloudinary.")
+				logging.For(c).Error("profile picture upload failed", "err", uploadErr)
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengunggah foto profil.")
 			}
 		}
 	}
 
 	if len(changes) > 0 { // Check if any meaningful field (beyond updated_at) was changed
 		// Melakukan update ke DB
-		_, err := usersColl.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": updateFields})
-		if err != nil {
+		if err := h.store.Users.UpdateProfile(ctx, userID, updateFields, time.Now()); err != nil {
 			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal memperbarui profil.")
 		}
 		
 		utils.LogActivity(c, "user_profile_update", fiber.Map{"changes": changes}, nil)
 	}
 	
-	return GetMe(c)
+	return h.GetMe(c)
 }
 
 // UpdatePassword handles PUT /api/users/password (Implementasi Lengkap)
-func UpdatePassword(c *fiber.Ctx) error {
+func (h *Handler) UpdatePassword(c *fiber.Ctx) error {
 	var req struct {
 		CurrentPassword string `json:"current_password"`
 		NewPassword     string `json:"new_password"`
@@ -180,12 +192,11 @@ func UpdatePassword(c *fiber.Ctx) error {
 
 	userID := c.Locals("userObjectID").(primitive.ObjectID)
 	sessionHash := c.Locals("sessionHash").(string) // Diperoleh dari Protected middleware
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
 	defer cancel()
 	
-	usersColl := database.GetCollection("users")
-	var user models.User
-	if err := usersColl.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+	user, err := h.store.Users.FindByID(ctx, userID)
+	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "User not found")
 	}
 
@@ -206,32 +217,13 @@ func UpdatePassword(c *fiber.Ctx) error {
 	// 2. Hash and Save new password
 	newHashedPassword, _ := utils.HashPassword(req.NewPassword)
 	
-	update := bson.M{"$set": bson.M{
-		"password": newHashedPassword,
-		"updated_at": time.Now(),
-	}}
-	_, err := usersColl.UpdateOne(ctx, bson.M{"_id": userID}, update)
-	if err != nil {
+	if err := h.store.Users.SetPassword(ctx, userID, newHashedPassword, time.Now()); err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengubah password.")
 	}
 
 	// 3. Revoke all sessions except the current one (MERN Logic: Revoke all other active sessions)
-	sessionColl := database.GetCollection("usersessions")
-	now := time.Now()
-	
-	// Query untuk mencabut sesi: user = ID, revoked_at = null, token != currentHash
-	revokeQuery := bson.M{
-		"user": user.ID,
-		"revoked_at": nil,
-		"token": bson.M{"$ne": sessionHash},
-	}
-	revokeUpdate := bson.M{"$set": bson.M{"revoked_at": now, "last_active_time": now}}
-	
-	revokeResult, err := sessionColl.UpdateMany(ctx, revokeQuery, revokeUpdate)
-	revokedCount := 0
-	if err == nil {
-		revokedCount = int(revokeResult.ModifiedCount)
-	}
+	// Cabut sesi: user = ID, revoked_at = null, token != currentHash
+	revokedCount, _ := h.store.Sessions.RevokeAllExcept(ctx, user.ID, sessionHash, time.Now())
 
 	utils.LogActivity(c, "user_password_change", fiber.Map{"revoked_sessions": revokedCount}, nil)
 
@@ -239,7 +231,7 @@ func UpdatePassword(c *fiber.Ctx) error {
 }
 
 // UpdateLanguage handles PUT /api/users/language (Implementasi Lengkap)
-func UpdateLanguage(c *fiber.Ctx) error {
+func (h *Handler) UpdateLanguage(c *fiber.Ctx) error {
 	var req struct {
 		Language string `json:"language"`
 	}
@@ -262,21 +254,18 @@ func UpdateLanguage(c *fiber.Ctx) error {
 	}
 
 	userID := c.Locals("userObjectID").(primitive.ObjectID)
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
-	usersColl := database.GetCollection("users")
-	
-	update := bson.M{"$set": bson.M{"language_pref": rawLanguage, "updated_at": time.Now()}}
-	result, err := usersColl.UpdateOne(ctx, bson.M{"_id": userID}, update)
-	
-	if err != nil || result.ModifiedCount == 0 {
+	if err := h.store.Users.SetLanguage(ctx, userID, rawLanguage, time.Now()); err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal memperbarui bahasa.")
 	}
 
 	// Ambil data user yang sudah diupdate (tanpa password)
-	var updatedUser models.User
-	usersColl.FindOne(ctx, bson.M{"_id": userID}).Decode(&updatedUser)
+	updatedUser, err := h.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "User not found")
+	}
 	
 	utils.LogActivity(c, "user_language_change", fiber.Map{"language": rawLanguage}, nil)
 
@@ -286,27 +275,214 @@ func UpdateLanguage(c *fiber.Ctx) error {
 	return c.JSON(updatedUser.ToPublic())
 }
 
+// UpdateTimezone handles PUT /api/users/timezone
+// timezone adalah nama zona IANA (misalnya "Asia/Jakarta"); dipakai untuk tanggal kalender,
+// pengelompokan per jam di statistik, default impor, dan ekspor data.
+func (h *Handler) UpdateTimezone(c *fiber.Ctx) error {
+	var req struct {
+		Timezone string `json:"timezone"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Input tidak valid.")
+	}
+
+	loc, err := models.LoadTimezone(req.Timezone)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Zona waktu tidak valid.")
+	}
+
+	userID := c.Locals("userObjectID").(primitive.ObjectID)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	if err := h.store.Users.SetTimezone(ctx, userID, loc.String(), time.Now()); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal memperbarui zona waktu.")
+	}
+
+	updatedUser, err := h.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "User not found")
+	}
+
+	utils.LogActivity(c, "user_timezone_change", fiber.Map{"timezone": loc.String()}, nil)
+
+	return c.JSON(updatedUser.ToPublic())
+}
+
+// requestLocation mengembalikan zona waktu untuk statistik: query ?timezone=... bila ada,
+// selain itu preferensi user. Error yang dikembalikan aman ditampilkan ke client.
+func requestLocation(c *fiber.Ctx, user *models.User) (*time.Location, error) {
+	if raw := c.Query("timezone"); raw != "" {
+		loc, err := models.LoadTimezone(raw)
+		if err != nil {
+			return nil, errors.New("Parameter timezone tidak valid.")
+		}
+		return loc, nil
+	}
+	return user.Location(), nil
+}
+
+// privacyResponse adalah bentuk respons endpoint /api/users/privacy
+func privacyResponse(user *models.User) fiber.Map {
+	return fiber.Map{
+		"ranking_visibility": user.Visibility(),
+		"anonymous_alias":    models.AnonymousAlias(user.ID),
+	}
+}
+
+// GetPrivacy handles GET /api/users/privacy
+func (h *Handler) GetPrivacy(c *fiber.Ctx) error {
+	userID := c.Locals("userObjectID").(primitive.ObjectID)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "User not found")
+	}
+
+	return c.JSON(privacyResponse(user))
+}
+
+// UpdatePrivacy handles PUT /api/users/privacy
+// ranking_visibility: "public" (username & nickname), "anonymous" (alias), atau "hidden" (tidak tampil di ranking publik)
+func (h *Handler) UpdatePrivacy(c *fiber.Ctx) error {
+	var req struct {
+		RankingVisibility string `json:"ranking_visibility"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Input tidak valid.")
+	}
+
+	visibility := strings.ToLower(utils.SanitizeString(req.RankingVisibility, 16))
+	if !models.ValidRankingVisibility(visibility) {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Pilihan privasi tidak valid.")
+	}
+
+	userID := c.Locals("userObjectID").(primitive.ObjectID)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "User not found")
+	}
+	oldVisibility := user.Visibility()
+
+	habits, err := h.store.Habits.ListByUser(ctx, userID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal memperbarui pengaturan privasi.")
+	}
+
+	// Ringkasan streak semua habit ikut diperbarui agar user "hidden" langsung tersaring dari query ranking
+	err = h.store.Tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := h.store.Users.SetRankingVisibility(ctx, userID, visibility, time.Now()); err != nil {
+			return err
+		}
+		for _, habit := range habits {
+			if err := services.RefreshStreakSummary(ctx, h.store, userID, habit.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal memperbarui pengaturan privasi.")
+	}
+	user.RankingVisibility = visibility
+
+	utils.LogActivity(c, "user_privacy_change", fiber.Map{
+		"old_visibility": oldVisibility,
+		"new_visibility": visibility,
+	}, nil)
+
+	return c.JSON(privacyResponse(user))
+}
+
+// rejectProfilePicture membalas upload foto profil yang gagal validasi dengan pesan dan
+// reason sesuai jenis kesalahan, lalu mencatatnya di activity log.
+func rejectProfilePicture(c *fiber.Ctx, err error, size int64) error {
+	status, reason, msg := fiber.StatusBadRequest, "read_failed", "Gagal membaca file foto profil."
+	switch {
+	case errors.Is(err, imaging.ErrEmpty):
+		reason, msg = "empty_file", "File foto profil kosong."
+	case errors.Is(err, imaging.ErrTooLarge):
+		status, reason = fiber.StatusRequestEntityTooLarge, "file_too_large"
+		msg = fmt.Sprintf("Ukuran foto profil maksimal %d MB.", imaging.MaxUploadBytes>>20)
+	case errors.Is(err, imaging.ErrUnsupportedFormat):
+		status, reason = fiber.StatusUnsupportedMediaType, "unsupported_format"
+		msg = "Format foto profil harus JPEG, PNG, atau WebP."
+	case errors.Is(err, imaging.ErrDimensionsTooLarge):
+		reason = "dimensions_too_large"
+		msg = fmt.Sprintf("Resolusi foto profil terlalu besar (maksimal %d piksel per sisi).", imaging.MaxDimension)
+	case errors.Is(err, imaging.ErrDimensionsTooSmall):
+		reason = "dimensions_too_small"
+		msg = fmt.Sprintf("Foto profil minimal %dx%d piksel.", imaging.MinDimension, imaging.MinDimension)
+	case errors.Is(err, imaging.ErrCorrupt):
+		reason, msg = "corrupt_image", "File foto profil rusak atau tidak dapat dibaca."
+	}
+
+	utils.LogActivity(c, "user_profile_picture_rejected", fiber.Map{"reason": reason, "size": size}, nil)
+	return c.Status(status).JSON(fiber.Map{
+		"ok":     false,
+		"msg":    msg,
+		"reason": reason,
+	})
+}
+
+// storeAvatar mengunggah kedua varian avatar dan mengembalikan URL-nya. Jika salah satu
+// gagal, varian yang sudah terunggah dihapus lagi.
+func (h *Handler) storeAvatar(c *fiber.Ctx, avatar *imaging.Avatar) (string, string, error) {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 30*time.Second)
+	defer cancel()
+
+	url, err := h.files.Put(ctx, "profile_pictures", bytes.NewReader(avatar.Large), int64(len(avatar.Large)), imaging.AvatarContentType)
+	if err != nil {
+		return "", "", err
+	}
+	smallURL, err := h.files.Put(ctx, "profile_pictures", bytes.NewReader(avatar.Small), int64(len(avatar.Small)), imaging.AvatarContentType)
+	if err != nil {
+		h.deleteStoredFile(c, url)
+		return "", "", err
+	}
+	return url, smallURL, nil
+}
+
+// deleteStoredFile menghapus foto profil lama dari storage. Kegagalan hanya dicatat karena
+// file yang tertinggal tidak memengaruhi user.
+func (h *Handler) deleteStoredFile(c *fiber.Ctx, url string) {
+	if h.files == nil || url == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+	if err := h.files.Delete(ctx, url); err != nil {
+		logging.For(c).Warn("failed to delete stored file", "url", url, "err", err)
+	}
+}
+
 // RemoveProfilePicture handles DELETE /api/users/profile-picture (Implementasi Lengkap)
-func RemoveProfilePicture(c *fiber.Ctx) error {
+func (h *Handler) RemoveProfilePicture(c *fiber.Ctx) error {
 	userID := c.Locals("userObjectID").(primitive.ObjectID)
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
 	defer cancel()
 	
-	usersColl := database.GetCollection("users")
-	var user models.User
-	if err := usersColl.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+	user, err := h.store.Users.FindByID(ctx, userID)
+	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "User not found")
 	}
-	
+
 	previousPicture := user.ProfilePicture
 	
 	if previousPicture != "/default.png" {
-		utils.DeleteFromCloudinary(previousPicture)
+		h.deleteStoredFile(c, previousPicture)
+		h.deleteStoredFile(c, user.ProfilePictureSmall)
 	}
 	
-	update := bson.M{"$set": bson.M{"profile_picture": "/default.png", "updated_at": time.Now()}}
-	_, err := usersColl.UpdateOne(ctx, bson.M{"_id": userID}, update)
-	if err != nil {
+	defaultPicture := "/default.png"
+	noSmallPicture := ""
+	update := store.UserProfileUpdate{ProfilePicture: &defaultPicture, ProfilePictureSmall: &noSmallPicture}
+	if err := h.store.Users.UpdateProfile(ctx, userID, update, time.Now()); err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal menghapus foto profil.")
 	}
 
@@ -316,12 +492,44 @@ func RemoveProfilePicture(c *fiber.Ctx) error {
 		"to": "/default.png",
 	}, nil)
 	
-	return GetMe(c)
+	return h.GetMe(c)
 }
 
+// removedUserData merangkum data yang dihapus bersama akun.
+type removedUserData struct {
+	ProfilePicture bool
+	Relapses       int
+	Habits         int
+	Sessions       int
+}
+
+// deleteUserData menghapus user beserta foto profil, relapse, habit, arsip ekspor, sesi,
+// ringkasan streak, dan achievement-nya. Dipakai oleh DeleteAccount dan admin DeleteUser.
+// Error hanya dikembalikan jika dokumen user gagal dihapus.
+func (h *Handler) deleteUserData(c *fiber.Ctx, ctx context.Context, user *models.User) (removedUserData, error) {
+	var removed removedUserData
+
+	if _, err := h.store.Users.Delete(ctx, user.ID); err != nil {
+		return removed, err
+	}
+
+	if user.ProfilePicture != "/default.png" {
+		h.deleteStoredFile(c, user.ProfilePicture)
+		h.deleteStoredFile(c, user.ProfilePictureSmall)
+		removed.ProfilePicture = true
+	}
+
+	removed.Relapses, _ = h.store.Relapses.DeleteAllByUser(ctx, user.ID)
+	removed.Habits, _ = h.store.Habits.DeleteAllByUser(ctx, user.ID)
+	export.RemoveForUser(ctx, h.store, user.ID)
+	removed.Sessions, _ = h.store.Sessions.RevokeAll(ctx, user.ID, time.Now())
+	h.store.StreakSummaries.DeleteByUser(ctx, user.ID)
+	h.store.Achievements.DeleteByUser(ctx, user.ID)
+	return removed, nil
+}
 
 // DeleteAccount handles DELETE /api/users/ (Implementasi Lengkap)
-func DeleteAccount(c *fiber.Ctx) error {
+func (h *Handler) DeleteAccount(c *fiber.Ctx) error {
 	var req struct {
 		Password string `json:"password"`
 	}
@@ -331,12 +539,11 @@ func DeleteAccount(c *fiber.Ctx) error {
 	password := req.Password
 
 	userID := c.Locals("userObjectID").(primitive.ObjectID)
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
 	defer cancel()
 
-	usersColl := database.GetCollection("users")
-	var user models.User
-	if err := usersColl.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+	user, err := h.store.Users.FindByID(ctx, userID)
+	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "User not found")
 	}
 
@@ -347,62 +554,39 @@ func DeleteAccount(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Password yang Anda masukkan salah.")
 	}
 
-	// 2. Cleanup: Profile Picture
-	removedProfilePicture := false
-	if user.ProfilePicture != "/default.png" {
-		utils.DeleteFromCloudinary(user.ProfilePicture)
-		removedProfilePicture = true
+	// 2. Hapus user beserta seluruh datanya
+	removed, err := h.deleteUserData(c, ctx, user)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal menghapus akun.")
 	}
 
-	// 3. Cleanup: Relapses
-	relapsesColl := database.GetCollection("relapselogs")
-	relapsesResult, _ := relapsesColl.DeleteMany(ctx, bson.M{"user": user.ID})
-	relapsesRemoved := int(relapsesResult.DeletedCount)
-
-	// 4. Cleanup: Sessions (Revoke All)
-	sessionColl := database.GetCollection("usersessions")
-	now := time.Now()
-	revokeResult, _ := sessionColl.UpdateMany(ctx, bson.M{"user": user.ID, "revoked_at": nil}, bson.M{"$set": bson.M{"revoked_at": now, "last_active_time": now}})
-	sessionsRevoked := int(revokeResult.ModifiedCount)
-
-	// 5. Delete User
-	usersColl.DeleteOne(ctx, bson.M{"_id": user.ID})
-
-	// 6. Log Activity
+	// 3. Log Activity
 	utils.LogActivity(c, "user_account_deleted", fiber.Map{
-		"removed_profile_picture": removedProfilePicture,
-		"relapses_deleted": relapsesRemoved,
-		"sessions_revoked": sessionsRevoked,
+		"removed_profile_picture": removed.ProfilePicture,
+		"relapses_deleted": removed.Relapses,
+		"habits_deleted": removed.Habits,
+		"sessions_revoked": removed.Sessions,
 	}, nil)
 
-	// 7. Clear Cookies
+	// 4. Clear Cookies
 	services.ClearAuthCookies(c)
 	
 	return c.JSON(fiber.Map{"msg": "Akun berhasil dihapus."})
 }
 
 // GetSessions handles GET /api/users/sessions (Implementasi Lengkap)
-func GetSessions(c *fiber.Ctx) error {
+func (h *Handler) GetSessions(c *fiber.Ctx) error {
 	userID := c.Locals("userObjectID").(primitive.ObjectID)
 	// sessionHash berisi hash token saat ini dari cookie.
 	currentHash := c.Locals("sessionHash").(string) 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
-	sessionColl := database.GetCollection("usersessions")
-	
 	// Ambil sesi yang belum dicabut, diurutkan dari yang paling aktif
-	opts := options.Find().SetSort(bson.D{{Key: "last_active_time", Value: -1}})
-	cursor, err := sessionColl.Find(ctx, bson.M{"user": userID, "revoked_at": nil}, opts)
+	sessions, err := h.store.Sessions.ListActive(ctx, userID)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengambil sesi.")
 	}
-	defer cursor.Close(ctx)
-
-	var sessions []models.UserSession
-	if err := cursor.All(ctx, &sessions); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mendekode sesi.")
-	}
 
 	mappedSessions := make([]fiber.Map, len(sessions))
 	for i, session := range sessions {
@@ -424,7 +608,7 @@ func GetSessions(c *fiber.Ctx) error {
 }
 
 // RevokeSession handles DELETE /api/users/sessions/:id (Implementasi Lengkap)
-func RevokeSession(c *fiber.Ctx) error {
+func (h *Handler) RevokeSession(c *fiber.Ctx) error {
 	sessionIDHex := c.Params("id")
 	sessionID, err := primitive.ObjectIDFromHex(sessionIDHex)
 	if err != nil {
@@ -433,15 +617,12 @@ func RevokeSession(c *fiber.Ctx) error {
 
 	userID := c.Locals("userObjectID").(primitive.ObjectID)
 	currentHash := c.Locals("sessionHash").(string) // Diperoleh dari Protected middleware
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
-	sessionColl := database.GetCollection("usersessions")
-	
-	var session models.UserSession
-	filter := bson.M{"_id": sessionID, "user": userID}
-	if err := sessionColl.FindOne(ctx, filter).Decode(&session); err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
+	session, err := h.store.Sessions.FindByID(ctx, sessionID, userID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
 			return utils.ErrorResponse(c, fiber.StatusNotFound, "Sesi tidak ditemukan.")
 		}
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Server Error.")
@@ -449,9 +630,7 @@ func RevokeSession(c *fiber.Ctx) error {
 
 	// Hanya cabut jika belum dicabut
 	if session.RevokedAt == nil {
-		now := time.Now()
-		update := bson.M{"$set": bson.M{"revoked_at": now, "last_active_time": now}}
-		sessionColl.UpdateOne(ctx, filter, update)
+		h.store.Sessions.Revoke(ctx, session.ID, time.Now())
 	}
 
 	sessionHash := session.Token
@@ -476,9 +655,11 @@ func RevokeSession(c *fiber.Ctx) error {
 }
 
 // StartStreak sets the initial streak start date (Sudah Ada)
-func StartStreak(c *fiber.Ctx) error {
+// habit_id opsional; tanpa habit_id streak habit default yang dimulai.
+func (h *Handler) StartStreak(c *fiber.Ctx) error {
 	var req struct {
 		StartTime string `json:"start_time"`
+		HabitID   string `json:"habit_id"`
 	}
 	if err := c.BodyParser(&req); err != nil {
 		return utils.ErrorResponse(c, 400, "Invalid body")
@@ -490,28 +671,37 @@ func StartStreak(c *fiber.Ctx) error {
 	}
 
 	userID := c.Locals("userObjectID").(primitive.ObjectID)
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
 	defer cancel()
 
-	usersColl := database.GetCollection("users")
-	
-	// Hanya update jika belum di-set (null)
-	res, err := usersColl.UpdateOne(ctx, 
-		bson.M{"_id": userID, "streak_start_date": bson.M{"$in": []interface{}{nil, primitive.Null{}}}}, 
-		bson.M{"$set": bson.M{"streak_start_date": parsedTime}},
-	)
+	user, err := h.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return utils.ErrorResponse(c, 404, "User not found")
+	}
+	habit, err := h.resolveHabit(ctx, user, req.HabitID)
+	if err != nil {
+		return habitError(c, err)
+	}
 
+	// Hanya update jika belum di-set (null)
+	started := false
+	err = h.withStreakUpdate(ctx, userID, habit.ID, func(ctx context.Context) error {
+		var err error
+		started, err = h.store.Habits.StartStreak(ctx, habit.ID, parsedTime)
+		return err
+	})
 	if err != nil {
 		return utils.ErrorResponse(c, 500, "Gagal memulai streak")
 	}
-	
-	if res.ModifiedCount == 0 {
+
+	if !started {
 		return utils.ErrorResponse(c, 400, "Streak sudah dimulai sebelumnya.")
 	}
 
 	utils.LogActivity(c, "user_streak_started", fiber.Map{
+		"habit_id":          habit.ID.Hex(),
 		"streak_start_date": parsedTime,
 	}, nil)
 
-	return GetMe(c)
+	return h.GetMe(c)
 }
\ No newline at end of file
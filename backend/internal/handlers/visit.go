@@ -7,7 +7,7 @@ import (
 
 // TrackVisit handles POST /api/track-visit
 // Mereplikasi logika dari backend/server.js untuk mencatat page_view ke ActivityLog
-func TrackVisit(c *fiber.Ctx) error {
+func (h *Handler) TrackVisit(c *fiber.Ctx) error {
 	var req struct {
 		Path     string `json:"path"`
 		Username string `json:"username"`
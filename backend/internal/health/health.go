@@ -0,0 +1,133 @@
+// Package health menjalankan pemeriksaan dependensi (MongoDB, storage, konfigurasi) untuk
+// endpoint readiness dan menyediakan informasi build serta uptime proses.
+package health
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Version dan Commit diisi saat build lewat
+// -ldflags "-X solivra-go/backend/internal/health.Version=... -X solivra-go/backend/internal/health.Commit=...".
+// Jika Commit kosong, revisi VCS dari build info Go dipakai.
+var (
+	Version = "dev"
+	Commit  = ""
+)
+
+var startedAt = time.Now()
+
+// defaultTimeout dipakai untuk Check tanpa Timeout.
+const defaultTimeout = 3 * time.Second
+
+// Status pemeriksaan
+const (
+	StatusOK          = "ok"
+	StatusError       = "error"
+	StatusUnavailable = "unavailable"
+)
+
+// Check adalah satu pemeriksaan dependensi. Run mengembalikan error jika dependensi tidak sehat.
+type Check struct {
+	Name    string
+	Timeout time.Duration
+	Run     func(ctx context.Context) error
+}
+
+// CheckResult adalah hasil satu Check. Error hanya untuk log, jangan dikirim ke klien.
+type CheckResult struct {
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Report adalah hasil semua Check. Status "ok" hanya jika semua Check lolos.
+type Report struct {
+	Status        string                 `json:"status"`
+	Version       string                 `json:"version"`
+	Commit        string                 `json:"commit"`
+	UptimeSeconds int64                  `json:"uptime_seconds"`
+	Timestamp     time.Time              `json:"timestamp"`
+	Checks        map[string]CheckResult `json:"checks"`
+}
+
+// Healthy melaporkan apakah semua Check lolos.
+func (r *Report) Healthy() bool {
+	return r.Status == StatusOK
+}
+
+// Build mengembalikan versi dan commit build ini.
+func Build() (string, string) {
+	commit := Commit
+	if commit == "" {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			for _, s := range info.Settings {
+				if s.Key == "vcs.revision" {
+					commit = s.Value
+				}
+			}
+		}
+	}
+	if commit == "" {
+		commit = "unknown"
+	}
+	return Version, commit
+}
+
+// Uptime mengembalikan lama proses sudah berjalan.
+func Uptime() time.Duration {
+	return time.Since(startedAt)
+}
+
+// Run menjalankan semua check secara paralel, masing-masing dengan timeout sendiri.
+func Run(ctx context.Context, checks []Check) *Report {
+	version, commit := Build()
+	report := &Report{
+		Status:        StatusOK,
+		Version:       version,
+		Commit:        commit,
+		UptimeSeconds: int64(Uptime().Seconds()),
+		Timestamp:     time.Now(),
+		Checks:        make(map[string]CheckResult, len(checks)),
+	}
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			result := runCheck(ctx, check)
+			mu.Lock()
+			report.Checks[check.Name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusUnavailable
+			}
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+	return report
+}
+
+func runCheck(ctx context.Context, check Check) CheckResult {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+	result := CheckResult{Status: StatusOK, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = StatusError
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,196 @@
+// Package imaging memvalidasi dan memproses gambar upload user sebelum disimpan.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+const (
+	// MaxUploadBytes adalah ukuran file foto profil maksimal.
+	MaxUploadBytes = 5 << 20
+	// MaxDimension dan MaxPixels membatasi ukuran gambar setelah didekode, sehingga file
+	// kecil yang mengembang menjadi bitmap raksasa (decompression bomb) ditolak dari header.
+	MaxDimension = 8000
+	MaxPixels    = 40_000_000
+	// MinDimension adalah sisi terpendek minimal.
+	MinDimension = 64
+
+	jpegQuality = 85
+)
+
+// Ukuran avatar (sisi persegi, piksel)
+const (
+	AvatarSize      = 512 // Foto profil utama
+	AvatarSizeSmall = 128 // Thumbnail untuk daftar dan navbar
+)
+
+// AvatarContentType adalah tipe semua varian avatar hasil ProcessAvatar.
+const AvatarContentType = "image/jpeg"
+
+// Alasan penolakan upload. Pesan untuk user disusun oleh handler.
+var (
+	ErrEmpty              = errors.New("image is empty")
+	ErrTooLarge           = errors.New("image file is too large")
+	ErrUnsupportedFormat  = errors.New("image format is not supported")
+	ErrDimensionsTooLarge = errors.New("image dimensions are too large")
+	ErrDimensionsTooSmall = errors.New("image dimensions are too small")
+	ErrCorrupt            = errors.New("image is corrupt")
+)
+
+// Format gambar yang diterima
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+)
+
+// Avatar berisi varian foto profil yang sudah diproses, dalam JPEG tanpa metadata.
+type Avatar struct {
+	Format string // Format file asli
+	Large  []byte // AvatarSize x AvatarSize
+	Small  []byte // AvatarSizeSmall x AvatarSizeSmall
+}
+
+// Sniff mengenali format dari magic bytes; "" jika bukan JPEG, PNG, atau WebP.
+// Content-Type dan nama file dari klien tidak dipercaya.
+func Sniff(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return FormatJPEG
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return FormatPNG
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return FormatWebP
+	default:
+		return ""
+	}
+}
+
+// ProcessAvatar membaca file upload dari r, memvalidasinya, lalu memotong bagian tengah
+// menjadi persegi dan mengubah ukurannya ke AvatarSize dan AvatarSizeSmall. Hasilnya
+// di-encode ulang sebagai JPEG sehingga metadata EXIF (termasuk lokasi GPS) tidak ikut
+// tersimpan; orientasi EXIF diterapkan lebih dulu agar foto dari ponsel tidak miring.
+// Error yang dikembalikan adalah salah satu Err* di paket ini atau error baca dari r.
+func ProcessAvatar(r io.Reader) (*Avatar, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxUploadBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, ErrEmpty
+	}
+	if len(data) > MaxUploadBytes {
+		return nil, ErrTooLarge
+	}
+
+	format := Sniff(data)
+	var (
+		decodeConfig func(io.Reader) (image.Config, error)
+		decode       func(io.Reader) (image.Image, error)
+	)
+	switch format {
+	case FormatJPEG:
+		decodeConfig, decode = jpeg.DecodeConfig, jpeg.Decode
+	case FormatPNG:
+		decodeConfig, decode = png.DecodeConfig, png.Decode
+	case FormatWebP:
+		decodeConfig, decode = webp.DecodeConfig, webp.Decode
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+
+	// Periksa dimensi dari header sebelum mengalokasikan bitmap
+	cfg, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+	if cfg.Width > MaxDimension || cfg.Height > MaxDimension || cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrDimensionsTooLarge
+	}
+	if cfg.Width < MinDimension || cfg.Height < MinDimension {
+		return nil, ErrDimensionsTooSmall
+	}
+
+	img, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+
+	orientation := 1
+	if format == FormatJPEG {
+		orientation = exifOrientation(data)
+	}
+
+	avatar := &Avatar{Format: format}
+	if avatar.Large, err = squareJPEG(img, AvatarSize, orientation); err != nil {
+		return nil, err
+	}
+	if avatar.Small, err = squareJPEG(img, AvatarSizeSmall, orientation); err != nil {
+		return nil, err
+	}
+	return avatar, nil
+}
+
+// squareJPEG memotong persegi di tengah img, mengubah ukurannya ke size, menerapkan
+// orientasi EXIF, lalu meng-encode sebagai JPEG. Karena potongan berada tepat di tengah,
+// orientasi cukup diterapkan pada hasil akhir yang kecil.
+func squareJPEG(img image.Image, size, orientation int) ([]byte, error) {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+	crop := image.Rect(x0, y0, x0+side, y0+side)
+
+	// Latar putih untuk piksel transparan karena JPEG tidak punya alpha
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, crop, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, orient(dst, orientation), &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// orient menerapkan tag orientasi EXIF (1-8) pada gambar persegi.
+func orient(src *image.RGBA, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+	n := src.Bounds().Dx()
+	last := n - 1
+	dst := image.NewRGBA(src.Bounds())
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // Cermin horizontal
+				sx, sy = last-x, y
+			case 3: // Putar 180°
+				sx, sy = last-x, last-y
+			case 4: // Cermin vertikal
+				sx, sy = x, last-y
+			case 5: // Transpose
+				sx, sy = y, x
+			case 6: // Putar 90° searah jarum jam
+				sx, sy = y, last-x
+			case 7: // Transverse
+				sx, sy = last-y, last-x
+			case 8: // Putar 90° berlawanan jarum jam
+				sx, sy = last-y, x
+			}
+			dst.SetRGBA(x, y, src.RGBAAt(sx, sy))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,280 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Warna tiap kuadran gambar uji: kiri atas, kanan atas, kiri bawah, kanan bawah
+var quadrantColors = [4]color.RGBA{
+	{R: 255, A: 255},
+	{G: 255, A: 255},
+	{B: 255, A: 255},
+	{R: 255, G: 255, A: 255},
+}
+
+// quadrants membuat gambar w x h yang tiap kuadrannya berwarna quadrantColors.
+func quadrants(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			q := 0
+			if x >= w/2 {
+				q++
+			}
+			if y >= h/2 {
+				q += 2
+			}
+			img.SetRGBA(x, y, quadrantColors[q])
+		}
+	}
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withSegments menyisipkan segmen tepat setelah SOI sebuah JPEG.
+func withSegments(data []byte, segments ...[]byte) []byte {
+	out := append([]byte(nil), data[:2]...)
+	for _, s := range segments {
+		out = append(out, s...)
+	}
+	return append(out, data[2:]...)
+}
+
+// pngWithSize mengganti lebar dan tinggi di chunk IHDR (beserta CRC-nya) tanpa mengubah
+// isi gambar, untuk menguji penolakan dari header saja.
+func pngWithSize(t *testing.T, w, h uint32) []byte {
+	t.Helper()
+	data := encodePNG(t, image.NewGray(image.Rect(0, 0, 1, 1)))
+	binary.BigEndian.PutUint32(data[16:20], w)
+	binary.BigEndian.PutUint32(data[20:24], h)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+// markers mengembalikan marker segmen JPEG sebelum SOS.
+func markers(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var result []byte
+	for i := 2; i+4 <= len(data); {
+		marker := data[i+1]
+		if data[i] != 0xFF || marker == 0xDA {
+			break
+		}
+		result = append(result, marker)
+		i += 2 + int(binary.BigEndian.Uint16(data[i+2:i+4]))
+	}
+	return result
+}
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{name: "jpeg", data: []byte{0xFF, 0xD8, 0xFF, 0xE0}, want: FormatJPEG},
+		{name: "png", data: []byte("\x89PNG\r\n\x1a\n\x00"), want: FormatPNG},
+		{name: "webp", data: []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), want: FormatWebP},
+		{name: "riff wave", data: []byte("RIFF\x00\x00\x00\x00WAVEfmt "), want: ""},
+		{name: "truncated riff", data: []byte("RIFF\x00\x00\x00\x00WEB"), want: ""},
+		{name: "gif", data: []byte("GIF89a"), want: ""},
+		{name: "svg", data: []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"/>"), want: ""},
+		{name: "empty", data: nil, want: ""},
+	}
+	for _, tt := range tests {
+		if got := Sniff(tt.data); got != tt.want {
+			t.Errorf("%s: Sniff = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestProcessAvatarRejects(t *testing.T) {
+	valid := encodePNG(t, quadrants(64, 64))
+	tooLarge := make([]byte, MaxUploadBytes+1)
+	copy(tooLarge, []byte{0xFF, 0xD8, 0xFF})
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{name: "empty", data: nil, want: ErrEmpty},
+		{name: "file too large", data: tooLarge, want: ErrTooLarge},
+		{name: "gif", data: []byte("GIF89a\x40\x00\x40\x00"), want: ErrUnsupportedFormat},
+		{name: "text", data: []byte(strings.Repeat("not an image ", 10)), want: ErrUnsupportedFormat},
+		{name: "width too large", data: pngWithSize(t, MaxDimension+1, 100), want: ErrDimensionsTooLarge},
+		{name: "height too large", data: pngWithSize(t, 100, MaxDimension+1), want: ErrDimensionsTooLarge},
+		{name: "too many pixels", data: pngWithSize(t, 7000, 7000), want: ErrDimensionsTooLarge},
+		{name: "too narrow", data: encodePNG(t, quadrants(MinDimension-1, 200)), want: ErrDimensionsTooSmall},
+		{name: "too short", data: encodeJPEG(t, quadrants(200, MinDimension-1)), want: ErrDimensionsTooSmall},
+		{name: "jpeg magic only", data: []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00}, want: ErrCorrupt},
+		{name: "png header only", data: valid[:40], want: ErrCorrupt},
+		{name: "webp magic only", data: []byte("RIFF\x10\x00\x00\x00WEBPVP8 "), want: ErrCorrupt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			avatar, err := ProcessAvatar(bytes.NewReader(tt.data))
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if avatar != nil {
+				t.Error("avatar returned with error")
+			}
+		})
+	}
+}
+
+func TestProcessAvatarAccepts(t *testing.T) {
+	webp, err := os.ReadFile("testdata/gopher.lossless.webp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// EXIF dengan data lain selain orientasi (mis. GPS) tidak boleh ikut tersimpan
+	exif := exifSegment(tiffHeader(false, tiffEntry{0x0112, 1}, tiffEntry{0x8825, 26}))
+	comment := segment(0xFE, []byte("taken at home"))
+	transparent := image.NewNRGBA(image.Rect(0, 0, 100, 80))
+
+	tests := []struct {
+		name   string
+		data   []byte
+		format string
+	}{
+		{name: "jpeg", data: encodeJPEG(t, quadrants(300, 200)), format: FormatJPEG},
+		{name: "jpeg with metadata", data: withSegments(encodeJPEG(t, quadrants(300, 200)), exif, comment), format: FormatJPEG},
+		{name: "png", data: encodePNG(t, quadrants(200, 300)), format: FormatPNG},
+		{name: "transparent png", data: encodePNG(t, transparent), format: FormatPNG},
+		{name: "webp", data: webp, format: FormatWebP},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			avatar, err := ProcessAvatar(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if avatar.Format != tt.format {
+				t.Errorf("Format = %q, want %q", avatar.Format, tt.format)
+			}
+			for _, v := range []struct {
+				data []byte
+				size int
+			}{{avatar.Large, AvatarSize}, {avatar.Small, AvatarSizeSmall}} {
+				cfg, format, err := image.DecodeConfig(bytes.NewReader(v.data))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if format != "jpeg" || cfg.Width != v.size || cfg.Height != v.size {
+					t.Errorf("variant = %s %dx%d, want jpeg %dx%d", format, cfg.Width, cfg.Height, v.size, v.size)
+				}
+				// Hanya tabel kuantisasi/Huffman dan frame; tanpa APPn (EXIF, JFIF) atau komentar
+				for _, m := range markers(t, v.data) {
+					if m >= 0xE0 && m <= 0xEF || m == 0xFE {
+						t.Errorf("variant %d keeps metadata segment FF%02X", v.size, m)
+					}
+				}
+			}
+		})
+	}
+}
+
+// near memeriksa apakah c mendekati want, dengan toleransi untuk kompresi JPEG.
+func near(c color.Color, want color.RGBA) bool {
+	r, g, b, _ := c.RGBA()
+	diff := func(got uint32, want uint8) bool {
+		d := int(got>>8) - int(want)
+		return d > -48 && d < 48
+	}
+	return diff(r, want.R) && diff(g, want.G) && diff(b, want.B)
+}
+
+func TestProcessAvatarOrientation(t *testing.T) {
+	tl, tr, bl, br := quadrantColors[0], quadrantColors[1], quadrantColors[2], quadrantColors[3]
+	// Warna yang diharapkan di kiri atas, kanan atas, kiri bawah, kanan bawah setelah orientasi diterapkan
+	tests := []struct {
+		orientation uint16
+		want        [4]color.RGBA
+	}{
+		{1, [4]color.RGBA{tl, tr, bl, br}},
+		{2, [4]color.RGBA{tr, tl, br, bl}},
+		{3, [4]color.RGBA{br, bl, tr, tl}},
+		{4, [4]color.RGBA{bl, br, tl, tr}},
+		{5, [4]color.RGBA{tl, bl, tr, br}},
+		{6, [4]color.RGBA{bl, tl, br, tr}},
+		{7, [4]color.RGBA{br, tr, bl, tl}},
+		{8, [4]color.RGBA{tr, br, tl, bl}},
+	}
+	base := encodeJPEG(t, quadrants(256, 256))
+	for _, tt := range tests {
+		data := withSegments(base, exifSegment(tiffHeader(true, tiffEntry{0x0112, tt.orientation})))
+		avatar, err := ProcessAvatar(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := jpeg.Decode(bytes.NewReader(avatar.Small))
+		if err != nil {
+			t.Fatal(err)
+		}
+		q := AvatarSizeSmall / 4
+		points := [4]image.Point{{q, q}, {3 * q, q}, {q, 3 * q}, {3 * q, 3 * q}}
+		for i, p := range points {
+			if got := img.At(p.X, p.Y); !near(got, tt.want[i]) {
+				t.Errorf("orientation %d: pixel %v = %v, want %v", tt.orientation, p, got, tt.want[i])
+			}
+		}
+	}
+}
+
+// orient diuji langsung pada gambar 2x2 agar setiap piksel bisa dicek persis.
+func TestOrient(t *testing.T) {
+	src := quadrants(2, 2)
+	tl, tr, bl, br := quadrantColors[0], quadrantColors[1], quadrantColors[2], quadrantColors[3]
+	tests := []struct {
+		orientation int
+		want        [4]color.RGBA
+	}{
+		{0, [4]color.RGBA{tl, tr, bl, br}},
+		{1, [4]color.RGBA{tl, tr, bl, br}},
+		{2, [4]color.RGBA{tr, tl, br, bl}},
+		{3, [4]color.RGBA{br, bl, tr, tl}},
+		{4, [4]color.RGBA{bl, br, tl, tr}},
+		{5, [4]color.RGBA{tl, bl, tr, br}},
+		{6, [4]color.RGBA{bl, tl, br, tr}},
+		{7, [4]color.RGBA{br, tr, bl, tl}},
+		{8, [4]color.RGBA{tr, br, tl, bl}},
+		{9, [4]color.RGBA{tl, tr, bl, br}},
+	}
+	for _, tt := range tests {
+		got := orient(src, tt.orientation)
+		for i, p := range []image.Point{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+			if c := color.RGBAModel.Convert(got.At(p.X, p.Y)); c != tt.want[i] {
+				t.Errorf("orientation %d: pixel %v = %v, want %v", tt.orientation, p, c, tt.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// tiffEntry adalah satu entri IFD bertipe SHORT.
+type tiffEntry struct {
+	tag, value uint16
+}
+
+// tiffHeader menyusun header TIFF (little endian, atau big endian jika bigEndian) dengan
+// satu IFD berisi entries.
+func tiffHeader(bigEndian bool, entries ...tiffEntry) []byte {
+	var order binary.AppendByteOrder = binary.LittleEndian
+	b := []byte("II")
+	if bigEndian {
+		order, b = binary.BigEndian, []byte("MM")
+	}
+	b = order.AppendUint16(b, 42)
+	b = order.AppendUint32(b, 8)
+	b = order.AppendUint16(b, uint16(len(entries)))
+	for _, e := range entries {
+		b = order.AppendUint16(b, e.tag)
+		b = order.AppendUint16(b, 3) // SHORT
+		b = order.AppendUint32(b, 1)
+		b = order.AppendUint16(b, e.value)
+		b = append(b, 0, 0)
+	}
+	return order.AppendUint32(b, 0) // Tidak ada IFD berikutnya
+}
+
+// segment menyusun segmen JPEG dengan marker dan payload.
+func segment(marker byte, payload []byte) []byte {
+	b := []byte{0xFF, marker}
+	b = binary.BigEndian.AppendUint16(b, uint16(len(payload)+2))
+	return append(b, payload...)
+}
+
+func exifSegment(tiff []byte) []byte {
+	return segment(0xE1, append([]byte("Exif\x00\x00"), tiff...))
+}
+
+// jpegWith menyusun awal file JPEG: SOI, segments, lalu SOS.
+func jpegWith(segments ...[]byte) []byte {
+	b := []byte{0xFF, 0xD8}
+	for _, s := range segments {
+		b = append(b, s...)
+	}
+	return append(b, 0xFF, 0xDA, 0x00, 0x02)
+}
+
+func TestExifOrientation(t *testing.T) {
+	orientation6 := exifSegment(tiffHeader(false, tiffEntry{0x0112, 6}))
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{name: "no exif", data: jpegWith(segment(0xDB, make([]byte, 65))), want: 1},
+		{name: "little endian", data: jpegWith(orientation6), want: 6},
+		{name: "big endian", data: jpegWith(exifSegment(tiffHeader(true, tiffEntry{0x0112, 8}))), want: 8},
+		{name: "after jfif segment", data: jpegWith(segment(0xE0, []byte("JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00")), orientation6), want: 6},
+		{name: "after other tags", data: jpegWith(exifSegment(tiffHeader(true, tiffEntry{0x010F, 1}, tiffEntry{0x0112, 3}))), want: 3},
+		{name: "no orientation tag", data: jpegWith(exifSegment(tiffHeader(false, tiffEntry{0x010F, 6}))), want: 1},
+		{name: "orientation zero", data: jpegWith(exifSegment(tiffHeader(false, tiffEntry{0x0112, 0}))), want: 1},
+		{name: "orientation out of range", data: jpegWith(exifSegment(tiffHeader(false, tiffEntry{0x0112, 9}))), want: 1},
+		{name: "xmp app1", data: jpegWith(segment(0xE1, []byte("http://ns.adobe.com/xap/1.0/\x00"))), want: 1},
+		{name: "exif after scan", data: append(jpegWith(), orientation6...), want: 1},
+		{name: "missing marker prefix", data: []byte{0xFF, 0xD8, 0x00, 0xE1, 0x00, 0x10}, want: 1},
+		{name: "segment length below two", data: []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x01}, want: 1},
+		{name: "segment longer than file", data: []byte{0xFF, 0xD8, 0xFF, 0xE1, 0xFF, 0xFF, 'E', 'x'}, want: 1},
+		{name: "empty", data: nil, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exifOrientation(tt.data); got != tt.want {
+				t.Errorf("exifOrientation = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// Setiap potongan file yang valid harus aman dibaca: hasilnya 1 atau orientasi aslinya.
+func TestExifOrientationTruncated(t *testing.T) {
+	for _, bigEndian := range []bool{false, true} {
+		data := jpegWith(exifSegment(tiffHeader(bigEndian, tiffEntry{0x010F, 1}, tiffEntry{0x0112, 6})))
+		for n := 0; n <= len(data); n++ {
+			if got := exifOrientation(data[:n]); got != 1 && got != 6 {
+				t.Errorf("big endian %v: exifOrientation(data[:%d]) = %d", bigEndian, n, got)
+			}
+		}
+	}
+}
+
+func TestTiffOrientation(t *testing.T) {
+	valid := tiffHeader(false, tiffEntry{0x0112, 5})
+	withOffset := func(offset uint32) []byte {
+		b := append([]byte(nil), valid...)
+		binary.LittleEndian.PutUint32(b[4:8], offset)
+		return b
+	}
+	tooManyEntries := append([]byte(nil), valid...)
+	binary.LittleEndian.PutUint16(tooManyEntries[8:10], 0xFFFF)
+	tooManyEntries[10] = 0x0F // Entri pertama bukan Orientation, sisanya terpotong
+
+	tests := []struct {
+		name string
+		tiff []byte
+		want int
+	}{
+		{name: "valid", tiff: valid, want: 5},
+		{name: "shorter than header", tiff: valid[:7], want: 1},
+		{name: "unknown byte order", tiff: append([]byte("XX"), valid[2:]...), want: 1},
+		{name: "ifd inside header", tiff: withOffset(4), want: 1},
+		{name: "ifd past end", tiff: withOffset(uint32(len(valid))), want: 1},
+		{name: "ifd offset overflow", tiff: withOffset(0xFFFFFFFF), want: 1},
+		{name: "entry count past end", tiff: tooManyEntries, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tiffOrientation(tt.tiff); got != tt.want {
+				t.Errorf("tiffOrientation = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,392 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvRow adalah satu baris CSV beserta nomor barisnya di file.
+type csvRow struct {
+	line   int
+	fields []string
+}
+
+// readCSV membaca semua baris CSV yang tidak kosong. BOM UTF-8 di awal file dibuang.
+func readCSV(r io.Reader) ([]csvRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.LazyQuotes = true
+
+	var rows []csvRow
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("CSV tidak valid: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		if len(rows) == 0 && len(fields) > 0 {
+			fields[0] = strings.TrimPrefix(fields[0], "\ufeff")
+		}
+		if isBlank(fields) {
+			continue
+		}
+		rows = append(rows, csvRow{line: line, fields: fields})
+	}
+	return rows, nil
+}
+
+func isBlank(fields []string) bool {
+	for _, f := range fields {
+		if strings.TrimSpace(f) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// columnIndex mencari kolom header yang namanya (tanpa memedulikan huruf besar, spasi,
+// dan garis bawah) sama dengan salah satu names. Mengembalikan -1 jika tidak ada.
+func columnIndex(header []string, names ...string) int {
+	normalize := func(s string) string {
+		s = strings.ToLower(strings.TrimSpace(s))
+		return strings.NewReplacer(" ", "", "_", "", "-", "").Replace(s)
+	}
+	for i, col := range header {
+		for _, name := range names {
+			if normalize(col) == normalize(name) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// field mengembalikan kolom i dari baris, atau string kosong bila tidak ada.
+func field(fields []string, i int) string {
+	if i < 0 || i >= len(fields) {
+		return ""
+	}
+	return strings.TrimSpace(fields[i])
+}
+
+// hasHeader menganggap baris pertama sebagai header bila kolom pertamanya bukan waktu.
+func hasHeader(rows []csvRow, loc *time.Location) bool {
+	if len(rows) == 0 {
+		return false
+	}
+	_, _, err := parseTime(field(rows[0].fields, 0), loc)
+	return err != nil
+}
+
+// localMidnight mengembalikan 00:00 pada tanggal t di zona loc, dalam UTC.
+func localMidnight(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc).UTC()
+}
+
+// appendEntry menambahkan entri dan menjaga batas MaxEntries.
+func appendEntry(entries []Entry, e Entry) ([]Entry, error) {
+	if len(entries) >= MaxEntries {
+		return entries, ErrTooManyEntries
+	}
+	return append(entries, e), nil
+}
+
+// parseCSV membaca format timestamp,note. Dengan header, kolom dicari berdasarkan nama
+// (timestamp/time/date/relapse_time dan note/notes/relapse_note/comment).
+func parseCSV(r io.Reader, opts Options) ([]Entry, []RowError, error) {
+	loc := opts.location()
+	rows, err := readCSV(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	timeCol, noteCol := 0, 1
+	if hasHeader(rows, loc) {
+		header := rows[0].fields
+		rows = rows[1:]
+		timeCol = columnIndex(header, "timestamp", "relapse_time", "datetime", "time", "date", "relapsed_at")
+		noteCol = columnIndex(header, "note", "notes", "relapse_note", "comment", "comments")
+		if timeCol < 0 {
+			return nil, nil, errors.New("kolom timestamp tidak ditemukan di header CSV")
+		}
+	}
+
+	var (
+		entries []Entry
+		rowErrs []RowError
+	)
+	for _, row := range rows {
+		t, dateOnly, err := parseTime(field(row.fields, timeCol), loc)
+		if err != nil {
+			rowErrs = append(rowErrs, RowError{Line: row.line, Msg: err.Error()})
+			continue
+		}
+		entries, err = appendEntry(entries, Entry{Line: row.line, Time: t, Note: field(row.fields, noteCol), DateOnly: dateOnly})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return entries, rowErrs, nil
+}
+
+// loopManualCheck adalah nilai checkmark Loop untuk hari yang ditandai manual (YES_MANUAL).
+// Nilai lain (YES_AUTO, NO, UNKNOWN) bukan relapse.
+const loopManualCheck = 2
+
+// parseLoop membaca Checkmarks.csv Loop Habit Tracker. Ekspor per habit tidak punya
+// header (Date,Value); ekspor gabungan punya header Date,<habit>,<habit>,... sehingga
+// Options.SourceHabit wajib diisi bila ada lebih dari satu habit. Setiap hari yang
+// ditandai manual dianggap satu relapse pada 00:00 di zona impor.
+func parseLoop(r io.Reader, opts Options) ([]Entry, []RowError, error) {
+	loc := opts.location()
+	rows, err := readCSV(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	valueCol := 1
+	if hasHeader(rows, loc) {
+		header := rows[0].fields
+		rows = rows[1:]
+		var habits []string
+		for _, name := range header[1:] {
+			if name = strings.TrimSpace(name); name != "" {
+				habits = append(habits, name)
+			}
+		}
+		switch {
+		case len(habits) == 0:
+			return nil, nil, errors.New("kolom habit tidak ditemukan di header Loop")
+		case opts.SourceHabit != "":
+			valueCol = columnIndex(header, opts.SourceHabit)
+			if valueCol <= 0 {
+				return nil, nil, fmt.Errorf("habit %q tidak ditemukan di file", opts.SourceHabit)
+			}
+		case len(habits) > 1:
+			return nil, nil, &MultipleHabitsError{Habits: habits}
+		}
+	}
+
+	var (
+		entries []Entry
+		rowErrs []RowError
+	)
+	for _, row := range rows {
+		t, _, err := parseTime(field(row.fields, 0), loc)
+		if err != nil {
+			rowErrs = append(rowErrs, RowError{Line: row.line, Msg: err.Error()})
+			continue
+		}
+		value, err := strconv.Atoi(field(row.fields, valueCol))
+		if err != nil {
+			rowErrs = append(rowErrs, RowError{Line: row.line, Msg: "nilai checkmark tidak valid"})
+			continue
+		}
+		if value != loopManualCheck {
+			continue
+		}
+		entries, err = appendEntry(entries, Entry{Line: row.line, Time: localMidnight(t, loc), DateOnly: true})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return entries, rowErrs, nil
+}
+
+// parseHabitBull membaca ekspor CSV HabitBull. Setiap baris dengan Value > 0 dianggap
+// relapse pada tanggal CalendarDate (00:00 di zona impor), CommentText menjadi catatan.
+func parseHabitBull(r io.Reader, opts Options) ([]Entry, []RowError, error) {
+	loc := opts.location()
+	rows, err := readCSV(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil, nil
+	}
+
+	header := rows[0].fields
+	rows = rows[1:]
+	nameCol := columnIndex(header, "HabitName")
+	dateCol := columnIndex(header, "CalendarDate")
+	valueCol := columnIndex(header, "Value")
+	commentCol := columnIndex(header, "CommentText")
+	if dateCol < 0 || valueCol < 0 {
+		return nil, nil, errors.New("header HabitBull harus memuat kolom CalendarDate dan Value")
+	}
+
+	// Tentukan habit yang diimpor sebelum membaca baris
+	seen := make(map[string]bool)
+	var habits []string
+	for _, row := range rows {
+		name := field(row.fields, nameCol)
+		if !seen[name] {
+			seen[name] = true
+			habits = append(habits, name)
+		}
+	}
+	selected := ""
+	switch {
+	case opts.SourceHabit != "":
+		for _, name := range habits {
+			if strings.EqualFold(name, opts.SourceHabit) {
+				selected = name
+			}
+		}
+		if selected == "" {
+			return nil, nil, fmt.Errorf("habit %q tidak ditemukan di file", opts.SourceHabit)
+		}
+	case len(habits) > 1:
+		return nil, nil, &MultipleHabitsError{Habits: habits}
+	case len(habits) == 1:
+		selected = habits[0]
+	}
+
+	var (
+		entries []Entry
+		rowErrs []RowError
+	)
+	for _, row := range rows {
+		if field(row.fields, nameCol) != selected {
+			continue
+		}
+		t, _, err := parseTime(field(row.fields, dateCol), loc)
+		if err != nil {
+			rowErrs = append(rowErrs, RowError{Line: row.line, Msg: err.Error()})
+			continue
+		}
+		value, err := strconv.ParseFloat(field(row.fields, valueCol), 64)
+		if err != nil {
+			rowErrs = append(rowErrs, RowError{Line: row.line, Msg: "nilai tidak valid"})
+			continue
+		}
+		if value <= 0 {
+			continue
+		}
+		entries, err = appendEntry(entries, Entry{
+			Line:     row.line,
+			Time:     localMidnight(t, loc),
+			Note:     field(row.fields, commentCol),
+			DateOnly: true,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return entries, rowErrs, nil
+}
+
+// solivraRelapse adalah subset dokumen relapse di arsip ekspor Solivra.
+type solivraRelapse struct {
+	HabitID     string  `json:"habit"`
+	RelapseTime string  `json:"relapse_time"`
+	RelapseNote *string `json:"relapse_note"`
+}
+
+// parseSolivra membaca relapses.json (array) atau ekspor JSON lengkap (objek dengan
+// field relapses dan habits). Bila relapse berasal dari beberapa habit,
+// Options.SourceHabit (ID atau nama habit) wajib diisi.
+func parseSolivra(r io.Reader, opts Options) ([]Entry, []RowError, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\ufeff")))
+
+	var (
+		relapses []solivraRelapse
+		names    = make(map[string]string) // ID habit -> nama
+	)
+	if bytes.HasPrefix(data, []byte("[")) {
+		if err := json.Unmarshal(data, &relapses); err != nil {
+			return nil, nil, fmt.Errorf("JSON tidak valid: %w", err)
+		}
+	} else {
+		var bundle struct {
+			Habits []struct {
+				ID   string `json:"_id"`
+				Name string `json:"name"`
+			} `json:"habits"`
+			Relapses []solivraRelapse `json:"relapses"`
+		}
+		if err := json.Unmarshal(data, &bundle); err != nil {
+			return nil, nil, fmt.Errorf("JSON tidak valid: %w", err)
+		}
+		relapses = bundle.Relapses
+		for _, habit := range bundle.Habits {
+			names[habit.ID] = habit.Name
+		}
+	}
+
+	label := func(id string) string {
+		if name, ok := names[id]; ok && name != "" {
+			return name
+		}
+		return id
+	}
+
+	selected := ""
+	if opts.SourceHabit != "" {
+		found := false
+		for _, rel := range relapses {
+			if rel.HabitID == opts.SourceHabit || strings.EqualFold(label(rel.HabitID), opts.SourceHabit) {
+				selected, found = rel.HabitID, true
+				break
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("habit %q tidak ditemukan di file", opts.SourceHabit)
+		}
+	} else {
+		seen := make(map[string]bool)
+		for _, rel := range relapses {
+			seen[rel.HabitID] = true
+		}
+		if len(seen) > 1 {
+			habits := make([]string, 0, len(seen))
+			for id := range seen {
+				habits = append(habits, label(id))
+			}
+			sort.Strings(habits)
+			return nil, nil, &MultipleHabitsError{Habits: habits}
+		}
+	}
+
+	var (
+		entries []Entry
+		rowErrs []RowError
+	)
+	loc := opts.location()
+	for i, rel := range relapses {
+		if opts.SourceHabit != "" && rel.HabitID != selected {
+			continue
+		}
+		t, dateOnly, err := parseTime(rel.RelapseTime, loc)
+		if err != nil {
+			rowErrs = append(rowErrs, RowError{Line: i + 1, Msg: err.Error()})
+			continue
+		}
+		entry := Entry{Line: i + 1, Time: t, DateOnly: dateOnly}
+		if rel.RelapseNote != nil {
+			entry.Note = *rel.RelapseNote
+		}
+		entries, err = appendEntry(entries, entry)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return entries, rowErrs, nil
+}
@@ -0,0 +1,157 @@
+// Package importer membaca riwayat relapse dari file ekspor (CSV biasa, aplikasi tracker
+// lain, atau arsip Solivra sendiri) dan menyusun rencana impor: entri baru, duplikat,
+// dan baris yang ditolak. Paket ini murni (tanpa DB); penyimpanan dilakukan handler.
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format adalah jenis file yang bisa diimpor.
+type Format string
+
+const (
+	// FormatCSV: kolom timestamp,note (header opsional).
+	FormatCSV Format = "csv"
+	// FormatLoop: Checkmarks.csv dari Loop Habit Tracker (Date,Value atau Date,<habit>...).
+	FormatLoop Format = "loop"
+	// FormatHabitBull: ekspor CSV HabitBull (HabitName,...,CalendarDate,Value,CommentText).
+	FormatHabitBull Format = "habitbull"
+	// FormatSolivra: relapses.json dari ekspor data Solivra, atau seluruh ekspor JSON-nya.
+	FormatSolivra Format = "solivra"
+)
+
+// Formats berisi semua format yang didukung.
+var Formats = []Format{FormatCSV, FormatLoop, FormatHabitBull, FormatSolivra}
+
+// Valid melaporkan apakah f adalah format yang dikenal.
+func (f Format) Valid() bool {
+	for _, known := range Formats {
+		if f == known {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxEntries membatasi jumlah baris relapse dalam satu file impor.
+const MaxEntries = 5000
+
+var (
+	// ErrTooManyEntries dikembalikan jika file berisi lebih dari MaxEntries relapse.
+	ErrTooManyEntries = fmt.Errorf("file impor berisi lebih dari %d relapse", MaxEntries)
+	// ErrMultipleHabits dikembalikan jika file berisi beberapa habit dan Options.SourceHabit kosong.
+	ErrMultipleHabits = errors.New("file impor berisi beberapa habit")
+)
+
+// MultipleHabitsError membawa nama habit yang ditemukan di file; errors.Is(err, ErrMultipleHabits) bernilai true.
+type MultipleHabitsError struct {
+	Habits []string
+}
+
+func (e *MultipleHabitsError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrMultipleHabits, strings.Join(e.Habits, ", "))
+}
+
+func (e *MultipleHabitsError) Is(target error) bool {
+	return target == ErrMultipleHabits
+}
+
+// Entry adalah satu relapse yang terbaca dari file.
+type Entry struct {
+	// Line adalah nomor baris CSV atau indeks elemen JSON (mulai dari 1).
+	Line int       `json:"line"`
+	Time time.Time `json:"relapse_time"`
+	Note string    `json:"relapse_note,omitempty"`
+	// DateOnly berarti sumber hanya mencatat tanggal; Time adalah 00:00 di zona impor.
+	DateOnly bool `json:"date_only,omitempty"`
+}
+
+// RowError adalah baris yang dilewati beserta alasannya.
+type RowError struct {
+	Line int    `json:"line"`
+	Msg  string `json:"msg"`
+}
+
+// Options mengatur cara file dibaca.
+type Options struct {
+	// Location dipakai untuk timestamp tanpa offset dan untuk format yang hanya
+	// mencatat tanggal. nil berarti UTC.
+	Location *time.Location
+	// SourceHabit memilih habit yang diimpor bila file berisi beberapa habit
+	// (Loop dengan banyak kolom, HabitBull). Perbandingan tidak peka huruf besar.
+	SourceHabit string
+}
+
+func (o Options) location() *time.Location {
+	if o.Location == nil {
+		return time.UTC
+	}
+	return o.Location
+}
+
+// Parse membaca r sesuai format. Error yang dikembalikan berarti file tidak bisa
+// dipakai sama sekali; baris yang rusak dilaporkan lewat []RowError dan dilewati.
+func Parse(r io.Reader, format Format, opts Options) ([]Entry, []RowError, error) {
+	switch format {
+	case FormatCSV:
+		return parseCSV(r, opts)
+	case FormatLoop:
+		return parseLoop(r, opts)
+	case FormatHabitBull:
+		return parseHabitBull(r, opts)
+	case FormatSolivra:
+		return parseSolivra(r, opts)
+	default:
+		return nil, nil, fmt.Errorf("format %q tidak didukung", format)
+	}
+}
+
+// Layout waktu yang dikenali, dari yang paling spesifik.
+var (
+	zonedLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05Z0700", "2006-01-02 15:04:05Z07:00"}
+	localLayouts = []string{
+		"2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05.999999999",
+		"2006-01-02T15:04", "2006-01-02 15:04",
+		"2006/01/02 15:04:05", "2006/01/02 15:04",
+	}
+	dateLayouts = []string{"2006-01-02", "2006/01/02"}
+)
+
+// parseTime membaca timestamp. Timestamp dengan offset dipakai apa adanya; tanpa offset
+// dianggap waktu lokal di loc. Angka murni dianggap Unix epoch (detik, atau milidetik
+// bila lebih dari 12 digit). dateOnly bernilai true bila hanya tanggal yang tercatat.
+func parseTime(raw string, loc *time.Location) (t time.Time, dateOnly bool, err error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, false, errors.New("timestamp kosong")
+	}
+
+	if n, convErr := strconv.ParseInt(raw, 10, 64); convErr == nil && n > 0 {
+		if len(raw) > 12 {
+			return time.UnixMilli(n).UTC(), false, nil
+		}
+		return time.Unix(n, 0).UTC(), false, nil
+	}
+	for _, layout := range zonedLayouts {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t.UTC(), false, nil
+		}
+	}
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, raw, loc); err == nil {
+			return t.UTC(), false, nil
+		}
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, raw, loc); err == nil {
+			return t.UTC(), true, nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("format waktu %q tidak dikenali", raw)
+}
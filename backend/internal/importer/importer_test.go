@@ -0,0 +1,220 @@
+package importer
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestParse(t *testing.T) {
+	jakarta := mustLocation(t, "Asia/Jakarta")
+	utc := func(s string) time.Time {
+		parsed, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name       string
+		format     Format
+		input      string
+		opts       Options
+		want       []Entry
+		rowErrs    []RowError
+		err        error    // errors.Is
+		errText    string   // Bagian pesan error lain
+		wantHabits []string // MultipleHabitsError.Habits
+		count      int      // Jika diisi, hanya jumlah entri yang diperiksa
+	}{
+		{
+			name:   "csv without header",
+			format: FormatCSV,
+			input:  "2024-01-02T10:00:00Z,note one\n2024-01-03 08:30,local\n2024-01-04\n",
+			opts:   Options{Location: jakarta},
+			want: []Entry{
+				{Line: 1, Time: utc("2024-01-02T10:00:00Z"), Note: "note one"},
+				{Line: 2, Time: utc("2024-01-03T01:30:00Z"), Note: "local"},
+				{Line: 3, Time: utc("2024-01-03T17:00:00Z"), DateOnly: true},
+			},
+		},
+		{
+			name:   "csv header in any order with epoch",
+			format: FormatCSV,
+			input:  "\ufeffNote,Relapse Time\nhello,1704189600\nmillis,1704189600000\n",
+			want: []Entry{
+				{Line: 2, Time: utc("2024-01-02T10:00:00Z"), Note: "hello"},
+				{Line: 3, Time: utc("2024-01-02T10:00:00Z"), Note: "millis"},
+			},
+		},
+		{
+			name:    "csv bad rows are skipped",
+			format:  FormatCSV,
+			input:   "2024-01-02T10:00:00Z\nnot a time\n\n,empty\n2024-01-05T00:00:00Z\n",
+			want:    []Entry{{Line: 1, Time: utc("2024-01-02T10:00:00Z")}, {Line: 5, Time: utc("2024-01-05T00:00:00Z")}},
+			rowErrs: []RowError{{Line: 2, Msg: `format waktu "not a time" tidak dikenali`}, {Line: 4, Msg: "timestamp kosong"}},
+		},
+		{
+			name:    "csv header without timestamp",
+			format:  FormatCSV,
+			input:   "note,mood\nhello,3\n",
+			errText: "kolom timestamp tidak ditemukan",
+		},
+		{
+			name:   "loop single habit",
+			format: FormatLoop,
+			input:  "2024-01-01,2\n2024-01-02,0\n2024-01-03,-1\n2024-01-04,2\n2024-01-05,x\n",
+			opts:   Options{Location: jakarta},
+			want: []Entry{
+				{Line: 1, Time: utc("2023-12-31T17:00:00Z"), DateOnly: true},
+				{Line: 4, Time: utc("2024-01-03T17:00:00Z"), DateOnly: true},
+			},
+			rowErrs: []RowError{{Line: 5, Msg: "nilai checkmark tidak valid"}},
+		},
+		{
+			name:       "loop multiple habits",
+			format:     FormatLoop,
+			input:      "Date,Smoking,Sugar\n2024-01-01,2,0\n",
+			err:        ErrMultipleHabits,
+			wantHabits: []string{"Smoking", "Sugar"},
+		},
+		{
+			name:   "loop selected habit",
+			format: FormatLoop,
+			input:  "Date,Smoking,Sugar\n2024-01-01,2,0\n2024-01-02,0,2\n",
+			opts:   Options{SourceHabit: "sugar"},
+			want:   []Entry{{Line: 3, Time: utc("2024-01-02T00:00:00Z"), DateOnly: true}},
+		},
+		{
+			name:    "loop unknown habit",
+			format:  FormatLoop,
+			input:   "Date,Smoking,Sugar\n2024-01-01,2,0\n",
+			opts:    Options{SourceHabit: "coffee"},
+			errText: `habit "coffee" tidak ditemukan`,
+		},
+		{
+			name:    "habitbull single habit",
+			format:  FormatHabitBull,
+			input:   "HabitName,HabitDescription,HabitCategory,CalendarDate,Value,CommentText\nQuit,,,2024-01-01,1,bad day\nQuit,,,2024-01-02,0,\nQuit,,,2024-01-03,abc,\n",
+			want:    []Entry{{Line: 2, Time: utc("2024-01-01T00:00:00Z"), Note: "bad day", DateOnly: true}},
+			rowErrs: []RowError{{Line: 4, Msg: "nilai tidak valid"}},
+		},
+		{
+			name:       "habitbull multiple habits",
+			format:     FormatHabitBull,
+			input:      "HabitName,CalendarDate,Value\nQuit,2024-01-01,1\nRun,2024-01-01,1\n",
+			err:        ErrMultipleHabits,
+			wantHabits: []string{"Quit", "Run"},
+		},
+		{
+			name:   "habitbull selected habit",
+			format: FormatHabitBull,
+			input:  "HabitName,CalendarDate,Value\nQuit,2024-01-01,1\nRun,2024-01-02,1\n",
+			opts:   Options{SourceHabit: "RUN"},
+			want:   []Entry{{Line: 3, Time: utc("2024-01-02T00:00:00Z"), DateOnly: true}},
+		},
+		{
+			name:    "habitbull missing columns",
+			format:  FormatHabitBull,
+			input:   "HabitName,Date\nQuit,2024-01-01\n",
+			errText: "CalendarDate dan Value",
+		},
+		{
+			name:    "solivra relapses array",
+			format:  FormatSolivra,
+			input:   `[{"habit":"h1","relapse_time":"2024-01-02T10:00:00Z","relapse_note":"n"},{"habit":"h1","relapse_time":"bad"}]`,
+			want:    []Entry{{Line: 1, Time: utc("2024-01-02T10:00:00Z"), Note: "n"}},
+			rowErrs: []RowError{{Line: 2, Msg: `format waktu "bad" tidak dikenali`}},
+		},
+		{
+			name:       "solivra export with multiple habits",
+			format:     FormatSolivra,
+			input:      `{"habits":[{"_id":"h1","name":"Smoking"},{"_id":"h2","name":"Alcohol"}],"relapses":[{"habit":"h1","relapse_time":"2024-01-02T10:00:00Z"},{"habit":"h2","relapse_time":"2024-01-03T10:00:00Z"}]}`,
+			err:        ErrMultipleHabits,
+			wantHabits: []string{"Alcohol", "Smoking"},
+		},
+		{
+			name:   "solivra export selected by name",
+			format: FormatSolivra,
+			input:  `{"habits":[{"_id":"h1","name":"Smoking"},{"_id":"h2","name":"Alcohol"}],"relapses":[{"habit":"h1","relapse_time":"2024-01-02T10:00:00Z"},{"habit":"h2","relapse_time":"2024-01-03"}]}`,
+			opts:   Options{SourceHabit: "alcohol"},
+			want:   []Entry{{Line: 2, Time: utc("2024-01-03T00:00:00Z"), DateOnly: true}},
+		},
+		{
+			name:    "solivra invalid json",
+			format:  FormatSolivra,
+			input:   `{"relapses":`,
+			errText: "JSON tidak valid",
+		},
+		{
+			name:    "unsupported format",
+			format:  Format("xlsx"),
+			errText: "tidak didukung",
+		},
+		{
+			name:   "max entries",
+			format: FormatCSV,
+			input:  strings.Repeat("2024-01-02T10:00:00Z\n", MaxEntries),
+			count:  MaxEntries,
+		},
+		{
+			name:   "too many entries",
+			format: FormatCSV,
+			input:  strings.Repeat("2024-01-02T10:00:00Z\n", MaxEntries+1),
+			err:    ErrTooManyEntries,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, rowErrs, err := Parse(strings.NewReader(tt.input), tt.format, tt.opts)
+
+			switch {
+			case tt.err != nil:
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("err = %v, want %v", err, tt.err)
+				}
+			case tt.errText != "":
+				if err == nil || !strings.Contains(err.Error(), tt.errText) {
+					t.Fatalf("err = %v, want containing %q", err, tt.errText)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantHabits != nil {
+				var multi *MultipleHabitsError
+				if !errors.As(err, &multi) || !reflect.DeepEqual(multi.Habits, tt.wantHabits) {
+					t.Errorf("err = %v, want habits %v", err, tt.wantHabits)
+				}
+			}
+			if err != nil {
+				return
+			}
+
+			if tt.count > 0 {
+				if len(entries) != tt.count {
+					t.Errorf("%d entries, want %d", len(entries), tt.count)
+				}
+				return
+			}
+			if !reflect.DeepEqual(entries, tt.want) {
+				t.Errorf("entries = %+v, want %+v", entries, tt.want)
+			}
+			if !reflect.DeepEqual(rowErrs, tt.rowErrs) {
+				t.Errorf("row errors = %+v, want %+v", rowErrs, tt.rowErrs)
+			}
+		})
+	}
+}
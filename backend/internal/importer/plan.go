@@ -0,0 +1,100 @@
+package importer
+
+import (
+	"sort"
+	"time"
+)
+
+// Plan adalah hasil pencocokan entri file dengan relapse yang sudah tersimpan.
+type Plan struct {
+	// New berisi entri yang akan disimpan, diurutkan naik berdasarkan waktu.
+	New []Entry `json:"new"`
+	// Duplicates berisi entri yang sudah ada di database atau muncul lebih dari sekali di file.
+	Duplicates []Entry `json:"duplicates"`
+	// Rejected berisi baris yang tidak bisa diimpor (misalnya waktu di masa depan).
+	Rejected []RowError `json:"rejected"`
+}
+
+// minuteKey dipakai untuk entri dengan jam lengkap: dua relapse di menit yang sama dianggap sama.
+func minuteKey(t time.Time) int64 {
+	return t.UTC().Truncate(time.Minute).Unix()
+}
+
+// dayKey dipakai untuk entri yang hanya bertanggal: relapse apa pun di tanggal lokal yang
+// sama dianggap sama, karena jam aslinya tidak diketahui.
+func dayKey(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format("2006-01-02")
+}
+
+// Span mengembalikan rentang [from, to) relapse tersimpan yang perlu diberikan ke Build
+// sebagai existing: dari awal tanggal lokal (zona loc, nil = UTC) entri paling awal sampai
+// akhir tanggal lokal entri paling akhir. ok bernilai false jika entries kosong.
+func Span(entries []Entry, loc *time.Location) (from, to time.Time, ok bool) {
+	if len(entries) == 0 {
+		return time.Time{}, time.Time{}, false
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	first, last := entries[0].Time, entries[0].Time
+	for _, e := range entries[1:] {
+		if e.Time.Before(first) {
+			first = e.Time
+		}
+		if e.Time.After(last) {
+			last = e.Time
+		}
+	}
+	from = localMidnight(first, loc)
+	y, m, d := last.In(loc).Date()
+	to = time.Date(y, m, d+1, 0, 0, 0, 0, loc).UTC()
+	return from, to, true
+}
+
+// Build memisahkan entries menjadi entri baru, duplikat, dan yang ditolak. existing adalah
+// waktu relapse yang sudah tersimpan untuk habit tujuan. loc adalah zona impor (nil = UTC)
+// dan menentukan tanggal untuk entri DateOnly. Entri setelah now ditolak.
+func Build(entries []Entry, existing []time.Time, loc *time.Location, now time.Time) Plan {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	minutes := make(map[int64]bool, len(existing)+len(entries))
+	days := make(map[string]bool, len(existing)+len(entries))
+	for _, t := range existing {
+		minutes[minuteKey(t)] = true
+		days[dayKey(t, loc)] = true
+	}
+
+	sorted := make([]Entry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+
+	plan := Plan{New: []Entry{}, Duplicates: []Entry{}, Rejected: []RowError{}}
+	for _, e := range sorted {
+		if e.Time.After(now) {
+			plan.Rejected = append(plan.Rejected, RowError{Line: e.Line, Msg: "waktu relapse di masa depan"})
+			continue
+		}
+
+		duplicate := minutes[minuteKey(e.Time)]
+		if e.DateOnly {
+			duplicate = days[dayKey(e.Time, loc)]
+		}
+		if duplicate {
+			plan.Duplicates = append(plan.Duplicates, e)
+			continue
+		}
+
+		minutes[minuteKey(e.Time)] = true
+		days[dayKey(e.Time, loc)] = true
+		plan.New = append(plan.New, e)
+	}
+
+	sort.SliceStable(plan.Rejected, func(i, j int) bool {
+		return plan.Rejected[i].Line < plan.Rejected[j].Line
+	})
+	return plan
+}
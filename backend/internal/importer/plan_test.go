@@ -0,0 +1,164 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuild(t *testing.T) {
+	jakarta := mustLocation(t, "Asia/Jakarta")
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(s string) time.Time {
+		parsed, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name       string
+		entries    []Entry
+		existing   []time.Time
+		loc        *time.Location
+		new        []int // Line entri baru, urut waktu
+		duplicates []int
+		rejected   []RowError
+	}{
+		{
+			name:       "same minute as stored relapse",
+			entries:    []Entry{{Line: 1, Time: at("2024-01-01T10:00:10Z")}, {Line: 2, Time: at("2024-01-01T10:01:00Z")}},
+			existing:   []time.Time{at("2024-01-01T10:00:30Z")},
+			new:        []int{2},
+			duplicates: []int{1},
+		},
+		{
+			name:       "same minute twice in file",
+			entries:    []Entry{{Line: 1, Time: at("2024-01-01T10:00:59Z")}, {Line: 2, Time: at("2024-01-01T10:00:00Z")}},
+			new:        []int{2},
+			duplicates: []int{1},
+		},
+		{
+			name:    "output sorted by time",
+			entries: []Entry{{Line: 1, Time: at("2024-03-01T00:00:00Z")}, {Line: 2, Time: at("2024-01-01T00:00:00Z")}, {Line: 3, Time: at("2024-02-01T00:00:00Z")}},
+			new:     []int{2, 3, 1},
+		},
+		{
+			name:       "date only matches stored relapse on local day",
+			entries:    []Entry{{Line: 1, Time: at("2024-01-01T17:00:00Z"), DateOnly: true}},
+			existing:   []time.Time{at("2024-01-01T20:00:00Z")}, // 2024-01-02 03:00 di Jakarta
+			loc:        jakarta,
+			duplicates: []int{1},
+		},
+		{
+			name:     "date only on different utc day",
+			entries:  []Entry{{Line: 1, Time: at("2024-01-02T00:00:00Z"), DateOnly: true}},
+			existing: []time.Time{at("2024-01-01T20:00:00Z")},
+			new:      []int{1},
+		},
+		{
+			name: "date only twice in file",
+			entries: []Entry{
+				{Line: 1, Time: at("2024-01-01T17:00:00Z"), DateOnly: true},
+				{Line: 2, Time: at("2024-01-01T17:00:00Z"), DateOnly: true},
+			},
+			loc:        jakarta,
+			new:        []int{1},
+			duplicates: []int{2},
+		},
+		{
+			name: "timed entry on imported day is kept",
+			entries: []Entry{
+				{Line: 1, Time: at("2024-01-02T00:00:00Z"), DateOnly: true},
+				{Line: 2, Time: at("2024-01-02T09:15:00Z")},
+			},
+			new: []int{1, 2},
+		},
+		{
+			name: "future entries rejected",
+			entries: []Entry{
+				{Line: 3, Time: now.Add(time.Hour)},
+				{Line: 1, Time: now.Add(24 * time.Hour), DateOnly: true},
+				{Line: 2, Time: now},
+			},
+			new: []int{2},
+			rejected: []RowError{
+				{Line: 1, Msg: "waktu relapse di masa depan"},
+				{Line: 3, Msg: "waktu relapse di masa depan"},
+			},
+		},
+	}
+
+	lines := func(entries []Entry) []int {
+		out := []int{}
+		for _, e := range entries {
+			out = append(out, e.Line)
+		}
+		return out
+	}
+	orEmpty := func(v []int) []int {
+		if v == nil {
+			return []int{}
+		}
+		return v
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := Build(tt.entries, tt.existing, tt.loc, now)
+
+			if got := lines(plan.New); !reflect.DeepEqual(got, orEmpty(tt.new)) {
+				t.Errorf("new = %v, want %v", got, tt.new)
+			}
+			if got := lines(plan.Duplicates); !reflect.DeepEqual(got, orEmpty(tt.duplicates)) {
+				t.Errorf("duplicates = %v, want %v", got, tt.duplicates)
+			}
+			want := tt.rejected
+			if want == nil {
+				want = []RowError{}
+			}
+			if !reflect.DeepEqual(plan.Rejected, want) {
+				t.Errorf("rejected = %v, want %v", plan.Rejected, want)
+			}
+		})
+	}
+}
+
+func TestSpan(t *testing.T) {
+	jakarta := mustLocation(t, "Asia/Jakarta")
+
+	tests := []struct {
+		name     string
+		entries  []Entry
+		loc      *time.Location
+		from, to time.Time
+		ok       bool
+	}{
+		{name: "empty"},
+		{
+			name:    "utc days",
+			entries: []Entry{{Time: time.Date(2024, 1, 5, 10, 0, 0, 0, time.UTC)}, {Time: time.Date(2024, 1, 2, 23, 0, 0, 0, time.UTC)}},
+			from:    time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			to:      time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC),
+			ok:      true,
+		},
+		{
+			name:    "local days",
+			entries: []Entry{{Time: time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)}}, // 2024-01-02 03:00 di Jakarta
+			loc:     jakarta,
+			from:    time.Date(2024, 1, 1, 17, 0, 0, 0, time.UTC),
+			to:      time.Date(2024, 1, 2, 17, 0, 0, 0, time.UTC),
+			ok:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, ok := Span(tt.entries, tt.loc)
+			if ok != tt.ok || !from.Equal(tt.from) || !to.Equal(tt.to) {
+				t.Errorf("Span = %v, %v, %v; want %v, %v, %v", from, to, ok, tt.from, tt.to, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"solivra-go/backend/internal/config"
+	"solivra-go/backend/internal/export"
+	"solivra-go/backend/internal/services"
+	"solivra-go/backend/internal/store"
+)
+
+// jobRunRetention adalah lama riwayat eksekusi job disimpan.
+const jobRunRetention = 30 * 24 * time.Hour
+
+// Builtin mengembalikan job bawaan server dengan masa simpan dari cfg.
+func Builtin(s *store.Stores, cfg *config.Config) []Job {
+	return []Job{
+		{
+			Name:        "purge_deleted_relapses",
+			Description: "Hapus permanen relapse yang di-soft delete melewati masa pemulihan",
+			Schedule:    "@hourly",
+			Timeout:     5 * time.Minute,
+			Run: func(ctx context.Context, now time.Time) (Result, error) {
+				purged, err := services.PurgeDeletedRelapses(ctx, s, cfg.RelapseRetention(), now)
+				return Result{"purged": purged}, err
+			},
+		},
+		{
+			Name:        "evaluate_achievements",
+			Description: "Beri badge streak kepada user yang tidak membuka aplikasi",
+			Schedule:    "@hourly",
+			Run: func(ctx context.Context, now time.Time) (Result, error) {
+				awarded, err := services.EvaluateAllAchievements(ctx, s, now)
+				return Result{"awarded": awarded}, err
+			},
+		},
+		{
+			Name:        "purge_expired_exports",
+			Description: "Hapus arsip ekspor data yang sudah kedaluwarsa",
+			Schedule:    "@hourly",
+			Timeout:     5 * time.Minute,
+			Run: func(ctx context.Context, now time.Time) (Result, error) {
+				removed, err := export.PurgeExpired(ctx, s, now)
+				return Result{"removed": removed}, err
+			},
+		},
+		{
+			Name:        "refresh_longest_streaks",
+			Description: "Simpan streak terpanjang dari streak yang sedang berjalan",
+			Schedule:    "30 2 * * *",
+			Run: func(ctx context.Context, now time.Time) (Result, error) {
+				updated, err := services.RefreshLongestStreaks(ctx, s, now)
+				return Result{"updated": updated}, err
+			},
+		},
+		{
+			Name:        "expire_auth_attempts",
+			Description: "Hapus login dan registration attempt lama yang tidak sedang mengunci IP",
+			Schedule:    "0 3 * * *",
+			Timeout:     5 * time.Minute,
+			Run: func(ctx context.Context, now time.Time) (Result, error) {
+				before := now.Add(-cfg.AuthAttemptRetention())
+				logins, err := s.LoginAttempts.DeleteExpired(ctx, before, now)
+				if err != nil {
+					return nil, err
+				}
+				registrations, err := s.RegistrationAttempts.DeleteExpired(ctx, before, now)
+				return Result{"login_attempts": logins, "registration_attempts": registrations}, err
+			},
+		},
+		{
+			Name:        "purge_revoked_sessions",
+			Description: "Hapus sesi login yang sudah di-revoke",
+			Schedule:    "15 3 * * *",
+			Timeout:     5 * time.Minute,
+			Run: func(ctx context.Context, now time.Time) (Result, error) {
+				deleted, err := s.Sessions.DeleteRevokedBefore(ctx, now.Add(-cfg.SessionRetention()))
+				return Result{"deleted": deleted}, err
+			},
+		},
+		{
+			Name:        "trim_activity_logs",
+			Description: "Hapus activity log yang melewati masa simpan",
+			Schedule:    "30 3 * * *",
+			Run: func(ctx context.Context, now time.Time) (Result, error) {
+				deleted, err := s.ActivityLogs.DeleteBefore(ctx, now.Add(-cfg.ActivityLogRetention()))
+				return Result{"deleted": deleted}, err
+			},
+		},
+		{
+			Name:        "trim_job_runs",
+			Description: "Hapus riwayat eksekusi job yang lebih lama dari 30 hari",
+			Schedule:    "45 3 * * *",
+			Timeout:     time.Minute,
+			Run: func(ctx context.Context, now time.Time) (Result, error) {
+				deleted, err := s.Jobs.DeleteRunsBefore(ctx, now.Add(-jobRunRetention))
+				return Result{"deleted": deleted}, err
+			},
+		},
+	}
+}
@@ -0,0 +1,312 @@
+// Package jobs menjalankan job latar belakang terjadwal di dalam proses server.
+//
+// Setiap replica menjalankan Runner, tetapi hanya satu yang menjadi pemimpin lewat lease
+// di koleksi "joblocks"; hanya pemimpin yang mengeksekusi job sehingga job tidak berjalan
+// ganda. Setiap eksekusi dicatat di koleksi "jobruns" dan jadwal berikutnya dihitung dari
+// eksekusi terakhir, jadi pemimpin baru melanjutkan jadwal pemimpin sebelumnya.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/store"
+)
+
+const (
+	// leaseName adalah nama lease pemimpin scheduler di koleksi "joblocks".
+	leaseName = "scheduler"
+	// leaseTTL adalah lama lease berlaku tanpa diperpanjang; replica lain mengambil alih
+	// setelah pemimpin berhenti selama ini.
+	leaseTTL = time.Minute
+	// tickInterval adalah jarak antar pemeriksaan lease dan jadwal.
+	tickInterval = 15 * time.Second
+	// defaultTimeout dipakai untuk job tanpa Timeout.
+	defaultTimeout = 10 * time.Minute
+)
+
+// Result berisi hitungan hasil eksekusi job (misal jumlah dokumen terhapus) yang disimpan
+// di riwayat.
+type Result map[string]interface{}
+
+// Job adalah satu pekerjaan terjadwal. Run menerima waktu mulai eksekusi.
+type Job struct {
+	Name        string
+	Description string
+	Schedule    string // Lihat ParseSchedule
+	Timeout     time.Duration
+	Run         func(ctx context.Context, now time.Time) (Result, error)
+}
+
+type entry struct {
+	Job
+	schedule Schedule
+	running  bool
+	next     time.Time // Nol = belum dihitung sejak menjadi pemimpin
+}
+
+// Runner menjadwalkan dan mengeksekusi job yang didaftarkan dengan Register.
+type Runner struct {
+	store    store.JobStore
+	instance string
+
+	mu      sync.Mutex
+	entries []*entry
+	leader  bool
+}
+
+// NewRunner membuat Runner untuk replica instance (lihat InstanceID).
+func NewRunner(s store.JobStore, instance string) *Runner {
+	return &Runner{store: s, instance: instance}
+}
+
+// InstanceID mengembalikan identitas unik replica ini: hostname, pid, dan sufiks acak.
+func InstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%04x", host, os.Getpid(), rand.Intn(0x10000))
+}
+
+// Register menambahkan job. Harus dipanggil sebelum Start; nama job harus unik.
+func (r *Runner) Register(job Job) error {
+	schedule, err := ParseSchedule(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", job.Name, err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.entries {
+		if e.Name == job.Name {
+			return fmt.Errorf("job %s sudah terdaftar", job.Name)
+		}
+	}
+	r.entries = append(r.entries, &entry{Job: job, schedule: schedule})
+	return nil
+}
+
+// Start memeriksa lease dan jadwal setiap tickInterval sampai ctx selesai, lalu melepas
+// lease agar replica lain bisa langsung mengambil alih. Dipanggil di goroutine terpisah.
+func (r *Runner) Start(ctx context.Context) {
+	slog.Info("job scheduler started", "instance", r.instance, "jobs", len(r.entries))
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	for {
+		r.tick(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := r.store.ReleaseLease(releaseCtx, leaseName, r.instance); err != nil {
+				slog.Error("job scheduler: failed to release lease", "err", err)
+			}
+			cancel()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick memperpanjang lease lalu, jika replica ini pemimpin, menjalankan job yang jatuh tempo.
+func (r *Runner) tick(ctx context.Context, now time.Time) {
+	leaseCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	leader, err := r.store.AcquireLease(leaseCtx, leaseName, r.instance, now, now.Add(leaseTTL))
+	cancel()
+	if err != nil {
+		slog.Error("job scheduler: failed to acquire lease", "err", err)
+		leader = false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if leader != r.leader {
+		if leader {
+			slog.Info("job scheduler: became leader", "instance", r.instance)
+		} else {
+			slog.Info("job scheduler: lost leadership", "instance", r.instance)
+		}
+		// Jadwal dihitung ulang dari riwayat saat menjadi pemimpin lagi
+		for _, e := range r.entries {
+			e.next = time.Time{}
+		}
+	}
+	r.leader = leader
+	if !leader {
+		return
+	}
+
+	for _, e := range r.entries {
+		if e.running {
+			continue
+		}
+		if e.next.IsZero() {
+			next, err := r.nextRun(ctx, e, now)
+			if err != nil {
+				slog.Error("job: failed to load last run", "job", e.Name, "err", err)
+				continue
+			}
+			if next.IsZero() {
+				// Masih berjalan di pemimpin sebelumnya; diperiksa lagi di tick berikutnya
+				continue
+			}
+			e.next = next
+		}
+		if now.Before(e.next) {
+			continue
+		}
+		e.running = true
+		go r.run(e)
+	}
+}
+
+// nextRun menghitung jadwal berikutnya dari eksekusi terakhir di riwayat. Job yang belum
+// pernah berjalan langsung jatuh tempo. Waktu nol berarti eksekusi terakhir masih
+// berjalan (misal di pemimpin sebelumnya) dan belum melewati timeout-nya, jadi job belum
+// boleh dijalankan lagi. Eksekusi "running" yang lebih tua dari timeout dianggap mati.
+func (r *Runner) nextRun(ctx context.Context, e *entry, now time.Time) (time.Time, error) {
+	last, err := r.store.LastRun(ctx, e.Name)
+	if errors.Is(err, store.ErrNotFound) {
+		return now, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	if last.Status == models.JobRunRunning && now.Before(last.StartedAt.Add(e.timeout())) {
+		return time.Time{}, nil
+	}
+	return e.schedule.Next(last.StartedAt), nil
+}
+
+// timeout mengembalikan batas waktu eksekusi job.
+func (e *entry) timeout() time.Duration {
+	if e.Timeout <= 0 {
+		return defaultTimeout
+	}
+	return e.Timeout
+}
+
+// run mengeksekusi satu job dan mencatat hasilnya di riwayat.
+func (r *Runner) run(e *entry) {
+	started := time.Now()
+	record := &models.JobRun{
+		Job:       e.Name,
+		Instance:  r.instance,
+		Status:    models.JobRunRunning,
+		StartedAt: started,
+	}
+	recordCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := r.store.StartRun(recordCtx, record); err != nil {
+		slog.Error("job: failed to record run", "job", e.Name, "err", err)
+	}
+	cancel()
+
+	result, err := r.execute(e, started)
+	finished := time.Now()
+
+	status, errMsg := models.JobRunSucceeded, ""
+	if err != nil {
+		status, errMsg = models.JobRunFailed, err.Error()
+		slog.Error("job failed", "job", e.Name, "duration_ms", finished.Sub(started).Milliseconds(), "err", err)
+	}
+	if !record.ID.IsZero() {
+		recordCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := r.store.FinishRun(recordCtx, record.ID, status, result, errMsg, finished); err != nil {
+			slog.Error("job: failed to record result", "job", e.Name, "err", err)
+		}
+		cancel()
+	}
+
+	r.mu.Lock()
+	e.running = false
+	e.next = e.schedule.Next(started)
+	r.mu.Unlock()
+}
+
+// execute memanggil e.Run dengan timeout; panic dilaporkan sebagai error.
+func (r *Runner) execute(e *entry, now time.Time) (result Result, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout())
+	defer cancel()
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return e.Run(ctx, now)
+}
+
+// JobStatus adalah keadaan satu job untuk dashboard admin.
+type JobStatus struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Schedule    string         `json:"schedule"`
+	NextRun     time.Time      `json:"next_run"`
+	LastRun     *models.JobRun `json:"last_run"`
+}
+
+// Status adalah keadaan scheduler untuk dashboard admin.
+type Status struct {
+	Instance string           `json:"instance"`
+	Leader   bool             `json:"leader"`
+	Lease    *models.JobLease `json:"lease"`
+	Jobs     []JobStatus      `json:"jobs"`
+}
+
+// Status mengembalikan keadaan semua job. Jadwal berikutnya dihitung dari riwayat, jadi
+// hasilnya sama di replica mana pun endpoint admin dipanggil.
+func (r *Runner) Status(ctx context.Context, now time.Time) (*Status, error) {
+	r.mu.Lock()
+	entries := make([]*entry, len(r.entries))
+	copy(entries, r.entries)
+	leader := r.leader
+	r.mu.Unlock()
+
+	status := &Status{Instance: r.instance, Leader: leader, Jobs: make([]JobStatus, 0, len(entries))}
+	lease, err := r.store.GetLease(ctx, leaseName)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+	if lease != nil && lease.ExpiresAt.After(now) {
+		status.Lease = lease
+	}
+
+	for _, e := range entries {
+		js := JobStatus{
+			Name:        e.Name,
+			Description: e.Description,
+			Schedule:    e.Job.Schedule,
+			NextRun:     now,
+		}
+		last, err := r.store.LastRun(ctx, e.Name)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return nil, err
+		}
+		if last != nil {
+			js.LastRun = last
+			js.NextRun = e.schedule.Next(last.StartedAt)
+		}
+		status.Jobs = append(status.Jobs, js)
+	}
+	return status, nil
+}
+
+// Has melaporkan apakah job dengan nama name terdaftar.
+func (r *Runner) Has(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.entries {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
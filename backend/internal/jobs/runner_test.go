@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/store"
+)
+
+// waitIdle menunggu sampai semua job runner selesai dieksekusi.
+func waitIdle(t *testing.T, r *Runner) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.Lock()
+		busy := false
+		for _, e := range r.entries {
+			busy = busy || e.running
+		}
+		r.mu.Unlock()
+		if !busy {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("job masih berjalan")
+}
+
+func TestRunnerSkipsJobStillRunningOnPreviousLeader(t *testing.T) {
+	ctx := context.Background()
+	jobs := store.NewMemory().Jobs
+	now := time.Now()
+
+	// Pemimpin sebelumnya mulai menjalankan job 2 menit lalu lalu berhenti tanpa melepas lease
+	if err := jobs.StartRun(ctx, &models.JobRun{
+		Job:       "cleanup",
+		Instance:  "old-leader",
+		Status:    models.JobRunRunning,
+		StartedAt: now.Add(-2 * time.Minute),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var runs atomic.Int32
+	r := NewRunner(jobs, "new-leader")
+	if err := r.Register(Job{
+		Name:     "cleanup",
+		Schedule: "@every 1m",
+		Timeout:  10 * time.Minute,
+		Run: func(context.Context, time.Time) (Result, error) {
+			runs.Add(1)
+			return nil, nil
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	r.tick(ctx, now)
+	waitIdle(t, r)
+	if got := runs.Load(); got != 0 {
+		t.Fatalf("job dijalankan %d kali saat eksekusi sebelumnya masih berjalan", got)
+	}
+
+	// Setelah timeout terlewati eksekusi lama dianggap mati dan job dijalankan lagi
+	r.tick(ctx, now.Add(9*time.Minute))
+	waitIdle(t, r)
+	if got := runs.Load(); got != 1 {
+		t.Fatalf("job dijalankan %d kali setelah timeout, want 1", got)
+	}
+
+	last, err := jobs.LastRun(ctx, "cleanup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.Instance != "new-leader" || last.Status != models.JobRunSucceeded {
+		t.Fatalf("last run = %+v", last)
+	}
+}
@@ -0,0 +1,162 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule menentukan kapan sebuah job berikutnya jatuh tempo.
+type Schedule interface {
+	// Next mengembalikan waktu jatuh tempo pertama setelah after.
+	Next(after time.Time) time.Time
+}
+
+// ParseSchedule mem-parse jadwal job. Format yang didukung:
+//
+//	@every <durasi>   interval tetap sejak eksekusi terakhir, misal "@every 15m"
+//	@hourly           sama dengan "0 * * * *"
+//	@daily            sama dengan "0 0 * * *"
+//	<menit> <jam> <tanggal> <bulan> <hari>   ekspresi cron lima field, dievaluasi dalam UTC
+//
+// Setiap field cron menerima "*", angka, rentang "a-b", langkah "*/n" atau "a-b/n", dan
+// daftar dipisah koma. Hari 0 dan 7 sama-sama Minggu.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	switch spec {
+	case "@hourly":
+		spec = "0 * * * *"
+	case "@daily", "@midnight":
+		spec = "0 0 * * *"
+	}
+
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("jadwal %q: %w", spec, err)
+		}
+		if d < time.Minute {
+			return nil, fmt.Errorf("jadwal %q: interval minimal 1m", spec)
+		}
+		return every(d), nil
+	}
+	return parseCron(spec)
+}
+
+// every adalah jadwal dengan interval tetap.
+type every time.Duration
+
+func (e every) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(e))
+}
+
+// cronField adalah himpunan nilai yang cocok, satu bit per nilai.
+type cronField uint64
+
+func (f cronField) has(v int) bool {
+	return f&(1<<uint(v)) != 0
+}
+
+type cron struct {
+	minute, hour, dom, month, dow cronField
+	// domAny/dowAny mencatat field "*": jika keduanya dibatasi, cukup salah satu yang cocok.
+	domAny, dowAny bool
+}
+
+func parseCron(spec string) (*cron, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("jadwal %q: butuh 5 field cron", spec)
+	}
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	var parsed [5]cronField
+	for i, field := range fields {
+		f, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("jadwal %q: %w", spec, err)
+		}
+		parsed[i] = f
+	}
+	if parsed[4].has(7) {
+		parsed[4] |= 1 // 7 = Minggu
+	}
+	return &cron{
+		minute: parsed[0],
+		hour:   parsed[1],
+		dom:    parsed[2],
+		month:  parsed[3],
+		dow:    parsed[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, min, max int) (cronField, error) {
+	var f cronField
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("langkah tidak valid %q", part)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		if rng != "*" {
+			loStr, hiStr, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = strconv.Atoi(loStr); err != nil {
+				return 0, fmt.Errorf("nilai tidak valid %q", part)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(hiStr); err != nil {
+					return 0, fmt.Errorf("nilai tidak valid %q", part)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("nilai di luar rentang %d-%d: %q", min, max, part)
+		}
+		for v := lo; v <= hi; v += step {
+			f |= 1 << uint(v)
+		}
+	}
+	return f, nil
+}
+
+func (c *cron) dayMatches(t time.Time) bool {
+	domOK := c.dom.has(t.Day())
+	dowOK := c.dow.has(int(t.Weekday()))
+	if c.domAny || c.dowAny {
+		return domOK && dowOK
+	}
+	return domOK || dowOK
+}
+
+func (c *cron) Next(after time.Time) time.Time {
+	t := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	// Lima tahun cukup untuk ekspresi apa pun yang bisa cocok (misal 29 Februari)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !c.month.has(int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case !c.hour.has(t.Hour()):
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case !c.minute.has(t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return limit
+}
@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func utc(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParseScheduleNext(t *testing.T) {
+	tests := []struct {
+		spec  string
+		after time.Time
+		want  time.Time
+	}{
+		{"@hourly", utc("2024-01-01 10:15"), utc("2024-01-01 11:00")},
+		{"@hourly", utc("2024-01-01 10:00"), utc("2024-01-01 11:00")},
+		{"@daily", utc("2024-01-01 10:00"), utc("2024-01-02 00:00")},
+		{"@midnight", utc("2024-12-31 23:59"), utc("2025-01-01 00:00")},
+		{"@every 15m", utc("2024-01-01 10:07"), utc("2024-01-01 10:22")},
+		{"@every 2h", utc("2024-01-01 23:30"), utc("2024-01-02 01:30")},
+		{"30 2 * * *", utc("2024-01-01 02:30"), utc("2024-01-02 02:30")},
+		{"30 2 * * *", utc("2024-01-01 02:29"), utc("2024-01-01 02:30")},
+		{"*/15 * * * *", utc("2024-01-01 10:07"), utc("2024-01-01 10:15")},
+		{"5,10 * * * *", utc("2024-01-01 10:06"), utc("2024-01-01 10:10")},
+		{"0 9-17/4 * * *", utc("2024-01-01 10:00"), utc("2024-01-01 13:00")},
+		{"0 9-17/4 * * *", utc("2024-01-01 17:00"), utc("2024-01-02 09:00")},
+		{"0 0 * * 0", utc("2024-01-01 00:00"), utc("2024-01-07 00:00")}, // 1 Jan 2024 hari Senin
+		{"0 0 * * 7", utc("2024-01-01 00:00"), utc("2024-01-07 00:00")},
+		{"0 0 1 * *", utc("2024-01-15 00:00"), utc("2024-02-01 00:00")},
+		{"0 0 * 6 *", utc("2024-01-15 00:00"), utc("2024-06-01 00:00")},
+		{"0 0 13 * 5", utc("2024-01-01 00:00"), utc("2024-01-05 00:00")}, // tanggal ATAU hari cocok
+		{"0 0 29 2 *", utc("2024-03-01 00:00"), utc("2028-02-29 00:00")},
+		{"0 3 * * *", time.Date(2024, 1, 1, 9, 0, 0, 0, time.FixedZone("WIB", 7*3600)), utc("2024-01-01 03:00")},
+		{"0 3 * * *", utc("2024-01-01 02:59").Add(30 * time.Second), utc("2024-01-01 03:00")},
+	}
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q) error: %v", tt.spec, err)
+			continue
+		}
+		if got := schedule.Next(tt.after); !got.Equal(tt.want) {
+			t.Errorf("ParseSchedule(%q).Next(%s) = %s, want %s", tt.spec, tt.after, got, tt.want)
+		}
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"@weekly",
+		"@every",
+		"@every x",
+		"@every 30s",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"1-a * * * *",
+		"1,,2 * * * *",
+	} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded, want error", spec)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// internal/leaderboard/cursor.go
+package leaderboard
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidCursor dikembalikan jika cursor rusak atau dibuat untuk sort/window/jenis habit lain.
+var ErrInvalidCursor = errors.New("invalid leaderboard cursor")
+
+// Cursor menandai posisi halaman berikutnya lewat kunci urutan baris terakhir (keyset),
+// sehingga perubahan streak di antara dua permintaan tidak membuat baris terlewat atau
+// terulang. Cursor bersifat opaque bagi client dan terikat pada sort, window, dan jenis
+// habit (kosong: habit default) tempat ia dibuat.
+type Cursor struct {
+	Sort      Sort   `json:"s"`
+	Window    Window `json:"w"`
+	HabitKind string `json:"k,omitempty"`
+	After     Key    `json:"a"`
+	// Rank adalah peringkat baris terakhir; halaman berikutnya dinomori mulai Rank + 1.
+	Rank int `json:"r"`
+}
+
+// Encode mengubah cursor menjadi string aman-URL.
+func (c Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor membaca cursor dan memastikan cocok dengan sort, window, dan jenis habit permintaan.
+func DecodeCursor(encoded string, by Sort, window Window, habitKind string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	if c.Sort != by || c.Window != window || c.HabitKind != habitKind || c.Rank < 1 || c.After.HabitID.IsZero() {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
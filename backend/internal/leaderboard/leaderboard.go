@@ -0,0 +1,186 @@
+// internal/leaderboard/leaderboard.go
+// Package leaderboard mengurutkan ringkasan streak menjadi ranking. Paket ini murni
+// (tanpa DB): handler membaca streaksummaries dan relapse, lalu menyerahkannya ke sini,
+// dan store memakai Less agar urutan ListRanked sama dengan urutan di sini.
+package leaderboard
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/streaks"
+)
+
+// Sort adalah metrik utama urutan ranking.
+type Sort string
+
+const (
+	SortCurrent        Sort = "current"         // Streak berjalan terpanjang
+	SortLongest        Sort = "longest"         // Streak terpanjang
+	SortAverage        Sort = "average"         // Rata-rata streak terpanjang
+	SortFewestRelapses Sort = "fewest_relapses" // Relapse paling sedikit
+)
+
+// ParseSort mengubah query param menjadi Sort. String kosong berarti SortCurrent.
+func ParseSort(raw string) (Sort, bool) {
+	switch s := Sort(raw); s {
+	case "":
+		return SortCurrent, true
+	case SortCurrent, SortLongest, SortAverage, SortFewestRelapses:
+		return s, true
+	}
+	return "", false
+}
+
+// Window membatasi metrik ke streak yang terjadi di dalam rentang waktu tertentu.
+type Window string
+
+const (
+	WindowAll   Window = "all"
+	WindowWeek  Window = "week"  // Minggu kalender berjalan (Senin, UTC)
+	WindowMonth Window = "month" // Bulan kalender berjalan (UTC)
+)
+
+// ParseWindow mengubah query param menjadi Window. String kosong berarti WindowAll.
+func ParseWindow(raw string) (Window, bool) {
+	switch w := Window(raw); w {
+	case "":
+		return WindowAll, true
+	case WindowAll, WindowWeek, WindowMonth:
+		return w, true
+	}
+	return "", false
+}
+
+// Start mengembalikan awal jendela pada waktu now. ok false untuk WindowAll.
+func (w Window) Start(now time.Time) (start time.Time, ok bool) {
+	switch w {
+	case WindowWeek:
+		return streaks.Week.Start(now, time.UTC), true
+	case WindowMonth:
+		return streaks.Month.Start(now, time.UTC), true
+	}
+	return time.Time{}, false
+}
+
+// Entry adalah metrik satu habit user yang siap diurutkan. Semua durasi dalam detik.
+type Entry struct {
+	HabitID primitive.ObjectID
+	UserID  primitive.ObjectID
+	// CurrentStart adalah awal streak berjalan; dipakai sebagai pemecah seri yang stabil.
+	CurrentStart time.Time
+	Current      int64
+	Longest      int64
+	Average      int64
+	Relapses     int
+}
+
+// FromSummary membuat Entry sepanjang masa dari ringkasan streak.
+func FromSummary(summary models.StreakSummary, now time.Time) Entry {
+	return Entry{
+		HabitID:      summary.HabitID,
+		UserID:       summary.UserID,
+		CurrentStart: summary.CurrentStreakStart,
+		Current:      summary.CurrentSeconds(now),
+		Longest:      summary.LongestSeconds(now),
+		Average:      summary.AverageSeconds(now),
+		Relapses:     summary.TotalRelapses,
+	}
+}
+
+// FromWindow membuat Entry yang hanya menghitung streak di dalam [windowStart, now].
+// relapses cukup berisi relapse habit di dalam jendela; streak yang sudah berjalan
+// sebelum windowStart dipotong pada windowStart.
+func FromWindow(summary models.StreakSummary, relapses []time.Time, windowStart, now time.Time) Entry {
+	start := summary.StreakStartDate
+	if start.Before(windowStart) {
+		start = windowStart
+	}
+	computed := streaks.Compute(start, relapses, now)
+	return Entry{
+		HabitID:      summary.HabitID,
+		UserID:       summary.UserID,
+		CurrentStart: summary.CurrentStreakStart,
+		Current:      computed.Current,
+		Longest:      computed.Longest,
+		Average:      computed.Average,
+		Relapses:     computed.TotalRelapses,
+	}
+}
+
+// Value mengembalikan nilai metrik utama e untuk urutan by (0 untuk SortCurrent, yang
+// cukup diurutkan dengan CurrentStart).
+func (e Entry) Value(by Sort) int64 {
+	switch by {
+	case SortLongest:
+		return e.Longest
+	case SortAverage:
+		return e.Average
+	case SortFewestRelapses:
+		return int64(e.Relapses)
+	}
+	return 0
+}
+
+// Key adalah posisi satu entry pada urutan tertentu: nilai metrik utama, awal streak
+// berjalan, lalu HabitID, yaitu kunci yang sama dengan Less.
+type Key struct {
+	Value        int64              `json:"v"`
+	CurrentStart time.Time          `json:"t"`
+	HabitID      primitive.ObjectID `json:"h"`
+}
+
+// KeyOf mengembalikan posisi e pada urutan by.
+func KeyOf(e Entry, by Sort) Key {
+	return Key{Value: e.Value(by), CurrentStart: e.CurrentStart, HabitID: e.HabitID}
+}
+
+// Before melaporkan apakah posisi k berada di atas e untuk urutan by, yaitu e termasuk
+// halaman setelah k.
+func (k Key) Before(e Entry, by Sort) bool {
+	key := Entry{HabitID: k.HabitID, CurrentStart: k.CurrentStart}
+	switch by {
+	case SortLongest:
+		key.Longest = k.Value
+	case SortAverage:
+		key.Average = k.Value
+	case SortFewestRelapses:
+		key.Relapses = int(k.Value)
+	}
+	return Less(key, e, by)
+}
+
+// Less melaporkan apakah a berada di atas b untuk urutan by. Seri dipecah oleh streak
+// berjalan terpanjang (CurrentStart paling awal), lalu HabitID, sehingga urutan selalu
+// deterministik dan sama dengan StreakSummaryStore.ListRanked.
+func Less(a, b Entry, by Sort) bool {
+	switch by {
+	case SortLongest:
+		if a.Longest != b.Longest {
+			return a.Longest > b.Longest
+		}
+	case SortAverage:
+		if a.Average != b.Average {
+			return a.Average > b.Average
+		}
+	case SortFewestRelapses:
+		if a.Relapses != b.Relapses {
+			return a.Relapses < b.Relapses
+		}
+	}
+	if !a.CurrentStart.Equal(b.CurrentStart) {
+		return a.CurrentStart.Before(b.CurrentStart)
+	}
+	return bytes.Compare(a.HabitID[:], b.HabitID[:]) < 0
+}
+
+// SortEntries mengurutkan entries di tempat menurut by.
+func SortEntries(entries []Entry, by Sort) {
+	sort.Slice(entries, func(i, j int) bool {
+		return Less(entries[i], entries[j], by)
+	})
+}
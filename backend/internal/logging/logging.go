@@ -0,0 +1,119 @@
+// Package logging menyiapkan logger terstruktur (log/slog) yang dipakai seluruh backend.
+// Setiap baris log dalam sebuah request membawa request_id, baik lewat For(c) maupun
+// lewat context yang diturunkan dari c.UserContext().
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// HeaderRequestID adalah header yang membawa ID request dari/ke klien dan proxy.
+	HeaderRequestID = "X-Request-ID"
+	// LocalsRequestID adalah key c.Locals tempat middleware RequestID menyimpan ID request.
+	LocalsRequestID = "requestId"
+	// KeyRequestID adalah nama atribut ID request di log dan activity log.
+	KeyRequestID = "request_id"
+)
+
+// Format output log
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID mengembalikan ctx yang membawa ID request.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID mengembalikan ID request di ctx, atau "" jika tidak ada.
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestIDFrom mengembalikan ID request c, atau "" jika middleware RequestID tidak berjalan.
+func RequestIDFrom(c *fiber.Ctx) string {
+	id, _ := c.Locals(LocalsRequestID).(string)
+	return id
+}
+
+// For mengembalikan logger default yang sudah membawa request_id milik c.
+func For(c *fiber.Ctx) *slog.Logger {
+	if id := RequestIDFrom(c); id != "" {
+		return slog.Default().With(KeyRequestID, id)
+	}
+	return slog.Default()
+}
+
+// contextHandler menambahkan request_id dari context ke setiap record (slog.*Context).
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String(KeyRequestID, id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// ParseLevel mengubah "debug", "info", "warn", atau "error" menjadi slog.Level (default info).
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// New membuat logger ke w dengan format text atau json.
+func New(w io.Writer, format string, level slog.Level) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	if format == FormatJSON {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(contextHandler{handler})
+}
+
+// Setup memasang logger ke stdout sebagai slog default. Output paket log standar (termasuk
+// dari library) ikut diteruskan ke logger ini.
+func Setup(format, level string) *slog.Logger {
+	logger := New(os.Stdout, format, ParseLevel(level))
+	slog.SetDefault(logger)
+	return logger
+}
+
+// Fatal mencatat msg di level error lalu menghentikan proses.
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
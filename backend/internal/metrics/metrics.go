@@ -0,0 +1,99 @@
+// Package metrics mengumpulkan metrik Prometheus aplikasi: latensi HTTP per route, durasi
+// operasi MongoDB per koleksi, hitungan event keamanan/aktivitas, dan statistik runtime Go.
+//
+// Metrik disimpan di registry sendiri (bukan registry global) dan disajikan oleh Handler,
+// baik di /metrics aplikasi utama (dengan METRICS_TOKEN) maupun di listener METRICS_ADDR.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "solivra"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Durasi request HTTP per method, route, dan status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	mongoDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "mongo_operation_duration_seconds",
+		Help:      "Durasi command MongoDB per koleksi, command, dan hasil (ok/error).",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"collection", "command", "result"})
+
+	events = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_total",
+		Help:      "Jumlah event aktivitas penting (login gagal, lockout, honeypot, relapse, ...).",
+	}, []string{"event"})
+)
+
+// trackedEvents adalah action activity log yang dihitung di events_total. Action lain
+// diabaikan agar jumlah label tetap terbatas.
+var trackedEvents = []string{
+	"auth_login_success",
+	"auth_login_failed",
+	"auth_login_blocked",
+	"auth_captcha_failed",
+	"auth_register",
+	"security_ip_lock",
+	"security_registration_block",
+	"security_refresh_reuse",
+	"honeypot_triggered",
+	"relapse_recorded",
+	"milestone_reached",
+}
+
+var trackedEventSet = make(map[string]bool, len(trackedEvents))
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpDuration,
+		mongoDuration,
+		events,
+	)
+	// Inisialisasi dengan nol agar rate() langsung punya deret sejak startup
+	for _, event := range trackedEvents {
+		trackedEventSet[event] = true
+		events.WithLabelValues(event)
+	}
+}
+
+// Handler menyajikan semua metrik dalam format eksposisi Prometheus.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// ObserveHTTP mencatat satu request HTTP. route harus berupa pola route (misal
+// "/api/relapses/:id"), bukan path mentah, agar jumlah label tetap terbatas.
+func ObserveHTTP(method, route string, status int, duration time.Duration) {
+	httpDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// RecordEvent menambah events_total untuk action yang ada di trackedEvents.
+func RecordEvent(action string) {
+	RecordEvents(action, 1)
+}
+
+// RecordEvents menambah events_total sebanyak n, untuk operasi batch (sync dan impor
+// relapse) yang hanya mencatat satu activity log untuk banyak event.
+func RecordEvents(action string, n int) {
+	if n > 0 && trackedEventSet[action] {
+		events.WithLabelValues(action).Add(float64(n))
+	}
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// commandCollection menyimpan koleksi tiap command yang sedang berjalan, dari
+// CommandStartedEvent sampai event selesai, dengan key RequestID.
+var commandCollection sync.Map
+
+// MongoMonitor mengembalikan CommandMonitor yang mencatat durasi setiap command MongoDB
+// ke mongo_operation_duration_seconds. Dipasang lewat options.Client().SetMonitor.
+func MongoMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(_ context.Context, e *event.CommandStartedEvent) {
+			commandCollection.Store(e.RequestID, collectionOf(e))
+		},
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			observeCommand(e.CommandFinishedEvent, "ok")
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			observeCommand(e.CommandFinishedEvent, "error")
+		},
+	}
+}
+
+func observeCommand(e event.CommandFinishedEvent, result string) {
+	collection := "none"
+	if v, ok := commandCollection.LoadAndDelete(e.RequestID); ok {
+		collection = v.(string)
+	}
+	mongoDuration.WithLabelValues(collection, e.CommandName, result).Observe(e.Duration.Seconds())
+}
+
+// collectionOf mengambil nama koleksi dari dokumen command: elemen pertama untuk find,
+// insert, update, aggregate, dst., atau field "collection" untuk getMore. Command tanpa
+// koleksi (ping, commitTransaction, ...) dilabeli "none".
+func collectionOf(e *event.CommandStartedEvent) string {
+	if e.CommandName == "getMore" {
+		if name, ok := e.Command.Lookup("collection").StringValueOK(); ok {
+			return name
+		}
+		return "none"
+	}
+	first, err := e.Command.IndexErr(0)
+	if err != nil {
+		return "none"
+	}
+	if name, ok := first.Value().StringValueOK(); ok && name != "" {
+		return name
+	}
+	return "none"
+}
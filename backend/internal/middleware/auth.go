@@ -7,17 +7,16 @@ import (
 "time"
 
 "github.com/gofiber/fiber/v2"
-"go.mongodb.org/mongo-driver/bson"
 "go.mongodb.org/mongo-driver/bson/primitive"
 
-"solivra-go/backend/internal/database"
-"solivra-go/backend/internal/models"
+"solivra-go/backend/internal/logging"
 "solivra-go/backend/internal/services"
+"solivra-go/backend/internal/store"
 "solivra-go/backend/pkg/utils"
 )
 
 // Protected protects routes ensuring a valid access token and active session
-func Protected() fiber.Handler {
+func Protected(sessions store.SessionStore) fiber.Handler {
 return func(c *fiber.Ctx) error {
 // 1. Ambil Access Token (Prioritas: Header -> Cookie)
 var tokenString string
@@ -57,22 +56,14 @@ return utils.ErrorResponse(c, 401, "Unauthorized: No session token")
 // Kita hash token yang diterima dari klien untuk dicocokkan dengan hash di DB
 sessionHash := utils.HashToken(sessionToken)
 
-ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 defer cancel()
 
-sessionsColl := database.GetCollection("usersessions")
-var session models.UserSession
-
 // Convert UserID dari claims ke ObjectID
 userObjID, _ := primitive.ObjectIDFromHex(claims.User.ID)
 
 // Cari sesi yang cocok, milik user ini, dan BELUM di-revoke
-err = sessionsColl.FindOne(ctx, bson.M{
-"user":       userObjID,
-"token":      sessionHash,
-"revoked_at": nil, // Session harus aktif
-}).Decode(&session)
-
+session, err := sessions.FindActive(ctx, userObjID, sessionHash)
 if err != nil {
 // Sesi tidak ditemukan atau sudah di-revoke -> Logout paksa
 services.ClearAuthCookies(c)
@@ -82,12 +73,12 @@ return utils.ErrorResponse(c, 401, "Unauthorized: Session revoked or invalid")
 // 5. Update Last Active Time (Async agar cepat)
 // OPTIMIZATION: Throttle updates to once every 5 minutes to save CPU/DB ops
 if time.Since(session.LastActiveTime) > 5*time.Minute {
+// c sudah dikembalikan ke pool Fiber saat goroutine berjalan, jadi request ID diambil di sini
+requestID := logging.RequestIDFrom(c)
 go func(sessID primitive.ObjectID) {
-bgCtx, bgCancel := context.WithTimeout(context.Background(), 5*time.Second)
+bgCtx, bgCancel := context.WithTimeout(logging.WithRequestID(context.Background(), requestID), 5*time.Second)
 defer bgCancel()
-sessionsColl.UpdateOne(bgCtx, bson.M{"_id": sessID}, bson.M{
-"$set": bson.M{"last_active_time": time.Now()},
-})
+sessions.Touch(bgCtx, sessID, time.Now())
 }(session.ID)
 }
 
@@ -0,0 +1,53 @@
+// internal/middleware/captcha.go
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"solivra-go/backend/internal/captcha"
+	"solivra-go/backend/internal/logging"
+	"solivra-go/backend/pkg/utils"
+)
+
+// CaptchaFailureHandler dipanggil ketika token captcha kosong atau ditolak.
+// reason berisi "missing_token" atau "invalid_token".
+type CaptchaFailureHandler func(c *fiber.Ctx, reason string) error
+
+type captchaPayload struct {
+	Token string `json:"cf_turnstile_token"`
+}
+
+// Captcha memverifikasi token captcha sebelum request diteruskan ke handler.
+// Token dibaca dari body JSON "cf_turnstile_token", fallback ke header CF-Turnstile-Response.
+func Captcha(verifier captcha.Verifier, onFailure CaptchaFailureHandler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var payload captchaPayload
+		_ = c.BodyParser(&payload)
+
+		token := payload.Token
+		if token == "" {
+			token = c.Get("CF-Turnstile-Response")
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+		defer cancel()
+
+		err := verifier.Verify(ctx, token, utils.GetClientIP(c))
+		switch {
+		case err == nil:
+			return c.Next()
+		case errors.Is(err, captcha.ErrMissingToken):
+			return onFailure(c, "missing_token")
+		case errors.Is(err, captcha.ErrInvalidToken):
+			return onFailure(c, "invalid_token")
+		default:
+			// Penyedia captcha tidak bisa dihubungi: tolak request tanpa menghitungnya sebagai percobaan gagal
+			logging.For(c).Error("captcha verification error", "err", err)
+			return utils.ErrorResponse(c, 503, "Verifikasi captcha sedang tidak tersedia. Coba lagi nanti.")
+		}
+	}
+}
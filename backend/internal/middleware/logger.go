@@ -21,6 +21,11 @@ func ActivityLoggerMiddleware(c *fiber.Ctx) error {
 	if path == "/api/track-visit" {
 		return c.Next()
 	}
+
+	// Health check dipanggil berkala oleh load balancer/orchestrator, bukan oleh user
+	if strings.HasPrefix(path, "/api/health") {
+		return c.Next()
+	}
 	
 	// Tentukan Action: Di MERN, logger default mencatat 'page_view' atau 'api_call'
 	action := "api_call"
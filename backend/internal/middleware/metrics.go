@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	fiberutils "github.com/gofiber/fiber/v2/utils"
+
+	"solivra-go/backend/internal/metrics"
+	"solivra-go/backend/pkg/utils"
+)
+
+// Metrics mencatat durasi setiap request ke histogram HTTP. Dipasang sebelum AccessLog
+// sehingga status yang terbaca sudah hasil ErrorHandler. Label route memakai pola route
+// yang cocok (misal "/api/relapses/:id"); request tanpa route memakai prefix middleware
+// terakhir yang dilewati.
+func Metrics(c *fiber.Ctx) error {
+	start := time.Now()
+	err := c.Next()
+	// Label disimpan registry, jadi method (buffer fasthttp) harus disalin
+	metrics.ObserveHTTP(fiberutils.CopyString(c.Method()), c.Route().Path, c.Response().StatusCode(), time.Since(start))
+	return err
+}
+
+// MetricsAuth melindungi /metrics dengan token statis (METRICS_TOKEN) yang dikirim
+// Prometheus sebagai "Authorization: Bearer <token>".
+func MetricsAuth(token string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		given := strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			return utils.ErrorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+		}
+		return c.Next()
+	}
+}
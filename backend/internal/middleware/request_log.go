@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	fiberutils "github.com/gofiber/fiber/v2/utils"
+
+	"solivra-go/backend/internal/logging"
+)
+
+// validRequestID membatasi ID request dari klien/proxy agar aman ditulis ke log.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+// RequestID memakai header X-Request-ID dari proxy jika valid atau membuat ID baru, lalu
+// menyimpannya di c.Locals, c.UserContext(), dan header respons.
+func RequestID(c *fiber.Ctx) error {
+	// Salin karena string dari c.Get dipakai ulang fasthttp setelah request selesai
+	id := fiberutils.CopyString(c.Get(logging.HeaderRequestID))
+	if !validRequestID.MatchString(id) {
+		id = fiberutils.UUIDv4()
+	}
+	c.Locals(logging.LocalsRequestID, id)
+	c.SetUserContext(logging.WithRequestID(c.UserContext(), id))
+	c.Set(logging.HeaderRequestID, id)
+	return c.Next()
+}
+
+// AccessLog mencatat setiap request (method, path, status, durasi) ke logger terstruktur.
+// Error dari handler diteruskan ke ErrorHandler di sini agar status yang dicatat sesuai
+// dengan respons yang dikirim.
+func AccessLog(c *fiber.Ctx) error {
+	start := time.Now()
+	if err := c.Next(); err != nil {
+		if handlerErr := c.App().Config().ErrorHandler(c, err); handlerErr != nil {
+			c.Status(fiber.StatusInternalServerError)
+		}
+	}
+
+	status := c.Response().StatusCode()
+	level := slog.LevelInfo
+	switch {
+	case status >= fiber.StatusInternalServerError:
+		level = slog.LevelError
+	case status >= fiber.StatusBadRequest:
+		level = slog.LevelWarn
+	}
+
+	// request_id ditambahkan oleh handler logging dari c.UserContext()
+	slog.Log(c.UserContext(), level, "request",
+		"method", c.Method(),
+		"path", c.Path(),
+		"status", status,
+		"duration_ms", time.Since(start).Milliseconds(),
+		"ip", c.IP(),
+		"bytes", len(c.Response().Body()),
+	)
+	return nil
+}
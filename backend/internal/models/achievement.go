@@ -0,0 +1,57 @@
+// internal/models/achievement.go
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kategori achievement (AchievementDefinition.Category)
+const (
+	AchievementCategoryStreak    = "streak"    // Streak bersih mencapai Days hari
+	AchievementCategoryMilestone = "milestone" // Momen khusus: minggu pertama, rekor pribadi
+	AchievementCategoryJournal   = "journal"   // Days hari berbeda dengan catatan relapse
+)
+
+// Kode achievement
+const (
+	AchievementFirstWeek    = "first_week"    // Tujuh hari pertama tanpa relapse
+	AchievementPersonalBest = "personal_best" // Streak berjalan melewati streak terpanjang sebelumnya
+)
+
+// AchievementDefinition describes one badge in the catalog. PerHabit badges are awarded
+// once per habit; the others once per user.
+type AchievementDefinition struct {
+	Code     string `json:"code"`
+	Category string `json:"category"`
+	Days     int    `json:"days,omitempty"`
+	PerHabit bool   `json:"per_habit"`
+}
+
+// AchievementCatalog lists every badge that can be awarded, in display order.
+var AchievementCatalog = []AchievementDefinition{
+	{Code: AchievementFirstWeek, Category: AchievementCategoryMilestone, Days: 7, PerHabit: true},
+	{Code: "streak_7_days", Category: AchievementCategoryStreak, Days: 7, PerHabit: true},
+	{Code: "streak_14_days", Category: AchievementCategoryStreak, Days: 14, PerHabit: true},
+	{Code: "streak_30_days", Category: AchievementCategoryStreak, Days: 30, PerHabit: true},
+	{Code: "streak_90_days", Category: AchievementCategoryStreak, Days: 90, PerHabit: true},
+	{Code: "streak_180_days", Category: AchievementCategoryStreak, Days: 180, PerHabit: true},
+	{Code: "streak_365_days", Category: AchievementCategoryStreak, Days: 365, PerHabit: true},
+	{Code: AchievementPersonalBest, Category: AchievementCategoryMilestone, PerHabit: true},
+	{Code: "journal_7_days", Category: AchievementCategoryJournal, Days: 7},
+	{Code: "journal_30_days", Category: AchievementCategoryJournal, Days: 30},
+}
+
+// Achievement is a badge awarded to a user (collection "achievements"). HabitID is empty
+// for badges that are not per habit. Value records the measurement that earned the badge
+// (streak seconds or journal days).
+type Achievement struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	UserID    primitive.ObjectID `bson:"user" json:"user"`
+	HabitID   primitive.ObjectID `bson:"habit,omitempty" json:"habit_id,omitempty"`
+	Code      string             `bson:"code" json:"code"`
+	Category  string             `bson:"category" json:"category"`
+	Value     int64              `bson:"value,omitempty" json:"value,omitempty"`
+	AwardedAt time.Time          `bson:"awarded_at" json:"awarded_at"`
+}
@@ -0,0 +1,47 @@
+// internal/models/export.go
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Status job ekspor data (DataExport.Status)
+const (
+	DataExportPending = "pending"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+)
+
+// Format arsip ekspor data (DataExport.Format)
+const (
+	DataExportFormatZip  = "zip"
+	DataExportFormatJSON = "json"
+)
+
+// DataExport is an asynchronous personal data export job (collection "dataexports").
+// The generated archive lives in EXPORT_DIR until ExpiresAt and is downloaded through
+// a signed link; FilePath is never sent to the client. Failed jobs also get an ExpiresAt
+// so the purge job removes them.
+type DataExport struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	UserID      primitive.ObjectID `bson:"user" json:"user"`
+	Status      string             `bson:"status" json:"status"`
+	Format      string             `bson:"format,omitempty" json:"format"`
+	FilePath    string             `bson:"file_path,omitempty" json:"-"`
+	SizeBytes   int64              `bson:"size_bytes,omitempty" json:"size_bytes,omitempty"`
+	Error       string             `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	CompletedAt *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time         `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+}
+
+// FileFormat returns the archive format, defaulting to zip for jobs created before
+// Format was stored.
+func (e *DataExport) FileFormat() string {
+	if e.Format == "" {
+		return DataExportFormatZip
+	}
+	return e.Format
+}
@@ -0,0 +1,59 @@
+// internal/models/habit.go
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Jenis habit bawaan. Habit dengan jenis selain HabitKindCustom bisa dibandingkan antar
+// user di ranking (?habit_kind=...), jadi setiap user hanya boleh punya satu per jenis.
+const (
+	HabitKindGeneral     = "general" // Dipakai habit default hasil migrasi streak lama
+	HabitKindSmoking     = "smoking"
+	HabitKindAlcohol     = "alcohol"
+	HabitKindSocialMedia = "social_media"
+	HabitKindGaming      = "gaming"
+	HabitKindJunkFood    = "junk_food"
+	HabitKindCustom      = "custom"
+)
+
+// HabitKinds berisi semua jenis habit yang dikenal.
+var HabitKinds = []string{
+	HabitKindGeneral, HabitKindSmoking, HabitKindAlcohol, HabitKindSocialMedia,
+	HabitKindGaming, HabitKindJunkFood, HabitKindCustom,
+}
+
+// ValidHabitKind reports whether kind is one of HabitKinds.
+func ValidHabitKind(kind string) bool {
+	for _, k := range HabitKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// HabitSettings holds per-habit display and ranking preferences.
+type HabitSettings struct {
+	Color          string `bson:"color,omitempty" json:"color,omitempty"`
+	Icon           string `bson:"icon,omitempty" json:"icon,omitempty"`
+	ShowInRankings bool   `bson:"show_in_rankings" json:"show_in_rankings"`
+}
+
+// Habit is one behaviour a user tracks independently (collection "habits").
+// Every user has exactly one default habit; legacy endpoints without habit_id operate on it
+// and its streak fields are mirrored onto User.StreakStartDate/LongestStreakSeconds.
+type Habit struct {
+	ID                   primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	UserID               primitive.ObjectID `bson:"user" json:"user"`
+	Name                 string             `bson:"name" json:"name"`
+	Kind                 string             `bson:"kind" json:"kind"`
+	IsDefault            bool               `bson:"is_default" json:"is_default"`
+	StreakStartDate      *time.Time         `bson:"streak_start_date,omitempty" json:"streak_start_date,omitempty"`
+	LongestStreakSeconds int64              `bson:"longest_streak_seconds" json:"longest_streak_seconds"`
+	Settings             HabitSettings      `bson:"settings" json:"settings"`
+	CreatedAt            time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt            time.Time          `bson:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,38 @@
+// internal/models/job.go
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Status satu eksekusi job terjadwal (JobRun.Status)
+const (
+	JobRunRunning   = "running"
+	JobRunSucceeded = "succeeded"
+	JobRunFailed    = "failed"
+)
+
+// JobRun is one execution of a scheduled background job (collection "jobruns").
+// Instance identifies the replica that ran it; Result holds job-specific counters.
+type JobRun struct {
+	ID         primitive.ObjectID     `bson:"_id,omitempty" json:"_id"`
+	Job        string                 `bson:"job" json:"job"`
+	Instance   string                 `bson:"instance" json:"instance"`
+	Status     string                 `bson:"status" json:"status"`
+	StartedAt  time.Time              `bson:"started_at" json:"started_at"`
+	FinishedAt *time.Time             `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+	DurationMs int64                  `bson:"duration_ms,omitempty" json:"duration_ms,omitempty"`
+	Result     map[string]interface{} `bson:"result,omitempty" json:"result,omitempty"`
+	Error      string                 `bson:"error,omitempty" json:"error,omitempty"`
+}
+
+// JobLease is a named lock held by one replica until ExpiresAt (collection "joblocks").
+// The scheduler uses it to elect a single leader that runs every job.
+type JobLease struct {
+	Name      string    `bson:"_id" json:"name"`
+	Owner     string    `bson:"owner" json:"owner"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+	RenewedAt time.Time `bson:"renewed_at" json:"renewed_at"`
+}
@@ -7,12 +7,166 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Kategori pemicu relapse (RelapseDetails.Triggers)
+const (
+	RelapseTriggerStress      = "stress"
+	RelapseTriggerBoredom     = "boredom"
+	RelapseTriggerLoneliness  = "loneliness"
+	RelapseTriggerAnxiety     = "anxiety"
+	RelapseTriggerFatigue     = "fatigue"
+	RelapseTriggerCraving     = "craving"
+	RelapseTriggerSocial      = "social_pressure"
+	RelapseTriggerConflict    = "conflict"
+	RelapseTriggerCelebration = "celebration"
+	RelapseTriggerOther       = "other"
+)
+
+// RelapseTriggers berisi semua kategori pemicu yang dikenal.
+var RelapseTriggers = []string{
+	RelapseTriggerStress, RelapseTriggerBoredom, RelapseTriggerLoneliness, RelapseTriggerAnxiety,
+	RelapseTriggerFatigue, RelapseTriggerCraving, RelapseTriggerSocial, RelapseTriggerConflict,
+	RelapseTriggerCelebration, RelapseTriggerOther,
+}
+
+// RelapseLocations berisi konteks lokasi yang dikenal (RelapseDetails.Location).
+var RelapseLocations = []string{"home", "work", "school", "commute", "social", "outdoors", "other"}
+
+// Batas nilai metadata relapse
+const (
+	RelapseMoodMin      = 1
+	RelapseMoodMax      = 5
+	RelapseIntensityMin = 1
+	RelapseIntensityMax = 10
+	MaxRelapseTriggers  = 5
+	MaxRelapseTags      = 10
+	MaxRelapseTagLength = 32
+)
+
+// ValidRelapseTrigger reports whether trigger is one of RelapseTriggers.
+func ValidRelapseTrigger(trigger string) bool {
+	return contains(RelapseTriggers, trigger)
+}
+
+// ValidRelapseLocation reports whether location is one of RelapseLocations.
+func ValidRelapseLocation(location string) bool {
+	return contains(RelapseLocations, location)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// RelapseDetails adalah metadata terstruktur opsional sebuah relapse. Nilai nol berarti
+// tidak diisi: mood 1-5 (sebelum dan sesudah relapse), intensitas 1-10.
+type RelapseDetails struct {
+	Triggers   []string `bson:"triggers,omitempty" json:"triggers,omitempty"`
+	MoodBefore int      `bson:"mood_before,omitempty" json:"mood_before,omitempty"`
+	MoodAfter  int      `bson:"mood_after,omitempty" json:"mood_after,omitempty"`
+	Intensity  int      `bson:"intensity,omitempty" json:"intensity,omitempty"`
+	Location   string   `bson:"location,omitempty" json:"location,omitempty"`
+	// Tags adalah label bebas buatan user (huruf kecil, tanpa duplikat).
+	Tags []string `bson:"tags,omitempty" json:"tags,omitempty"`
+}
+
+// Empty melaporkan apakah tidak ada metadata yang diisi.
+func (d RelapseDetails) Empty() bool {
+	return d.Equal(RelapseDetails{})
+}
+
+// Equal membandingkan dua metadata; slice nil dan kosong dianggap sama.
+func (d RelapseDetails) Equal(other RelapseDetails) bool {
+	return sameStrings(d.Triggers, other.Triggers) && sameStrings(d.Tags, other.Tags) &&
+		d.MoodBefore == other.MoodBefore && d.MoodAfter == other.MoodAfter &&
+		d.Intensity == other.Intensity && d.Location == other.Location
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // RelapseLog represents a relapse entry (RelapseLog.js in MERN)
 type RelapseLog struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"_id"` // MERN expects _id
 	UserID      primitive.ObjectID `bson:"user" json:"user"`
+	HabitID     primitive.ObjectID `bson:"habit,omitempty" json:"habit,omitempty"` // Kosong hanya untuk data sebelum migrasi habit
 	RelapseTime time.Time          `bson:"relapse_time" json:"relapse_time"`
 	RelapseNote *string            `bson:"relapse_note,omitempty" json:"relapse_note,omitempty"` // FIXED: Changed from Notes to match MERN
-	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+	// Metadata terstruktur opsional (pemicu, mood, intensitas, lokasi, tag)
+	RelapseDetails `bson:",inline"`
+	// ClientID adalah ID buatan klien offline (batch sync); unik per user.
+	ClientID string `bson:"client_id,omitempty" json:"client_id,omitempty"`
+	// ClientUpdatedAt adalah waktu tulis terakhir menurut penulisnya (jam klien untuk
+	// batch sync, jam server untuk edit lewat API biasa). Dasar last-writer-wins.
+	ClientUpdatedAt *time.Time `bson:"client_updated_at,omitempty" json:"client_updated_at,omitempty"`
+	// DeletedAt terisi jika relapse di-soft delete; relapse bisa dipulihkan selama masa
+	// retensi lalu dihapus permanen oleh purger.
+	DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	// DeletionID mengelompokkan relapse yang dihapus bersamaan (hapus semua) untuk restore massal.
+	DeletionID primitive.ObjectID `bson:"deletion_id,omitempty" json:"-"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt  time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// LastWrite mengembalikan waktu tulis terakhir relapse untuk resolusi konflik sync.
+func (r *RelapseLog) LastWrite() time.Time {
+	if r.ClientUpdatedAt != nil {
+		return *r.ClientUpdatedAt
+	}
+	return r.UpdatedAt
+}
+
+// RelapseTombstone menandai relapse yang sudah dihapus (collection "relapsetombstones"),
+// supaya klien batch sync ikut menghapus salinan lokalnya.
+type RelapseTombstone struct {
+	ID        primitive.ObjectID `bson:"_id" json:"_id"` // ID relapse yang dihapus
+	UserID    primitive.ObjectID `bson:"user" json:"user"`
+	HabitID   primitive.ObjectID `bson:"habit,omitempty" json:"habit,omitempty"`
+	ClientID  string             `bson:"client_id,omitempty" json:"client_id,omitempty"`
+	DeletedAt time.Time          `bson:"deleted_at" json:"deleted_at"`
+}
+
+// Sumber perubahan relapse (RelapseRevision.Source)
+const (
+	RevisionSourceOriginal = "original" // Isi relapse sebelum edit pertama
+	RevisionSourceAPI      = "api"
+	RevisionSourceSync     = "sync"
+)
+
+// Field relapse yang bisa berubah antar versi (RelapseRevision.Changes)
+const (
+	RevisionFieldNote    = "relapse_note"
+	RevisionFieldTime    = "relapse_time"
+	RevisionFieldDetails = "details" // Salah satu field RelapseDetails
+)
+
+// RelapseRevision adalah satu versi isi relapse (collection "relapserevisions").
+// Versi 1 adalah isi awal relapse; setiap edit catatan, metadata, atau koreksi waktu menambah satu
+// versi berisi salinan lengkap relapse setelah edit. Riwayat ikut terhapus saat relapse
+// dihapus permanen.
+type RelapseRevision struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	RelapseID   primitive.ObjectID `bson:"relapse" json:"relapse"`
+	UserID      primitive.ObjectID `bson:"user" json:"user"`
+	Version     int                `bson:"version" json:"version"`
+	RelapseTime time.Time          `bson:"relapse_time" json:"relapse_time"`
+	RelapseNote *string            `bson:"relapse_note,omitempty" json:"relapse_note,omitempty"`
+	// Metadata relapse pada versi ini
+	RelapseDetails `bson:",inline"`
+	// Changes berisi field yang berubah dari versi sebelumnya (kosong untuk versi 1).
+	Changes  []string  `bson:"changes" json:"changes"`
+	Source   string    `bson:"source" json:"source"`
+	EditedAt time.Time `bson:"edited_at" json:"edited_at"`
 }
@@ -0,0 +1,74 @@
+// internal/models/streak.go
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StreakSummary is a precomputed per-habit streak aggregate (collection "streaksummaries").
+// It is rebuilt whenever the habit's relapses or streak start change, so rankings can be
+// served from one sorted query instead of reading every user's relapse logs.
+type StreakSummary struct {
+	HabitID                 primitive.ObjectID `bson:"_id" json:"habit_id"`
+	UserID                  primitive.ObjectID `bson:"user" json:"user_id"`
+	HabitKind               string             `bson:"habit_kind" json:"habit_kind"`
+	IsDefault               bool               `bson:"is_default" json:"is_default"` // Default habit; the main leaderboard only ranks these
+	StreakStartDate         time.Time          `bson:"streak_start_date" json:"streak_start_date"`
+	CurrentStreakStart      time.Time          `bson:"current_streak_start" json:"current_streak_start"` // Last relapse, or streak start if none
+	LastRelapseAt           *time.Time         `bson:"last_relapse_at,omitempty" json:"last_relapse_at,omitempty"`
+	TotalRelapses           int                `bson:"total_relapses" json:"total_relapses"`
+	LongestCompletedSeconds int64              `bson:"longest_completed_seconds" json:"longest_completed_seconds"` // Longest streak that ended in a relapse
+	SumCompletedSeconds     int64              `bson:"sum_completed_seconds" json:"sum_completed_seconds"`         // Sum of all streaks that ended in a relapse
+	StoredLongestSeconds    int64              `bson:"stored_longest_seconds" json:"stored_longest_seconds"`       // Copy of habits.longest_streak_seconds (survives relapse deletes)
+	Visibility              string             `bson:"visibility" json:"visibility"`                               // users.ranking_visibility, or hidden if the habit opts out of rankings
+	UpdatedAt               time.Time          `bson:"updated_at" json:"updated_at"`
+
+	// Sort keys for the longest/average rankings, filled by SetSortKeys
+	RecordSeconds           int64     `bson:"record_seconds" json:"-"`            // Longest of LongestCompletedSeconds and StoredLongestSeconds
+	RecordOvertakenAt       time.Time `bson:"record_overtaken_at" json:"-"`       // When the running streak becomes longer than RecordSeconds
+	AverageCompletedSeconds int64     `bson:"average_completed_seconds" json:"-"` // SumCompletedSeconds / TotalRelapses, 0 without relapses
+}
+
+// SetSortKeys fills the ranking sort keys from the other fields. Call it before storing
+// the summary.
+func (s *StreakSummary) SetSortKeys() {
+	s.RecordSeconds = s.LongestCompletedSeconds
+	if s.StoredLongestSeconds > s.RecordSeconds {
+		s.RecordSeconds = s.StoredLongestSeconds
+	}
+	s.RecordOvertakenAt = s.CurrentStreakStart.Add(time.Duration(s.RecordSeconds) * time.Second)
+	s.AverageCompletedSeconds = 0
+	if s.TotalRelapses > 0 {
+		s.AverageCompletedSeconds = s.SumCompletedSeconds / int64(s.TotalRelapses)
+	}
+}
+
+// CurrentSeconds returns the running streak length at now (never negative)
+func (s *StreakSummary) CurrentSeconds(now time.Time) int64 {
+	if !now.After(s.CurrentStreakStart) {
+		return 0
+	}
+	return int64(now.Sub(s.CurrentStreakStart) / time.Second)
+}
+
+// LongestSeconds returns the longest streak including the running one and the stored record
+func (s *StreakSummary) LongestSeconds(now time.Time) int64 {
+	longest := s.CurrentSeconds(now)
+	if s.LongestCompletedSeconds > longest {
+		longest = s.LongestCompletedSeconds
+	}
+	if s.StoredLongestSeconds > longest {
+		longest = s.StoredLongestSeconds
+	}
+	return longest
+}
+
+// AverageSeconds returns the mean completed streak, or the running streak if none completed yet
+func (s *StreakSummary) AverageSeconds(now time.Time) int64 {
+	if s.TotalRelapses == 0 {
+		return s.CurrentSeconds(now)
+	}
+	return s.SumCompletedSeconds / int64(s.TotalRelapses)
+}
@@ -2,16 +2,95 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// RefreshTokenSchema represents a refresh token entry inside User
+// RefreshTokenSchema represents a refresh token entry inside User.
+// Each entry is bound to one UserSession; RotatedAt is set once the token has been exchanged.
 type RefreshTokenSchema struct {
-	JTI       string    `bson:"jti" json:"jti"`
-	CreatedAt time.Time `bson:"created_at" json:"created_at"`
-	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+	JTI       string             `bson:"jti" json:"jti"`
+	SessionID primitive.ObjectID `bson:"session_id" json:"session_id"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+	RotatedAt *time.Time         `bson:"rotated_at,omitempty" json:"rotated_at,omitempty"`
+}
+
+const (
+	// RefreshReuseGrace is how long a rotated refresh token may be presented again without
+	// counting as reuse, so concurrent refreshes (e.g. two tabs) do not revoke the session.
+	RefreshReuseGrace = 30 * time.Second
+	// RefreshRotatedRetention is how long a rotated entry is kept for reuse detection. Older
+	// entries are pruned; presenting one afterwards fails as an unknown token.
+	RefreshRotatedRetention = time.Hour
+)
+
+// InReuseGrace reports whether the token was rotated no longer than RefreshReuseGrace before now.
+func (t *RefreshTokenSchema) InReuseGrace(now time.Time) bool {
+	return t.RotatedAt != nil && now.Sub(*t.RotatedAt) <= RefreshReuseGrace
+}
+
+// FindRefreshToken returns the refresh token entry with the given JTI, or nil.
+func (u *User) FindRefreshToken(jti string) *RefreshTokenSchema {
+	for i := range u.RefreshTokens {
+		if u.RefreshTokens[i].JTI == jti {
+			return &u.RefreshTokens[i]
+		}
+	}
+	return nil
+}
+
+// TwoFactorSettings holds TOTP state for a user. Never sent to the client.
+type TwoFactorSettings struct {
+	Enabled       bool       `bson:"enabled" json:"enabled"`
+	Secret        string     `bson:"secret,omitempty" json:"-"`         // Base32 TOTP secret (aktif)
+	PendingSecret string     `bson:"pending_secret,omitempty" json:"-"` // Secret yang belum dikonfirmasi
+	RecoveryCodes []string   `bson:"recovery_codes,omitempty" json:"-"` // SHA256 hash, sekali pakai
+	LastUsedStep  int64      `bson:"last_used_step,omitempty" json:"-"` // Mencegah kode yang sama dipakai dua kali
+	EnabledAt     *time.Time `bson:"enabled_at,omitempty" json:"enabled_at,omitempty"`
+}
+
+// Visibilitas user di ranking publik (User.RankingVisibility)
+const (
+	RankingVisibilityPublic    = "public"    // Tampil dengan username dan nickname
+	RankingVisibilityAnonymous = "anonymous" // Tampil dengan alias anonim
+	RankingVisibilityHidden    = "hidden"    // Tidak tampil sama sekali
+)
+
+// ValidRankingVisibility reports whether v is a known ranking visibility value.
+func ValidRankingVisibility(v string) bool {
+	return v == RankingVisibilityPublic || v == RankingVisibilityAnonymous || v == RankingVisibilityHidden
+}
+
+// AnonymousAlias returns the stable public alias used for anonymous users in rankings.
+func AnonymousAlias(id primitive.ObjectID) string {
+	sum := sha256.Sum256([]byte("ranking-alias:" + id.Hex()))
+	return "Anonim-" + strings.ToUpper(hex.EncodeToString(sum[:3]))
+}
+
+// DefaultTimezone is used for users who have not chosen a time zone.
+const DefaultTimezone = "UTC"
+
+// ErrInvalidTimezone is returned by LoadTimezone for unknown zone names.
+var ErrInvalidTimezone = errors.New("invalid time zone")
+
+// LoadTimezone parses an IANA time zone name such as "Asia/Jakarta". "Local" is rejected
+// so the server's own zone never leaks into user data.
+func LoadTimezone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "Local" || len(name) > 64 {
+		return nil, ErrInvalidTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	return loc, nil
 }
 
 // User represents a user in the system
@@ -23,38 +102,76 @@ type User struct {
 	Role                 string               `bson:"role" json:"role"`
 	LanguagePref         string               `bson:"language_pref" json:"language_pref"`
 	ProfilePicture       string               `bson:"profile_picture" json:"profile_picture"`
+	ProfilePictureSmall  string               `bson:"profile_picture_small,omitempty" json:"profile_picture_small,omitempty"` // Thumbnail foto profil upload
 	StreakStartDate      *time.Time           `bson:"streak_start_date,omitempty" json:"streak_start_date,omitempty"`
 	LongestStreakSeconds int64                `bson:"longest_streak_seconds" json:"longest_streak_seconds"`
 	FailedLoginAttempts  int                  `bson:"failed_login_attempts" json:"-"`
 	LockoutUntil         *time.Time           `bson:"lockout_until,omitempty" json:"lockout_until,omitempty"`
 	RefreshTokens        []RefreshTokenSchema `bson:"refreshTokens" json:"-"` // Store server-side, don't send to client
-	CreatedAt            time.Time            `bson:"created_at" json:"createdAt"` // Mongoose often uses createdAt
+	TwoFactor            TwoFactorSettings    `bson:"two_factor" json:"-"`
+	RankingVisibility    string               `bson:"ranking_visibility,omitempty" json:"ranking_visibility"`
+	Timezone             string               `bson:"timezone,omitempty" json:"timezone,omitempty"` // IANA name; empty means DefaultTimezone
+	CreatedAt            time.Time            `bson:"created_at" json:"createdAt"`                  // Mongoose often uses createdAt
 	UpdatedAt            time.Time            `bson:"updated_at" json:"updatedAt"`
 }
 
+// Visibility returns the user's ranking visibility; users created before the setting existed are public.
+func (u *User) Visibility() string {
+	if u.RankingVisibility == "" {
+		return RankingVisibilityPublic
+	}
+	return u.RankingVisibility
+}
+
+// TimezoneName returns the user's time zone name, DefaultTimezone when unset.
+func (u *User) TimezoneName() string {
+	if u.Timezone == "" {
+		return DefaultTimezone
+	}
+	return u.Timezone
+}
+
+// Location returns the user's time zone for calendar and hour-of-day bucketing; UTC when
+// unset or no longer known to the server's tz database.
+func (u *User) Location() *time.Location {
+	loc, err := LoadTimezone(u.TimezoneName())
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
 // UserPublic represents public user data (safe to send to client)
 type UserPublic struct {
-	ID              primitive.ObjectID `json:"_id"` // Changed to _id
-	Nickname        string             `json:"nickname"`
-	Username        string             `json:"username"`
-	Role            string             `json:"role"`
-	LanguagePref    string             `json:"language_pref"`
-	ProfilePicture  string             `json:"profile_picture"`
-	StreakStartDate *time.Time         `json:"streak_start_date,omitempty"`
-	CreatedAt       time.Time          `json:"created_at"`
+	ID                  primitive.ObjectID `json:"_id"` // Changed to _id
+	Nickname            string             `json:"nickname"`
+	Username            string             `json:"username"`
+	Role                string             `json:"role"`
+	LanguagePref        string             `json:"language_pref"`
+	ProfilePicture      string             `json:"profile_picture"`
+	ProfilePictureSmall string             `json:"profile_picture_small,omitempty"`
+	StreakStartDate     *time.Time         `json:"streak_start_date,omitempty"`
+	TwoFactorEnabled    bool               `json:"two_factor_enabled"`
+	RankingVisibility   string             `json:"ranking_visibility"`
+	Timezone            string             `json:"timezone"`
+	CreatedAt           time.Time          `json:"created_at"`
 }
 
 // ToPublic converts User to UserPublic (removes sensitive fields)
 func (u *User) ToPublic() *UserPublic {
 	return &UserPublic{
-		ID:              u.ID,
-		Nickname:        u.Nickname,
-		Username:        u.Username,
-		Role:            u.Role,
-		LanguagePref:    u.LanguagePref,
-		ProfilePicture:  u.ProfilePicture,
-		StreakStartDate: u.StreakStartDate,
-		CreatedAt:       u.CreatedAt,
+		ID:                  u.ID,
+		Nickname:            u.Nickname,
+		Username:            u.Username,
+		Role:                u.Role,
+		LanguagePref:        u.LanguagePref,
+		ProfilePicture:      u.ProfilePicture,
+		ProfilePictureSmall: u.ProfilePictureSmall,
+		StreakStartDate:     u.StreakStartDate,
+		TwoFactorEnabled:    u.TwoFactor.Enabled,
+		RankingVisibility:   u.Visibility(),
+		Timezone:            u.TimezoneName(),
+		CreatedAt:           u.CreatedAt,
 	}
 }
 
@@ -91,4 +208,4 @@ type RegistrationAttempt struct {
 	AttemptTime  time.Time          `bson:"attempt_time" json:"attempt_time"`
 	Status       string             `bson:"status" json:"status"` // success, blocked
 	BlockedUntil *time.Time         `bson:"blocked_until,omitempty" json:"blocked_until,omitempty"`
-}
\ No newline at end of file
+}
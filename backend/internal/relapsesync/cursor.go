@@ -0,0 +1,68 @@
+package relapsesync
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidCursor dikembalikan jika cursor sync rusak.
+var ErrInvalidCursor = errors.New("invalid sync cursor")
+
+// Overlap adalah jarak mundur cursor setelah klien tersinkron penuh. Transaksi yang
+// sedang berjalan bisa meng-commit perubahan dengan updatedAt sedikit lebih lama dari
+// waktu baca, jadi beberapa detik terakhir dikirim ulang; klien menerapkannya secara
+// idempoten berdasarkan _id.
+const Overlap = 5 * time.Second
+
+// Cursor menandai posisi terakhir yang sudah dikirim ke klien: perubahan (updatedAt
+// atau deleted_at) setelah (Time, ID). Cursor kosong berarti sejak awal.
+type Cursor struct {
+	Time time.Time
+	ID   primitive.ObjectID
+}
+
+type cursorJSON struct {
+	T int64  `json:"t"`
+	I string `json:"i,omitempty"`
+}
+
+// Encode mengubah cursor menjadi string aman-URL.
+func (c Cursor) Encode() string {
+	raw := cursorJSON{T: c.Time.UnixNano()}
+	if !c.ID.IsZero() {
+		raw.I = c.ID.Hex()
+	}
+	data, _ := json.Marshal(raw)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// before melaporkan apakah c berada sebelum other.
+func (c Cursor) before(other Cursor) bool {
+	return c.Time.Before(other.Time) || (c.Time.Equal(other.Time) && c.ID.Hex() < other.ID.Hex())
+}
+
+// DecodeCursor membaca cursor dari klien. String kosong menghasilkan cursor awal.
+func DecodeCursor(encoded string) (Cursor, error) {
+	if encoded == "" {
+		return Cursor{}, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	var raw cursorJSON
+	if err := json.Unmarshal(data, &raw); err != nil || raw.T < 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+	c := Cursor{Time: time.Unix(0, raw.T).UTC()}
+	if raw.I != "" {
+		if c.ID, err = primitive.ObjectIDFromHex(raw.I); err != nil {
+			return Cursor{}, ErrInvalidCursor
+		}
+	}
+	return c, nil
+}
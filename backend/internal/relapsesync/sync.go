@@ -0,0 +1,393 @@
+// Package relapsesync menerapkan protokol sinkronisasi batch untuk klien offline-first.
+//
+// Klien mengirim daftar operasi (create, update_note, delete); setiap relapse dikenali
+// lewat client_id buatan klien (atau _id server) dan setiap operasi membawa
+// client_timestamp, yaitu waktu operasi dilakukan di perangkat. Aturan konflik:
+//
+//   - create idempoten per client_id: pengiriman ulang mengembalikan relapse yang sudah
+//     ada (duplicate). Create tidak pernah menghapus relapse lain, sehingga urutan sinkron
+//     antar perangkat tidak mengubah riwayat.
+//   - update_note dan delete memakai last-writer-wins: operasi diterapkan hanya jika
+//     client_timestamp tidak lebih lama dari waktu tulis terakhir relapse
+//     (RelapseLog.LastWrite); jika lebih lama, hasilnya stale beserta versi server.
+//   - Sync tidak bisa menghidupkan relapse yang sudah dihapus (pemulihan hanya lewat
+//     endpoint restore): create/update untuk relapse yang sudah dihapus berstatus
+//     deleted, dan delete ulang berstatus duplicate.
+//
+// client_timestamp kosong dianggap waktu server saat operasi diterima; timestamp di masa
+// depan dipotong ke waktu server agar jam perangkat yang salah tidak selalu menang.
+package relapsesync
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/services"
+	"solivra-go/backend/internal/store"
+	"solivra-go/backend/pkg/utils"
+)
+
+// MaxOperations membatasi jumlah operasi dalam satu batch.
+const MaxOperations = 500
+
+// maxClientIDLength membatasi panjang client_id (cukup untuk UUID atau ULID).
+const maxClientIDLength = 64
+
+// OpKind adalah jenis operasi sync.
+type OpKind string
+
+const (
+	OpCreate     OpKind = "create"
+	OpUpdateNote OpKind = "update_note"
+	OpDelete     OpKind = "delete"
+)
+
+// Status adalah hasil penerapan satu operasi.
+type Status string
+
+const (
+	StatusApplied   Status = "applied"
+	StatusDuplicate Status = "duplicate" // Sudah diterapkan sebelumnya; tidak ada perubahan
+	StatusStale     Status = "stale"     // Kalah dari penulisan yang lebih baru (last-writer-wins)
+	StatusDeleted   Status = "deleted"   // Relapse sudah dihapus
+	StatusNotFound  Status = "not_found"
+	StatusInvalid   Status = "invalid"
+)
+
+// Operation adalah satu operasi dari klien.
+type Operation struct {
+	Op OpKind `json:"op"`
+	// ClientID wajib untuk create; update_note/delete boleh memakai ClientID atau ID.
+	ClientID string `json:"client_id"`
+	ID       string `json:"id"`
+	// HabitID hanya dipakai create; kosong berarti habit default.
+	HabitID         string  `json:"habit_id"`
+	RelapseTime     string  `json:"relapse_time"`
+	RelapseNote     *string `json:"relapse_note"`
+	ClientTimestamp string  `json:"client_timestamp"`
+}
+
+// Result adalah hasil satu operasi, dalam urutan yang sama dengan permintaan.
+type Result struct {
+	Index    int                `json:"index"`
+	Op       OpKind             `json:"op"`
+	ClientID string             `json:"client_id,omitempty"`
+	Status   Status             `json:"status"`
+	Msg      string             `json:"msg,omitempty"`
+	Relapse  *models.RelapseLog `json:"relapse,omitempty"`
+}
+
+// HabitResolver mengembalikan habit milik user untuk habit_id (kosong berarti habit
+// default). Habit yang tidak ada menghasilkan store.ErrNotFound.
+type HabitResolver func(ctx context.Context, rawID string) (*models.Habit, error)
+
+// Batch adalah hasil Apply.
+type Batch struct {
+	Results []Result
+	// Habits berisi habit yang relapse-nya bertambah atau berkurang; streak-nya perlu
+	// dihitung ulang oleh pemanggil di transaksi yang sama.
+	Habits []primitive.ObjectID
+}
+
+func (b *Batch) touch(habitID primitive.ObjectID) {
+	for _, id := range b.Habits {
+		if id == habitID {
+			return
+		}
+	}
+	b.Habits = append(b.Habits, habitID)
+}
+
+// applier menyimpan state satu pemanggilan Apply.
+type applier struct {
+	s       *store.Stores
+	userID  primitive.ObjectID
+	resolve HabitResolver
+	now     time.Time
+	batch   *Batch
+}
+
+// Apply menerapkan ops secara berurutan. Operasi yang tidak valid atau kalah konflik
+// dilaporkan di Result tanpa menghentikan batch; error yang dikembalikan hanya error
+// store, dan pemanggil sebaiknya membatalkan seluruh transaksi.
+func Apply(ctx context.Context, s *store.Stores, userID primitive.ObjectID, ops []Operation, resolve HabitResolver, now time.Time) (*Batch, error) {
+	a := &applier{s: s, userID: userID, resolve: resolve, now: now, batch: &Batch{Results: make([]Result, 0, len(ops))}}
+
+	for i, op := range ops {
+		op.ClientID = utils.SanitizeString(op.ClientID, maxClientIDLength)
+		result := Result{Index: i, Op: op.Op, ClientID: op.ClientID}
+
+		writtenAt, err := a.clientTime(op.ClientTimestamp)
+		if err != nil {
+			result.Status, result.Msg = StatusInvalid, "client_timestamp tidak valid"
+			a.batch.Results = append(a.batch.Results, result)
+			continue
+		}
+
+		switch op.Op {
+		case OpCreate:
+			err = a.create(ctx, op, writtenAt, &result)
+		case OpUpdateNote:
+			err = a.updateNote(ctx, op, writtenAt, &result)
+		case OpDelete:
+			err = a.delete(ctx, op, writtenAt, &result)
+		default:
+			result.Status, result.Msg = StatusInvalid, "operasi tidak dikenal"
+		}
+		if err != nil {
+			return nil, err
+		}
+		a.batch.Results = append(a.batch.Results, result)
+	}
+	return a.batch, nil
+}
+
+// clientTime membaca client_timestamp; kosong berarti now, masa depan dipotong ke now.
+func (a *applier) clientTime(raw string) (time.Time, error) {
+	if raw == "" {
+		return a.now, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if t.After(a.now) {
+		return a.now, nil
+	}
+	return t, nil
+}
+
+func (a *applier) create(ctx context.Context, op Operation, writtenAt time.Time, result *Result) error {
+	if op.ClientID == "" {
+		result.Status, result.Msg = StatusInvalid, "client_id wajib diisi"
+		return nil
+	}
+	relapseTime, err := time.Parse(time.RFC3339, op.RelapseTime)
+	if err != nil {
+		result.Status, result.Msg = StatusInvalid, "relapse_time tidak valid"
+		return nil
+	}
+	if relapseTime.After(a.now.Add(time.Second)) {
+		result.Status, result.Msg = StatusInvalid, "relapse_time tidak boleh di masa depan"
+		return nil
+	}
+
+	existing, err := a.s.Relapses.FindByClientID(ctx, a.userID, op.ClientID)
+	if err == nil {
+		result.Status, result.Relapse = StatusDuplicate, existing
+		return nil
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+	if done, err := a.buried(ctx, primitive.NilObjectID, op.ClientID, StatusDeleted, result); done || err != nil {
+		return err
+	}
+
+	habit, err := a.resolve(ctx, op.HabitID)
+	if errors.Is(err, store.ErrNotFound) {
+		result.Status, result.Msg = StatusInvalid, "habit tidak ditemukan"
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	relapse := models.RelapseLog{
+		ID:              primitive.NewObjectID(),
+		UserID:          a.userID,
+		HabitID:         habit.ID,
+		RelapseTime:     relapseTime,
+		ClientID:        op.ClientID,
+		ClientUpdatedAt: &writtenAt,
+		CreatedAt:       a.now,
+		UpdatedAt:       a.now,
+	}
+	if op.RelapseNote != nil {
+		if note := utils.SanitizeString(*op.RelapseNote, 2048); note != "" {
+			relapse.RelapseNote = &note
+		}
+	}
+	if err := a.s.Relapses.Create(ctx, &relapse); err != nil {
+		return err
+	}
+
+	a.batch.touch(habit.ID)
+	result.Status, result.Relapse = StatusApplied, &relapse
+	return nil
+}
+
+func (a *applier) updateNote(ctx context.Context, op Operation, writtenAt time.Time, result *Result) error {
+	target, err := a.target(ctx, op, StatusDeleted, result)
+	if target == nil || err != nil {
+		return err
+	}
+	if writtenAt.Before(target.LastWrite()) {
+		result.Status, result.Relapse = StatusStale, target
+		return nil
+	}
+
+	var note *string
+	if op.RelapseNote != nil {
+		if sanitized := utils.SanitizeString(*op.RelapseNote, 2048); sanitized != "" {
+			note = &sanitized
+		}
+	}
+	if sameNote(note, target.RelapseNote) {
+		result.Status, result.Relapse = StatusDuplicate, target
+		return nil
+	}
+
+	updated, err := a.s.Relapses.UpdateNote(ctx, target.ID, a.userID, note, writtenAt, a.now)
+	if err != nil {
+		return err
+	}
+	if err := services.RecordRelapseRevision(ctx, a.s, target, updated, models.RevisionSourceSync, a.now); err != nil {
+		return err
+	}
+	result.Status, result.Relapse = StatusApplied, updated
+	return nil
+}
+
+func (a *applier) delete(ctx context.Context, op Operation, writtenAt time.Time, result *Result) error {
+	target, err := a.target(ctx, op, StatusDuplicate, result)
+	if target == nil || err != nil {
+		return err
+	}
+	if writtenAt.Before(target.LastWrite()) {
+		result.Status, result.Relapse = StatusStale, target
+		return nil
+	}
+
+	if _, err := a.s.Relapses.SoftDelete(ctx, target.ID, a.userID, a.now); err != nil {
+		return err
+	}
+
+	habitID := target.HabitID
+	if habitID.IsZero() {
+		// Relapse lama tanpa habit milik habit default
+		habit, err := a.resolve(ctx, "")
+		if err != nil {
+			return err
+		}
+		habitID = habit.ID
+	}
+	a.batch.touch(habitID)
+	result.Status = StatusApplied
+	return nil
+}
+
+// target mencari relapse yang dituju op (lewat id atau client_id). Jika tidak ada,
+// result diisi (ifBuried bila relapse sudah dihapus, not_found bila belum pernah ada)
+// dan target bernilai nil.
+func (a *applier) target(ctx context.Context, op Operation, ifBuried Status, result *Result) (*models.RelapseLog, error) {
+	var (
+		id  primitive.ObjectID
+		rel *models.RelapseLog
+		err error
+	)
+	switch {
+	case op.ID != "":
+		if id, err = primitive.ObjectIDFromHex(op.ID); err != nil {
+			result.Status, result.Msg = StatusInvalid, "id tidak valid"
+			return nil, nil
+		}
+		rel, err = a.s.Relapses.FindByID(ctx, id, a.userID)
+	case op.ClientID != "":
+		rel, err = a.s.Relapses.FindByClientID(ctx, a.userID, op.ClientID)
+	default:
+		result.Status, result.Msg = StatusInvalid, "id atau client_id wajib diisi"
+		return nil, nil
+	}
+	if err == nil {
+		return rel, nil
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+
+	if done, err := a.buried(ctx, id, op.ClientID, ifBuried, result); done || err != nil {
+		return nil, err
+	}
+	result.Status = StatusNotFound
+	return nil, nil
+}
+
+// buried mengisi result dengan status bila relapse (id atau clientID) punya tombstone.
+func (a *applier) buried(ctx context.Context, id primitive.ObjectID, clientID string, status Status, result *Result) (bool, error) {
+	_, err := a.s.Relapses.FindTombstone(ctx, a.userID, id, clientID)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	result.Status = status
+	return true, nil
+}
+
+func sameNote(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// Changes adalah perubahan server sejak cursor klien.
+type Changes struct {
+	Relapses []models.RelapseLog       `json:"relapses"`
+	Deleted  []models.RelapseTombstone `json:"deleted"`
+	Cursor   string                    `json:"cursor"`
+	HasMore  bool                      `json:"has_more"`
+}
+
+// ChangesSince mengembalikan paling banyak limit perubahan (relapse baru/berubah dan
+// tombstone) setelah cursor, diurutkan berdasarkan waktu perubahan. Jika HasMore,
+// klien memanggil lagi dengan Cursor yang dikembalikan.
+func ChangesSince(ctx context.Context, s *store.Stores, userID primitive.ObjectID, since Cursor, limit int, now time.Time) (*Changes, error) {
+	relapses, err := s.Relapses.ListChangedSince(ctx, userID, since.Time, since.ID, int64(limit+1))
+	if err != nil {
+		return nil, err
+	}
+	tombstones, err := s.Relapses.ListTombstonesSince(ctx, userID, since.Time, since.ID, int64(limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	changes := &Changes{Relapses: []models.RelapseLog{}, Deleted: []models.RelapseTombstone{}}
+	var last Cursor
+	i, j := 0, 0
+	for i+j < limit && (i < len(relapses) || j < len(tombstones)) {
+		var rel, dead Cursor
+		if i < len(relapses) {
+			rel = Cursor{Time: relapses[i].UpdatedAt, ID: relapses[i].ID}
+		}
+		if j < len(tombstones) {
+			dead = Cursor{Time: tombstones[j].DeletedAt, ID: tombstones[j].ID}
+		}
+		if j >= len(tombstones) || (i < len(relapses) && rel.before(dead)) {
+			changes.Relapses = append(changes.Relapses, relapses[i])
+			last = rel
+			i++
+		} else {
+			changes.Deleted = append(changes.Deleted, tombstones[j])
+			last = dead
+			j++
+		}
+	}
+
+	changes.HasMore = i < len(relapses) || j < len(tombstones)
+	next := last
+	if !changes.HasMore {
+		next = Cursor{Time: now.Add(-Overlap)}
+		if next.before(since) {
+			next = since
+		}
+	}
+	changes.Cursor = next.Encode()
+	return changes, nil
+}
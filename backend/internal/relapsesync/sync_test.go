@@ -0,0 +1,369 @@
+package relapsesync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/store"
+)
+
+// fixture adalah store in-memory dengan satu user dan habit default-nya.
+type fixture struct {
+	s     *store.Stores
+	user  primitive.ObjectID
+	habit *models.Habit
+	now   time.Time
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	f := &fixture{
+		s:    store.NewMemory(),
+		user: primitive.NewObjectID(),
+		now:  time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC),
+	}
+	f.habit = &models.Habit{ID: primitive.NewObjectID(), UserID: f.user, Name: "General", Kind: models.HabitKindGeneral, IsDefault: true}
+	if err := f.s.Habits.Create(context.Background(), f.habit); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func (f *fixture) resolve(_ context.Context, rawID string) (*models.Habit, error) {
+	if rawID == "" || rawID == f.habit.ID.Hex() {
+		return f.habit, nil
+	}
+	return nil, store.ErrNotFound
+}
+
+func (f *fixture) apply(t *testing.T, ops ...Operation) *Batch {
+	t.Helper()
+	batch, err := Apply(context.Background(), f.s, f.user, ops, f.resolve, f.now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batch.Results) != len(ops) {
+		t.Fatalf("got %d results for %d operations", len(batch.Results), len(ops))
+	}
+	return batch
+}
+
+// ts memformat now+d sebagai RFC 3339 untuk relapse_time dan client_timestamp.
+func (f *fixture) ts(d time.Duration) string {
+	return f.now.Add(d).Format(time.RFC3339)
+}
+
+func note(s string) *string { return &s }
+
+func TestApplyCreate(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup dijalankan sebelum Apply (opsional)
+		setup func(t *testing.T, f *fixture)
+		ops   func(f *fixture) []Operation
+		want  []Status
+		touch bool // habit masuk Batch.Habits
+	}{
+		{
+			name: "created",
+			ops: func(f *fixture) []Operation {
+				return []Operation{{Op: OpCreate, ClientID: "a", RelapseTime: f.ts(-time.Hour)}}
+			},
+			want:  []Status{StatusApplied},
+			touch: true,
+		},
+		{
+			name: "resent create is a duplicate",
+			ops: func(f *fixture) []Operation {
+				op := Operation{Op: OpCreate, ClientID: "a", RelapseTime: f.ts(-time.Hour)}
+				return []Operation{op, op}
+			},
+			want:  []Status{StatusApplied, StatusDuplicate},
+			touch: true,
+		},
+		{
+			name: "create after soft delete",
+			ops: func(f *fixture) []Operation {
+				return []Operation{
+					{Op: OpCreate, ClientID: "a", RelapseTime: f.ts(-time.Hour)},
+					{Op: OpDelete, ClientID: "a"},
+					{Op: OpCreate, ClientID: "a", RelapseTime: f.ts(-time.Hour)},
+				}
+			},
+			want:  []Status{StatusApplied, StatusApplied, StatusDeleted},
+			touch: true,
+		},
+		{
+			name: "create after permanent delete",
+			setup: func(t *testing.T, f *fixture) {
+				ctx := context.Background()
+				if err := f.s.Relapses.Create(ctx, &models.RelapseLog{UserID: f.user, HabitID: f.habit.ID, ClientID: "a", RelapseTime: f.now.Add(-time.Hour)}); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := f.s.Relapses.DeleteAllByHabit(ctx, f.habit.ID, f.now.Add(-time.Minute)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			ops: func(f *fixture) []Operation {
+				return []Operation{{Op: OpCreate, ClientID: "a", RelapseTime: f.ts(-time.Hour)}}
+			},
+			want: []Status{StatusDeleted},
+		},
+		{
+			name: "invalid operations",
+			ops: func(f *fixture) []Operation {
+				return []Operation{
+					{Op: OpCreate, RelapseTime: f.ts(-time.Hour)},
+					{Op: OpCreate, ClientID: "b", RelapseTime: "yesterday"},
+					{Op: OpCreate, ClientID: "c", RelapseTime: f.ts(time.Hour)},
+					{Op: OpCreate, ClientID: "d", RelapseTime: f.ts(-time.Hour), HabitID: primitive.NewObjectID().Hex()},
+					{Op: OpCreate, ClientID: "e", RelapseTime: f.ts(-time.Hour), ClientTimestamp: "not-a-time"},
+					{Op: "rename", ClientID: "f"},
+				}
+			},
+			want: []Status{StatusInvalid, StatusInvalid, StatusInvalid, StatusInvalid, StatusInvalid, StatusInvalid},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			if tt.setup != nil {
+				tt.setup(t, f)
+			}
+			batch := f.apply(t, tt.ops(f)...)
+			for i, want := range tt.want {
+				if got := batch.Results[i]; got.Status != want || got.Index != i {
+					t.Errorf("result %d = %s (index %d, msg %q), want %s", i, got.Status, got.Index, got.Msg, want)
+				}
+			}
+			if touched := len(batch.Habits) == 1 && batch.Habits[0] == f.habit.ID; touched != tt.touch {
+				t.Errorf("Habits = %v, want touched %v", batch.Habits, tt.touch)
+			}
+		})
+	}
+}
+
+func TestApplyDuplicateReturnsExistingRelapse(t *testing.T) {
+	f := newFixture(t)
+	op := Operation{Op: OpCreate, ClientID: "a", RelapseTime: f.ts(-time.Hour), RelapseNote: note("first")}
+	first := f.apply(t, op).Results[0]
+	op.RelapseNote = note("resent with another note")
+	again := f.apply(t, op).Results[0]
+
+	if again.Status != StatusDuplicate || again.Relapse == nil || again.Relapse.ID != first.Relapse.ID {
+		t.Fatalf("resent create = %+v, want duplicate of %s", again, first.Relapse.ID.Hex())
+	}
+	if again.Relapse.RelapseNote == nil || *again.Relapse.RelapseNote != "first" {
+		t.Errorf("duplicate create changed the note to %v", again.Relapse.RelapseNote)
+	}
+}
+
+func TestApplyLastWriterWins(t *testing.T) {
+	f := newFixture(t)
+	created := f.apply(t, Operation{Op: OpCreate, ClientID: "a", RelapseTime: f.ts(-time.Hour), ClientTimestamp: f.ts(-50 * time.Minute)}).Results[0]
+	id := created.Relapse.ID.Hex()
+
+	// Setiap langkah diterapkan berurutan di atas hasil langkah sebelumnya
+	steps := []struct {
+		name string
+		op   Operation
+		want Status
+		note *string // Catatan relapse setelah langkah ini (hanya dicek jika relapse dikembalikan)
+	}{
+		{name: "newer write wins", op: Operation{Op: OpUpdateNote, ClientID: "a", RelapseNote: note("second"), ClientTimestamp: f.ts(-30 * time.Minute)}, want: StatusApplied, note: note("second")},
+		{name: "older write arriving late is stale", op: Operation{Op: OpUpdateNote, ClientID: "a", RelapseNote: note("first"), ClientTimestamp: f.ts(-40 * time.Minute)}, want: StatusStale, note: note("second")},
+		{name: "same note again is a duplicate", op: Operation{Op: OpUpdateNote, ID: id, RelapseNote: note("second"), ClientTimestamp: f.ts(-30 * time.Minute)}, want: StatusDuplicate, note: note("second")},
+		{name: "older delete is stale", op: Operation{Op: OpDelete, ClientID: "a", ClientTimestamp: f.ts(-35 * time.Minute)}, want: StatusStale, note: note("second")},
+		{name: "future timestamp is clipped to now", op: Operation{Op: OpUpdateNote, ClientID: "a", RelapseNote: note("third"), ClientTimestamp: f.ts(time.Hour)}, want: StatusApplied, note: note("third")},
+		{name: "clipped write still beats earlier writes", op: Operation{Op: OpUpdateNote, ClientID: "a", RelapseNote: note("fourth"), ClientTimestamp: f.ts(-time.Second)}, want: StatusStale, note: note("third")},
+		{name: "clearing the note", op: Operation{Op: OpUpdateNote, ID: id}, want: StatusApplied},
+		{name: "newer delete wins", op: Operation{Op: OpDelete, ID: id}, want: StatusApplied},
+		{name: "repeated delete is a duplicate", op: Operation{Op: OpDelete, ClientID: "a"}, want: StatusDuplicate},
+		{name: "update after delete", op: Operation{Op: OpUpdateNote, ClientID: "a", RelapseNote: note("late")}, want: StatusDeleted},
+		{name: "unknown client id", op: Operation{Op: OpUpdateNote, ClientID: "zzz", RelapseNote: note("x")}, want: StatusNotFound},
+		{name: "unknown id", op: Operation{Op: OpDelete, ID: primitive.NewObjectID().Hex()}, want: StatusNotFound},
+		{name: "malformed id", op: Operation{Op: OpDelete, ID: "nope"}, want: StatusInvalid},
+		{name: "no target", op: Operation{Op: OpUpdateNote}, want: StatusInvalid},
+	}
+
+	for _, step := range steps {
+		got := f.apply(t, step.op).Results[0]
+		if got.Status != step.want {
+			t.Fatalf("%s: status = %s (msg %q), want %s", step.name, got.Status, got.Msg, step.want)
+		}
+		if got.Relapse == nil {
+			continue
+		}
+		if !sameNote(got.Relapse.RelapseNote, step.note) {
+			t.Errorf("%s: note = %v, want %v", step.name, got.Relapse.RelapseNote, step.note)
+		}
+	}
+
+	// Penulisan yang menang dicatat di riwayat edit sebagai sumber sync
+	revisions, err := f.s.Relapses.ListRevisions(context.Background(), created.Relapse.ID, f.user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	synced := 0
+	for _, rev := range revisions {
+		switch rev.Source {
+		case models.RevisionSourceSync:
+			synced++
+		case models.RevisionSourceOriginal:
+		default:
+			t.Errorf("revision source = %q, want sync or original", rev.Source)
+		}
+	}
+	// Tiga update_note yang diterapkan; yang stale dan duplicate tidak tercatat
+	if synced != 3 {
+		t.Errorf("recorded %d sync revisions, want 3", synced)
+	}
+}
+
+func TestChangesSincePaging(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	changedAt := f.now.Add(-time.Hour)
+
+	// Lima relapse dan tiga tombstone dengan waktu perubahan yang sama persis
+	want := make(map[primitive.ObjectID]bool)
+	for i := 0; i < 5; i++ {
+		r := models.RelapseLog{UserID: f.user, HabitID: f.habit.ID, RelapseTime: changedAt, CreatedAt: changedAt, UpdatedAt: changedAt}
+		if err := f.s.Relapses.Create(ctx, &r); err != nil {
+			t.Fatal(err)
+		}
+		want[r.ID] = true
+	}
+	other := primitive.NewObjectID()
+	for i := 0; i < 3; i++ {
+		r := models.RelapseLog{UserID: f.user, HabitID: other, RelapseTime: changedAt, CreatedAt: changedAt, UpdatedAt: changedAt.Add(-time.Hour)}
+		if err := f.s.Relapses.Create(ctx, &r); err != nil {
+			t.Fatal(err)
+		}
+		want[r.ID] = true
+	}
+	if _, err := f.s.Relapses.DeleteAllByHabit(ctx, other, changedAt); err != nil {
+		t.Fatal(err)
+	}
+	// Perubahan user lain tidak ikut terkirim
+	if err := f.s.Relapses.Create(ctx, &models.RelapseLog{UserID: primitive.NewObjectID(), RelapseTime: changedAt, UpdatedAt: changedAt}); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[primitive.ObjectID]bool)
+	cursor := Cursor{}
+	pages := 0
+	for {
+		changes, err := ChangesSince(ctx, f.s, f.user, cursor, 3, f.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages++
+		if n := len(changes.Relapses) + len(changes.Deleted); n > 3 {
+			t.Fatalf("page %d has %d changes, limit 3", pages, n)
+		}
+		for _, r := range changes.Relapses {
+			if seen[r.ID] {
+				t.Errorf("relapse %s sent twice", r.ID.Hex())
+			}
+			seen[r.ID] = true
+		}
+		for _, d := range changes.Deleted {
+			if seen[d.ID] {
+				t.Errorf("tombstone %s sent twice", d.ID.Hex())
+			}
+			seen[d.ID] = true
+		}
+
+		if cursor, err = DecodeCursor(changes.Cursor); err != nil {
+			t.Fatal(err)
+		}
+		if !changes.HasMore {
+			break
+		}
+		if pages > 10 {
+			t.Fatal("paging did not terminate")
+		}
+	}
+
+	if pages != 3 {
+		t.Errorf("pages = %d, want 3", pages)
+	}
+	if len(seen) != len(want) {
+		t.Errorf("received %d changes, want %d", len(seen), len(want))
+	}
+	for id := range want {
+		if !seen[id] {
+			t.Errorf("change %s never sent", id.Hex())
+		}
+	}
+
+	// Setelah tersinkron penuh cursor mundur Overlap dari now, dan tidak ada perubahan baru
+	if !cursor.Time.Equal(f.now.Add(-Overlap)) || !cursor.ID.IsZero() {
+		t.Errorf("final cursor = %+v, want now-Overlap", cursor)
+	}
+	changes, err := ChangesSince(ctx, f.s, f.user, cursor, 3, f.now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes.Relapses)+len(changes.Deleted) != 0 || changes.HasMore {
+		t.Errorf("caught-up client received %+v", changes)
+	}
+}
+
+func TestChangesSinceCursorNeverMovesBack(t *testing.T) {
+	f := newFixture(t)
+	// Cursor klien lebih baru dari now-Overlap (misal jam server mundur)
+	since := Cursor{Time: f.now.Add(-time.Second), ID: primitive.NewObjectID()}
+	changes, err := ChangesSince(context.Background(), f.s, f.user, since, 10, f.now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecodeCursor(changes.Cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Time.Equal(since.Time) || got.ID != since.ID {
+		t.Errorf("cursor = %+v, want unchanged %+v", got, since)
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	valid := Cursor{Time: time.Date(2024, 5, 10, 12, 0, 0, 123, time.UTC), ID: primitive.NewObjectID()}
+	got, err := DecodeCursor(valid.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Time.Equal(valid.Time) || got.ID != valid.ID {
+		t.Errorf("round trip = %+v, want %+v", got, valid)
+	}
+
+	if got, err := DecodeCursor(""); err != nil || !got.Time.IsZero() || !got.ID.IsZero() {
+		t.Errorf("DecodeCursor(\"\") = %+v, %v; want the zero cursor", got, err)
+	}
+
+	encoded := valid.Encode()
+	tampered := []struct{ name, cursor string }{
+		{name: "not base64", cursor: "***"},
+		{name: "padded base64", cursor: encoded + "=="},
+		{name: "truncated", cursor: encoded[:len(encoded)/2]},
+		{name: "not json", cursor: "bm90IGpzb24"},
+		{name: "wrong type", cursor: "eyJ0IjoieCJ9"},                          // {"t":"x"}
+		{name: "negative time", cursor: "eyJ0IjotMX0"},                        // {"t":-1}
+		{name: "invalid id", cursor: "eyJ0IjoxLCJpIjoienp6In0"},               // {"t":1,"i":"zzz"}
+		{name: "json array", cursor: "WzEsMl0"},                               // [1,2]
+		{name: "id too short", cursor: "eyJ0IjoxLCJpIjoiMDEyMzQ1Njc4OWFiIn0"}, // {"t":1,"i":"0123456789ab"}
+	}
+	for _, tt := range tampered {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.cursor); err != ErrInvalidCursor {
+				t.Errorf("DecodeCursor(%q) err = %v, want ErrInvalidCursor", tt.cursor, err)
+			}
+		})
+	}
+}
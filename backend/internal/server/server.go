@@ -0,0 +1,269 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/helmet"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"solivra-go/backend/internal/captcha"
+	"solivra-go/backend/internal/config"
+	"solivra-go/backend/internal/database"
+	"solivra-go/backend/internal/handlers"
+	"solivra-go/backend/internal/health"
+	"solivra-go/backend/internal/jobs"
+	"solivra-go/backend/internal/logging"
+	"solivra-go/backend/internal/metrics"
+	"solivra-go/backend/internal/middleware"
+	"solivra-go/backend/internal/storage"
+	"solivra-go/backend/internal/store"
+	"solivra-go/backend/pkg/utils"
+)
+
+// Deps berisi dependensi eksternal yang dibutuhkan aplikasi.
+type Deps struct {
+	Stores *store.Stores
+	// Captcha memverifikasi token pada login/register. nil berarti captcha dinonaktifkan.
+	Captcha captcha.Verifier
+	// Jobs adalah scheduler job latar belakang untuk endpoint admin. nil berarti endpoint
+	// /api/admin/jobs mengembalikan 503.
+	Jobs *jobs.Runner
+	// Storage menyimpan foto profil. nil berarti upload foto profil mengembalikan 503.
+	Storage storage.Storage
+	// Mongo dipakai readiness check untuk ping database. nil berarti store bukan MongoDB
+	// (misal store in-memory) dan check mongodb dilewati.
+	Mongo *mongo.Database
+	// Checks adalah readiness check tambahan yang dijalankan setelah check bawaan.
+	Checks []health.Check
+}
+
+// New membangun aplikasi Fiber lengkap (middleware + route) tanpa menjalankan listener,
+// sehingga bisa dipakai oleh main maupun oleh app.Test().
+func New(cfg *config.Config, deps Deps) *fiber.App {
+	utils.InitActivityLog(deps.Stores.ActivityLogs)
+	h := handlers.New(deps.Stores, deps.Jobs, deps.Storage, cfg.ExportDir, readinessChecks(cfg, deps))
+
+	app := fiber.New(fiber.Config{
+		AppName:   "Solivra Go Backend",
+		BodyLimit: 10 * 1024 * 1024,
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			code := fiber.StatusInternalServerError
+			if e, ok := err.(*fiber.Error); ok {
+				code = e.Code
+			}
+			requestID := logging.RequestIDFrom(c)
+			if code >= fiber.StatusInternalServerError {
+				logging.For(c).Error("unhandled error", "method", c.Method(), "path", c.Path(), "status", code, "err", err)
+			}
+			// PASTIKAN MENGEMBALIKAN "msg" UNTUK KONSISTENSI DENGAN NODE.JS.
+			// request_id dikirim agar user bisa melaporkannya dan kita bisa mencari di log.
+			return c.Status(code).JSON(fiber.Map{
+				"ok":         false,
+				"msg":        err.Error(),
+				"request_id": requestID,
+			})
+		},
+	})
+
+	// Middlewares Global. RequestID paling awal agar semua log (termasuk access log dan
+	// panic yang ditangkap recover) membawa request_id.
+	app.Use(middleware.RequestID)
+	app.Use(middleware.Metrics)
+	app.Use(middleware.AccessLog)
+	app.Use(recover.New())
+	app.Use(helmet.New())
+
+	app.Use(cors.New(cors.Config{
+		AllowOrigins:     cfg.CorsAllowedOrigins,
+		AllowCredentials: true,
+		AllowMethods:     "GET,POST,HEAD,PUT,DELETE,PATCH,OPTIONS",
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-Requested-With, X-Session-Token, CF-Turnstile-Response, X-Request-ID",
+		ExposeHeaders:    "X-Request-ID",
+	}))
+
+	// Rate Limiting (can be disabled or configured via env vars)
+	if cfg.RateLimitMax > 0 {
+		app.Use(limiter.New(limiter.Config{
+			Max:        cfg.RateLimitMax,
+			Expiration: time.Duration(cfg.RateLimitExpiration) * time.Minute,
+			KeyGenerator: func(c *fiber.Ctx) string {
+				return utils.GetClientIP(c)
+			},
+			LimitReached: func(c *fiber.Ctx) error {
+				return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+					"ok":  false,
+					"msg": "Too many requests, please try again later.",
+				})
+			},
+		}))
+	} else {
+		slog.Warn("rate limiting is DISABLED (RATE_LIMIT_MAX=0)")
+	}
+
+	// File driver storage local disajikan sebelum activity logger agar unduhan gambar
+	// tidak tercatat sebagai page_view; CORP dilonggarkan agar bisa dimuat origin frontend.
+	if local, ok := deps.Storage.(*storage.Local); ok {
+		app.Use(storage.LocalURLPrefix, func(c *fiber.Ctx) error {
+			c.Set(fiber.HeaderCrossOriginResourcePolicy, "cross-origin")
+			return c.Next()
+		})
+		app.Static(storage.LocalURLPrefix, local.Dir(), fiber.Static{MaxAge: 86400})
+	}
+
+	// Endpoint Prometheus di port utama hanya jika METRICS_TOKEN diisi; dipasang sebelum
+	// activity logger agar scrape berkala tidak tercatat sebagai page_view.
+	if cfg.MetricsToken != "" {
+		app.Get("/metrics", middleware.MetricsAuth(cfg.MetricsToken), adaptor.HTTPHandler(metrics.Handler()))
+	}
+
+	app.Use(middleware.ActivityLoggerMiddleware)
+
+	registerRoutes(app, h, deps)
+
+	return app
+}
+
+// readinessChecks menyusun pemeriksaan dependensi untuk /api/health/ready.
+func readinessChecks(cfg *config.Config, deps Deps) []health.Check {
+	checks := []health.Check{{
+		Name: "config",
+		Run: func(context.Context) error {
+			if problems := cfg.Problems(); len(problems) > 0 {
+				return errors.New(strings.Join(problems, "; "))
+			}
+			return nil
+		},
+	}, {
+		Name:    "storage",
+		Timeout: 5 * time.Second,
+		Run: func(ctx context.Context) error {
+			if deps.Storage == nil {
+				return errors.New("storage tidak dikonfigurasi")
+			}
+			return deps.Storage.Check(ctx)
+		},
+	}}
+	if deps.Mongo != nil {
+		checks = append(checks, health.Check{
+			Name:    "mongodb",
+			Timeout: 2 * time.Second,
+			Run: func(ctx context.Context) error {
+				return database.Ping(ctx, deps.Mongo)
+			},
+		})
+	}
+	return append(checks, deps.Checks...)
+}
+
+// registerRoutes memasang seluruh route API.
+func registerRoutes(app *fiber.App, h *handlers.Handler, deps Deps) {
+	api := app.Group("/api")
+
+	// Health Check
+	api.Get("/health", h.HealthCheck)
+	api.Get("/health/live", h.LiveCheck)
+	api.Get("/health/ready", h.ReadyCheck)
+	api.Post("/track-visit", h.TrackVisit)
+
+	// Auth Routes
+	auth := api.Group("/auth")
+	if deps.Captcha != nil {
+		auth.Post("/login", middleware.Captcha(deps.Captcha, h.LoginCaptchaFailed), h.Login)
+		auth.Post("/register", middleware.Captcha(deps.Captcha, h.RegisterCaptchaFailed), h.Register)
+	} else {
+		auth.Post("/login", h.Login)
+		auth.Post("/register", h.Register)
+	}
+	auth.Post("/login/2fa", h.VerifyTwoFactorLogin)
+	auth.Post("/refresh", h.Refresh)
+	auth.Post("/logout", h.Logout)
+
+	// Honeypot (Public)
+	honeypot := api.Group("/honeypot")
+	honeypot.Post("/admin-login", h.FakeAdminLogin) // FIXED: Nama handler Kapital
+	honeypot.Get("/admin-access", h.LogAdminAccess) // FIXED: Nama handler Kapital
+
+	// Public user utilities
+	publicUsers := api.Group("/users")
+	publicUsers.Get("/check-username/:username", h.CheckUsername)
+
+	// Unduhan ekspor data memakai token bertanda tangan, bukan sesi
+	api.Get("/exports/:id/download", h.DownloadDataExport)
+
+	// Protected Routes (Need Valid Session)
+	api.Use(middleware.Protected(deps.Stores.Sessions))
+
+	// User Routes
+	users := api.Group("/users")
+	users.Get("/me", h.GetMe)
+	users.Put("/profile", h.UpdateProfile)
+	users.Put("/password", h.UpdatePassword)
+	users.Put("/language", h.UpdateLanguage)
+	users.Put("/timezone", h.UpdateTimezone)
+	users.Get("/privacy", h.GetPrivacy)
+	users.Get("/achievements", h.GetAchievements)
+	users.Get("/export", h.ExportData)
+	users.Get("/export/:id", h.GetDataExport)
+	users.Put("/privacy", h.UpdatePrivacy)
+	users.Delete("/profile-picture", h.RemoveProfilePicture)
+	users.Delete("/", h.DeleteAccount)
+	users.Get("/sessions", h.GetSessions)
+	users.Delete("/sessions/:id", h.RevokeSession)
+	users.Post("/start-streak", h.StartStreak)
+	users.Get("/2fa", h.GetTwoFactorStatus)
+	users.Post("/2fa/setup", h.SetupTwoFactor)
+	users.Post("/2fa/confirm", h.ConfirmTwoFactor)
+	users.Post("/2fa/disable", h.DisableTwoFactor)
+
+	// Relapse Routes
+	relapses := api.Group("/relapses")
+	relapses.Get("/", h.GetRelapses)
+	relapses.Post("/", h.CreateRelapse)
+	relapses.Post("/sync", h.SyncRelapse)
+	relapses.Post("/sync/batch", h.SyncRelapsesBatch)
+	relapses.Post("/import", h.ImportRelapses)
+	relapses.Post("/restore", h.RestoreRelapses)
+	relapses.Get("/:id/history", h.GetRelapseHistory)
+	relapses.Put("/:id", h.UpdateRelapse)
+	relapses.Delete("/:id", h.DeleteRelapse)
+	relapses.Post("/:id/restore", h.RestoreRelapse)
+	relapses.Delete("/", h.DeleteAllRelapses)
+
+	// Habit Routes
+	habits := api.Group("/habits")
+	habits.Get("/", h.ListHabits)
+	habits.Post("/", h.CreateHabit)
+	habits.Get("/:id", h.GetHabit)
+	habits.Get("/:id/stats", h.GetHabitStats)
+	habits.Put("/:id", h.UpdateHabit)
+	habits.Delete("/:id", h.DeleteHabit)
+
+	// Stats Routes
+	stats := api.Group("/stats")
+	stats.Get("/", h.GetStats)
+	stats.Get("/rankings", h.GetRankings)
+	stats.Get("/insights", h.GetInsights)
+
+	// Admin Routes (Protected + Admin Role Check)
+	admin := api.Group("/admin", middleware.AdminOnly())
+	admin.Get("/dashboard", h.GetAdminDashboard)
+	admin.Get("/logs", h.GetAdminLogs)
+	admin.Get("/rankings", h.GetAdminRankings)
+	admin.Get("/users", h.GetAllUsers)
+	admin.Delete("/users/:id", h.DeleteUser)
+	admin.Get("/jobs", h.GetAdminJobs)
+	admin.Get("/jobs/:name/runs", h.GetAdminJobRuns)
+
+	// Honeypot Stats (Admin only)
+	honeypotAdmin := api.Group("/honeypot", middleware.AdminOnly())
+	honeypotAdmin.Get("/stats", h.GetHoneypotStats)
+}
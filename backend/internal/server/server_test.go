@@ -0,0 +1,533 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/captcha"
+	"solivra-go/backend/internal/config"
+	"solivra-go/backend/internal/health"
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/storage"
+	"solivra-go/backend/internal/store"
+	"solivra-go/backend/pkg/utils"
+)
+
+const testPassword = "password123"
+
+func TestMain(m *testing.M) {
+	os.Setenv("MONGO_URI", "mongodb://localhost:27017/solivra-test")
+	os.Setenv("JWT_ACCESS_SECRET", "test-access-secret")
+	os.Setenv("JWT_REFRESH_SECRET", "test-refresh-secret")
+	os.Setenv("ADMIN_EMAILS", "boss,chief")
+	os.Setenv("RATE_LIMIT_MAX", "0")
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+// testServer adalah aplikasi lengkap (semua middleware dan route) di atas store in-memory.
+type testServer struct {
+	app   *fiber.App
+	store *store.Stores
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	return newTestServerWith(t, nil)
+}
+
+// newTestServerWith sama seperti newTestServer; setup (opsional) mengubah salinan konfigurasi
+// dan dependensi sebelum aplikasi dibuat.
+func newTestServerWith(t *testing.T, setup func(cfg *config.Config, deps *Deps)) *testServer {
+	t.Helper()
+	st := store.NewMemory()
+	cfg := *config.Load()
+	deps := Deps{Stores: st}
+	if setup != nil {
+		setup(&cfg, &deps)
+	}
+	return &testServer{app: New(&cfg, deps), store: st}
+}
+
+// session adalah token yang didapat dari login, dikirim lewat header seperti klien non-browser.
+type session struct {
+	access, refresh, token string
+}
+
+func (s session) headers() map[string]string {
+	if s.access == "" {
+		return nil
+	}
+	return map[string]string{
+		fiber.HeaderAuthorization: "Bearer " + s.access,
+		"X-Session-Token":         s.token,
+		"X-Refresh-Token":         s.refresh,
+	}
+}
+
+// do mengirim request dan mengembalikan status serta body JSON (objek atau array).
+func (s *testServer) do(t *testing.T, method, path, body string, as session) (int, interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	for k, v := range as.headers() {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+	var decoded interface{}
+	json.Unmarshal(raw, &decoded)
+	return resp.StatusCode, decoded
+}
+
+// expect sama seperti do, tetapi menggagalkan test jika status tidak sesuai.
+func (s *testServer) expect(t *testing.T, status int, method, path, body string, as session) map[string]interface{} {
+	t.Helper()
+	got, decoded := s.do(t, method, path, body, as)
+	if got != status {
+		t.Fatalf("%s %s: status %d, want %d (body %v)", method, path, got, status, decoded)
+	}
+	m, _ := decoded.(map[string]interface{})
+	return m
+}
+
+// login mendaftarkan username lalu login dan mengembalikan sesinya.
+func (s *testServer) login(t *testing.T, username string) session {
+	t.Helper()
+	s.expect(t, 200, "POST", "/api/auth/register", `{"nickname":"`+username+`","username":"`+username+`","password":"`+testPassword+`"}`, session{})
+	return s.signIn(t, username)
+}
+
+// signIn login sebagai username yang sudah terdaftar.
+func (s *testServer) signIn(t *testing.T, username string) session {
+	t.Helper()
+	body := s.expect(t, 200, "POST", "/api/auth/login", `{"username":"`+username+`","password":"`+testPassword+`"}`, session{})
+	return session{
+		access:  body["access_token"].(string),
+		refresh: body["refresh_token"].(string),
+		token:   body["session_token"].(string),
+	}
+}
+
+func TestAuthFlow(t *testing.T) {
+	srv := newTestServer(t)
+
+	srv.expect(t, 401, "GET", "/api/users/me", "", session{})
+
+	alice := srv.login(t, "alice")
+	me := srv.expect(t, 200, "GET", "/api/users/me", "", alice)
+	if me["username"] != "alice" {
+		t.Errorf("GET /api/users/me username = %v, want alice", me["username"])
+	}
+
+	// Refresh menerbitkan access dan refresh token baru untuk sesi yang sama
+	refreshed := srv.expect(t, 200, "POST", "/api/auth/refresh", "", alice)
+	rotated := session{
+		access:  refreshed["access_token"].(string),
+		refresh: refreshed["refresh_token"].(string),
+		token:   alice.token,
+	}
+	if rotated.refresh == alice.refresh {
+		t.Error("refresh token was not rotated")
+	}
+	srv.expect(t, 200, "GET", "/api/users/me", "", rotated)
+
+	srv.expect(t, 200, "POST", "/api/auth/logout", "", rotated)
+
+	// Sesi yang sudah logout ditolak, meskipun access token-nya belum kedaluwarsa
+	srv.expect(t, 401, "GET", "/api/users/me", "", rotated)
+	srv.expect(t, 401, "POST", "/api/auth/refresh", "", rotated)
+
+	// Login ulang membuat sesi baru
+	again := srv.signIn(t, "alice")
+	srv.expect(t, 200, "GET", "/api/users/me", "", again)
+}
+
+// ageRotation memundurkan waktu rotasi refresh token as sejauh d, seolah token itu
+// sudah di-rotate d yang lalu.
+func (s *testServer) ageRotation(t *testing.T, username string, as session, d time.Duration) {
+	t.Helper()
+	ctx := context.Background()
+	claims, err := utils.ValidateRefreshToken(as.refresh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := s.store.Users.FindByUsername(ctx, username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := user.FindRefreshToken(claims.ID)
+	if entry == nil || entry.RotatedAt == nil {
+		t.Fatalf("refresh token %s has not been rotated", claims.ID)
+	}
+	rotatedAt := entry.RotatedAt.Add(-d)
+	entry.RotatedAt = &rotatedAt
+	if err := s.store.Users.RemoveSessionRefreshTokens(ctx, user.ID, entry.SessionID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.store.Users.AddRefreshToken(ctx, user.ID, *entry, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRefreshReuseRevokesSession(t *testing.T) {
+	srv := newTestServer(t)
+	alice := srv.login(t, "alice")
+
+	srv.expect(t, 200, "POST", "/api/auth/refresh", "", alice)
+	srv.ageRotation(t, "alice", alice, models.RefreshReuseGrace+time.Second)
+	// Refresh token lama dipakai lagi setelah masa tenggang: seluruh sesi dicabut
+	srv.expect(t, 401, "POST", "/api/auth/refresh", "", alice)
+	srv.expect(t, 401, "GET", "/api/users/me", "", alice)
+}
+
+func TestConcurrentRefreshWithinGrace(t *testing.T) {
+	srv := newTestServer(t)
+	alice := srv.login(t, "alice")
+
+	// Dua tab me-refresh dengan token yang sama hampir bersamaan: keduanya berhasil
+	first := srv.expect(t, 200, "POST", "/api/auth/refresh", "", alice)
+	second := srv.expect(t, 200, "POST", "/api/auth/refresh", "", alice)
+	for _, body := range []map[string]interface{}{first, second} {
+		tab := session{access: body["access_token"].(string), refresh: body["refresh_token"].(string), token: alice.token}
+		srv.expect(t, 200, "GET", "/api/users/me", "", tab)
+		srv.expect(t, 200, "POST", "/api/auth/refresh", "", tab)
+	}
+}
+
+func TestLoginFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{name: "missing credentials", body: `{"username":"alice"}`, status: 400},
+		{name: "unknown user", body: `{"username":"nobody","password":"password123"}`, status: 401},
+		{name: "wrong password", body: `{"username":"alice","password":"wrong-password"}`, status: 401},
+	}
+
+	srv := newTestServer(t)
+	srv.login(t, "alice")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv.expect(t, tt.status, "POST", "/api/auth/login", tt.body, session{})
+		})
+	}
+}
+
+// captchaDown mensimulasikan penyedia captcha yang tidak bisa dihubungi.
+type captchaDown struct{}
+
+func (captchaDown) Verify(context.Context, string, string) error {
+	return errors.New("dial tcp: connection refused")
+}
+
+func TestCaptcha(t *testing.T) {
+	register := func(username, token string) string {
+		return `{"nickname":"` + username + `","username":"` + username + `","password":"` + testPassword + `","cf_turnstile_token":"` + token + `"}`
+	}
+	login := func(username, token string) string {
+		return `{"username":"` + username + `","password":"` + testPassword + `","cf_turnstile_token":"` + token + `"}`
+	}
+
+	tests := []struct {
+		name     string
+		verifier captcha.Verifier
+		token    string
+		status   int
+	}{
+		{name: "missing token", verifier: captcha.FakeVerifier{}, token: "", status: 400},
+		{name: "rejected token", verifier: captcha.FakeVerifier{}, token: captcha.FakeRejectToken, status: 400},
+		{name: "provider error", verifier: captchaDown{}, token: "token", status: 503},
+		{name: "valid token", verifier: captcha.FakeVerifier{}, token: "token", status: 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServerWith(t, func(_ *config.Config, deps *Deps) {
+				deps.Captcha = tt.verifier
+			})
+			ctx := context.Background()
+
+			srv.expect(t, tt.status, "POST", "/api/auth/register", register("alice", tt.token), session{})
+			_, err := srv.store.Users.FindByUsername(ctx, "alice")
+			if registered := err == nil; registered != (tt.status == 200) {
+				t.Errorf("alice registered = %v with status %d", registered, tt.status)
+			}
+
+			// Login diuji dengan akun yang disimpan langsung tanpa melewati captcha
+			hashed, err := utils.HashPassword(testPassword)
+			if err != nil {
+				t.Fatal(err)
+			}
+			bob := &models.User{ID: primitive.NewObjectID(), Nickname: "bob", Username: "bob", Password: hashed, Role: "user", CreatedAt: time.Now()}
+			if err := srv.store.Users.Create(ctx, bob); err != nil {
+				t.Fatal(err)
+			}
+			srv.expect(t, tt.status, "POST", "/api/auth/login", login("bob", tt.token), session{})
+
+			// Kegagalan captcha dicatat sebagai percobaan gagal; gangguan penyedia tidak
+			var want int64
+			if tt.status == 400 {
+				want = 1
+			}
+			since := time.Now().Add(-time.Hour)
+			loginFailed, err := srv.store.LoginAttempts.CountByIP(ctx, "0.0.0.0", since, "captcha_failed")
+			if err != nil {
+				t.Fatal(err)
+			}
+			registerFailed, err := srv.store.RegistrationAttempts.CountByIP(ctx, "0.0.0.0", since, "captcha_failed")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if loginFailed != want || registerFailed != want {
+				t.Errorf("captcha_failed attempts: login %d, register %d, want %d", loginFailed, registerFailed, want)
+			}
+		})
+	}
+}
+
+func TestRelapseCRUD(t *testing.T) {
+	srv := newTestServer(t)
+	alice := srv.login(t, "alice")
+	bob := srv.login(t, "bob")
+	now := time.Now().UTC().Truncate(time.Second)
+	ts := func(d time.Duration) string { return now.Add(d).Format(time.RFC3339) }
+
+	list := func(as session) []interface{} {
+		t.Helper()
+		_, body := srv.do(t, "GET", "/api/relapses", "", as)
+		relapses, _ := body.([]interface{})
+		return relapses
+	}
+
+	created := srv.expect(t, 201, "POST", "/api/relapses", `{"relapse_time":"`+ts(-48*time.Hour)+`","relapse_note":"first"}`, alice)
+	id := created["relapse"].(map[string]interface{})["_id"].(string)
+	srv.expect(t, 201, "POST", "/api/relapses", `{"relapse_time":"`+ts(-time.Hour)+`"}`, alice)
+	if got := len(list(alice)); got != 2 {
+		t.Fatalf("listed %d relapses, want 2", got)
+	}
+
+	srv.expect(t, 400, "POST", "/api/relapses", `{"relapse_time":"`+ts(time.Hour)+`"}`, alice)
+	srv.expect(t, 401, "POST", "/api/relapses", `{"relapse_time":"`+ts(-time.Hour)+`"}`, session{})
+
+	// Update dan riwayat edit
+	srv.expect(t, 200, "PUT", "/api/relapses/"+id, `{"relapse_note":"edited"}`, alice)
+	srv.expect(t, 400, "PUT", "/api/relapses/"+id, `{}`, alice)
+	srv.expect(t, 200, "GET", "/api/relapses/"+id+"/history", "", alice)
+
+	// Relapse milik user lain tidak terlihat
+	srv.expect(t, 404, "PUT", "/api/relapses/"+id, `{"relapse_note":"hijack"}`, bob)
+	srv.expect(t, 404, "DELETE", "/api/relapses/"+id, "", bob)
+	if got := len(list(bob)); got != 0 {
+		t.Errorf("bob listed %d relapses, want 0", got)
+	}
+
+	// Hapus satu lalu pulihkan
+	srv.expect(t, 200, "DELETE", "/api/relapses/"+id, "", alice)
+	if got := len(list(alice)); got != 1 {
+		t.Errorf("after delete listed %d relapses, want 1", got)
+	}
+	srv.expect(t, 200, "POST", "/api/relapses/"+id+"/restore", "", alice)
+	if got := len(list(alice)); got != 2 {
+		t.Errorf("after restore listed %d relapses, want 2", got)
+	}
+
+	// Hapus semua lalu pulihkan sekaligus
+	deleted := srv.expect(t, 200, "DELETE", "/api/relapses", "", alice)
+	if deleted["deleted_count"] != float64(2) {
+		t.Errorf("deleted_count = %v, want 2", deleted["deleted_count"])
+	}
+	if got := len(list(alice)); got != 0 {
+		t.Errorf("after delete all listed %d relapses, want 0", got)
+	}
+	restored := srv.expect(t, 200, "POST", "/api/relapses/restore", "", alice)
+	if restored["restored_count"] != float64(2) {
+		t.Errorf("restored_count = %v, want 2", restored["restored_count"])
+	}
+}
+
+func TestAdminOnlyRoutes(t *testing.T) {
+	srv := newTestServer(t)
+	boss := srv.login(t, "boss")
+	alice := srv.login(t, "alice")
+
+	routes := []struct {
+		method, path string
+	}{
+		{"GET", "/api/admin/dashboard"},
+		{"GET", "/api/admin/logs"},
+		{"GET", "/api/admin/rankings"},
+		{"GET", "/api/admin/users"},
+		{"GET", "/api/honeypot/stats"},
+	}
+	callers := []struct {
+		name   string
+		as     session
+		status int
+	}{
+		{name: "anonymous", status: 401},
+		{name: "user", as: alice, status: 403},
+		{name: "admin", as: boss, status: 200},
+	}
+
+	for _, route := range routes {
+		for _, caller := range callers {
+			t.Run(caller.name+" "+route.path, func(t *testing.T) {
+				srv.expect(t, caller.status, route.method, route.path, "", caller.as)
+			})
+		}
+	}
+}
+
+func TestAdminDeleteUser(t *testing.T) {
+	srv := newTestServer(t)
+	boss := srv.login(t, "boss")
+	chief := srv.login(t, "chief")
+	alice := srv.login(t, "alice")
+
+	id := func(as session) string {
+		t.Helper()
+		return srv.expect(t, 200, "GET", "/api/users/me", "", as)["_id"].(string)
+	}
+	aliceID := id(alice)
+
+	tests := []struct {
+		name   string
+		as     session
+		target string
+		status int
+	}{
+		{name: "not an admin", as: alice, target: id(boss), status: 403},
+		{name: "self", as: boss, target: id(boss), status: 400},
+		{name: "other admin", as: boss, target: id(chief), status: 403},
+		{name: "unknown user", as: boss, target: "000000000000000000000000", status: 404},
+		{name: "invalid id", as: boss, target: "not-an-id", status: 400},
+		{name: "regular user", as: boss, target: aliceID, status: 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv.expect(t, tt.status, "DELETE", "/api/admin/users/"+tt.target, "", tt.as)
+		})
+	}
+
+	// Sesi user yang dihapus ikut dicabut
+	srv.expect(t, 401, "GET", "/api/users/me", "", alice)
+}
+
+func TestMetricsAccess(t *testing.T) {
+	const token = "scrape-secret"
+	tests := []struct {
+		name   string
+		token  string // METRICS_TOKEN
+		auth   string // Header Authorization
+		status int
+	}{
+		{name: "no metrics token", auth: "Bearer " + token, status: 404},
+		{name: "missing auth", token: token, status: 401},
+		{name: "wrong token", token: token, auth: "Bearer wrong", status: 401},
+		{name: "valid token", token: token, auth: "Bearer " + token, status: 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServerWith(t, func(cfg *config.Config, _ *Deps) {
+				cfg.MetricsToken = tt.token
+			})
+			req := httptest.NewRequest("GET", "/metrics", nil)
+			if tt.auth != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.auth)
+			}
+			resp, err := srv.app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			raw, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d (body %s)", resp.StatusCode, tt.status, raw)
+			}
+			if exposed := strings.Contains(string(raw), "go_goroutines"); exposed != (tt.status == 200) {
+				t.Errorf("metrics exposed = %v with status %d", exposed, tt.status)
+			}
+		})
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	const secret = "dial tcp 10.0.0.5:6379: connection refused"
+	tests := []struct {
+		name   string
+		checks []health.Check
+		status int
+		want   map[string]string // Status per check
+	}{
+		{
+			name:   "all healthy",
+			status: 200,
+			want:   map[string]string{"config": health.StatusOK, "storage": health.StatusOK},
+		},
+		{
+			name:   "failing dependency",
+			checks: []health.Check{{Name: "cache", Run: func(context.Context) error { return errors.New(secret) }}},
+			status: 503,
+			want:   map[string]string{"config": health.StatusOK, "storage": health.StatusOK, "cache": health.StatusError},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := storage.NewLocal(t.TempDir(), "http://localhost")
+			if err != nil {
+				t.Fatal(err)
+			}
+			srv := newTestServerWith(t, func(_ *config.Config, deps *Deps) {
+				deps.Storage = files
+				deps.Checks = tt.checks
+			})
+
+			req := httptest.NewRequest("GET", "/api/health/ready", nil)
+			resp, err := srv.app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			raw, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d (body %s)", resp.StatusCode, tt.status, raw)
+			}
+			// Detail error hanya untuk log, tidak boleh sampai ke klien
+			if strings.Contains(string(raw), secret) || strings.Contains(string(raw), `"error":"`) {
+				t.Errorf("response leaks check error: %s", raw)
+			}
+
+			var report health.Report
+			if err := json.Unmarshal(raw, &report); err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]string{}
+			for name, result := range report.Checks {
+				got[name] = result.Status
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("checks = %v, want %v", got, tt.want)
+			}
+			if report.Healthy() != (tt.status == 200) {
+				t.Errorf("report status = %q with HTTP %d", report.Status, tt.status)
+			}
+		})
+	}
+}
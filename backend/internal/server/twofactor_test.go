@@ -0,0 +1,95 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// totpAt menghitung kode TOTP 6 digit seperti aplikasi authenticator, terpisah dari implementasi server.
+func totpAt(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatalf("decode secret: %v", err)
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(at.Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000)
+}
+
+func TestTwoFactorFlow(t *testing.T) {
+	srv := newTestServer(t)
+	alice := srv.login(t, "alice")
+
+	srv.expect(t, 400, "POST", "/api/users/2fa/confirm", `{"code":"123456"}`, alice)
+
+	setup := srv.expect(t, 200, "POST", "/api/users/2fa/setup", "", alice)
+	secret := setup["secret"].(string)
+
+	srv.expect(t, 400, "POST", "/api/users/2fa/confirm", `{"code":"000000"}`, alice)
+	now := time.Now()
+	confirmed := srv.expect(t, 200, "POST", "/api/users/2fa/confirm", `{"code":"`+totpAt(t, secret, now)+`"}`, alice)
+	raw := confirmed["recovery_codes"].([]interface{})
+	if len(raw) != 10 {
+		t.Fatalf("got %d recovery codes, want 10", len(raw))
+	}
+	recovery := raw[0].(string)
+
+	status := srv.expect(t, 200, "GET", "/api/users/2fa", "", alice)
+	if status["enabled"] != true || status["recovery_codes_remaining"] != float64(10) {
+		t.Fatalf("2fa status = %v", status)
+	}
+
+	// Login kini berhenti di challenge tanpa token sesi
+	challenge := func() string {
+		t.Helper()
+		body := srv.expect(t, 200, "POST", "/api/auth/login", `{"username":"alice","password":"`+testPassword+`"}`, session{})
+		if body["two_factor_required"] != true || body["access_token"] != nil {
+			t.Fatalf("login with 2FA enabled returned %v", body)
+		}
+		return body["challenge_token"].(string)
+	}
+	verify := func(status int, token, field, code string) map[string]interface{} {
+		t.Helper()
+		return srv.expect(t, status, "POST", "/api/auth/login/2fa", `{"challenge_token":"`+token+`","`+field+`":"`+code+`"}`, session{})
+	}
+
+	token := challenge()
+	verify(401, "not-a-token", "code", totpAt(t, secret, now))
+	verify(401, token, "code", "000000")
+	// Kode yang sudah dipakai saat konfirmasi tidak boleh dipakai lagi
+	verify(401, token, "code", totpAt(t, secret, now))
+
+	// Kode langkah berikutnya masih dalam toleransi ±1 langkah
+	next := totpAt(t, secret, now.Add(30*time.Second))
+	body := verify(200, token, "code", next)
+	if body["access_token"] == nil {
+		t.Fatalf("2fa login returned no access token: %v", body)
+	}
+	verify(401, challenge(), "code", next)
+
+	// Recovery code hanya berlaku sekali, dan boleh diketik tanpa tanda hubung
+	verify(200, challenge(), "recovery_code", recovery[:5]+recovery[6:])
+	verify(401, challenge(), "recovery_code", recovery)
+	status = srv.expect(t, 200, "GET", "/api/users/2fa", "", alice)
+	if status["recovery_codes_remaining"] != float64(9) {
+		t.Errorf("recovery_codes_remaining = %v, want 9", status["recovery_codes_remaining"])
+	}
+
+	srv.expect(t, 400, "POST", "/api/users/2fa/disable", `{"password":"wrong-password"}`, alice)
+	srv.expect(t, 200, "POST", "/api/users/2fa/disable", `{"password":"`+testPassword+`"}`, alice)
+
+	// Setelah dinonaktifkan, login kembali langsung membuat sesi
+	again := srv.signIn(t, "alice")
+	srv.expect(t, 200, "GET", "/api/users/me", "", again)
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/store"
+	"solivra-go/backend/pkg/utils"
+)
+
+const secondsPerDay = 24 * 60 * 60
+
+// achievementKey mengidentifikasi satu badge milik user (HabitID kosong untuk badge non-habit).
+type achievementKey struct {
+	habitID primitive.ObjectID
+	code    string
+}
+
+// habitAchievements mengembalikan badge per habit yang sudah tercapai menurut ringkasan streak.
+// Badge streak dan minggu pertama memakai streak bersih terpanjang, jadi streak yang sempat
+// tercapai tetap dihitung meskipun evaluasi baru berjalan setelah relapse.
+func habitAchievements(summary *models.StreakSummary, now time.Time) []models.Achievement {
+	current := summary.CurrentSeconds(now)
+	longest := summary.LongestSeconds(now)
+
+	var earned []models.Achievement
+	for _, def := range models.AchievementCatalog {
+		if !def.PerHabit {
+			continue
+		}
+		var value int64
+		switch {
+		case def.Category == models.AchievementCategoryStreak, def.Code == models.AchievementFirstWeek:
+			value = longest
+		case def.Code == models.AchievementPersonalBest:
+			// Hanya bila ada rekor sebelumnya (streak yang diakhiri relapse) untuk dikalahkan
+			if summary.LongestCompletedSeconds == 0 || current <= summary.LongestCompletedSeconds {
+				continue
+			}
+			value = current
+		}
+		if value < int64(def.Days)*secondsPerDay {
+			continue
+		}
+		earned = append(earned, models.Achievement{
+			HabitID:  summary.HabitID,
+			Code:     def.Code,
+			Category: def.Category,
+			Value:    value,
+		})
+	}
+	return earned
+}
+
+// journalAchievements mengembalikan badge jurnal untuk jumlah hari dengan catatan relapse.
+func journalAchievements(days int) []models.Achievement {
+	var earned []models.Achievement
+	for _, def := range models.AchievementCatalog {
+		if def.Category == models.AchievementCategoryJournal && days >= def.Days {
+			earned = append(earned, models.Achievement{Code: def.Code, Category: def.Category, Value: int64(days)})
+		}
+	}
+	return earned
+}
+
+// EvaluateAchievements memeriksa semua achievement user pada waktu now dan menyimpan
+// yang baru tercapai. Setiap badge baru dicatat sebagai activity log "milestone_reached".
+// Aman dipanggil berulang kali; badge yang sudah dimiliki tidak ditulis ulang.
+func EvaluateAchievements(ctx context.Context, s *store.Stores, userID primitive.ObjectID, now time.Time) ([]models.Achievement, error) {
+	user, err := s.Users.FindByID(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.Achievements.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	owned := make(map[achievementKey]bool, len(existing))
+	for _, a := range existing {
+		owned[achievementKey{a.HabitID, a.Code}] = true
+	}
+
+	habits, err := s.Habits.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	habitNames := make(map[primitive.ObjectID]string, len(habits))
+	var candidates []models.Achievement
+	for _, habit := range habits {
+		habitNames[habit.ID] = habit.Name
+		if habit.StreakStartDate == nil {
+			continue
+		}
+		summary, err := s.StreakSummaries.Get(ctx, habit.ID)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, habitAchievements(summary, now)...)
+	}
+
+	journalDays, err := s.Relapses.CountNoteDays(ctx, userID, user.Location())
+	if err != nil {
+		return nil, err
+	}
+	candidates = append(candidates, journalAchievements(journalDays)...)
+
+	awarded := []models.Achievement{}
+	for _, a := range candidates {
+		if owned[achievementKey{a.HabitID, a.Code}] {
+			continue
+		}
+		a.UserID = userID
+		a.AwardedAt = now
+		ok, err := s.Achievements.Award(ctx, &a)
+		if err != nil {
+			return awarded, err
+		}
+		if !ok {
+			continue
+		}
+		awarded = append(awarded, a)
+
+		details := map[string]interface{}{
+			"code":     a.Code,
+			"category": a.Category,
+			"value":    a.Value,
+		}
+		if !a.HabitID.IsZero() {
+			details["habit_id"] = a.HabitID.Hex()
+			details["habit_name"] = habitNames[a.HabitID]
+		}
+		utils.LogActivityInternal(ctx, "milestone_reached", details, map[string]interface{}{
+			"userId":   userID.Hex(),
+			"username": user.Username,
+		})
+	}
+	return awarded, nil
+}
+
+// EvaluateAllAchievements menjalankan EvaluateAchievements untuk setiap user yang bisa
+// mendapat badge: punya habit dengan streak berjalan atau punya catatan relapse (badge
+// jurnal), lalu mengembalikan jumlah badge baru.
+func EvaluateAllAchievements(ctx context.Context, s *store.Stores, now time.Time) (int, error) {
+	habits, err := s.Habits.ListWithStreak(ctx)
+	if err != nil {
+		return 0, err
+	}
+	journalUsers, err := s.Relapses.ListNoteUserIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	userIDs := make([]primitive.ObjectID, 0, len(habits)+len(journalUsers))
+	for _, habit := range habits {
+		userIDs = append(userIDs, habit.UserID)
+	}
+	userIDs = append(userIDs, journalUsers...)
+
+	seen := make(map[primitive.ObjectID]bool)
+	total := 0
+	for _, userID := range userIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		awarded, err := EvaluateAchievements(ctx, s, userID, now)
+		if err != nil {
+			return total, err
+		}
+		total += len(awarded)
+	}
+	return total, nil
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/store"
+)
+
+const day = 24 * time.Hour
+
+func codes(achievements []models.Achievement) []string {
+	var result []string
+	for _, a := range achievements {
+		result = append(result, a.Code)
+	}
+	return result
+}
+
+func TestHabitAchievements(t *testing.T) {
+	now := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name            string
+		start           time.Time     // StreakStartDate
+		current         time.Time     // CurrentStreakStart
+		longestComplete time.Duration // LongestCompletedSeconds
+		storedLongest   time.Duration // StoredLongestSeconds
+		want            []string
+	}{
+		{
+			// Dilacak sebulan, tapi tidak pernah seminggu tanpa relapse
+			name:            "relapsed daily since tracking started",
+			start:           now.Add(-30 * day),
+			current:         now.Add(-12 * time.Hour),
+			longestComplete: day,
+		},
+		{
+			name:    "first clean week",
+			start:   now.Add(-8 * day),
+			current: now.Add(-8 * day),
+			want:    []string{models.AchievementFirstWeek, "streak_7_days"},
+		},
+		{
+			name:    "six clean days",
+			start:   now.Add(-6 * day),
+			current: now.Add(-6 * day),
+		},
+		{
+			// Streak yang sudah tercapai tetap dihitung setelah relapse
+			name:            "record kept after relapse",
+			start:           now.Add(-20 * day),
+			current:         now.Add(-time.Hour),
+			longestComplete: 15 * day,
+			want:            []string{models.AchievementFirstWeek, "streak_7_days", "streak_14_days"},
+		},
+		{
+			name:            "running streak beats record",
+			start:           now.Add(-20 * day),
+			current:         now.Add(-10 * day),
+			longestComplete: 8 * day,
+			want:            []string{models.AchievementFirstWeek, "streak_7_days", models.AchievementPersonalBest},
+		},
+		{
+			// Tanpa relapse tidak ada rekor sebelumnya untuk dikalahkan
+			name:    "no relapse is not a personal best",
+			start:   now.Add(-20 * day),
+			current: now.Add(-20 * day),
+			want:    []string{models.AchievementFirstWeek, "streak_7_days", "streak_14_days"},
+		},
+		{
+			name:          "stored longest survives relapse deletes",
+			start:         now.Add(-2 * day),
+			current:       now.Add(-2 * day),
+			storedLongest: 31 * day,
+			want:          []string{models.AchievementFirstWeek, "streak_7_days", "streak_14_days", "streak_30_days"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary := &models.StreakSummary{
+				HabitID:                 primitive.NewObjectID(),
+				StreakStartDate:         tt.start,
+				CurrentStreakStart:      tt.current,
+				LongestCompletedSeconds: seconds(tt.longestComplete),
+				StoredLongestSeconds:    seconds(tt.storedLongest),
+			}
+			got := habitAchievements(summary, now)
+			if !reflect.DeepEqual(codes(got), tt.want) {
+				t.Errorf("codes = %v, want %v", codes(got), tt.want)
+			}
+			for _, a := range got {
+				if a.HabitID != summary.HabitID {
+					t.Errorf("%s: HabitID = %v, want %v", a.Code, a.HabitID, summary.HabitID)
+				}
+			}
+		})
+	}
+}
+
+func TestJournalAchievements(t *testing.T) {
+	tests := []struct {
+		days int
+		want []string
+	}{
+		{days: 0},
+		{days: 6},
+		{days: 7, want: []string{"journal_7_days"}},
+		{days: 30, want: []string{"journal_7_days", "journal_30_days"}},
+	}
+	for _, tt := range tests {
+		got := journalAchievements(tt.days)
+		if !reflect.DeepEqual(codes(got), tt.want) {
+			t.Errorf("journalAchievements(%d) = %v, want %v", tt.days, codes(got), tt.want)
+		}
+		for _, a := range got {
+			if !a.HabitID.IsZero() || a.Value != int64(tt.days) {
+				t.Errorf("journalAchievements(%d): %s has habit %v, value %d", tt.days, a.Code, a.HabitID, a.Value)
+			}
+		}
+	}
+}
+
+func TestEvaluateAllAchievements(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemory()
+	now := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+
+	// Streak bersih 15 hari, tanpa catatan
+	runner := &models.User{ID: primitive.NewObjectID(), Username: "runner"}
+	start := now.Add(-15 * day)
+	streakHabit := &models.Habit{ID: primitive.NewObjectID(), UserID: runner.ID, Kind: models.HabitKindGeneral, IsDefault: true, StreakStartDate: &start}
+	summary := &models.StreakSummary{HabitID: streakHabit.ID, UserID: runner.ID, StreakStartDate: start, CurrentStreakStart: start}
+
+	// Hanya menulis jurnal; streak belum dimulai sehingga tidak muncul di ListWithStreak
+	writer := &models.User{ID: primitive.NewObjectID(), Username: "writer"}
+	journalHabit := &models.Habit{ID: primitive.NewObjectID(), UserID: writer.ID, Kind: models.HabitKindGeneral, IsDefault: true}
+
+	for _, u := range []*models.User{runner, writer} {
+		if err := s.Users.Create(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, h := range []*models.Habit{streakHabit, journalHabit} {
+		if err := s.Habits.Create(ctx, h); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.StreakSummaries.Upsert(ctx, summary); err != nil {
+		t.Fatal(err)
+	}
+	note, empty := "triggered by stress", ""
+	for i := 0; i < 8; i++ {
+		r := &models.RelapseLog{ID: primitive.NewObjectID(), UserID: writer.ID, HabitID: journalHabit.ID, RelapseTime: now.Add(-time.Duration(i) * day), RelapseNote: &note}
+		if i == 7 {
+			r.RelapseNote = &empty // Catatan kosong tidak dihitung
+		}
+		if err := s.Relapses.Create(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	total, err := EvaluateAllAchievements(ctx, s, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 4 {
+		t.Errorf("first run awarded %d, want 4", total)
+	}
+	want := map[*models.User][]string{
+		runner: {models.AchievementFirstWeek, "streak_7_days", "streak_14_days"},
+		writer: {"journal_7_days"},
+	}
+	for user, codesWant := range want {
+		got, err := s.Achievements.ListByUser(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(codes(got), codesWant) {
+			t.Errorf("%s has %v, want %v", user.Username, codes(got), codesWant)
+		}
+	}
+
+	// Evaluasi ulang tidak memberi badge ganda
+	total, err = EvaluateAllAchievements(ctx, s, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 0 {
+		t.Errorf("second run awarded %d, want 0", total)
+	}
+	awarded, err := EvaluateAchievements(ctx, s, runner.ID, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(awarded) != 0 {
+		t.Errorf("re-evaluating runner awarded %v", codes(awarded))
+	}
+	got, err := s.Achievements.ListByUser(ctx, runner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Errorf("runner has %d achievements after re-evaluation, want 3", len(got))
+	}
+}
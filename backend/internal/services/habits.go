@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/store"
+)
+
+// DefaultHabitName adalah nama habit default yang dibuat dari streak lama user.
+const DefaultHabitName = "Kebiasaan Utama"
+
+// EnsureDefaultHabit mengembalikan habit default user, membuatnya jika belum ada.
+// Habit baru mewarisi streak_start_date dan longest_streak_seconds user, dan semua
+// relapse user yang belum punya habit dipasangkan ke habit tersebut, lalu ringkasan
+// streak-nya dibangun.
+func EnsureDefaultHabit(ctx context.Context, s *store.Stores, user *models.User) (*models.Habit, error) {
+	now := time.Now()
+	habit, created, err := s.Habits.EnsureDefault(ctx, &models.Habit{
+		UserID:               user.ID,
+		Name:                 DefaultHabitName,
+		Kind:                 models.HabitKindGeneral,
+		StreakStartDate:      user.StreakStartDate,
+		LongestStreakSeconds: user.LongestStreakSeconds,
+		Settings:             models.HabitSettings{ShowInRankings: true},
+		CreatedAt:            now,
+		UpdatedAt:            now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		if _, err := s.Relapses.AssignHabit(ctx, user.ID, habit.ID); err != nil {
+			return nil, err
+		}
+		if err := RefreshStreakSummary(ctx, s, user.ID, habit.ID); err != nil {
+			return nil, err
+		}
+	}
+	return habit, nil
+}
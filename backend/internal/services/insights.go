@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/store"
+	"solivra-go/backend/internal/streaks"
+)
+
+const (
+	// insightWeeks dan insightMonths adalah panjang seri frekuensi (termasuk periode berjalan).
+	insightWeeks  = 12
+	insightMonths = 12
+	// insightTrendDays adalah jendela yang menentukan Insights.Trend.
+	insightTrendDays = 30
+)
+
+// insightWindows adalah panjang jendela tren dalam hari.
+var insightWindows = []int{7, 30, 90}
+
+// Arah tren: jumlah relapse jendela terbaru dibandingkan jendela sebelumnya.
+const (
+	TrendImproving    = "improving"
+	TrendWorsening    = "worsening"
+	TrendStable       = "stable"
+	TrendInsufficient = "insufficient_data" // Streak dimulai setelah awal jendela sebelumnya
+)
+
+// StreakInsights meringkas panjang streak dalam detik. Average dan Median dihitung dari
+// streak yang sudah selesai; jika belum ada, keduanya sama dengan Current.
+type StreakInsights struct {
+	Current   int64 `json:"current"`
+	Longest   int64 `json:"longest"`
+	Completed int   `json:"completed"`
+	Average   int64 `json:"average"`
+	Median    int64 `json:"median"`
+}
+
+// FrequencyInsights adalah frekuensi relapse. PerWeek dan PerMonth dirata-rata sejak
+// streak dimulai; Weekly dan Monthly berisi periode terakhir, termasuk yang nol.
+type FrequencyInsights struct {
+	PerWeek  float64             `json:"per_week"`
+	PerMonth float64             `json:"per_month"`
+	Weekly   []store.PeriodCount `json:"weekly"`
+	Monthly  []store.PeriodCount `json:"monthly"`
+}
+
+// HeatmapInsights adalah sebaran relapse per hari (0 = Senin) dan jam pada zona user.
+type HeatmapInsights struct {
+	ByWeekday []int      `json:"by_weekday"`
+	ByHour    []int      `json:"by_hour"`
+	Matrix    [7][24]int `json:"matrix"`
+}
+
+// TrendWindow membandingkan jumlah relapse Days hari terakhir dengan Days hari sebelumnya.
+type TrendWindow struct {
+	Days      int      `json:"days"`
+	Recent    int      `json:"recent"`
+	Previous  int      `json:"previous"`
+	Change    *float64 `json:"change_percent"` // nil jika Previous nol
+	Direction string   `json:"direction"`
+}
+
+// CommunityInsights membandingkan streak berjalan dengan habit sejenis milik user lain.
+// Percentile adalah persentase habit lain yang streaknya lebih pendek; nil jika belum
+// ada pembanding.
+type CommunityInsights struct {
+	Rank       int64    `json:"rank"`
+	Total      int64    `json:"total"`
+	Percentile *float64 `json:"percentile"`
+}
+
+// Insights adalah respons GET /api/stats/insights.
+type Insights struct {
+	HabitID       primitive.ObjectID `json:"habit_id"`
+	Timezone      string             `json:"timezone"`
+	StreakStarted bool               `json:"streakStarted"`
+	TotalRelapses int                `json:"total_relapses"`
+	Streaks       StreakInsights     `json:"streaks"`
+	Frequency     FrequencyInsights  `json:"frequency"`
+	Heatmap       HeatmapInsights    `json:"heatmap"`
+	Trend         string             `json:"trend"`
+	Windows       []TrendWindow      `json:"windows"`
+	// Community nil untuk habit custom atau habit yang belum punya ringkasan streak.
+	Community   *CommunityInsights `json:"community"`
+	GeneratedAt time.Time          `json:"generated_at"`
+}
+
+// BuildInsights menghitung analitik pribadi satu habit. Relapse diagregasi oleh store
+// (pipeline Mongo) sehingga tidak ada relapse yang dimuat ke memori.
+func BuildInsights(ctx context.Context, s *store.Stores, habit *models.Habit, loc *time.Location, now time.Time) (*Insights, error) {
+	weeksSince := streaks.Week.Start(now, loc).AddDate(0, 0, -7*(insightWeeks-1))
+	monthsSince := streaks.Month.Start(now, loc).AddDate(0, -(insightMonths - 1), 0)
+
+	insights := &Insights{
+		HabitID:     habit.ID,
+		Timezone:    loc.String(),
+		Trend:       TrendInsufficient,
+		Windows:     make([]TrendWindow, 0, len(insightWindows)),
+		GeneratedAt: now,
+	}
+	if habit.StreakStartDate == nil {
+		insights.Frequency = frequency(&store.RelapseInsights{}, weeksSince, monthsSince, now, 0, loc)
+		insights.Heatmap = heatmap([7][24]int{})
+		return insights, nil
+	}
+	start := *habit.StreakStartDate
+	insights.StreakStarted = true
+
+	windows := make([]time.Duration, len(insightWindows))
+	for i, days := range insightWindows {
+		windows[i] = time.Duration(days) * 24 * time.Hour
+	}
+	agg, err := s.Relapses.Insights(ctx, store.InsightsQuery{
+		HabitID:     habit.ID,
+		Start:       start,
+		Location:    loc,
+		WeeksSince:  weeksSince,
+		MonthsSince: monthsSince,
+		Now:         now,
+		Windows:     windows,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	insights.TotalRelapses = agg.Total
+	insights.Streaks = streakInsights(agg, start, habit.LongestStreakSeconds, now)
+	insights.Frequency = frequency(agg, weeksSince, monthsSince, now, now.Sub(start), loc)
+	insights.Heatmap = heatmap(agg.Heatmap)
+	for i, w := range agg.Windows {
+		window := trendWindow(insightWindows[i], w, start, now)
+		if window.Days == insightTrendDays {
+			insights.Trend = window.Direction
+		}
+		insights.Windows = append(insights.Windows, window)
+	}
+
+	insights.Community, err = community(ctx, s, habit)
+	if err != nil {
+		return nil, err
+	}
+	return insights, nil
+}
+
+func streakInsights(agg *store.RelapseInsights, start time.Time, storedLongest int64, now time.Time) StreakInsights {
+	currentStart := start
+	if agg.LastRelapse != nil && agg.LastRelapse.After(start) {
+		currentStart = *agg.LastRelapse
+	}
+	st := StreakInsights{
+		Current:   max(0, int64(now.Sub(currentStart)/time.Second)),
+		Completed: agg.Total,
+		Average:   agg.AverageStreak,
+		Median:    agg.MedianStreak,
+	}
+	st.Longest = max(st.Current, agg.LongestStreak, storedLongest)
+	if agg.Total == 0 {
+		st.Average = st.Current
+		st.Median = st.Current
+	}
+	return st
+}
+
+// frequency menghitung rata-rata relapse per minggu/bulan selama tracked dan mengisi
+// seri periode yang kosong dengan nol.
+func frequency(agg *store.RelapseInsights, weeksSince, monthsSince, now time.Time, tracked time.Duration, loc *time.Location) FrequencyInsights {
+	const week = 7 * 24 * time.Hour
+	const month = 30.436875 * 24 * float64(time.Hour) // Rata-rata panjang bulan
+
+	return FrequencyInsights{
+		PerWeek:  rate(agg.Total, math.Max(1, float64(tracked)/float64(week))),
+		PerMonth: rate(agg.Total, math.Max(1, float64(tracked)/month)),
+		Weekly:   fillPeriods(agg.Weekly, weeksSince, now, loc, func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }),
+		Monthly:  fillPeriods(agg.Monthly, monthsSince, now, loc, func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }),
+	}
+}
+
+// rate mengembalikan count/periods dibulatkan dua desimal.
+func rate(count int, periods float64) float64 {
+	return math.Round(float64(count)/periods*100) / 100
+}
+
+// fillPeriods menyusun seri dari since sampai periode yang memuat now, pada zona loc.
+func fillPeriods(counts []store.PeriodCount, since, now time.Time, loc *time.Location, next func(time.Time) time.Time) []store.PeriodCount {
+	byStart := make(map[int64]int, len(counts))
+	for _, p := range counts {
+		byStart[p.Start.Unix()] = p.Count
+	}
+	var periods []store.PeriodCount
+	for t := since; !t.After(now); t = next(t) {
+		periods = append(periods, store.PeriodCount{Start: t.In(loc), Count: byStart[t.Unix()]})
+	}
+	return periods
+}
+
+func heatmap(matrix [7][24]int) HeatmapInsights {
+	h := HeatmapInsights{ByWeekday: make([]int, 7), ByHour: make([]int, 24), Matrix: matrix}
+	for day, hours := range matrix {
+		for hour, count := range hours {
+			h.ByWeekday[day] += count
+			h.ByHour[hour] += count
+		}
+	}
+	return h
+}
+
+func trendWindow(days int, w store.WindowCount, start, now time.Time) TrendWindow {
+	window := TrendWindow{Days: days, Recent: w.Recent, Previous: w.Previous}
+	if w.Previous > 0 {
+		change := math.Round(float64(w.Recent-w.Previous)/float64(w.Previous)*1000) / 10
+		window.Change = &change
+	}
+	switch {
+	case start.After(now.Add(-2 * w.Window)):
+		window.Direction = TrendInsufficient
+	case w.Recent < w.Previous:
+		window.Direction = TrendImproving
+	case w.Recent > w.Previous:
+		window.Direction = TrendWorsening
+	default:
+		window.Direction = TrendStable
+	}
+	return window
+}
+
+// community menghitung posisi streak berjalan habit di antara habit sejenis (habit default
+// dibandingkan dengan habit default, jenis lain dengan jenis yang sama), termasuk user
+// yang menyembunyikan diri dari ranking karena tidak ada data user lain yang ditampilkan.
+func community(ctx context.Context, s *store.Stores, habit *models.Habit) (*CommunityInsights, error) {
+	filter := store.RankingFilter{}
+	if !habit.IsDefault {
+		if habit.Kind == models.HabitKindCustom {
+			return nil, nil
+		}
+		filter.HabitKind = habit.Kind
+	}
+
+	rank, err := s.StreakSummaries.Rank(ctx, filter, habit.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.StreakSummaries.Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &CommunityInsights{Rank: rank, Total: total}
+	if total > 1 {
+		percentile := math.Round(float64(total-rank)/float64(total-1)*1000) / 10
+		c.Percentile = &percentile
+	}
+	return c, nil
+}
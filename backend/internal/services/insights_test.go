@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/store"
+)
+
+// insightStores mengembalikan store yang diuji: in-memory selalu, dan MongoDB (database
+// sementara) jika SOLIVRA_TEST_MONGO_URI diisi, agar pipeline $facet dicek dengan data
+// dan ekspektasi yang sama.
+func insightStores(t *testing.T) map[string]func(t *testing.T) *store.Stores {
+	t.Helper()
+	stores := map[string]func(t *testing.T) *store.Stores{
+		"memory": func(*testing.T) *store.Stores { return store.NewMemory() },
+	}
+	uri := os.Getenv("SOLIVRA_TEST_MONGO_URI")
+	if uri == "" {
+		return stores
+	}
+	stores["mongo"] = func(t *testing.T) *store.Stores {
+		ctx := context.Background()
+		client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+		if err != nil {
+			t.Fatal(err)
+		}
+		db := client.Database("solivra_test_" + primitive.NewObjectID().Hex())
+		t.Cleanup(func() {
+			db.Drop(ctx)
+			client.Disconnect(ctx)
+		})
+		return store.NewMongo(db)
+	}
+	return stores
+}
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func seconds(d time.Duration) int64 { return int64(d / time.Second) }
+
+// addHabit menyimpan habit yang streaknya dimulai pada start (nil: belum dimulai) beserta relapse-nya.
+func addHabit(t *testing.T, s *store.Stores, start *time.Time, relapses []time.Time) *models.Habit {
+	t.Helper()
+	ctx := context.Background()
+	habit := &models.Habit{ID: primitive.NewObjectID(), UserID: primitive.NewObjectID(), Kind: models.HabitKindGeneral, IsDefault: true, StreakStartDate: start}
+	if err := s.Habits.Create(ctx, habit); err != nil {
+		t.Fatal(err)
+	}
+	for _, at := range relapses {
+		r := &models.RelapseLog{ID: primitive.NewObjectID(), UserID: habit.UserID, HabitID: habit.ID, RelapseTime: at.UTC(), CreatedAt: at, UpdatedAt: at}
+		if err := s.Relapses.Create(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return habit
+}
+
+func TestBuildInsights(t *testing.T) {
+	jakarta := mustLocation(t, "Asia/Jakarta")
+	jkt := func(m time.Month, d, h, min int) time.Time { return time.Date(2024, m, d, h, min, 0, 0, jakarta) }
+
+	now := jkt(time.May, 15, 12, 0) // Rabu
+	start := jkt(time.February, 1, 0, 0)
+	a := jkt(time.February, 3, 0, 0) // Sabtu
+	b := jkt(time.February, 9, 0, 0) // Jumat
+	c := jkt(time.April, 29, 6, 30)  // Senin di Jakarta, masih Minggu di UTC
+	d := jkt(time.May, 1, 0, 30)     // Mei di Jakarta, masih April di UTC
+	e := jkt(time.May, 12, 23, 0)    // Minggu
+	// Streak selesai, diurutkan: d-c, a-start, b-a, e-d, c-b
+	completed := []int64{seconds(d.Sub(c)), seconds(a.Sub(start)), seconds(b.Sub(a)), seconds(e.Sub(d)), seconds(c.Sub(b))}
+	var sum int64
+	for _, s := range completed {
+		sum += s
+	}
+
+	for name, newStore := range insightStores(t) {
+		t.Run(name, func(t *testing.T) {
+			s := newStore(t)
+			// Relapse disimpan tidak berurutan
+			habit := addHabit(t, s, &start, []time.Time{e, a, c, b, d})
+
+			got, err := BuildInsights(context.Background(), s, habit, jakarta, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !got.StreakStarted || got.TotalRelapses != 5 || got.Timezone != "Asia/Jakarta" {
+				t.Errorf("header = started %v, total %d, timezone %q", got.StreakStarted, got.TotalRelapses, got.Timezone)
+			}
+			wantStreaks := StreakInsights{
+				Current:   seconds(now.Sub(e)),
+				Longest:   seconds(c.Sub(b)),
+				Completed: 5,
+				Average:   sum / 5,
+				Median:    completed[2],
+			}
+			if got.Streaks != wantStreaks {
+				t.Errorf("Streaks = %+v, want %+v", got.Streaks, wantStreaks)
+			}
+
+			if got.Frequency.PerWeek != 0.33 || got.Frequency.PerMonth != 1.46 {
+				t.Errorf("PerWeek/PerMonth = %v/%v, want 0.33/1.46", got.Frequency.PerWeek, got.Frequency.PerMonth)
+			}
+			// 12 minggu (mulai Senin, zona Jakarta) sampai minggu berjalan, termasuk yang nol
+			wantWeekly := map[time.Time]int{jkt(time.April, 29, 0, 0): 2, jkt(time.May, 6, 0, 0): 1}
+			checkSeries(t, "weekly", got.Frequency.Weekly, jkt(time.February, 26, 0, 0), 12, jakarta,
+				func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }, wantWeekly)
+			wantMonthly := map[time.Time]int{jkt(time.February, 1, 0, 0): 2, jkt(time.April, 1, 0, 0): 1, jkt(time.May, 1, 0, 0): 2}
+			checkSeries(t, "monthly", got.Frequency.Monthly, time.Date(2023, time.June, 1, 0, 0, 0, 0, jakarta), 12, jakarta,
+				func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }, wantMonthly)
+
+			// Senin = 0 ... Minggu = 6, jam lokal
+			var wantMatrix [7][24]int
+			wantMatrix[5][0]++  // a
+			wantMatrix[4][0]++  // b
+			wantMatrix[0][6]++  // c
+			wantMatrix[2][0]++  // d
+			wantMatrix[6][23]++ // e
+			if got.Heatmap.Matrix != wantMatrix {
+				t.Errorf("Heatmap.Matrix = %v, want %v", got.Heatmap.Matrix, wantMatrix)
+			}
+			if want := []int{1, 0, 1, 0, 1, 1, 1}; !reflect.DeepEqual(got.Heatmap.ByWeekday, want) {
+				t.Errorf("ByWeekday = %v, want %v", got.Heatmap.ByWeekday, want)
+			}
+			wantHours := make([]int, 24)
+			wantHours[0], wantHours[6], wantHours[23] = 3, 1, 1
+			if !reflect.DeepEqual(got.Heatmap.ByHour, wantHours) {
+				t.Errorf("ByHour = %v, want %v", got.Heatmap.ByHour, wantHours)
+			}
+
+			fifty := 50.0
+			wantWindows := []TrendWindow{
+				{Days: 7, Recent: 1, Previous: 0, Direction: TrendWorsening},
+				{Days: 30, Recent: 3, Previous: 0, Direction: TrendWorsening},
+				// Jendela sebelumnya dimulai sebelum streak: datanya belum cukup
+				{Days: 90, Recent: 3, Previous: 2, Change: &fifty, Direction: TrendInsufficient},
+			}
+			if !reflect.DeepEqual(got.Windows, wantWindows) {
+				t.Errorf("Windows = %+v, want %+v", got.Windows, wantWindows)
+			}
+			if got.Trend != TrendWorsening {
+				t.Errorf("Trend = %q, want %q", got.Trend, TrendWorsening)
+			}
+			if got.Community != nil {
+				t.Errorf("Community = %+v, want nil without streak summaries", got.Community)
+			}
+		})
+	}
+}
+
+func checkSeries(t *testing.T, name string, got []store.PeriodCount, since time.Time, n int, loc *time.Location, next func(time.Time) time.Time, counts map[time.Time]int) {
+	t.Helper()
+	if len(got) != n {
+		t.Fatalf("%s has %d periods, want %d", name, len(got), n)
+	}
+	want := since
+	for i, p := range got {
+		if !p.Start.Equal(want) || p.Start.Location() != loc {
+			t.Errorf("%s[%d].Start = %v, want %v", name, i, p.Start, want)
+		}
+		if p.Count != counts[want] {
+			t.Errorf("%s[%d] (%s) count = %d, want %d", name, i, want.Format("2006-01-02"), p.Count, counts[want])
+		}
+		want = next(want)
+	}
+}
+
+func TestBuildInsightsInsufficientData(t *testing.T) {
+	now := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+
+	for name, newStore := range insightStores(t) {
+		t.Run(name, func(t *testing.T) {
+			s := newStore(t)
+
+			// Streak baru 20 hari: jendela 30 hari sebelumnya belum tercakup
+			start := now.AddDate(0, 0, -20)
+			habit := addHabit(t, s, &start, []time.Time{now.AddDate(0, 0, -3)})
+			got, err := BuildInsights(context.Background(), s, habit, time.UTC, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Trend != TrendInsufficient {
+				t.Errorf("Trend = %q, want %q", got.Trend, TrendInsufficient)
+			}
+			// Satu streak selesai: rata-rata dan median sama dengan streak itu
+			if want := seconds(17 * 24 * time.Hour); got.Streaks.Average != want || got.Streaks.Median != want {
+				t.Errorf("Average/Median = %d/%d, want %d", got.Streaks.Average, got.Streaks.Median, want)
+			}
+
+			// Tanpa relapse: rata-rata dan median sama dengan streak berjalan
+			clean := addHabit(t, s, &start, nil)
+			got, err = BuildInsights(context.Background(), s, clean, time.UTC, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cur := seconds(20 * 24 * time.Hour); got.Streaks != (StreakInsights{Current: cur, Longest: cur, Average: cur, Median: cur}) {
+				t.Errorf("Streaks = %+v, want all %d", got.Streaks, cur)
+			}
+
+			// Streak belum dimulai: seri tetap lengkap dan bernilai nol
+			idle := addHabit(t, s, nil, nil)
+			got, err = BuildInsights(context.Background(), s, idle, time.UTC, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.StreakStarted || got.Trend != TrendInsufficient || len(got.Windows) != 0 {
+				t.Errorf("idle habit = started %v, trend %q, %d windows", got.StreakStarted, got.Trend, len(got.Windows))
+			}
+			if len(got.Frequency.Weekly) != insightWeeks || len(got.Frequency.Monthly) != insightMonths {
+				t.Errorf("idle habit has %d weeks and %d months, want %d and %d", len(got.Frequency.Weekly), len(got.Frequency.Monthly), insightWeeks, insightMonths)
+			}
+		})
+	}
+}
+
+func TestTrendWindow(t *testing.T) {
+	now := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+	old := now.AddDate(-1, 0, 0)
+	window := 30 * 24 * time.Hour
+
+	tests := []struct {
+		name             string
+		start            time.Time
+		recent, previous int
+		direction        string
+		change           *float64
+	}{
+		{name: "improving", start: old, recent: 1, previous: 4, direction: TrendImproving, change: ptr(-75.0)},
+		{name: "worsening", start: old, recent: 3, previous: 2, direction: TrendWorsening, change: ptr(50.0)},
+		{name: "stable", start: old, recent: 2, previous: 2, direction: TrendStable, change: ptr(0.0)},
+		{name: "no previous relapses", start: old, recent: 2, previous: 0, direction: TrendWorsening},
+		{name: "started inside previous window", start: now.Add(-2*window + time.Hour), recent: 0, previous: 1, direction: TrendInsufficient, change: ptr(-100.0)},
+		{name: "started exactly at previous window", start: now.Add(-2 * window), recent: 0, previous: 1, direction: TrendImproving, change: ptr(-100.0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := trendWindow(30, store.WindowCount{Window: window, Recent: tt.recent, Previous: tt.previous}, tt.start, now)
+			if got.Direction != tt.direction {
+				t.Errorf("Direction = %q, want %q", got.Direction, tt.direction)
+			}
+			if !reflect.DeepEqual(got.Change, tt.change) {
+				t.Errorf("Change = %v, want %v", got.Change, tt.change)
+			}
+		})
+	}
+}
+
+func ptr(f float64) *float64 { return &f }
@@ -0,0 +1,14 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"solivra-go/backend/internal/store"
+)
+
+// PurgeDeletedRelapses menghapus permanen relapse yang di-soft delete lebih lama dari
+// retention. Ringkasan streak tidak berubah karena relapse terhapus sudah diabaikan.
+func PurgeDeletedRelapses(ctx context.Context, s *store.Stores, retention time.Duration, now time.Time) (int, error) {
+	return s.Relapses.PurgeDeleted(ctx, now.Add(-retention), now)
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"math"
+	"sort"
+
+	"solivra-go/backend/internal/models"
+)
+
+// maxBreakdownTags membatasi jumlah tag teratas di ringkasan metadata.
+const maxBreakdownTags = 10
+
+// CountEntry adalah jumlah relapse untuk satu nilai metadata.
+type CountEntry struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// ScaleStats meringkas nilai skala (mood atau intensitas). Distribution[i] adalah jumlah
+// relapse dengan nilai min+i; Average nil bila belum ada relapse yang mengisinya.
+type ScaleStats struct {
+	Count        int      `json:"count"`
+	Average      *float64 `json:"average"`
+	Distribution []int    `json:"distribution"`
+}
+
+// RelapseBreakdown meringkas metadata relapse satu habit untuk halaman statistik.
+type RelapseBreakdown struct {
+	// Tracked adalah jumlah relapse yang punya minimal satu metadata.
+	Tracked    int          `json:"tracked"`
+	Triggers   []CountEntry `json:"triggers"`
+	Locations  []CountEntry `json:"locations"`
+	Tags       []CountEntry `json:"tags"`
+	MoodBefore ScaleStats   `json:"mood_before"`
+	MoodAfter  ScaleStats   `json:"mood_after"`
+	// AverageMoodChange adalah rata-rata (mood_after - mood_before) dari relapse yang
+	// mengisi keduanya; negatif berarti mood memburuk setelah relapse.
+	AverageMoodChange *float64   `json:"average_mood_change"`
+	Intensity         ScaleStats `json:"intensity"`
+}
+
+// scale mengumpulkan nilai skala antara min dan max.
+type scale struct {
+	min, sum int
+	stats    ScaleStats
+}
+
+func newScale(min, max int) *scale {
+	return &scale{min: min, stats: ScaleStats{Distribution: make([]int, max-min+1)}}
+}
+
+func (s *scale) add(v int) {
+	if v == 0 {
+		return
+	}
+	s.stats.Count++
+	s.stats.Distribution[v-s.min]++
+	s.sum += v
+}
+
+func (s *scale) result() ScaleStats {
+	if s.stats.Count > 0 {
+		s.stats.Average = average(s.sum, s.stats.Count)
+	}
+	return s.stats
+}
+
+// average mengembalikan sum/count dibulatkan dua desimal.
+func average(sum, count int) *float64 {
+	avg := math.Round(float64(sum)/float64(count)*100) / 100
+	return &avg
+}
+
+// sortedCounts mengubah hitungan menjadi daftar terurut (terbanyak lebih dulu, lalu nama).
+func sortedCounts(counts map[string]int) []CountEntry {
+	entries := make([]CountEntry, 0, len(counts))
+	for value, count := range counts {
+		entries = append(entries, CountEntry{Value: value, Count: count})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Count != entries[j].Count {
+			return entries[i].Count > entries[j].Count
+		}
+		return entries[i].Value < entries[j].Value
+	})
+	return entries
+}
+
+// BuildRelapseBreakdown menghitung distribusi pemicu, lokasi, tag, mood, dan intensitas
+// dari relapse. Relapse tanpa metadata hanya tidak ikut terhitung.
+func BuildRelapseBreakdown(relapses []models.RelapseLog) RelapseBreakdown {
+	var (
+		triggers   = make(map[string]int)
+		locations  = make(map[string]int)
+		tags       = make(map[string]int)
+		moodBefore = newScale(models.RelapseMoodMin, models.RelapseMoodMax)
+		moodAfter  = newScale(models.RelapseMoodMin, models.RelapseMoodMax)
+		intensity  = newScale(models.RelapseIntensityMin, models.RelapseIntensityMax)
+		tracked    int
+		changeSum  int
+		changes    int
+	)
+	for _, r := range relapses {
+		d := r.RelapseDetails
+		if d.Empty() {
+			continue
+		}
+		tracked++
+		for _, t := range d.Triggers {
+			triggers[t]++
+		}
+		for _, t := range d.Tags {
+			tags[t]++
+		}
+		if d.Location != "" {
+			locations[d.Location]++
+		}
+		moodBefore.add(d.MoodBefore)
+		moodAfter.add(d.MoodAfter)
+		intensity.add(d.Intensity)
+		if d.MoodBefore != 0 && d.MoodAfter != 0 {
+			changeSum += d.MoodAfter - d.MoodBefore
+			changes++
+		}
+	}
+
+	breakdown := RelapseBreakdown{
+		Tracked:    tracked,
+		Triggers:   sortedCounts(triggers),
+		Locations:  sortedCounts(locations),
+		Tags:       sortedCounts(tags),
+		MoodBefore: moodBefore.result(),
+		MoodAfter:  moodAfter.result(),
+		Intensity:  intensity.result(),
+	}
+	if len(breakdown.Tags) > maxBreakdownTags {
+		breakdown.Tags = breakdown.Tags[:maxBreakdownTags]
+	}
+	if changes > 0 {
+		breakdown.AverageMoodChange = average(changeSum, changes)
+	}
+	return breakdown
+}
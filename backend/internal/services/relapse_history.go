@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/store"
+)
+
+// revisionChanges mengembalikan field yang berbeda antara dua isi relapse.
+func revisionChanges(before, after *models.RelapseLog) []string {
+	changes := []string{}
+	if !sameNote(before.RelapseNote, after.RelapseNote) {
+		changes = append(changes, models.RevisionFieldNote)
+	}
+	if !before.RelapseTime.Equal(after.RelapseTime) {
+		changes = append(changes, models.RevisionFieldTime)
+	}
+	if !before.RelapseDetails.Equal(after.RelapseDetails) {
+		changes = append(changes, models.RevisionFieldDetails)
+	}
+	return changes
+}
+
+func sameNote(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// revisionOf menyalin isi relapse menjadi satu versi riwayat.
+func revisionOf(r *models.RelapseLog, version int, changes []string, source string, editedAt time.Time) *models.RelapseRevision {
+	return &models.RelapseRevision{
+		RelapseID:      r.ID,
+		UserID:         r.UserID,
+		Version:        version,
+		RelapseTime:    r.RelapseTime,
+		RelapseNote:    r.RelapseNote,
+		RelapseDetails: r.RelapseDetails,
+		Changes:        changes,
+		Source:         source,
+		EditedAt:       editedAt,
+	}
+}
+
+// RecordRelapseRevision mencatat edit relapse (isi before menjadi after) ke riwayat edit.
+// Relapse yang belum punya riwayat lebih dulu dicatat isi awalnya sebagai versi 1, sehingga
+// relapse lama tidak perlu migrasi. Tidak mencatat apa pun bila isinya tidak berubah.
+// Panggil di dalam transaksi yang sama dengan update relapse.
+func RecordRelapseRevision(ctx context.Context, s *store.Stores, before, after *models.RelapseLog, source string, now time.Time) error {
+	changes := revisionChanges(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	revisions, err := s.Relapses.ListRevisions(ctx, before.ID, before.UserID)
+	if err != nil {
+		return err
+	}
+	version := len(revisions)
+	if version == 0 {
+		original := revisionOf(before, 1, []string{}, models.RevisionSourceOriginal, before.CreatedAt)
+		if err := s.Relapses.AddRevision(ctx, original); err != nil {
+			return err
+		}
+		version = 1
+	}
+	return s.Relapses.AddRevision(ctx, revisionOf(after, version+1, changes, source, now))
+}
+
+// RelapseHistory mengembalikan riwayat edit relapse, versi terlama lebih dulu. Relapse
+// yang belum pernah diedit hanya punya versi 1 (isi saat ini).
+func RelapseHistory(ctx context.Context, s *store.Stores, relapse *models.RelapseLog) ([]models.RelapseRevision, error) {
+	revisions, err := s.Relapses.ListRevisions(ctx, relapse.ID, relapse.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		revisions = []models.RelapseRevision{*revisionOf(relapse, 1, []string{}, models.RevisionSourceOriginal, relapse.CreatedAt)}
+	}
+	return revisions, nil
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/store"
+	"solivra-go/backend/internal/streaks"
+)
+
+// BuildStreakSummary menghitung ringkasan streak dari tanggal mulai dan relapse satu habit.
+func BuildStreakSummary(habit *models.Habit, start time.Time, relapses []models.RelapseLog, now time.Time) *models.StreakSummary {
+	computed := streaks.Compute(start, relapseTimes(relapses), now)
+
+	summary := &models.StreakSummary{
+		HabitID:            habit.ID,
+		UserID:             habit.UserID,
+		HabitKind:          habit.Kind,
+		IsDefault:          habit.IsDefault,
+		StreakStartDate:    start,
+		CurrentStreakStart: start,
+		LastRelapseAt:      computed.LastRelapse,
+		TotalRelapses:      computed.TotalRelapses,
+		UpdatedAt:          now,
+	}
+	if computed.LastRelapse != nil {
+		summary.CurrentStreakStart = *computed.LastRelapse
+	}
+	for _, s := range computed.Historical {
+		summary.SumCompletedSeconds += s
+		if s > summary.LongestCompletedSeconds {
+			summary.LongestCompletedSeconds = s
+		}
+	}
+	return summary
+}
+
+// RefreshStreakSummary membangun ulang ringkasan streak satu habit dari data terbaru.
+// Habit yang belum memulai streak (atau sudah dihapus) tidak punya ringkasan. Streak
+// habit default juga disalin ke field streak user agar klien lama tetap bekerja.
+// Panggil di dalam Tx.WithTransaction bersama operasi yang mengubah relapse/streak.
+func RefreshStreakSummary(ctx context.Context, s *store.Stores, userID, habitID primitive.ObjectID) error {
+	user, err := s.Users.FindByID(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return s.StreakSummaries.DeleteByUser(ctx, userID)
+	}
+	if err != nil {
+		return err
+	}
+
+	habit, err := s.Habits.FindByID(ctx, habitID, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return s.StreakSummaries.Delete(ctx, habitID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if habit.IsDefault {
+		if err := s.Users.SetStreakMirror(ctx, userID, habit.StreakStartDate, habit.LongestStreakSeconds); err != nil {
+			return err
+		}
+	}
+
+	if habit.StreakStartDate == nil {
+		return s.StreakSummaries.Delete(ctx, habitID)
+	}
+
+	relapses, err := s.Relapses.ListByHabit(ctx, habitID, true)
+	if err != nil {
+		return err
+	}
+
+	summary := BuildStreakSummary(habit, *habit.StreakStartDate, relapses, time.Now())
+	summary.StoredLongestSeconds = habit.LongestStreakSeconds
+	summary.Visibility = user.Visibility()
+	if !habit.Settings.ShowInRankings {
+		summary.Visibility = models.RankingVisibilityHidden
+	}
+	summary.SetSortKeys()
+	return s.StreakSummaries.Upsert(ctx, summary)
+}
+
+// RecomputeStreak menghitung ulang streak_start_date dan longest_streak_seconds habit dari
+// seluruh relapse yang tersimpan (dipakai setelah impor riwayat). Tanggal mulai dimundurkan
+// ke relapse paling awal bila perlu; streak terpanjang dihitung ulang dari timeline sehingga
+// bisa turun bila riwayat impor memotong streak lama. Ringkasan streak tidak ikut
+// diperbarui; panggil di dalam withStreakUpdate.
+func RecomputeStreak(ctx context.Context, s *store.Stores, userID, habitID primitive.ObjectID) (*models.Habit, error) {
+	relapses, err := s.Relapses.ListByHabit(ctx, habitID, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(relapses) > 0 {
+		if _, err := s.Habits.UpdateStreakStartIfLater(ctx, habitID, relapses[0].RelapseTime); err != nil {
+			return nil, err
+		}
+	}
+
+	habit, err := s.Habits.FindByID(ctx, habitID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if habit.StreakStartDate == nil {
+		return habit, nil
+	}
+
+	computed := streaks.Compute(*habit.StreakStartDate, relapseTimes(relapses), time.Now())
+	if computed.Longest != habit.LongestStreakSeconds {
+		if err := s.Habits.SetLongestStreak(ctx, habitID, computed.Longest); err != nil {
+			return nil, err
+		}
+		habit.LongestStreakSeconds = computed.Longest
+	}
+	return habit, nil
+}
+
+// RefreshLongestStreaks menyimpan streak terpanjang setiap habit yang sedang streak bila
+// streak berjalan sudah melewati longest_streak_seconds, agar rekor user yang jarang membuka
+// aplikasi tetap tersimpan. Nilai tersimpan tidak pernah diturunkan. Mengembalikan jumlah
+// habit yang diperbarui.
+func RefreshLongestStreaks(ctx context.Context, s *store.Stores, now time.Time) (int, error) {
+	habits, err := s.Habits.ListWithStreak(ctx)
+	if err != nil {
+		return 0, err
+	}
+	updated := 0
+	for _, habit := range habits {
+		relapses, err := s.Relapses.ListByHabit(ctx, habit.ID, true)
+		if err != nil {
+			return updated, err
+		}
+		computed := streaks.Compute(*habit.StreakStartDate, relapseTimes(relapses), now)
+		if computed.Longest <= habit.LongestStreakSeconds {
+			continue
+		}
+		err = s.Tx.WithTransaction(ctx, func(ctx context.Context) error {
+			if err := s.Habits.SetLongestStreak(ctx, habit.ID, computed.Longest); err != nil {
+				return err
+			}
+			return RefreshStreakSummary(ctx, s, habit.UserID, habit.ID)
+		})
+		if err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+func relapseTimes(relapses []models.RelapseLog) []time.Time {
+	times := make([]time.Time, len(relapses))
+	for i, r := range relapses {
+		times[i] = r.RelapseTime
+	}
+	return times
+}
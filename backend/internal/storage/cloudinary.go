@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+)
+
+// versionSegment cocok dengan segmen versi Cloudinary di URL, misal "v1712345678/".
+var versionSegment = regexp.MustCompile(`^v\d+/`)
+
+// Cloudinary menyimpan file di akun Cloudinary.
+type Cloudinary struct {
+	cld *cloudinary.Cloudinary
+}
+
+// NewCloudinary membuat driver Cloudinary dari kredensial akun.
+func NewCloudinary(cloudName, apiKey, apiSecret string) (*Cloudinary, error) {
+	cld, err := cloudinary.NewFromParams(cloudName, apiKey, apiSecret)
+	if err != nil {
+		return nil, fmt.Errorf("gagal inisialisasi Cloudinary: %w", err)
+	}
+	// Samakan dengan konfigurasi MERN: selalu gunakan HTTPS agar SecureURL terisi.
+	// Struktur URL pada SDK Go tidak berupa pointer, jadi cukup set flag Secure.
+	cld.Config.URL.Secure = true
+	return &Cloudinary{cld: cld}, nil
+}
+
+func (s *Cloudinary) Put(ctx context.Context, folder string, r io.Reader, _ int64, _ string) (string, error) {
+	uniqueFilename, err := objectName("")
+	if err != nil {
+		return "", err
+	}
+
+	uploadResult, err := s.cld.Upload.Upload(ctx, r, uploader.UploadParams{
+		Folder: folder,
+		// PublicID memberi nama unik yang kita buat.
+		PublicID: uniqueFilename,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "cloudinary upload error", "err", err)
+		return "", err
+	}
+	if uploadResult.Error.Message != "" {
+		slog.ErrorContext(ctx, "cloudinary upload API error", "err", uploadResult.Error.Message)
+		return "", errors.New(uploadResult.Error.Message)
+	}
+
+	// Cloudinary Go SDK hanya mengisi SecureURL jika mode secure aktif.
+	secureURL := uploadResult.SecureURL
+	if secureURL == "" {
+		secureURL = uploadResult.URL
+	}
+
+	if secureURL == "" {
+		resourceType := uploadResult.ResourceType
+		if resourceType == "" {
+			resourceType = "image"
+		}
+
+		version := ""
+		if uploadResult.Version > 0 {
+			version = fmt.Sprintf("v%d/", uploadResult.Version)
+		}
+
+		if cloudName := s.cld.Config.Cloud.CloudName; cloudName != "" && uploadResult.PublicID != "" {
+			secureURL = fmt.Sprintf(
+				"https://res.cloudinary.com/%s/%s/upload/%s%s",
+				cloudName,
+				resourceType,
+				version,
+				uploadResult.PublicID,
+			)
+		}
+	}
+
+	if secureURL == "" {
+		return "", errors.New("Cloudinary tidak mengembalikan URL file")
+	}
+	return secureURL, nil
+}
+
+// publicID mengambil Public ID dari URL upload milik akun ini (mirip getPublicId di MERN):
+// bagian setelah "/upload/" tanpa segmen versi dan ekstensi.
+func (s *Cloudinary) publicID(url string) string {
+	marker := "/" + s.cld.Config.Cloud.CloudName + "/image/upload/"
+	i := strings.Index(url, marker)
+	if i < 0 {
+		return ""
+	}
+	id := versionSegment.ReplaceAllString(url[i+len(marker):], "")
+	id = strings.SplitN(id, "?", 2)[0]
+	return strings.TrimSuffix(id, path.Ext(id))
+}
+
+func (s *Cloudinary) Delete(ctx context.Context, url string) error {
+	publicID := s.publicID(url)
+	if publicID == "" {
+		return nil // Abaikan URL default atau URL luar
+	}
+
+	_, err := s.cld.Upload.Destroy(ctx, uploader.DestroyParams{
+		PublicID:     publicID,
+		ResourceType: "image",
+	})
+	return err
+}
+
+// Open mengunduh file lewat URL publiknya; Cloudinary tidak punya API baca objek.
+func (s *Cloudinary) Open(ctx context.Context, url string) (io.ReadCloser, error) {
+	if s.publicID(url) == "" {
+		return nil, ErrForeignURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("storage cloudinary: status %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+func (s *Cloudinary) Check(ctx context.Context) error {
+	res, err := s.cld.Admin.Ping(ctx)
+	if err != nil {
+		return err
+	}
+	if res.Error.Message != "" {
+		return errors.New(res.Error.Message)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalURLPrefix adalah path tempat server menyajikan file driver local.
+const LocalURLPrefix = "/uploads"
+
+// Local menyimpan file di disk dan mengandalkan server menyajikan Dir di LocalURLPrefix.
+type Local struct {
+	dir     string
+	baseURL string // publicURL + LocalURLPrefix
+}
+
+// NewLocal membuat driver local di dir. publicURL adalah origin backend yang dipakai di URL
+// file (misal "https://api.example.com").
+func NewLocal(dir, publicURL string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("storage local: %w", err)
+	}
+	return &Local{
+		dir:     dir,
+		baseURL: strings.TrimRight(publicURL, "/") + LocalURLPrefix,
+	}, nil
+}
+
+// Dir mengembalikan folder yang harus disajikan di LocalURLPrefix.
+func (l *Local) Dir() string {
+	return l.dir
+}
+
+func (l *Local) Put(_ context.Context, folder string, r io.Reader, _ int64, contentType string) (string, error) {
+	name, err := objectName(contentType)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Join(l.dir, folder), 0o755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(l.dir, folder, name)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return l.baseURL + "/" + folder + "/" + name, nil
+}
+
+func (l *Local) Delete(_ context.Context, url string) error {
+	key := keyFromURL(url, l.baseURL)
+	if key == "" {
+		return nil
+	}
+	err := os.Remove(filepath.Join(l.dir, filepath.FromSlash(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Open menerima URL lengkap maupun path relatif LocalURLPrefix, sehingga file tetap
+// terbaca meskipun STORAGE_PUBLIC_URL berubah sejak upload.
+func (l *Local) Open(_ context.Context, url string) (io.ReadCloser, error) {
+	key := keyFromURL(url, l.baseURL)
+	if key == "" {
+		key = keyFromURL(url, LocalURLPrefix)
+	}
+	if key == "" {
+		return nil, ErrForeignURL
+	}
+	return os.Open(filepath.Join(l.dir, filepath.FromSlash(key)))
+}
+
+// Check memastikan folder bisa ditulisi dengan membuat lalu menghapus file sementara.
+func (l *Local) Check(_ context.Context) error {
+	f, err := os.CreateTemp(l.dir, ".healthcheck-*")
+	if err != nil {
+		return fmt.Errorf("storage local: folder %s tidak bisa ditulisi: %w", l.dir, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocalOpen(t *testing.T) {
+	ctx := context.Background()
+	local, err := NewLocal(t.TempDir(), "https://api.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, err := local.Put(ctx, "profile_pictures", strings.NewReader("avatar"), 6, "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	relative := strings.TrimPrefix(url, "https://api.example.com")
+
+	tests := []struct {
+		name    string
+		url     string
+		foreign bool
+		missing bool
+	}{
+		{name: "absolute url", url: url},
+		{name: "relative url", url: relative},
+		{name: "query string", url: url + "?v=2"},
+		{name: "missing file", url: LocalURLPrefix + "/profile_pictures/nope.png", missing: true},
+		{name: "default picture", url: "/default.png", foreign: true},
+		{name: "other host", url: "https://cdn.example.com/profile_pictures/a.png", foreign: true},
+		{name: "path traversal", url: LocalURLPrefix + "/../secret", foreign: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := local.Open(ctx, tt.url)
+			switch {
+			case tt.foreign:
+				if !errors.Is(err, ErrForeignURL) {
+					t.Fatalf("err = %v, want ErrForeignURL", err)
+				}
+				return
+			case tt.missing:
+				if err == nil || errors.Is(err, ErrForeignURL) {
+					t.Fatalf("err = %v, want a not exist error", err)
+				}
+				return
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer r.Close()
+			data, _ := io.ReadAll(r)
+			if string(data) != "avatar" {
+				t.Errorf("read %q, want %q", data, "avatar")
+			}
+		})
+	}
+}
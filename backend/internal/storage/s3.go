@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Options adalah konfigurasi driver S3-compatible (AWS S3, MinIO, Cloudflare R2, dst).
+type S3Options struct {
+	Endpoint        string // Host tanpa skema, misal "s3.amazonaws.com" atau "minio:9000"
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	UseSSL          bool
+	// PublicURL adalah base URL objek untuk dibaca publik (misal CDN atau domain bucket).
+	// Kosong berarti <skema>://<endpoint>/<bucket>.
+	PublicURL string
+}
+
+// S3 menyimpan file di bucket S3-compatible. Bucket harus mengizinkan baca publik atau
+// disajikan lewat PublicURL.
+type S3 struct {
+	client  *minio.Client
+	bucket  string
+	baseURL string
+}
+
+// NewS3 membuat driver S3 dari opts.
+func NewS3(opts S3Options) (*S3, error) {
+	if opts.Endpoint == "" || opts.Bucket == "" {
+		return nil, errors.New("storage s3: S3_ENDPOINT dan S3_BUCKET wajib diisi")
+	}
+	client, err := minio.New(opts.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(opts.AccessKeyID, opts.SecretAccessKey, ""),
+		Secure: opts.UseSSL,
+		Region: opts.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage s3: %w", err)
+	}
+
+	baseURL := opts.PublicURL
+	if baseURL == "" {
+		scheme := "http"
+		if opts.UseSSL {
+			scheme = "https"
+		}
+		baseURL = fmt.Sprintf("%s://%s/%s", scheme, opts.Endpoint, opts.Bucket)
+	}
+	return &S3{client: client, bucket: opts.Bucket, baseURL: strings.TrimRight(baseURL, "/")}, nil
+}
+
+func (s *S3) Put(ctx context.Context, folder string, r io.Reader, size int64, contentType string) (string, error) {
+	name, err := objectName(contentType)
+	if err != nil {
+		return "", err
+	}
+	key := folder + "/" + name
+	_, err = s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return "", err
+	}
+	return s.baseURL + "/" + key, nil
+}
+
+func (s *S3) Delete(ctx context.Context, url string) error {
+	key := keyFromURL(url, s.baseURL)
+	if key == "" {
+		return nil
+	}
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *S3) Open(ctx context.Context, url string) (io.ReadCloser, error) {
+	key := keyFromURL(url, s.baseURL)
+	if key == "" {
+		return nil, ErrForeignURL
+	}
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// GetObject baru menghubungi server saat dibaca; Stat memunculkan error (misal
+	// objek tidak ada) di sini.
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (s *S3) Check(ctx context.Context) error {
+	exists, err := s.client.BucketExists(ctx, s.bucket)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("storage s3: bucket %s tidak ditemukan", s.bucket)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// Package storage menyimpan file upload user (foto profil) di backend yang dipilih lewat
+// config: Cloudinary, disk lokal, atau object storage S3-compatible.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"solivra-go/backend/internal/config"
+	"solivra-go/backend/pkg/utils"
+)
+
+// Driver yang didukung (config STORAGE_DRIVER)
+const (
+	DriverCloudinary = "cloudinary"
+	DriverLocal      = "local"
+	DriverS3         = "s3"
+)
+
+// ErrForeignURL dikembalikan Open untuk URL yang bukan milik driver ini.
+var ErrForeignURL = errors.New("storage: url bukan milik driver ini")
+
+// Storage menyimpan, membaca, dan menghapus file yang diakses publik lewat URL.
+type Storage interface {
+	// Put menyimpan isi r (size byte, -1 jika tidak diketahui) sebagai file baru di folder
+	// dan mengembalikan URL publiknya.
+	Put(ctx context.Context, folder string, r io.Reader, size int64, contentType string) (string, error)
+	// Delete menghapus file berdasarkan URL dari Put. URL yang bukan milik driver ini
+	// (misal foto bawaan atau file dari driver sebelumnya) diabaikan.
+	Delete(ctx context.Context, url string) error
+	// Open membaca file berdasarkan URL dari Put. URL yang bukan milik driver ini
+	// menghasilkan ErrForeignURL.
+	Open(ctx context.Context, url string) (io.ReadCloser, error)
+	// Check memastikan backend bisa dipakai (kredensial valid, bucket/folder ada) untuk
+	// readiness check.
+	Check(ctx context.Context) error
+}
+
+// New membuat Storage sesuai cfg.StorageDriver.
+func New(cfg *config.Config) (Storage, error) {
+	switch cfg.StorageDriver {
+	case DriverCloudinary:
+		return NewCloudinary(cfg.CloudinaryCloudName, cfg.CloudinaryAPIKey, cfg.CloudinaryAPISecret)
+	case DriverLocal:
+		return NewLocal(cfg.StorageLocalDir, cfg.StoragePublicURL)
+	case DriverS3:
+		return NewS3(S3Options{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			UseSSL:          cfg.S3UseSSL,
+			PublicURL:       cfg.StoragePublicURL,
+		})
+	default:
+		return nil, fmt.Errorf("storage driver tidak dikenal: %q", cfg.StorageDriver)
+	}
+}
+
+// objectName membuat nama file acak dengan ekstensi sesuai contentType.
+func objectName(contentType string) (string, error) {
+	name, err := utils.GenerateRandomToken(10)
+	if err != nil {
+		return "", fmt.Errorf("gagal menghasilkan nama file unik: %w", err)
+	}
+	return name + extension(contentType), nil
+}
+
+func extension(contentType string) string {
+	switch strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])) {
+	case "image/jpeg", "image/jpg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	default:
+		return ""
+	}
+}
+
+// keyFromURL mengembalikan path objek relatif terhadap base, atau "" jika url tidak
+// berada di bawah base atau mencoba keluar dari folder.
+func keyFromURL(url, base string) string {
+	prefix := strings.TrimRight(base, "/") + "/"
+	if !strings.HasPrefix(url, prefix) {
+		return ""
+	}
+	key := strings.SplitN(strings.TrimPrefix(url, prefix), "?", 2)[0]
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return ""
+		}
+	}
+	return key
+}
@@ -0,0 +1,110 @@
+// internal/store/memory.go
+package store
+
+import (
+	"context"
+	"regexp"
+	"sync"
+)
+
+// NewMemory membuat seluruh store dalam memori proses.
+// Dipakai untuk pengujian dan pengembangan lokal tanpa MongoDB.
+//
+// Tx menyerialkan transaksi dan memulihkan isi semua store jika fn gagal, tetapi tidak
+// mengisolasi: penulisan di luar transaksi terlihat di dalamnya dan ikut hilang jika
+// transaksi di-rollback.
+func NewMemory() *Stores {
+	s := &Stores{
+		Users:                newMemoryUserStore(),
+		Sessions:             newMemorySessionStore(),
+		Relapses:             newMemoryRelapseStore(),
+		Habits:               newMemoryHabitStore(),
+		ActivityLogs:         &memoryActivityLogStore{},
+		Honeypots:            &memoryHoneypotStore{},
+		LoginAttempts:        &memoryLoginAttemptStore{},
+		RegistrationAttempts: &memoryRegistrationAttemptStore{},
+		StreakSummaries:      newMemoryStreakSummaryStore(),
+		DataExports:          newMemoryDataExportStore(),
+		Achievements:         &memoryAchievementStore{},
+		Jobs:                 newMemoryJobStore(),
+	}
+	s.Tx = &memoryTransactor{stores: []memorySnapshotter{
+		s.Users.(memorySnapshotter),
+		s.Sessions.(memorySnapshotter),
+		s.Relapses.(memorySnapshotter),
+		s.Habits.(memorySnapshotter),
+		s.ActivityLogs.(memorySnapshotter),
+		s.Honeypots.(memorySnapshotter),
+		s.LoginAttempts.(memorySnapshotter),
+		s.RegistrationAttempts.(memorySnapshotter),
+		s.StreakSummaries.(memorySnapshotter),
+		s.DataExports.(memorySnapshotter),
+		s.Achievements.(memorySnapshotter),
+		s.Jobs.(memorySnapshotter),
+	}}
+	return s
+}
+
+// memorySnapshotter diimplementasikan setiap store in-memory agar memoryTransactor bisa rollback.
+type memorySnapshotter interface {
+	// snapshot menyalin isi store dan mengembalikan fungsi untuk memulihkannya.
+	snapshot() (restore func())
+}
+
+// memoryTransactor menyerialkan fn dan memulihkan snapshot semua store jika fn gagal.
+type memoryTransactor struct {
+	mu     sync.Mutex
+	stores []memorySnapshotter
+}
+
+func (t *memoryTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	restores := make([]func(), len(t.stores))
+	for i, s := range t.stores {
+		restores[i] = s.snapshot()
+	}
+	err := fn(ctx)
+	if err != nil {
+		for _, restore := range restores {
+			restore()
+		}
+	}
+	return err
+}
+
+// memoryBase menyediakan mutex bersama untuk setiap store in-memory.
+type memoryBase struct {
+	mu sync.RWMutex
+}
+
+// snapshotOf menyalin (shallow) data di bawah lock b dan mengembalikan fungsi yang
+// memulihkannya. Nilai dokumen disimpan sebagai struct, jadi salinan map/slice cukup.
+func snapshotOf[T any](b *memoryBase, data *T, clone func(T) T) func() {
+	b.mu.RLock()
+	saved := clone(*data)
+	b.mu.RUnlock()
+	return func() {
+		b.mu.Lock()
+		*data = saved
+		b.mu.Unlock()
+	}
+}
+
+// matchRegexCI meniru {$regex, $options: "i"} milik Mongo; pola yang tidak valid dicocokkan secara literal.
+func matchRegexCI(pattern, value string) bool {
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		re = regexp.MustCompile("(?i)" + regexp.QuoteMeta(pattern))
+	}
+	return re.MatchString(value)
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+// internal/store/memory_achievements.go
+package store
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/models"
+)
+
+type memoryAchievementStore struct {
+	memoryBase
+	achievements []models.Achievement
+}
+
+func (s *memoryAchievementStore) snapshot() func() {
+	return snapshotOf(&s.memoryBase, &s.achievements, slices.Clone)
+}
+
+func (s *memoryAchievementStore) Award(_ context.Context, achievement *models.Achievement) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range s.achievements {
+		if a.UserID == achievement.UserID && a.HabitID == achievement.HabitID && a.Code == achievement.Code {
+			return false, nil
+		}
+	}
+	if achievement.ID.IsZero() {
+		achievement.ID = primitive.NewObjectID()
+	}
+	s.achievements = append(s.achievements, *achievement)
+	return true, nil
+}
+
+func (s *memoryAchievementStore) ListByUser(_ context.Context, userID primitive.ObjectID) ([]models.Achievement, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := []models.Achievement{}
+	for _, a := range s.achievements {
+		if a.UserID == userID {
+			result = append(result, a)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].AwardedAt.Before(result[j].AwardedAt)
+	})
+	return result, nil
+}
+
+func (s *memoryAchievementStore) deleteWhere(match func(models.Achievement) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.achievements[:0]
+	for _, a := range s.achievements {
+		if !match(a) {
+			kept = append(kept, a)
+		}
+	}
+	s.achievements = kept
+}
+
+func (s *memoryAchievementStore) DeleteByHabit(_ context.Context, habitID primitive.ObjectID) error {
+	s.deleteWhere(func(a models.Achievement) bool { return a.HabitID == habitID })
+	return nil
+}
+
+func (s *memoryAchievementStore) DeleteByUser(_ context.Context, userID primitive.ObjectID) error {
+	s.deleteWhere(func(a models.Achievement) bool { return a.UserID == userID })
+	return nil
+}
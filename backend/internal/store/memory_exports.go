@@ -0,0 +1,149 @@
+// internal/store/memory_exports.go
+package store
+
+import (
+	"context"
+	"maps"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/models"
+)
+
+type memoryDataExportStore struct {
+	memoryBase
+	exports map[primitive.ObjectID]models.DataExport
+}
+
+func newMemoryDataExportStore() *memoryDataExportStore {
+	return &memoryDataExportStore{exports: make(map[primitive.ObjectID]models.DataExport)}
+}
+
+func (s *memoryDataExportStore) snapshot() func() {
+	return snapshotOf(&s.memoryBase, &s.exports, maps.Clone)
+}
+
+func (s *memoryDataExportStore) filter(keep func(models.DataExport) bool) []models.DataExport {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := []models.DataExport{}
+	for _, e := range s.exports {
+		if keep(e) {
+			result = append(result, e)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return result
+}
+
+func (s *memoryDataExportStore) first(keep func(models.DataExport) bool) (*models.DataExport, error) {
+	matched := s.filter(keep)
+	if len(matched) == 0 {
+		return nil, ErrNotFound
+	}
+	return &matched[0], nil
+}
+
+func (s *memoryDataExportStore) update(id primitive.ObjectID, fn func(e *models.DataExport)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.exports[id]; ok {
+		fn(&e)
+		s.exports[id] = e
+	}
+}
+
+func (s *memoryDataExportStore) Create(_ context.Context, export *models.DataExport) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if export.ID.IsZero() {
+		export.ID = primitive.NewObjectID()
+	}
+	s.exports[export.ID] = *export
+	return nil
+}
+
+func (s *memoryDataExportStore) Get(_ context.Context, id primitive.ObjectID) (*models.DataExport, error) {
+	return s.first(func(e models.DataExport) bool { return e.ID == id })
+}
+
+func (s *memoryDataExportStore) FindByID(_ context.Context, id, userID primitive.ObjectID) (*models.DataExport, error) {
+	return s.first(func(e models.DataExport) bool { return e.ID == id && e.UserID == userID })
+}
+
+func (s *memoryDataExportStore) FindPending(_ context.Context, userID primitive.ObjectID) (*models.DataExport, error) {
+	return s.first(func(e models.DataExport) bool {
+		return e.UserID == userID && e.Status == models.DataExportPending
+	})
+}
+
+func (s *memoryDataExportStore) ListByUser(_ context.Context, userID primitive.ObjectID) ([]models.DataExport, error) {
+	return s.filter(func(e models.DataExport) bool { return e.UserID == userID }), nil
+}
+
+func (s *memoryDataExportStore) MarkReady(_ context.Context, id primitive.ObjectID, filePath string, size int64, expiresAt, now time.Time) error {
+	s.update(id, func(e *models.DataExport) {
+		e.Status = models.DataExportReady
+		e.FilePath = filePath
+		e.SizeBytes = size
+		e.CompletedAt = &now
+		e.ExpiresAt = &expiresAt
+	})
+	return nil
+}
+
+func (s *memoryDataExportStore) MarkFailed(_ context.Context, id primitive.ObjectID, reason string, expiresAt, now time.Time) error {
+	s.update(id, func(e *models.DataExport) {
+		markFailed(e, reason, expiresAt, now)
+	})
+	return nil
+}
+
+func (s *memoryDataExportStore) FailStale(_ context.Context, createdBefore time.Time, reason string, expiresAt, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	failed := 0
+	for id, e := range s.exports {
+		if e.Status == models.DataExportPending && e.CreatedAt.Before(createdBefore) {
+			markFailed(&e, reason, expiresAt, now)
+			s.exports[id] = e
+			failed++
+		}
+	}
+	return failed, nil
+}
+
+func markFailed(e *models.DataExport, reason string, expiresAt, now time.Time) {
+	e.Status = models.DataExportFailed
+	e.Error = reason
+	e.CompletedAt = &now
+	e.ExpiresAt = &expiresAt
+}
+
+func (s *memoryDataExportStore) ListExpired(_ context.Context, now time.Time) ([]models.DataExport, error) {
+	return s.filter(func(e models.DataExport) bool {
+		return e.ExpiresAt != nil && !e.ExpiresAt.After(now)
+	}), nil
+}
+
+func (s *memoryDataExportStore) Delete(_ context.Context, id primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.exports, id)
+	return nil
+}
+
+func (s *memoryDataExportStore) DeleteByUser(_ context.Context, userID primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, e := range s.exports {
+		if e.UserID == userID {
+			delete(s.exports, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+// internal/store/memory_habits.go
+package store
+
+import (
+	"context"
+	"maps"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/models"
+)
+
+type memoryHabitStore struct {
+	memoryBase
+	habits map[primitive.ObjectID]models.Habit
+}
+
+func newMemoryHabitStore() *memoryHabitStore {
+	return &memoryHabitStore{habits: make(map[primitive.ObjectID]models.Habit)}
+}
+
+func (s *memoryHabitStore) snapshot() func() {
+	return snapshotOf(&s.memoryBase, &s.habits, maps.Clone)
+}
+
+func (s *memoryHabitStore) filter(keep func(models.Habit) bool) []models.Habit {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := []models.Habit{}
+	for _, h := range s.habits {
+		if keep(h) {
+			result = append(result, h)
+		}
+	}
+	return result
+}
+
+func (s *memoryHabitStore) first(keep func(models.Habit) bool) (*models.Habit, error) {
+	matched := s.filter(keep)
+	if len(matched) == 0 {
+		return nil, ErrNotFound
+	}
+	return &matched[0], nil
+}
+
+func (s *memoryHabitStore) update(id primitive.ObjectID, fn func(h *models.Habit) bool) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.habits[id]
+	if !ok {
+		return false, ErrNotFound
+	}
+	changed := fn(&h)
+	s.habits[id] = h
+	return changed, nil
+}
+
+func (s *memoryHabitStore) Create(_ context.Context, habit *models.Habit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if habit.ID.IsZero() {
+		habit.ID = primitive.NewObjectID()
+	}
+	s.habits[habit.ID] = *habit
+	return nil
+}
+
+func (s *memoryHabitStore) EnsureDefault(_ context.Context, habit *models.Habit) (*models.Habit, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, h := range s.habits {
+		if h.UserID == habit.UserID && h.IsDefault {
+			return &h, false, nil
+		}
+	}
+	if habit.ID.IsZero() {
+		habit.ID = primitive.NewObjectID()
+	}
+	habit.IsDefault = true
+	s.habits[habit.ID] = *habit
+	return habit, true, nil
+}
+
+func (s *memoryHabitStore) FindByID(_ context.Context, id, userID primitive.ObjectID) (*models.Habit, error) {
+	return s.first(func(h models.Habit) bool { return h.ID == id && h.UserID == userID })
+}
+
+func (s *memoryHabitStore) FindDefault(_ context.Context, userID primitive.ObjectID) (*models.Habit, error) {
+	return s.first(func(h models.Habit) bool { return h.UserID == userID && h.IsDefault })
+}
+
+func (s *memoryHabitStore) FindByKind(_ context.Context, userID primitive.ObjectID, kind string) (*models.Habit, error) {
+	return s.first(func(h models.Habit) bool { return h.UserID == userID && h.Kind == kind })
+}
+
+func (s *memoryHabitStore) ListByUser(_ context.Context, userID primitive.ObjectID) ([]models.Habit, error) {
+	habits := s.filter(func(h models.Habit) bool { return h.UserID == userID })
+	sort.SliceStable(habits, func(i, j int) bool {
+		if habits[i].IsDefault != habits[j].IsDefault {
+			return habits[i].IsDefault
+		}
+		return habits[i].CreatedAt.Before(habits[j].CreatedAt)
+	})
+	return habits, nil
+}
+
+func (s *memoryHabitStore) ListWithStreak(_ context.Context) ([]models.Habit, error) {
+	return s.filter(func(h models.Habit) bool { return h.StreakStartDate != nil }), nil
+}
+
+func (s *memoryHabitStore) CountByUser(_ context.Context, userID primitive.ObjectID) (int64, error) {
+	return int64(len(s.filter(func(h models.Habit) bool { return h.UserID == userID }))), nil
+}
+
+func (s *memoryHabitStore) Update(_ context.Context, id, userID primitive.ObjectID, update HabitUpdate, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.habits[id]
+	if !ok || h.UserID != userID {
+		return ErrNotFound
+	}
+	if update.Name != nil {
+		h.Name = *update.Name
+	}
+	if update.Color != nil {
+		h.Settings.Color = *update.Color
+	}
+	if update.Icon != nil {
+		h.Settings.Icon = *update.Icon
+	}
+	if update.ShowInRankings != nil {
+		h.Settings.ShowInRankings = *update.ShowInRankings
+	}
+	h.UpdatedAt = now
+	s.habits[id] = h
+	return nil
+}
+
+func (s *memoryHabitStore) Delete(_ context.Context, id, userID primitive.ObjectID) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.habits[id]
+	if !ok || h.UserID != userID {
+		return false, nil
+	}
+	delete(s.habits, id)
+	return true, nil
+}
+
+func (s *memoryHabitStore) DeleteAllByUser(_ context.Context, userID primitive.ObjectID) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for id, h := range s.habits {
+		if h.UserID == userID {
+			delete(s.habits, id)
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *memoryHabitStore) StartStreak(_ context.Context, id primitive.ObjectID, start time.Time) (bool, error) {
+	changed, err := s.update(id, func(h *models.Habit) bool {
+		if h.StreakStartDate != nil {
+			return false
+		}
+		h.StreakStartDate = &start
+		return true
+	})
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return changed, err
+}
+
+func (s *memoryHabitStore) UpdateStreakStartIfLater(_ context.Context, id primitive.ObjectID, start time.Time) (bool, error) {
+	changed, err := s.update(id, func(h *models.Habit) bool {
+		if h.StreakStartDate != nil && !h.StreakStartDate.After(start) {
+			return false
+		}
+		h.StreakStartDate = &start
+		return true
+	})
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return changed, err
+}
+
+func (s *memoryHabitStore) SetLongestStreak(_ context.Context, id primitive.ObjectID, seconds int64) error {
+	_, err := s.update(id, func(h *models.Habit) bool {
+		h.LongestStreakSeconds = seconds
+		return true
+	})
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+func (s *memoryHabitStore) ResetStreak(_ context.Context, id primitive.ObjectID) error {
+	_, err := s.update(id, func(h *models.Habit) bool {
+		h.StreakStartDate = nil
+		h.LongestStreakSeconds = 0
+		return true
+	})
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,145 @@
+// internal/store/memory_jobs.go
+package store
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/models"
+)
+
+type memoryJobStore struct {
+	memoryBase
+	leases map[string]models.JobLease
+	runs   []models.JobRun
+}
+
+func newMemoryJobStore() *memoryJobStore {
+	return &memoryJobStore{leases: make(map[string]models.JobLease)}
+}
+
+func (s *memoryJobStore) snapshot() func() {
+	s.mu.RLock()
+	leases, runs := maps.Clone(s.leases), slices.Clone(s.runs)
+	s.mu.RUnlock()
+	return func() {
+		s.mu.Lock()
+		s.leases, s.runs = leases, runs
+		s.mu.Unlock()
+	}
+}
+
+func (s *memoryJobStore) AcquireLease(_ context.Context, name, owner string, now, until time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if lease, ok := s.leases[name]; ok && lease.Owner != owner && lease.ExpiresAt.After(now) {
+		return false, nil
+	}
+	s.leases[name] = models.JobLease{Name: name, Owner: owner, ExpiresAt: until, RenewedAt: now}
+	return true, nil
+}
+
+func (s *memoryJobStore) ReleaseLease(_ context.Context, name, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if lease, ok := s.leases[name]; ok && lease.Owner == owner {
+		delete(s.leases, name)
+	}
+	return nil
+}
+
+func (s *memoryJobStore) GetLease(_ context.Context, name string) (*models.JobLease, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	lease, ok := s.leases[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &lease, nil
+}
+
+func (s *memoryJobStore) StartRun(_ context.Context, run *models.JobRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if run.ID.IsZero() {
+		run.ID = primitive.NewObjectID()
+	}
+	s.runs = append(s.runs, *run)
+	return nil
+}
+
+func (s *memoryJobStore) FinishRun(_ context.Context, id primitive.ObjectID, status string, result map[string]interface{}, errMsg string, finishedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.runs {
+		run := &s.runs[i]
+		if run.ID != id {
+			continue
+		}
+		finished := finishedAt
+		run.Status = status
+		run.FinishedAt = &finished
+		run.DurationMs = finishedAt.Sub(run.StartedAt).Milliseconds()
+		if result != nil {
+			run.Result = result
+		}
+		if errMsg != "" {
+			run.Error = errMsg
+		}
+		return nil
+	}
+	return ErrNotFound
+}
+
+// byJob mengembalikan eksekusi job, terbaru lebih dulu (harus dipanggil dengan lock baca).
+func (s *memoryJobStore) byJob(job string) []models.JobRun {
+	runs := []models.JobRun{}
+	for _, run := range s.runs {
+		if run.Job == job {
+			runs = append(runs, run)
+		}
+	}
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].StartedAt.After(runs[j].StartedAt)
+	})
+	return runs
+}
+
+func (s *memoryJobStore) LastRun(_ context.Context, job string) (*models.JobRun, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	runs := s.byJob(job)
+	if len(runs) == 0 {
+		return nil, ErrNotFound
+	}
+	return &runs[0], nil
+}
+
+func (s *memoryJobStore) ListRuns(_ context.Context, job string, limit int64) ([]models.JobRun, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	runs := s.byJob(job)
+	if limit > 0 && int64(len(runs)) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
+
+func (s *memoryJobStore) DeleteRunsBefore(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.runs[:0]
+	for _, run := range s.runs {
+		if !run.StartedAt.Before(before) {
+			kept = append(kept, run)
+		}
+	}
+	deleted := int64(len(s.runs) - len(kept))
+	s.runs = kept
+	return deleted, nil
+}
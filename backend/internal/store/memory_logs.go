@@ -0,0 +1,307 @@
+// internal/store/memory_logs.go
+package store
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/models"
+)
+
+type memoryActivityLogStore struct {
+	memoryBase
+	logs []models.ActivityLog
+}
+
+func (s *memoryActivityLogStore) snapshot() func() {
+	return snapshotOf(&s.memoryBase, &s.logs, slices.Clone)
+}
+
+func (s *memoryActivityLogStore) Insert(_ context.Context, entry *models.ActivityLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	s.logs = append(s.logs, *entry)
+	return nil
+}
+
+func (s *memoryActivityLogStore) List(_ context.Context, q ActivityLogQuery) ([]models.ActivityLog, int64, error) {
+	s.mu.RLock()
+	var matched []models.ActivityLog
+	for _, entry := range s.logs {
+		if q.Action != "" && entry.Action != q.Action {
+			continue
+		}
+		if q.Action == "" && q.ExcludeAction != "" && entry.Action == q.ExcludeAction {
+			continue
+		}
+		if q.UsernameSearch != "" && !matchRegexCI(q.UsernameSearch, entry.Username) {
+			continue
+		}
+		matched = append(matched, entry)
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		if q.Ascending {
+			return matched[i].Timestamp.Before(matched[j].Timestamp)
+		}
+		return matched[i].Timestamp.After(matched[j].Timestamp)
+	})
+
+	total := int64(len(matched))
+	if q.Skip >= total {
+		return []models.ActivityLog{}, total, nil
+	}
+	matched = matched[q.Skip:]
+	if q.Limit > 0 && int64(len(matched)) > q.Limit {
+		matched = matched[:q.Limit]
+	}
+	return matched, total, nil
+}
+
+// byUser mengembalikan log milik userID dalam urutan waktu (harus dipanggil dengan lock baca).
+func (s *memoryActivityLogStore) byUser(userID primitive.ObjectID) []models.ActivityLog {
+	logs := []models.ActivityLog{}
+	for _, entry := range s.logs {
+		if entry.UserID != nil && *entry.UserID == userID {
+			logs = append(logs, entry)
+		}
+	}
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].Timestamp.Before(logs[j].Timestamp)
+	})
+	return logs
+}
+
+func (s *memoryActivityLogStore) ListByUser(_ context.Context, userID primitive.ObjectID) ([]models.ActivityLog, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.byUser(userID), nil
+}
+
+func (s *memoryActivityLogStore) CountByUser(_ context.Context, userID primitive.ObjectID) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return int64(len(s.byUser(userID))), nil
+}
+
+func (s *memoryActivityLogStore) DeleteBefore(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.logs[:0]
+	for _, entry := range s.logs {
+		if !entry.Timestamp.Before(before) {
+			kept = append(kept, entry)
+		}
+	}
+	deleted := int64(len(s.logs) - len(kept))
+	s.logs = kept
+	return deleted, nil
+}
+
+type memoryHoneypotStore struct {
+	memoryBase
+	incidents []models.HoneypotLog
+}
+
+func (s *memoryHoneypotStore) snapshot() func() {
+	return snapshotOf(&s.memoryBase, &s.incidents, slices.Clone)
+}
+
+func (s *memoryHoneypotStore) Insert(_ context.Context, incident *models.HoneypotLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if incident.ID.IsZero() {
+		incident.ID = primitive.NewObjectID()
+	}
+	s.incidents = append(s.incidents, *incident)
+	return nil
+}
+
+func (s *memoryHoneypotStore) Stats(_ context.Context, recentSince time.Time) (*HoneypotStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := &HoneypotStats{TotalAttempts: int64(len(s.incidents))}
+	ips := make(map[string]struct{})
+	usernameCounts := make(map[interface{}]int)
+	for _, incident := range s.incidents {
+		ips[incident.IPAddress] = struct{}{}
+		if !incident.IncidentTime.Before(recentSince) {
+			stats.RecentAttempts++
+		}
+		switch incident.HoneypotType {
+		case "fake_admin_login_attempt":
+			stats.LoginAttempts++
+			var username interface{}
+			if data, ok := incident.SubmittedData.(map[string]interface{}); ok {
+				username = data["username"]
+			}
+			usernameCounts[username]++
+		case "admin_page_access_attempt":
+			stats.AccessAttempts++
+		}
+	}
+	stats.UniqueIPs = len(ips)
+
+	for username, count := range usernameCounts {
+		stats.TopUsernames = append(stats.TopUsernames, HoneypotUsernameCount{Username: username, Count: count})
+	}
+	sort.SliceStable(stats.TopUsernames, func(i, j int) bool {
+		return stats.TopUsernames[i].Count > stats.TopUsernames[j].Count
+	})
+	if len(stats.TopUsernames) > 10 {
+		stats.TopUsernames = stats.TopUsernames[:10]
+	}
+	return stats, nil
+}
+
+type memoryLoginAttemptStore struct {
+	memoryBase
+	attempts []models.LoginAttempt
+}
+
+func (s *memoryLoginAttemptStore) snapshot() func() {
+	return snapshotOf(&s.memoryBase, &s.attempts, slices.Clone)
+}
+
+func (s *memoryLoginAttemptStore) Insert(_ context.Context, attempt *models.LoginAttempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if attempt.ID.IsZero() {
+		attempt.ID = primitive.NewObjectID()
+	}
+	s.attempts = append(s.attempts, *attempt)
+	return nil
+}
+
+func (s *memoryLoginAttemptStore) FindActiveIPLock(_ context.Context, ip string, now time.Time) (*models.LoginAttempt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var latest *models.LoginAttempt
+	for i := range s.attempts {
+		a := s.attempts[i]
+		if a.IPAddress != ip || a.Outcome != "ip_locked" || a.LockoutUntil == nil || !a.LockoutUntil.After(now) {
+			continue
+		}
+		if latest == nil || a.AttemptTime.After(latest.AttemptTime) {
+			latest = &a
+		}
+	}
+	if latest == nil {
+		return nil, ErrNotFound
+	}
+	return latest, nil
+}
+
+func (s *memoryLoginAttemptStore) CountByIP(_ context.Context, ip string, since time.Time, outcomes ...string) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var count int64
+	for _, a := range s.attempts {
+		if a.IPAddress == ip && containsString(outcomes, a.Outcome) && !a.AttemptTime.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *memoryLoginAttemptStore) ListByUser(_ context.Context, userID primitive.ObjectID) ([]models.LoginAttempt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	attempts := []models.LoginAttempt{}
+	for _, a := range s.attempts {
+		if a.UserID != nil && *a.UserID == userID {
+			attempts = append(attempts, a)
+		}
+	}
+	sort.SliceStable(attempts, func(i, j int) bool {
+		return attempts[i].AttemptTime.Before(attempts[j].AttemptTime)
+	})
+	return attempts, nil
+}
+
+func (s *memoryLoginAttemptStore) DeleteExpired(_ context.Context, before, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.attempts[:0]
+	for _, a := range s.attempts {
+		if !a.AttemptTime.Before(before) || (a.LockoutUntil != nil && a.LockoutUntil.After(now)) {
+			kept = append(kept, a)
+		}
+	}
+	deleted := int64(len(s.attempts) - len(kept))
+	s.attempts = kept
+	return deleted, nil
+}
+
+type memoryRegistrationAttemptStore struct {
+	memoryBase
+	attempts []models.RegistrationAttempt
+}
+
+func (s *memoryRegistrationAttemptStore) snapshot() func() {
+	return snapshotOf(&s.memoryBase, &s.attempts, slices.Clone)
+}
+
+func (s *memoryRegistrationAttemptStore) Insert(_ context.Context, attempt *models.RegistrationAttempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if attempt.ID.IsZero() {
+		attempt.ID = primitive.NewObjectID()
+	}
+	s.attempts = append(s.attempts, *attempt)
+	return nil
+}
+
+func (s *memoryRegistrationAttemptStore) FindActiveBlock(_ context.Context, ip string, now time.Time) (*models.RegistrationAttempt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var latest *models.RegistrationAttempt
+	for i := range s.attempts {
+		a := s.attempts[i]
+		if a.IPAddress != ip || a.Status != "blocked" || a.BlockedUntil == nil || !a.BlockedUntil.After(now) {
+			continue
+		}
+		if latest == nil || a.AttemptTime.After(latest.AttemptTime) {
+			latest = &a
+		}
+	}
+	if latest == nil {
+		return nil, ErrNotFound
+	}
+	return latest, nil
+}
+
+func (s *memoryRegistrationAttemptStore) CountByIP(_ context.Context, ip string, since time.Time, statuses ...string) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var count int64
+	for _, a := range s.attempts {
+		if a.IPAddress == ip && containsString(statuses, a.Status) && !a.AttemptTime.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *memoryRegistrationAttemptStore) DeleteExpired(_ context.Context, before, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.attempts[:0]
+	for _, a := range s.attempts {
+		if !a.AttemptTime.Before(before) || (a.BlockedUntil != nil && a.BlockedUntil.After(now)) {
+			kept = append(kept, a)
+		}
+	}
+	deleted := int64(len(s.attempts) - len(kept))
+	s.attempts = kept
+	return deleted, nil
+}
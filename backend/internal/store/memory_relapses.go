@@ -0,0 +1,538 @@
+// internal/store/memory_relapses.go
+package store
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/streaks"
+)
+
+type memoryRelapseStore struct {
+	memoryBase
+	relapses   map[primitive.ObjectID]models.RelapseLog
+	tombstones map[primitive.ObjectID]models.RelapseTombstone
+	revisions  []models.RelapseRevision
+}
+
+func newMemoryRelapseStore() *memoryRelapseStore {
+	return &memoryRelapseStore{
+		relapses:   make(map[primitive.ObjectID]models.RelapseLog),
+		tombstones: make(map[primitive.ObjectID]models.RelapseTombstone),
+	}
+}
+
+func (s *memoryRelapseStore) snapshot() func() {
+	s.mu.RLock()
+	relapses, tombstones, revisions := maps.Clone(s.relapses), maps.Clone(s.tombstones), slices.Clone(s.revisions)
+	s.mu.RUnlock()
+	return func() {
+		s.mu.Lock()
+		s.relapses, s.tombstones, s.revisions = relapses, tombstones, revisions
+		s.mu.Unlock()
+	}
+}
+
+func (s *memoryRelapseStore) Create(_ context.Context, relapse *models.RelapseLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if relapse.ID.IsZero() {
+		relapse.ID = primitive.NewObjectID()
+	}
+	s.relapses[relapse.ID] = *relapse
+	return nil
+}
+
+func (s *memoryRelapseStore) CreateMany(_ context.Context, relapses []models.RelapseLog) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range relapses {
+		if relapses[i].ID.IsZero() {
+			relapses[i].ID = primitive.NewObjectID()
+		}
+		s.relapses[relapses[i].ID] = relapses[i]
+	}
+	return len(relapses), nil
+}
+
+func (s *memoryRelapseStore) FindByID(_ context.Context, id, userID primitive.ObjectID) (*models.RelapseLog, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	relapse, ok := s.relapses[id]
+	if !ok || relapse.UserID != userID || relapse.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+	return &relapse, nil
+}
+
+func (s *memoryRelapseStore) FindByClientID(_ context.Context, userID primitive.ObjectID, clientID string) (*models.RelapseLog, error) {
+	matches := s.filter(func(r models.RelapseLog) bool { return r.UserID == userID && r.ClientID == clientID })
+	if clientID == "" || len(matches) == 0 {
+		return nil, ErrNotFound
+	}
+	return &matches[0], nil
+}
+
+// after melaporkan apakah posisi (t, id) berada setelah (since, afterID).
+func after(t time.Time, id primitive.ObjectID, since time.Time, afterID primitive.ObjectID) bool {
+	return t.After(since) || (t.Equal(since) && id.Hex() > afterID.Hex())
+}
+
+func (s *memoryRelapseStore) ListChangedSince(_ context.Context, userID primitive.ObjectID, since time.Time, afterID primitive.ObjectID, limit int64) ([]models.RelapseLog, error) {
+	relapses := s.filterAll(func(r models.RelapseLog) bool {
+		return r.UserID == userID && after(r.UpdatedAt, r.ID, since, afterID)
+	})
+	sort.Slice(relapses, func(i, j int) bool {
+		return after(relapses[j].UpdatedAt, relapses[j].ID, relapses[i].UpdatedAt, relapses[i].ID)
+	})
+	if limit > 0 && int64(len(relapses)) > limit {
+		relapses = relapses[:limit]
+	}
+	return relapses, nil
+}
+
+// filter mengembalikan relapse aktif (belum di-soft delete) yang cocok dengan keep.
+func (s *memoryRelapseStore) filter(keep func(models.RelapseLog) bool) []models.RelapseLog {
+	return s.filterAll(func(r models.RelapseLog) bool { return r.DeletedAt == nil && keep(r) })
+}
+
+// filterAll sama seperti filter, termasuk relapse yang di-soft delete.
+func (s *memoryRelapseStore) filterAll(keep func(models.RelapseLog) bool) []models.RelapseLog {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var result []models.RelapseLog
+	for _, r := range s.relapses {
+		if keep(r) {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+func (s *memoryRelapseStore) ListByUser(_ context.Context, userID primitive.ObjectID, ascending bool) ([]models.RelapseLog, error) {
+	return s.sorted(s.filter(func(r models.RelapseLog) bool { return r.UserID == userID }), ascending), nil
+}
+
+func (s *memoryRelapseStore) ListByHabit(_ context.Context, habitID primitive.ObjectID, ascending bool) ([]models.RelapseLog, error) {
+	return s.sorted(s.filter(func(r models.RelapseLog) bool { return r.HabitID == habitID }), ascending), nil
+}
+
+func (s *memoryRelapseStore) ListByHabitBetween(_ context.Context, habitID primitive.ObjectID, from, to time.Time) ([]models.RelapseLog, error) {
+	return s.sorted(s.filter(func(r models.RelapseLog) bool {
+		return r.HabitID == habitID && !r.RelapseTime.Before(from) && r.RelapseTime.Before(to)
+	}), true), nil
+}
+
+func (s *memoryRelapseStore) ListFiltered(_ context.Context, habitID primitive.ObjectID, filter RelapseFilter, ascending bool) ([]models.RelapseLog, error) {
+	return s.sorted(s.filter(func(r models.RelapseLog) bool { return r.HabitID == habitID && filter.Match(&r) }), ascending), nil
+}
+
+func (s *memoryRelapseStore) sorted(relapses []models.RelapseLog, ascending bool) []models.RelapseLog {
+	sort.SliceStable(relapses, func(i, j int) bool {
+		if ascending {
+			return relapses[i].RelapseTime.Before(relapses[j].RelapseTime)
+		}
+		return relapses[i].RelapseTime.After(relapses[j].RelapseTime)
+	})
+	return relapses
+}
+
+func (s *memoryRelapseStore) CountByHabit(_ context.Context, habitID primitive.ObjectID) (int64, error) {
+	return int64(len(s.filter(func(r models.RelapseLog) bool { return r.HabitID == habitID }))), nil
+}
+
+func (s *memoryRelapseStore) CountSince(_ context.Context, since time.Time) (int64, error) {
+	return int64(len(s.filter(func(r models.RelapseLog) bool { return !r.RelapseTime.Before(since) }))), nil
+}
+
+func (s *memoryRelapseStore) ListTimesSince(_ context.Context, habitIDs []primitive.ObjectID, since time.Time) (map[primitive.ObjectID][]time.Time, error) {
+	wanted := make(map[primitive.ObjectID]bool, len(habitIDs))
+	for _, id := range habitIDs {
+		wanted[id] = true
+	}
+	relapses := s.filter(func(r models.RelapseLog) bool { return wanted[r.HabitID] && !r.RelapseTime.Before(since) })
+	sort.SliceStable(relapses, func(i, j int) bool {
+		return relapses[i].RelapseTime.Before(relapses[j].RelapseTime)
+	})
+
+	times := make(map[primitive.ObjectID][]time.Time)
+	for _, r := range relapses {
+		times[r.HabitID] = append(times[r.HabitID], r.RelapseTime)
+	}
+	return times, nil
+}
+
+func (s *memoryRelapseStore) CountNoteDays(_ context.Context, userID primitive.ObjectID, loc *time.Location) (int, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	days := make(map[string]bool)
+	for _, r := range s.filter(func(r models.RelapseLog) bool { return r.UserID == userID }) {
+		if r.RelapseNote != nil && *r.RelapseNote != "" {
+			days[r.RelapseTime.In(loc).Format("2006-01-02")] = true
+		}
+	}
+	return len(days), nil
+}
+
+func (s *memoryRelapseStore) ListNoteUserIDs(_ context.Context) ([]primitive.ObjectID, error) {
+	seen := make(map[primitive.ObjectID]bool)
+	var ids []primitive.ObjectID
+	for _, r := range s.filter(func(r models.RelapseLog) bool { return r.RelapseNote != nil && *r.RelapseNote != "" }) {
+		if !seen[r.UserID] {
+			seen[r.UserID] = true
+			ids = append(ids, r.UserID)
+		}
+	}
+	return ids, nil
+}
+
+func (s *memoryRelapseStore) CountByHour(_ context.Context, loc *time.Location) ([]int, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	byHour := make([]int, 24)
+	for _, r := range s.filter(func(models.RelapseLog) bool { return true }) {
+		byHour[r.RelapseTime.In(loc).Hour()]++
+	}
+	return byHour, nil
+}
+
+func (s *memoryRelapseStore) Insights(_ context.Context, q InsightsQuery) (*RelapseInsights, error) {
+	if q.Location == nil {
+		q.Location = time.UTC
+	}
+	relapses := s.sorted(s.filter(func(r models.RelapseLog) bool { return r.HabitID == q.HabitID }), true)
+
+	insights := &RelapseInsights{Total: len(relapses), Windows: make([]WindowCount, len(q.Windows))}
+	weekly := make(map[time.Time]int)
+	monthly := make(map[time.Time]int)
+	lengths := make([]int64, 0, len(relapses))
+	previous := q.Start
+	for _, r := range relapses {
+		lengths = append(lengths, max(0, int64(r.RelapseTime.Sub(previous)/time.Second)))
+		previous = r.RelapseTime
+
+		if !r.RelapseTime.Before(q.WeeksSince) {
+			weekly[streaks.Week.Start(r.RelapseTime, q.Location)]++
+		}
+		if !r.RelapseTime.Before(q.MonthsSince) {
+			monthly[streaks.Month.Start(r.RelapseTime, q.Location)]++
+		}
+		local := r.RelapseTime.In(q.Location)
+		insights.Heatmap[(int(local.Weekday())+6)%7][local.Hour()]++
+	}
+	if len(relapses) > 0 {
+		insights.LastRelapse = &previous
+	}
+	insights.AverageStreak = streaks.Average(lengths)
+	insights.MedianStreak = streaks.Median(lengths)
+	for _, l := range lengths {
+		insights.LongestStreak = max(insights.LongestStreak, l)
+	}
+	insights.Weekly = periodCounts(weekly)
+	insights.Monthly = periodCounts(monthly)
+
+	for i, w := range q.Windows {
+		insights.Windows[i].Window = w
+		for _, r := range relapses {
+			switch {
+			case r.RelapseTime.Before(q.Now.Add(-2 * w)), !r.RelapseTime.Before(q.Now):
+			case r.RelapseTime.Before(q.Now.Add(-w)):
+				insights.Windows[i].Previous++
+			default:
+				insights.Windows[i].Recent++
+			}
+		}
+	}
+	return insights, nil
+}
+
+// periodCounts mengubah hitungan per awal periode menjadi daftar terurut naik (UTC, seperti Mongo).
+func periodCounts(counts map[time.Time]int) []PeriodCount {
+	periods := make([]PeriodCount, 0, len(counts))
+	for start, count := range counts {
+		periods = append(periods, PeriodCount{Start: start.UTC(), Count: count})
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].Start.Before(periods[j].Start) })
+	return periods
+}
+
+func (s *memoryRelapseStore) UpdateNote(_ context.Context, id, userID primitive.ObjectID, note *string, writtenAt, now time.Time) (*models.RelapseLog, error) {
+	return s.update(id, userID, writtenAt, now, func(r *models.RelapseLog) { r.RelapseNote = note })
+}
+
+func (s *memoryRelapseStore) UpdateTime(_ context.Context, id, userID primitive.ObjectID, relapseTime, writtenAt, now time.Time) (*models.RelapseLog, error) {
+	return s.update(id, userID, writtenAt, now, func(r *models.RelapseLog) { r.RelapseTime = relapseTime })
+}
+
+func (s *memoryRelapseStore) UpdateDetails(_ context.Context, id, userID primitive.ObjectID, details models.RelapseDetails, writtenAt, now time.Time) (*models.RelapseLog, error) {
+	return s.update(id, userID, writtenAt, now, func(r *models.RelapseLog) { r.RelapseDetails = details })
+}
+
+// update menerapkan apply pada relapse aktif dan mengembalikan dokumen setelah update.
+func (s *memoryRelapseStore) update(id, userID primitive.ObjectID, writtenAt, now time.Time, apply func(*models.RelapseLog)) (*models.RelapseLog, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	relapse, ok := s.relapses[id]
+	if !ok || relapse.UserID != userID || relapse.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+	apply(&relapse)
+	relapse.ClientUpdatedAt = &writtenAt
+	relapse.UpdatedAt = now
+	s.relapses[id] = relapse
+	return &relapse, nil
+}
+
+// updateWhere menerapkan apply pada semua relapse yang cocok dengan match dan
+// mengembalikan jumlahnya.
+func (s *memoryRelapseStore) updateWhere(match func(models.RelapseLog) bool, apply func(*models.RelapseLog)) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	updated := 0
+	for id, r := range s.relapses {
+		if match(r) {
+			apply(&r)
+			s.relapses[id] = r
+			updated++
+		}
+	}
+	return updated
+}
+
+func (s *memoryRelapseStore) SoftDelete(_ context.Context, id, userID primitive.ObjectID, now time.Time) (bool, error) {
+	deleted := s.updateWhere(func(r models.RelapseLog) bool {
+		return r.ID == id && r.UserID == userID && r.DeletedAt == nil
+	}, func(r *models.RelapseLog) {
+		r.DeletedAt = &now
+		r.UpdatedAt = now
+	})
+	return deleted > 0, nil
+}
+
+func (s *memoryRelapseStore) SoftDeleteByHabit(_ context.Context, habitID, deletionID primitive.ObjectID, now time.Time) (int, error) {
+	return s.updateWhere(func(r models.RelapseLog) bool {
+		return r.HabitID == habitID && r.DeletedAt == nil
+	}, func(r *models.RelapseLog) {
+		r.DeletedAt = &now
+		r.DeletionID = deletionID
+		r.UpdatedAt = now
+	}), nil
+}
+
+func (s *memoryRelapseStore) SoftDeleteAfter(_ context.Context, habitID, deletionID primitive.ObjectID, t, now time.Time) (int, error) {
+	return s.updateWhere(func(r models.RelapseLog) bool {
+		return r.HabitID == habitID && r.DeletedAt == nil && r.RelapseTime.After(t)
+	}, func(r *models.RelapseLog) {
+		r.DeletedAt = &now
+		r.DeletionID = deletionID
+		r.UpdatedAt = now
+	}), nil
+}
+
+// deletedSince melaporkan apakah relapse di-soft delete pada atau setelah since.
+func deletedSince(r models.RelapseLog, since time.Time) bool {
+	return r.DeletedAt != nil && !r.DeletedAt.Before(since)
+}
+
+func restore(now time.Time) func(*models.RelapseLog) {
+	return func(r *models.RelapseLog) {
+		r.DeletedAt = nil
+		r.DeletionID = primitive.NilObjectID
+		r.UpdatedAt = now
+	}
+}
+
+func (s *memoryRelapseStore) Restore(_ context.Context, id, userID primitive.ObjectID, since, now time.Time) (*models.RelapseLog, error) {
+	restored := s.updateWhere(func(r models.RelapseLog) bool {
+		return r.ID == id && r.UserID == userID && deletedSince(r, since)
+	}, restore(now))
+	if restored == 0 {
+		return nil, ErrNotFound
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	relapse := s.relapses[id]
+	return &relapse, nil
+}
+
+func (s *memoryRelapseStore) RestoreDeletion(_ context.Context, habitID, deletionID primitive.ObjectID, since, now time.Time) (int, error) {
+	return s.updateWhere(func(r models.RelapseLog) bool {
+		return r.HabitID == habitID && r.DeletionID == deletionID && deletedSince(r, since)
+	}, restore(now)), nil
+}
+
+func (s *memoryRelapseStore) LastDeletion(_ context.Context, habitID primitive.ObjectID, since time.Time) (primitive.ObjectID, error) {
+	var latest *models.RelapseLog
+	for _, r := range s.filterAll(func(r models.RelapseLog) bool {
+		return r.HabitID == habitID && !r.DeletionID.IsZero() && deletedSince(r, since)
+	}) {
+		if latest == nil || r.DeletedAt.After(*latest.DeletedAt) {
+			r := r
+			latest = &r
+		}
+	}
+	if latest == nil {
+		return primitive.NilObjectID, ErrNotFound
+	}
+	return latest.DeletionID, nil
+}
+
+func (s *memoryRelapseStore) PurgeDeleted(_ context.Context, before, now time.Time) (int, error) {
+	return s.deleteWhere(func(r models.RelapseLog) bool {
+		return r.DeletedAt != nil && r.DeletedAt.Before(before)
+	}, &now), nil
+}
+
+// deleteWhere menghapus semua relapse yang cocok dengan match beserta riwayat editnya.
+// Jika buriedAt tidak nil, tombstone dicatat untuk setiap relapse yang dihapus.
+func (s *memoryRelapseStore) deleteWhere(match func(models.RelapseLog) bool, buriedAt *time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleted := 0
+	for id, r := range s.relapses {
+		if match(r) {
+			if buriedAt != nil {
+				s.tombstones[id] = models.RelapseTombstone{
+					ID:        id,
+					UserID:    r.UserID,
+					HabitID:   r.HabitID,
+					ClientID:  r.ClientID,
+					DeletedAt: *buriedAt,
+				}
+			}
+			delete(s.relapses, id)
+			deleted++
+		}
+	}
+	if deleted > 0 {
+		revisions := s.revisions[:0]
+		for _, rev := range s.revisions {
+			if _, ok := s.relapses[rev.RelapseID]; ok {
+				revisions = append(revisions, rev)
+			}
+		}
+		s.revisions = revisions
+	}
+	return deleted
+}
+
+func (s *memoryRelapseStore) DeleteAllByUser(_ context.Context, userID primitive.ObjectID) (int, error) {
+	deleted := s.deleteWhere(func(r models.RelapseLog) bool { return r.UserID == userID }, nil)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, t := range s.tombstones {
+		if t.UserID == userID {
+			delete(s.tombstones, id)
+		}
+	}
+	return deleted, nil
+}
+
+func (s *memoryRelapseStore) DeleteAllByHabit(_ context.Context, habitID primitive.ObjectID, now time.Time) (int, error) {
+	return s.deleteWhere(func(r models.RelapseLog) bool { return r.HabitID == habitID }, &now), nil
+}
+
+func (s *memoryRelapseStore) FindTombstone(_ context.Context, userID, id primitive.ObjectID, clientID string) (*models.RelapseTombstone, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	matches := func(relapseID primitive.ObjectID, relapseClientID string) bool {
+		return relapseID == id || (clientID != "" && relapseClientID == clientID)
+	}
+	// Relapse yang masih di-soft delete diperlakukan sama seperti tombstone
+	for _, r := range s.relapses {
+		if r.UserID == userID && r.DeletedAt != nil && matches(r.ID, r.ClientID) {
+			return &models.RelapseTombstone{
+				ID:        r.ID,
+				UserID:    r.UserID,
+				HabitID:   r.HabitID,
+				ClientID:  r.ClientID,
+				DeletedAt: *r.DeletedAt,
+			}, nil
+		}
+	}
+	for _, t := range s.tombstones {
+		if t.UserID == userID && matches(t.ID, t.ClientID) {
+			return &t, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *memoryRelapseStore) ListTombstonesSince(_ context.Context, userID primitive.ObjectID, since time.Time, afterID primitive.ObjectID, limit int64) ([]models.RelapseTombstone, error) {
+	s.mu.RLock()
+	var tombstones []models.RelapseTombstone
+	for _, t := range s.tombstones {
+		if t.UserID == userID && after(t.DeletedAt, t.ID, since, afterID) {
+			tombstones = append(tombstones, t)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(tombstones, func(i, j int) bool {
+		return after(tombstones[j].DeletedAt, tombstones[j].ID, tombstones[i].DeletedAt, tombstones[i].ID)
+	})
+	if limit > 0 && int64(len(tombstones)) > limit {
+		tombstones = tombstones[:limit]
+	}
+	return tombstones, nil
+}
+
+func (s *memoryRelapseStore) AddRevision(_ context.Context, revision *models.RelapseRevision) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if revision.ID.IsZero() {
+		revision.ID = primitive.NewObjectID()
+	}
+	s.revisions = append(s.revisions, *revision)
+	return nil
+}
+
+func (s *memoryRelapseStore) ListRevisions(_ context.Context, relapseID, userID primitive.ObjectID) ([]models.RelapseRevision, error) {
+	return s.listRevisions(func(rev models.RelapseRevision) bool {
+		return rev.RelapseID == relapseID && rev.UserID == userID
+	}), nil
+}
+
+func (s *memoryRelapseStore) ListRevisionsByUser(_ context.Context, userID primitive.ObjectID) ([]models.RelapseRevision, error) {
+	return s.listRevisions(func(rev models.RelapseRevision) bool { return rev.UserID == userID }), nil
+}
+
+func (s *memoryRelapseStore) listRevisions(keep func(models.RelapseRevision) bool) []models.RelapseRevision {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var revisions []models.RelapseRevision
+	for _, rev := range s.revisions {
+		if keep(rev) {
+			revisions = append(revisions, rev)
+		}
+	}
+	sort.SliceStable(revisions, func(i, j int) bool {
+		if revisions[i].RelapseID != revisions[j].RelapseID {
+			return revisions[i].RelapseID.Hex() < revisions[j].RelapseID.Hex()
+		}
+		return revisions[i].Version < revisions[j].Version
+	})
+	return revisions
+}
+
+func (s *memoryRelapseStore) AssignHabit(_ context.Context, userID, habitID primitive.ObjectID) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	assigned := 0
+	for id, r := range s.relapses {
+		if r.UserID == userID && r.HabitID.IsZero() {
+			r.HabitID = habitID
+			s.relapses[id] = r
+			assigned++
+		}
+	}
+	return assigned, nil
+}
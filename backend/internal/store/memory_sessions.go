@@ -0,0 +1,148 @@
+// internal/store/memory_sessions.go
+package store
+
+import (
+	"context"
+	"maps"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/models"
+)
+
+type memorySessionStore struct {
+	memoryBase
+	sessions map[primitive.ObjectID]models.UserSession
+}
+
+func newMemorySessionStore() *memorySessionStore {
+	return &memorySessionStore{sessions: make(map[primitive.ObjectID]models.UserSession)}
+}
+
+func (s *memorySessionStore) snapshot() func() {
+	return snapshotOf(&s.memoryBase, &s.sessions, maps.Clone)
+}
+
+func (s *memorySessionStore) Create(_ context.Context, session *models.UserSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if session.ID.IsZero() {
+		session.ID = primitive.NewObjectID()
+	}
+	s.sessions[session.ID] = *session
+	return nil
+}
+
+func (s *memorySessionStore) FindByID(_ context.Context, id, userID primitive.ObjectID) (*models.UserSession, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	session, ok := s.sessions[id]
+	if !ok || session.UserID != userID {
+		return nil, ErrNotFound
+	}
+	return &session, nil
+}
+
+func (s *memorySessionStore) FindActive(_ context.Context, userID primitive.ObjectID, tokenHash string) (*models.UserSession, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, session := range s.sessions {
+		if session.UserID == userID && session.Token == tokenHash && session.RevokedAt == nil {
+			return &session, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *memorySessionStore) ListActive(_ context.Context, userID primitive.ObjectID) ([]models.UserSession, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var sessions []models.UserSession
+	for _, session := range s.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastActiveTime.After(sessions[j].LastActiveTime)
+	})
+	return sessions, nil
+}
+
+func (s *memorySessionStore) ListByUser(_ context.Context, userID primitive.ObjectID) ([]models.UserSession, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sessions := []models.UserSession{}
+	for _, session := range s.sessions {
+		if session.UserID == userID {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LoginTime.After(sessions[j].LoginTime)
+	})
+	return sessions, nil
+}
+
+func (s *memorySessionStore) Touch(_ context.Context, id primitive.ObjectID, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if session, ok := s.sessions[id]; ok {
+		session.LastActiveTime = at
+		s.sessions[id] = session
+	}
+	return nil
+}
+
+// revoke mencabut semua sesi aktif yang cocok dengan match.
+func (s *memorySessionStore) revoke(match func(models.UserSession) bool, at time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	revoked := 0
+	for id, session := range s.sessions {
+		if session.RevokedAt != nil || !match(session) {
+			continue
+		}
+		revokedAt := at
+		session.RevokedAt = &revokedAt
+		session.LastActiveTime = at
+		s.sessions[id] = session
+		revoked++
+	}
+	return revoked
+}
+
+func (s *memorySessionStore) Revoke(_ context.Context, id primitive.ObjectID, at time.Time) error {
+	s.revoke(func(session models.UserSession) bool { return session.ID == id }, at)
+	return nil
+}
+
+func (s *memorySessionStore) RevokeByToken(_ context.Context, tokenHash string, at time.Time) error {
+	s.revoke(func(session models.UserSession) bool { return session.Token == tokenHash }, at)
+	return nil
+}
+
+func (s *memorySessionStore) RevokeAllExcept(_ context.Context, userID primitive.ObjectID, exceptHash string, at time.Time) (int, error) {
+	return s.revoke(func(session models.UserSession) bool {
+		return session.UserID == userID && session.Token != exceptHash
+	}, at), nil
+}
+
+func (s *memorySessionStore) RevokeAll(_ context.Context, userID primitive.ObjectID, at time.Time) (int, error) {
+	return s.revoke(func(session models.UserSession) bool { return session.UserID == userID }, at), nil
+}
+
+func (s *memorySessionStore) DeleteRevokedBefore(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	for id, session := range s.sessions {
+		if session.RevokedAt != nil && session.RevokedAt.Before(before) {
+			delete(s.sessions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,113 @@
+// internal/store/memory_streaks.go
+package store
+
+import (
+	"context"
+	"maps"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/models"
+)
+
+type memoryStreakSummaryStore struct {
+	memoryBase
+	summaries map[primitive.ObjectID]models.StreakSummary
+}
+
+func newMemoryStreakSummaryStore() *memoryStreakSummaryStore {
+	return &memoryStreakSummaryStore{summaries: make(map[primitive.ObjectID]models.StreakSummary)}
+}
+
+func (s *memoryStreakSummaryStore) snapshot() func() {
+	return snapshotOf(&s.memoryBase, &s.summaries, maps.Clone)
+}
+
+func (s *memoryStreakSummaryStore) Get(_ context.Context, habitID primitive.ObjectID) (*models.StreakSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	summary, ok := s.summaries[habitID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &summary, nil
+}
+
+func (s *memoryStreakSummaryStore) Upsert(_ context.Context, summary *models.StreakSummary) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.summaries[summary.HabitID] = *summary
+	return nil
+}
+
+func (s *memoryStreakSummaryStore) Delete(_ context.Context, habitID primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.summaries, habitID)
+	return nil
+}
+
+func (s *memoryStreakSummaryStore) DeleteByUser(_ context.Context, userID primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, summary := range s.summaries {
+		if summary.UserID == userID {
+			delete(s.summaries, id)
+		}
+	}
+	return nil
+}
+
+// DeleteLegacy tidak melakukan apa-apa: store memory tidak pernah menyimpan format lama.
+func (s *memoryStreakSummaryStore) DeleteLegacy(_ context.Context) (int64, error) {
+	return 0, nil
+}
+
+// ranked mengembalikan ringkasan yang lolos filter dalam urutan ranking (harus dipanggil dengan lock baca).
+func (s *memoryStreakSummaryStore) ranked(filter RankingFilter) []models.StreakSummary {
+	list := make([]models.StreakSummary, 0, len(s.summaries))
+	for _, summary := range s.summaries {
+		if !filter.matches(&summary) {
+			continue
+		}
+		list = append(list, summary)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return filter.less(&list[i], &list[j])
+	})
+	return list
+}
+
+func (s *memoryStreakSummaryStore) ListRanked(_ context.Context, filter RankingFilter, skip, limit int64) ([]models.StreakSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := s.ranked(filter)
+	first := sort.Search(len(list), func(i int) bool { return filter.afterKey(&list[i]) })
+	list = list[first:]
+	if skip >= int64(len(list)) {
+		return []models.StreakSummary{}, nil
+	}
+	list = list[skip:]
+	if limit > 0 && limit < int64(len(list)) {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+func (s *memoryStreakSummaryStore) Rank(_ context.Context, filter RankingFilter, habitID primitive.ObjectID) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for i, summary := range s.ranked(filter) {
+		if summary.HabitID == habitID {
+			return int64(i) + 1, nil
+		}
+	}
+	return 0, ErrNotFound
+}
+
+func (s *memoryStreakSummaryStore) Count(_ context.Context, filter RankingFilter) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return int64(len(s.ranked(filter))), nil
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/models"
+)
+
+func TestMemoryTransaction(t *testing.T) {
+	errFail := errors.New("fail")
+
+	tests := []struct {
+		name     string
+		err      error
+		relapses int
+		longest  int64
+	}{
+		{name: "commit", relapses: 2, longest: 3600},
+		{name: "rollback", err: errFail, relapses: 1, longest: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := NewMemory()
+			now := time.Now()
+			habit := &models.Habit{ID: primitive.NewObjectID(), UserID: primitive.NewObjectID(), CreatedAt: now}
+			if err := s.Habits.Create(ctx, habit); err != nil {
+				t.Fatal(err)
+			}
+			existing := &models.RelapseLog{UserID: habit.UserID, HabitID: habit.ID, RelapseTime: now.Add(-time.Hour)}
+			if err := s.Relapses.Create(ctx, existing); err != nil {
+				t.Fatal(err)
+			}
+
+			err := s.Tx.WithTransaction(ctx, func(ctx context.Context) error {
+				if _, err := s.Relapses.SoftDeleteByHabit(ctx, habit.ID, primitive.NewObjectID(), now); err != nil {
+					return err
+				}
+				for _, at := range []time.Time{now.Add(-30 * time.Minute), now} {
+					if err := s.Relapses.Create(ctx, &models.RelapseLog{UserID: habit.UserID, HabitID: habit.ID, RelapseTime: at}); err != nil {
+						return err
+					}
+				}
+				if err := s.Habits.SetLongestStreak(ctx, habit.ID, 3600); err != nil {
+					return err
+				}
+				return tt.err
+			})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+
+			relapses, err := s.Relapses.ListByHabit(ctx, habit.ID, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(relapses) != tt.relapses {
+				t.Errorf("%d active relapses, want %d", len(relapses), tt.relapses)
+			}
+			stored, err := s.Habits.FindByID(ctx, habit.ID, habit.UserID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.LongestStreakSeconds != tt.longest {
+				t.Errorf("LongestStreakSeconds = %d, want %d", stored.LongestStreakSeconds, tt.longest)
+			}
+		})
+	}
+}
+
+func TestMemoryRefreshTokenPruning(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemory()
+	now := time.Now()
+	user := &models.User{ID: primitive.NewObjectID(), Username: "alice"}
+	if err := s.Users.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+
+	rotatedAt := func(d time.Duration) *time.Time { at := now.Add(-d); return &at }
+	entries := map[string]models.RefreshTokenSchema{
+		"active":           {ExpiresAt: now.Add(time.Hour)},
+		"expired":          {ExpiresAt: now.Add(-time.Minute)},
+		"recently rotated": {ExpiresAt: now.Add(time.Hour), RotatedAt: rotatedAt(time.Minute)},
+		"rotated long ago": {ExpiresAt: now.Add(time.Hour), RotatedAt: rotatedAt(models.RefreshRotatedRetention + time.Minute)},
+	}
+	// Entri awal disimpan sebelum aturan pembersihan berlaku (now lama)
+	for jti, entry := range entries {
+		entry.JTI = jti
+		if err := s.Users.AddRefreshToken(ctx, user.ID, entry, now.Add(-24*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Users.AddRefreshToken(ctx, user.ID, models.RefreshTokenSchema{JTI: "new", ExpiresAt: now.Add(time.Hour)}, now); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Users.FindByID(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for jti, want := range map[string]bool{"active": true, "expired": false, "recently rotated": true, "rotated long ago": false, "new": true} {
+		if kept := got.FindRefreshToken(jti) != nil; kept != want {
+			t.Errorf("%s kept = %v, want %v", jti, kept, want)
+		}
+	}
+}
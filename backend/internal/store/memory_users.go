@@ -0,0 +1,316 @@
+// internal/store/memory_users.go
+package store
+
+import (
+	"context"
+	"maps"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/models"
+)
+
+type memoryUserStore struct {
+	memoryBase
+	users map[primitive.ObjectID]models.User
+}
+
+func newMemoryUserStore() *memoryUserStore {
+	return &memoryUserStore{users: make(map[primitive.ObjectID]models.User)}
+}
+
+func (s *memoryUserStore) snapshot() func() {
+	return snapshotOf(&s.memoryBase, &s.users, maps.Clone)
+}
+
+// cloneUser menyalin slice di dalam User agar pemanggil tidak mengubah data store.
+func cloneUser(u models.User) *models.User {
+	u.RefreshTokens = append([]models.RefreshTokenSchema(nil), u.RefreshTokens...)
+	u.TwoFactor.RecoveryCodes = append([]string(nil), u.TwoFactor.RecoveryCodes...)
+	return &u
+}
+
+func (s *memoryUserStore) FindByID(_ context.Context, id primitive.ObjectID) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	u, ok := s.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return cloneUser(u), nil
+}
+
+func (s *memoryUserStore) FindByUsername(_ context.Context, username string) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, u := range s.users {
+		if u.Username == username {
+			return cloneUser(u), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *memoryUserStore) FindByIDs(_ context.Context, ids []primitive.ObjectID) ([]models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	users := make([]models.User, 0, len(ids))
+	for _, id := range ids {
+		if u, ok := s.users[id]; ok {
+			users = append(users, *cloneUser(u))
+		}
+	}
+	return users, nil
+}
+
+func (s *memoryUserStore) UsernameTaken(_ context.Context, username string, excludeID primitive.ObjectID) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for id, u := range s.users {
+		if u.Username == username && id != excludeID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *memoryUserStore) Create(_ context.Context, user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	s.users[user.ID] = *cloneUser(*user)
+	return nil
+}
+
+func (s *memoryUserStore) Delete(_ context.Context, id primitive.ObjectID) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[id]; !ok {
+		return false, nil
+	}
+	delete(s.users, id)
+	return true, nil
+}
+
+func (s *memoryUserStore) filter(keep func(models.User) bool) []models.User {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]models.User, 0, len(s.users))
+	for _, u := range s.users {
+		if keep(u) {
+			result = append(result, *cloneUser(u))
+		}
+	}
+	return result
+}
+
+func (s *memoryUserStore) List(_ context.Context) ([]models.User, error) {
+	users := s.filter(func(models.User) bool { return true })
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].CreatedAt.After(users[j].CreatedAt)
+	})
+	return users, nil
+}
+
+func (s *memoryUserStore) Count(_ context.Context) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return int64(len(s.users)), nil
+}
+
+func (s *memoryUserStore) CountCreatedSince(_ context.Context, since time.Time) (int64, error) {
+	return int64(len(s.filter(func(u models.User) bool { return !u.CreatedAt.Before(since) }))), nil
+}
+
+func (s *memoryUserStore) LanguageDistribution(_ context.Context) ([]LanguageCount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	counts := make(map[string]int)
+	for _, u := range s.users {
+		counts[u.LanguagePref]++
+	}
+	result := make([]LanguageCount, 0, len(counts))
+	for lang, count := range counts {
+		result = append(result, LanguageCount{LanguagePref: lang, UserCount: count})
+	}
+	return result, nil
+}
+
+// update menjalankan fn terhadap user di bawah lock tulis.
+func (s *memoryUserStore) update(id primitive.ObjectID, fn func(u *models.User) bool) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[id]
+	if !ok {
+		return false, ErrNotFound
+	}
+	changed := fn(&u)
+	s.users[id] = u
+	return changed, nil
+}
+
+func (s *memoryUserStore) SetLoginState(_ context.Context, id primitive.ObjectID, failedAttempts int, lockoutUntil *time.Time) error {
+	_, err := s.update(id, func(u *models.User) bool {
+		u.FailedLoginAttempts = failedAttempts
+		u.LockoutUntil = lockoutUntil
+		return true
+	})
+	return err
+}
+
+func (s *memoryUserStore) UpdateProfile(_ context.Context, id primitive.ObjectID, update UserProfileUpdate, now time.Time) error {
+	_, err := s.update(id, func(u *models.User) bool {
+		if update.Nickname != nil {
+			u.Nickname = *update.Nickname
+		}
+		if update.Username != nil {
+			u.Username = *update.Username
+		}
+		if update.ProfilePicture != nil {
+			u.ProfilePicture = *update.ProfilePicture
+		}
+		if update.ProfilePictureSmall != nil {
+			u.ProfilePictureSmall = *update.ProfilePictureSmall
+		}
+		u.UpdatedAt = now
+		return true
+	})
+	return err
+}
+
+func (s *memoryUserStore) SetPassword(_ context.Context, id primitive.ObjectID, hash string, now time.Time) error {
+	_, err := s.update(id, func(u *models.User) bool {
+		u.Password = hash
+		u.UpdatedAt = now
+		return true
+	})
+	return err
+}
+
+func (s *memoryUserStore) SetLanguage(_ context.Context, id primitive.ObjectID, lang string, now time.Time) error {
+	_, err := s.update(id, func(u *models.User) bool {
+		u.LanguagePref = lang
+		u.UpdatedAt = now
+		return true
+	})
+	return err
+}
+
+func (s *memoryUserStore) SetRankingVisibility(_ context.Context, id primitive.ObjectID, visibility string, now time.Time) error {
+	_, err := s.update(id, func(u *models.User) bool {
+		u.RankingVisibility = visibility
+		u.UpdatedAt = now
+		return true
+	})
+	return err
+}
+
+func (s *memoryUserStore) SetTimezone(_ context.Context, id primitive.ObjectID, timezone string, now time.Time) error {
+	_, err := s.update(id, func(u *models.User) bool {
+		u.Timezone = timezone
+		u.UpdatedAt = now
+		return true
+	})
+	return err
+}
+
+func (s *memoryUserStore) SetStreakMirror(_ context.Context, id primitive.ObjectID, start *time.Time, longestSeconds int64) error {
+	_, err := s.update(id, func(u *models.User) bool {
+		u.StreakStartDate = start
+		u.LongestStreakSeconds = longestSeconds
+		return true
+	})
+	return err
+}
+
+func (s *memoryUserStore) AddRefreshToken(_ context.Context, id primitive.ObjectID, token models.RefreshTokenSchema, now time.Time) error {
+	_, err := s.update(id, func(u *models.User) bool {
+		rotatedBefore := now.Add(-models.RefreshRotatedRetention)
+		kept := u.RefreshTokens[:0]
+		for _, t := range u.RefreshTokens {
+			if t.ExpiresAt.After(now) && (t.RotatedAt == nil || t.RotatedAt.After(rotatedBefore)) {
+				kept = append(kept, t)
+			}
+		}
+		u.RefreshTokens = append(kept, token)
+		return true
+	})
+	return err
+}
+
+func (s *memoryUserStore) RotateRefreshToken(_ context.Context, id primitive.ObjectID, jti string, at time.Time) (bool, error) {
+	changed, err := s.update(id, func(u *models.User) bool {
+		t := u.FindRefreshToken(jti)
+		if t == nil || t.RotatedAt != nil {
+			return false
+		}
+		t.RotatedAt = &at
+		return true
+	})
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return changed, err
+}
+
+func (s *memoryUserStore) RemoveSessionRefreshTokens(_ context.Context, id, sessionID primitive.ObjectID) error {
+	_, err := s.update(id, func(u *models.User) bool {
+		kept := u.RefreshTokens[:0]
+		for _, t := range u.RefreshTokens {
+			if t.SessionID != sessionID {
+				kept = append(kept, t)
+			}
+		}
+		u.RefreshTokens = kept
+		return true
+	})
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+func (s *memoryUserStore) SetTwoFactor(_ context.Context, id primitive.ObjectID, settings models.TwoFactorSettings, now time.Time) error {
+	settings.RecoveryCodes = append([]string(nil), settings.RecoveryCodes...)
+	_, err := s.update(id, func(u *models.User) bool {
+		u.TwoFactor = settings
+		u.UpdatedAt = now
+		return true
+	})
+	return err
+}
+
+func (s *memoryUserStore) UseTwoFactorStep(_ context.Context, id primitive.ObjectID, step int64) (bool, error) {
+	changed, err := s.update(id, func(u *models.User) bool {
+		if u.TwoFactor.LastUsedStep >= step {
+			return false
+		}
+		u.TwoFactor.LastUsedStep = step
+		return true
+	})
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return changed, err
+}
+
+func (s *memoryUserStore) ConsumeRecoveryCode(_ context.Context, id primitive.ObjectID, codeHash string) (bool, error) {
+	changed, err := s.update(id, func(u *models.User) bool {
+		for i, h := range u.TwoFactor.RecoveryCodes {
+			if h == codeHash {
+				codes := append([]string(nil), u.TwoFactor.RecoveryCodes[:i]...)
+				u.TwoFactor.RecoveryCodes = append(codes, u.TwoFactor.RecoveryCodes[i+1:]...)
+				return true
+			}
+		}
+		return false
+	})
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return changed, err
+}
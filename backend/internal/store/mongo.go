@@ -0,0 +1,78 @@
+// internal/store/mongo.go
+package store
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// NewMongo membuat seluruh store yang didukung oleh database MongoDB.
+func NewMongo(db *mongo.Database) *Stores {
+	return &Stores{
+		Users:                &mongoUserStore{coll: db.Collection("users")},
+		Sessions:             &mongoSessionStore{coll: db.Collection("usersessions")},
+		Relapses:             &mongoRelapseStore{coll: db.Collection("relapselogs"), tombstones: db.Collection("relapsetombstones"), revisions: db.Collection("relapserevisions")},
+		Habits:               &mongoHabitStore{coll: db.Collection("habits")},
+		ActivityLogs:         &mongoActivityLogStore{coll: db.Collection("activitylogs")},
+		Honeypots:            &mongoHoneypotStore{coll: db.Collection("honeypotlogs")},
+		LoginAttempts:        &mongoLoginAttemptStore{coll: db.Collection("loginattempts")},
+		RegistrationAttempts: &mongoRegistrationAttemptStore{coll: db.Collection("registrationattempts")},
+		StreakSummaries:      &mongoStreakSummaryStore{coll: db.Collection("streaksummaries")},
+		DataExports:          &mongoDataExportStore{coll: db.Collection("dataexports")},
+		Achievements:         &mongoAchievementStore{coll: db.Collection("achievements")},
+		Jobs:                 &mongoJobStore{leases: db.Collection("joblocks"), runs: db.Collection("jobruns")},
+		Tx:                   &mongoTransactor{client: db.Client()},
+	}
+}
+
+// mongoTransactor memakai multi-document transaction MongoDB. Server standalone tidak
+// mendukung transaksi; pada kasus itu fn dijalankan tanpa transaksi (sekali log peringatan).
+type mongoTransactor struct {
+	client      *mongo.Client
+	unsupported atomic.Bool
+}
+
+func (t *mongoTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if t.unsupported.Load() {
+		return fn(ctx)
+	}
+
+	session, err := t.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	if isTransactionUnsupported(err) {
+		// Operasi pertama di dalam transaksi langsung ditolak, jadi belum ada yang tertulis
+		if t.unsupported.CompareAndSwap(false, true) {
+			slog.WarnContext(ctx, "MongoDB does not support transactions (not a replica set), running operations without a transaction")
+		}
+		return fn(ctx)
+	}
+	return err
+}
+
+// isTransactionUnsupported mendeteksi error "Transaction numbers are only allowed on a replica set member or mongos".
+func isTransactionUnsupported(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Code == 20 // IllegalOperation
+	}
+	return false
+}
+
+// mapErr menerjemahkan error driver menjadi error store.
+func mapErr(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+// internal/store/mongo_achievements.go
+package store
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"solivra-go/backend/internal/models"
+)
+
+type mongoAchievementStore struct {
+	coll *mongo.Collection
+}
+
+func (s *mongoAchievementStore) Award(ctx context.Context, achievement *models.Achievement) (bool, error) {
+	if achievement.ID.IsZero() {
+		achievement.ID = primitive.NewObjectID()
+	}
+	filter := bson.M{"user": achievement.UserID, "code": achievement.Code, "habit": bson.M{"$exists": false}}
+	if !achievement.HabitID.IsZero() {
+		filter["habit"] = achievement.HabitID
+	}
+
+	// Upsert dengan $setOnInsert: evaluasi yang berjalan bersamaan tidak memberi badge dua kali
+	res, err := s.coll.UpdateOne(ctx, filter,
+		bson.M{"$setOnInsert": achievement},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.UpsertedCount > 0, nil
+}
+
+func (s *mongoAchievementStore) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.Achievement, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "awarded_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := s.coll.Find(ctx, bson.M{"user": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	achievements := []models.Achievement{}
+	if err := cursor.All(ctx, &achievements); err != nil {
+		return nil, err
+	}
+	return achievements, nil
+}
+
+func (s *mongoAchievementStore) DeleteByHabit(ctx context.Context, habitID primitive.ObjectID) error {
+	_, err := s.coll.DeleteMany(ctx, bson.M{"habit": habitID})
+	return err
+}
+
+func (s *mongoAchievementStore) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := s.coll.DeleteMany(ctx, bson.M{"user": userID})
+	return err
+}
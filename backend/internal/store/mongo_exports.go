@@ -0,0 +1,115 @@
+// internal/store/mongo_exports.go
+package store
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"solivra-go/backend/internal/models"
+)
+
+type mongoDataExportStore struct {
+	coll *mongo.Collection
+}
+
+func (s *mongoDataExportStore) findOne(ctx context.Context, filter bson.M) (*models.DataExport, error) {
+	var export models.DataExport
+	if err := s.coll.FindOne(ctx, filter).Decode(&export); err != nil {
+		return nil, mapErr(err)
+	}
+	return &export, nil
+}
+
+func (s *mongoDataExportStore) find(ctx context.Context, filter bson.M) ([]models.DataExport, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := s.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	exports := []models.DataExport{}
+	if err := cursor.All(ctx, &exports); err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+func (s *mongoDataExportStore) Create(ctx context.Context, export *models.DataExport) error {
+	if export.ID.IsZero() {
+		export.ID = primitive.NewObjectID()
+	}
+	_, err := s.coll.InsertOne(ctx, export)
+	return err
+}
+
+func (s *mongoDataExportStore) Get(ctx context.Context, id primitive.ObjectID) (*models.DataExport, error) {
+	return s.findOne(ctx, bson.M{"_id": id})
+}
+
+func (s *mongoDataExportStore) FindByID(ctx context.Context, id, userID primitive.ObjectID) (*models.DataExport, error) {
+	return s.findOne(ctx, bson.M{"_id": id, "user": userID})
+}
+
+func (s *mongoDataExportStore) FindPending(ctx context.Context, userID primitive.ObjectID) (*models.DataExport, error) {
+	return s.findOne(ctx, bson.M{"user": userID, "status": models.DataExportPending})
+}
+
+func (s *mongoDataExportStore) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.DataExport, error) {
+	return s.find(ctx, bson.M{"user": userID})
+}
+
+func (s *mongoDataExportStore) MarkReady(ctx context.Context, id primitive.ObjectID, filePath string, size int64, expiresAt, now time.Time) error {
+	_, err := s.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"status":       models.DataExportReady,
+		"file_path":    filePath,
+		"size_bytes":   size,
+		"completed_at": now,
+		"expires_at":   expiresAt,
+	}})
+	return err
+}
+
+func (s *mongoDataExportStore) MarkFailed(ctx context.Context, id primitive.ObjectID, reason string, expiresAt, now time.Time) error {
+	_, err := s.coll.UpdateOne(ctx, bson.M{"_id": id}, failedUpdate(reason, expiresAt, now))
+	return err
+}
+
+func (s *mongoDataExportStore) FailStale(ctx context.Context, createdBefore time.Time, reason string, expiresAt, now time.Time) (int, error) {
+	res, err := s.coll.UpdateMany(ctx, bson.M{
+		"status":     models.DataExportPending,
+		"created_at": bson.M{"$lt": createdBefore},
+	}, failedUpdate(reason, expiresAt, now))
+	if err != nil {
+		return 0, err
+	}
+	return int(res.ModifiedCount), nil
+}
+
+func failedUpdate(reason string, expiresAt, now time.Time) bson.M {
+	return bson.M{"$set": bson.M{
+		"status":       models.DataExportFailed,
+		"error":        reason,
+		"completed_at": now,
+		"expires_at":   expiresAt,
+	}}
+}
+
+func (s *mongoDataExportStore) ListExpired(ctx context.Context, now time.Time) ([]models.DataExport, error) {
+	return s.find(ctx, bson.M{"expires_at": bson.M{"$lte": now}})
+}
+
+func (s *mongoDataExportStore) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.coll.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (s *mongoDataExportStore) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := s.coll.DeleteMany(ctx, bson.M{"user": userID})
+	return err
+}
@@ -0,0 +1,172 @@
+// internal/store/mongo_habits.go
+package store
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"solivra-go/backend/internal/models"
+)
+
+type mongoHabitStore struct {
+	coll *mongo.Collection
+}
+
+func (s *mongoHabitStore) findOne(ctx context.Context, filter bson.M) (*models.Habit, error) {
+	var habit models.Habit
+	if err := s.coll.FindOne(ctx, filter).Decode(&habit); err != nil {
+		return nil, mapErr(err)
+	}
+	return &habit, nil
+}
+
+func (s *mongoHabitStore) find(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]models.Habit, error) {
+	cursor, err := s.coll.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	habits := []models.Habit{}
+	if err := cursor.All(ctx, &habits); err != nil {
+		return nil, err
+	}
+	return habits, nil
+}
+
+func (s *mongoHabitStore) Create(ctx context.Context, habit *models.Habit) error {
+	if habit.ID.IsZero() {
+		habit.ID = primitive.NewObjectID()
+	}
+	_, err := s.coll.InsertOne(ctx, habit)
+	return err
+}
+
+func (s *mongoHabitStore) EnsureDefault(ctx context.Context, habit *models.Habit) (*models.Habit, bool, error) {
+	if habit.ID.IsZero() {
+		habit.ID = primitive.NewObjectID()
+	}
+	habit.IsDefault = true
+
+	// Upsert dengan $setOnInsert: dua request bersamaan tidak membuat dua habit default
+	res, err := s.coll.UpdateOne(ctx,
+		bson.M{"user": habit.UserID, "is_default": true},
+		bson.M{"$setOnInsert": habit},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return nil, false, err
+	}
+	if res.UpsertedCount > 0 {
+		return habit, true, nil
+	}
+	existing, err := s.FindDefault(ctx, habit.UserID)
+	return existing, false, err
+}
+
+func (s *mongoHabitStore) FindByID(ctx context.Context, id, userID primitive.ObjectID) (*models.Habit, error) {
+	return s.findOne(ctx, bson.M{"_id": id, "user": userID})
+}
+
+func (s *mongoHabitStore) FindDefault(ctx context.Context, userID primitive.ObjectID) (*models.Habit, error) {
+	return s.findOne(ctx, bson.M{"user": userID, "is_default": true})
+}
+
+func (s *mongoHabitStore) FindByKind(ctx context.Context, userID primitive.ObjectID, kind string) (*models.Habit, error) {
+	return s.findOne(ctx, bson.M{"user": userID, "kind": kind})
+}
+
+func (s *mongoHabitStore) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.Habit, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "is_default", Value: -1}, {Key: "created_at", Value: 1}})
+	return s.find(ctx, bson.M{"user": userID}, opts)
+}
+
+func (s *mongoHabitStore) ListWithStreak(ctx context.Context) ([]models.Habit, error) {
+	return s.find(ctx, bson.M{"streak_start_date": bson.M{"$ne": nil}})
+}
+
+func (s *mongoHabitStore) CountByUser(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return s.coll.CountDocuments(ctx, bson.M{"user": userID})
+}
+
+func (s *mongoHabitStore) Update(ctx context.Context, id, userID primitive.ObjectID, update HabitUpdate, now time.Time) error {
+	set := bson.M{"updated_at": now}
+	if update.Name != nil {
+		set["name"] = *update.Name
+	}
+	if update.Color != nil {
+		set["settings.color"] = *update.Color
+	}
+	if update.Icon != nil {
+		set["settings.icon"] = *update.Icon
+	}
+	if update.ShowInRankings != nil {
+		set["settings.show_in_rankings"] = *update.ShowInRankings
+	}
+	res, err := s.coll.UpdateOne(ctx, bson.M{"_id": id, "user": userID}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *mongoHabitStore) Delete(ctx context.Context, id, userID primitive.ObjectID) (bool, error) {
+	res, err := s.coll.DeleteOne(ctx, bson.M{"_id": id, "user": userID})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
+func (s *mongoHabitStore) DeleteAllByUser(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	res, err := s.coll.DeleteMany(ctx, bson.M{"user": userID})
+	if err != nil {
+		return 0, err
+	}
+	return int(res.DeletedCount), nil
+}
+
+func (s *mongoHabitStore) StartStreak(ctx context.Context, id primitive.ObjectID, start time.Time) (bool, error) {
+	res, err := s.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "streak_start_date": bson.M{"$in": []interface{}{nil, primitive.Null{}}}},
+		bson.M{"$set": bson.M{"streak_start_date": start}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func (s *mongoHabitStore) UpdateStreakStartIfLater(ctx context.Context, id primitive.ObjectID, start time.Time) (bool, error) {
+	filter := bson.M{
+		"_id": id,
+		"$or": bson.A{
+			bson.M{"streak_start_date": bson.M{"$exists": false}},
+			bson.M{"streak_start_date": nil},
+			bson.M{"streak_start_date": bson.M{"$gt": start}},
+		},
+	}
+	res, err := s.coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"streak_start_date": start}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func (s *mongoHabitStore) SetLongestStreak(ctx context.Context, id primitive.ObjectID, seconds int64) error {
+	_, err := s.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"longest_streak_seconds": seconds}})
+	return err
+}
+
+func (s *mongoHabitStore) ResetStreak(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"streak_start_date": nil, "longest_streak_seconds": 0}})
+	return err
+}
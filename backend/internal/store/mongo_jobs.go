@@ -0,0 +1,116 @@
+// internal/store/mongo_jobs.go
+package store
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"solivra-go/backend/internal/models"
+)
+
+type mongoJobStore struct {
+	leases *mongo.Collection
+	runs   *mongo.Collection
+}
+
+func (s *mongoJobStore) AcquireLease(ctx context.Context, name, owner string, now, until time.Time) (bool, error) {
+	// Dokumen hanya cocok jika lease milik owner atau sudah kedaluwarsa. Jika tidak cocok,
+	// upsert mencoba insert dengan _id yang sama dan gagal duplicate key: lease dipegang replica lain.
+	filter := bson.M{
+		"_id": name,
+		"$or": []bson.M{
+			{"owner": owner},
+			{"expires_at": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"owner": owner, "expires_at": until, "renewed_at": now}}
+	_, err := s.leases.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *mongoJobStore) ReleaseLease(ctx context.Context, name, owner string) error {
+	_, err := s.leases.DeleteOne(ctx, bson.M{"_id": name, "owner": owner})
+	return err
+}
+
+func (s *mongoJobStore) GetLease(ctx context.Context, name string) (*models.JobLease, error) {
+	var lease models.JobLease
+	if err := s.leases.FindOne(ctx, bson.M{"_id": name}).Decode(&lease); err != nil {
+		return nil, mapErr(err)
+	}
+	return &lease, nil
+}
+
+func (s *mongoJobStore) StartRun(ctx context.Context, run *models.JobRun) error {
+	if run.ID.IsZero() {
+		run.ID = primitive.NewObjectID()
+	}
+	_, err := s.runs.InsertOne(ctx, run)
+	return err
+}
+
+func (s *mongoJobStore) FinishRun(ctx context.Context, id primitive.ObjectID, status string, result map[string]interface{}, errMsg string, finishedAt time.Time) error {
+	var run models.JobRun
+	if err := s.runs.FindOne(ctx, bson.M{"_id": id}).Decode(&run); err != nil {
+		return mapErr(err)
+	}
+	set := bson.M{
+		"status":      status,
+		"finished_at": finishedAt,
+		"duration_ms": finishedAt.Sub(run.StartedAt).Milliseconds(),
+	}
+	if result != nil {
+		set["result"] = result
+	}
+	if errMsg != "" {
+		set["error"] = errMsg
+	}
+	_, err := s.runs.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
+
+func (s *mongoJobStore) LastRun(ctx context.Context, job string) (*models.JobRun, error) {
+	var run models.JobRun
+	opts := options.FindOne().SetSort(bson.D{{Key: "started_at", Value: -1}})
+	if err := s.runs.FindOne(ctx, bson.M{"job": job}, opts).Decode(&run); err != nil {
+		return nil, mapErr(err)
+	}
+	return &run, nil
+}
+
+func (s *mongoJobStore) ListRuns(ctx context.Context, job string, limit int64) ([]models.JobRun, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := s.runs.Find(ctx, bson.M{"job": job}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	runs := []models.JobRun{}
+	if err := cursor.All(ctx, &runs); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+func (s *mongoJobStore) DeleteRunsBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.runs.DeleteMany(ctx, bson.M{"started_at": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
@@ -0,0 +1,250 @@
+// internal/store/mongo_logs.go
+package store
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"solivra-go/backend/internal/models"
+)
+
+type mongoActivityLogStore struct {
+	coll *mongo.Collection
+}
+
+func (s *mongoActivityLogStore) Insert(ctx context.Context, entry *models.ActivityLog) error {
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	_, err := s.coll.InsertOne(ctx, entry)
+	return err
+}
+
+func (s *mongoActivityLogStore) List(ctx context.Context, q ActivityLogQuery) ([]models.ActivityLog, int64, error) {
+	query := bson.M{}
+	if q.Action != "" {
+		query["action"] = q.Action
+	} else if q.ExcludeAction != "" {
+		query["action"] = bson.M{"$ne": q.ExcludeAction}
+	}
+	if q.UsernameSearch != "" {
+		query["username"] = bson.M{"$regex": q.UsernameSearch, "$options": "i"}
+	}
+
+	count, err := s.coll.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	direction := -1
+	if q.Ascending {
+		direction = 1
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: direction}}).
+		SetSkip(q.Skip)
+	if q.Limit > 0 {
+		opts.SetLimit(q.Limit)
+	}
+
+	cursor, err := s.coll.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var logs []models.ActivityLog
+	if err := cursor.All(ctx, &logs); err != nil {
+		return nil, 0, err
+	}
+	return logs, count, nil
+}
+
+func (s *mongoActivityLogStore) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.ActivityLog, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+	cursor, err := s.coll.Find(ctx, bson.M{"user": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	logs := []models.ActivityLog{}
+	if err := cursor.All(ctx, &logs); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+func (s *mongoActivityLogStore) CountByUser(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return s.coll.CountDocuments(ctx, bson.M{"user": userID})
+}
+
+func (s *mongoActivityLogStore) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.coll.DeleteMany(ctx, bson.M{"timestamp": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+type mongoHoneypotStore struct {
+	coll *mongo.Collection
+}
+
+func (s *mongoHoneypotStore) Insert(ctx context.Context, incident *models.HoneypotLog) error {
+	if incident.ID.IsZero() {
+		incident.ID = primitive.NewObjectID()
+	}
+	_, err := s.coll.InsertOne(ctx, incident)
+	return err
+}
+
+func (s *mongoHoneypotStore) Stats(ctx context.Context, recentSince time.Time) (*HoneypotStats, error) {
+	stats := &HoneypotStats{}
+	var err error
+
+	if stats.TotalAttempts, err = s.coll.CountDocuments(ctx, bson.M{}); err != nil {
+		return nil, err
+	}
+	if stats.LoginAttempts, err = s.coll.CountDocuments(ctx, bson.M{"honeypot_type": "fake_admin_login_attempt"}); err != nil {
+		return nil, err
+	}
+	if stats.AccessAttempts, err = s.coll.CountDocuments(ctx, bson.M{"honeypot_type": "admin_page_access_attempt"}); err != nil {
+		return nil, err
+	}
+	if stats.RecentAttempts, err = s.coll.CountDocuments(ctx, bson.M{"incident_time": bson.M{"$gte": recentSince}}); err != nil {
+		return nil, err
+	}
+
+	uniqueIPs, err := s.coll.Distinct(ctx, "ip_address", bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	stats.UniqueIPs = len(uniqueIPs)
+
+	// Top username yang paling sering dicoba (Aggregation)
+	pipeline := []bson.M{
+		{"$match": bson.M{"honeypot_type": "fake_admin_login_attempt"}},
+		{"$group": bson.M{"_id": "$submitted_data.username", "count": bson.M{"$sum": 1}}},
+		{"$sort": bson.M{"count": -1}},
+		{"$limit": 10},
+	}
+	cursor, err := s.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err := cursor.All(ctx, &stats.TopUsernames); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+type mongoLoginAttemptStore struct {
+	coll *mongo.Collection
+}
+
+func (s *mongoLoginAttemptStore) Insert(ctx context.Context, attempt *models.LoginAttempt) error {
+	if attempt.ID.IsZero() {
+		attempt.ID = primitive.NewObjectID()
+	}
+	_, err := s.coll.InsertOne(ctx, attempt)
+	return err
+}
+
+func (s *mongoLoginAttemptStore) FindActiveIPLock(ctx context.Context, ip string, now time.Time) (*models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+	err := s.coll.FindOne(ctx, bson.M{
+		"ip_address":    ip,
+		"outcome":       "ip_locked",
+		"lockout_until": bson.M{"$gt": now},
+	}, options.FindOne().SetSort(bson.D{{Key: "attempt_time", Value: -1}})).Decode(&attempt)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &attempt, nil
+}
+
+func (s *mongoLoginAttemptStore) CountByIP(ctx context.Context, ip string, since time.Time, outcomes ...string) (int64, error) {
+	return s.coll.CountDocuments(ctx, bson.M{
+		"ip_address":   ip,
+		"outcome":      bson.M{"$in": outcomes},
+		"attempt_time": bson.M{"$gte": since},
+	})
+}
+
+func (s *mongoLoginAttemptStore) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.LoginAttempt, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "attempt_time", Value: 1}})
+	cursor, err := s.coll.Find(ctx, bson.M{"user": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	attempts := []models.LoginAttempt{}
+	if err := cursor.All(ctx, &attempts); err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+func (s *mongoLoginAttemptStore) DeleteExpired(ctx context.Context, before, now time.Time) (int64, error) {
+	res, err := s.coll.DeleteMany(ctx, bson.M{
+		"attempt_time":  bson.M{"$lt": before},
+		"lockout_until": bson.M{"$not": bson.M{"$gt": now}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+type mongoRegistrationAttemptStore struct {
+	coll *mongo.Collection
+}
+
+func (s *mongoRegistrationAttemptStore) Insert(ctx context.Context, attempt *models.RegistrationAttempt) error {
+	if attempt.ID.IsZero() {
+		attempt.ID = primitive.NewObjectID()
+	}
+	_, err := s.coll.InsertOne(ctx, attempt)
+	return err
+}
+
+func (s *mongoRegistrationAttemptStore) FindActiveBlock(ctx context.Context, ip string, now time.Time) (*models.RegistrationAttempt, error) {
+	var attempt models.RegistrationAttempt
+	err := s.coll.FindOne(ctx, bson.M{
+		"ip_address":    ip,
+		"status":        "blocked",
+		"blocked_until": bson.M{"$gt": now},
+	}, options.FindOne().SetSort(bson.D{{Key: "attempt_time", Value: -1}})).Decode(&attempt)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &attempt, nil
+}
+
+func (s *mongoRegistrationAttemptStore) CountByIP(ctx context.Context, ip string, since time.Time, statuses ...string) (int64, error) {
+	return s.coll.CountDocuments(ctx, bson.M{
+		"ip_address":   ip,
+		"status":       bson.M{"$in": statuses},
+		"attempt_time": bson.M{"$gte": since},
+	})
+}
+
+func (s *mongoRegistrationAttemptStore) DeleteExpired(ctx context.Context, before, now time.Time) (int64, error) {
+	res, err := s.coll.DeleteMany(ctx, bson.M{
+		"attempt_time":  bson.M{"$lt": before},
+		"blocked_until": bson.M{"$not": bson.M{"$gt": now}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
@@ -0,0 +1,721 @@
+// internal/store/mongo_relapses.go
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"solivra-go/backend/internal/models"
+)
+
+type mongoRelapseStore struct {
+	coll       *mongo.Collection
+	tombstones *mongo.Collection
+	revisions  *mongo.Collection
+}
+
+// active menambahkan syarat "belum di-soft delete" pada filter.
+func active(filter bson.M) bson.M {
+	filter["deleted_at"] = nil
+	return filter
+}
+
+func (s *mongoRelapseStore) Create(ctx context.Context, relapse *models.RelapseLog) error {
+	if relapse.ID.IsZero() {
+		relapse.ID = primitive.NewObjectID()
+	}
+	_, err := s.coll.InsertOne(ctx, relapse)
+	return err
+}
+
+func (s *mongoRelapseStore) CreateMany(ctx context.Context, relapses []models.RelapseLog) (int, error) {
+	if len(relapses) == 0 {
+		return 0, nil
+	}
+	docs := make([]interface{}, len(relapses))
+	for i := range relapses {
+		if relapses[i].ID.IsZero() {
+			relapses[i].ID = primitive.NewObjectID()
+		}
+		docs[i] = relapses[i]
+	}
+	res, err := s.coll.InsertMany(ctx, docs)
+	if err != nil {
+		return 0, err
+	}
+	return len(res.InsertedIDs), nil
+}
+
+func (s *mongoRelapseStore) FindByID(ctx context.Context, id, userID primitive.ObjectID) (*models.RelapseLog, error) {
+	var relapse models.RelapseLog
+	if err := s.coll.FindOne(ctx, active(bson.M{"_id": id, "user": userID})).Decode(&relapse); err != nil {
+		return nil, mapErr(err)
+	}
+	return &relapse, nil
+}
+
+func (s *mongoRelapseStore) FindByClientID(ctx context.Context, userID primitive.ObjectID, clientID string) (*models.RelapseLog, error) {
+	var relapse models.RelapseLog
+	if err := s.coll.FindOne(ctx, active(bson.M{"user": userID, "client_id": clientID})).Decode(&relapse); err != nil {
+		return nil, mapErr(err)
+	}
+	return &relapse, nil
+}
+
+// changedSince membuat filter "field setelah posisi (since, afterID)" milik user.
+func changedSince(userID primitive.ObjectID, field string, since time.Time, afterID primitive.ObjectID) bson.M {
+	return bson.M{
+		"user": userID,
+		"$or": bson.A{
+			bson.M{field: bson.M{"$gt": since}},
+			bson.M{field: since, "_id": bson.M{"$gt": afterID}},
+		},
+	}
+}
+
+func (s *mongoRelapseStore) ListChangedSince(ctx context.Context, userID primitive.ObjectID, since time.Time, afterID primitive.ObjectID, limit int64) ([]models.RelapseLog, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "updatedAt", Value: 1}, {Key: "_id", Value: 1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := s.coll.Find(ctx, changedSince(userID, "updatedAt", since, afterID), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var relapses []models.RelapseLog
+	if err := cursor.All(ctx, &relapses); err != nil {
+		return nil, err
+	}
+	return relapses, nil
+}
+
+func (s *mongoRelapseStore) ListByUser(ctx context.Context, userID primitive.ObjectID, ascending bool) ([]models.RelapseLog, error) {
+	return s.list(ctx, active(bson.M{"user": userID}), ascending)
+}
+
+func (s *mongoRelapseStore) ListByHabit(ctx context.Context, habitID primitive.ObjectID, ascending bool) ([]models.RelapseLog, error) {
+	return s.list(ctx, active(bson.M{"habit": habitID}), ascending)
+}
+
+func (s *mongoRelapseStore) ListByHabitBetween(ctx context.Context, habitID primitive.ObjectID, from, to time.Time) ([]models.RelapseLog, error) {
+	return s.list(ctx, active(bson.M{
+		"habit":        habitID,
+		"relapse_time": bson.M{"$gte": from, "$lt": to},
+	}), true)
+}
+
+func (s *mongoRelapseStore) ListFiltered(ctx context.Context, habitID primitive.ObjectID, filter RelapseFilter, ascending bool) ([]models.RelapseLog, error) {
+	query := active(bson.M{"habit": habitID})
+	if filter.Trigger != "" {
+		query["triggers"] = filter.Trigger
+	}
+	if filter.Tag != "" {
+		query["tags"] = filter.Tag
+	}
+	if filter.Location != "" {
+		query["location"] = filter.Location
+	}
+	if filter.MoodBefore != 0 {
+		query["mood_before"] = filter.MoodBefore
+	}
+	if filter.MoodAfter != 0 {
+		query["mood_after"] = filter.MoodAfter
+	}
+	if filter.MinIntensity != 0 || filter.MaxIntensity != 0 {
+		// Relapse tanpa intensitas tidak pernah lolos filter intensitas
+		intensity := bson.M{"$gte": max(filter.MinIntensity, models.RelapseIntensityMin)}
+		if filter.MaxIntensity != 0 {
+			intensity["$lte"] = filter.MaxIntensity
+		}
+		query["intensity"] = intensity
+	}
+	return s.list(ctx, query, ascending)
+}
+
+func (s *mongoRelapseStore) list(ctx context.Context, filter bson.M, ascending bool) ([]models.RelapseLog, error) {
+	direction := -1
+	if ascending {
+		direction = 1
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "relapse_time", Value: direction}})
+	cursor, err := s.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var relapses []models.RelapseLog
+	if err := cursor.All(ctx, &relapses); err != nil {
+		return nil, err
+	}
+	return relapses, nil
+}
+
+func (s *mongoRelapseStore) ListTimesSince(ctx context.Context, habitIDs []primitive.ObjectID, since time.Time) (map[primitive.ObjectID][]time.Time, error) {
+	times := make(map[primitive.ObjectID][]time.Time)
+	if len(habitIDs) == 0 {
+		return times, nil
+	}
+
+	filter := active(bson.M{"habit": bson.M{"$in": habitIDs}, "relapse_time": bson.M{"$gte": since}})
+	opts := options.Find().
+		SetSort(bson.D{{Key: "relapse_time", Value: 1}}).
+		SetProjection(bson.M{"_id": 0, "habit": 1, "relapse_time": 1})
+	cursor, err := s.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var r struct {
+			HabitID     primitive.ObjectID `bson:"habit"`
+			RelapseTime time.Time          `bson:"relapse_time"`
+		}
+		if err := cursor.Decode(&r); err != nil {
+			return nil, err
+		}
+		times[r.HabitID] = append(times[r.HabitID], r.RelapseTime)
+	}
+	return times, cursor.Err()
+}
+
+func (s *mongoRelapseStore) CountByHabit(ctx context.Context, habitID primitive.ObjectID) (int64, error) {
+	return s.coll.CountDocuments(ctx, active(bson.M{"habit": habitID}))
+}
+
+func (s *mongoRelapseStore) CountSince(ctx context.Context, since time.Time) (int64, error) {
+	return s.coll.CountDocuments(ctx, active(bson.M{"relapse_time": bson.M{"$gte": since}}))
+}
+
+func (s *mongoRelapseStore) CountNoteDays(ctx context.Context, userID primitive.ObjectID, loc *time.Location) (int, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	day := bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$relapse_time", "timezone": loc.String()}}
+	pipeline := []bson.M{
+		{"$match": active(bson.M{"user": userID, "relapse_note": bson.M{"$nin": bson.A{nil, ""}}})},
+		{"$group": bson.M{"_id": day}},
+		{"$count": "days"},
+	}
+	cursor, err := s.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Days int `bson:"days"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil || len(rows) == 0 {
+		return 0, err
+	}
+	return rows[0].Days, nil
+}
+
+func (s *mongoRelapseStore) ListNoteUserIDs(ctx context.Context) ([]primitive.ObjectID, error) {
+	values, err := s.coll.Distinct(ctx, "user", active(bson.M{"relapse_note": bson.M{"$nin": bson.A{nil, ""}}}))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (s *mongoRelapseStore) CountByHour(ctx context.Context, loc *time.Location) ([]int, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	hour := bson.M{"$hour": bson.M{"date": "$relapse_time", "timezone": loc.String()}}
+	pipeline := []bson.M{
+		{"$match": active(bson.M{})},
+		{"$group": bson.M{"_id": hour, "count": bson.M{"$sum": 1}}},
+		{"$sort": bson.M{"_id": 1}},
+	}
+	cursor, err := s.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Hour  int `bson:"_id"`
+		Count int `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	byHour := make([]int, 24)
+	for _, row := range rows {
+		if row.Hour >= 0 && row.Hour < 24 {
+			byHour[row.Hour] = row.Count
+		}
+	}
+	return byHour, nil
+}
+
+// Insights menjalankan satu pipeline $facet di server. Panjang streak dihitung dengan
+// $setWindowFields ($shift ke relapse sebelumnya), dan periode dengan $dateTrunc, jadi
+// butuh MongoDB 5.0 atau lebih baru.
+func (s *mongoRelapseStore) Insights(ctx context.Context, q InsightsQuery) (*RelapseInsights, error) {
+	if q.Location == nil {
+		q.Location = time.UTC
+	}
+	tz := q.Location.String()
+	local := func(op string) bson.M {
+		return bson.M{op: bson.M{"date": "$relapse_time", "timezone": tz}}
+	}
+	trunc := func(unit string) bson.M {
+		spec := bson.M{"date": "$relapse_time", "unit": unit, "timezone": tz}
+		if unit == "week" {
+			spec["startOfWeek"] = "monday"
+		}
+		return bson.M{"$dateTrunc": spec}
+	}
+	between := func(from, to time.Time) bson.M {
+		inRange := bson.M{"$and": bson.A{
+			bson.M{"$gte": bson.A{"$relapse_time", from}},
+			bson.M{"$lt": bson.A{"$relapse_time", to}},
+		}}
+		return bson.M{"$sum": bson.M{"$cond": bson.A{inRange, 1, 0}}}
+	}
+
+	windows := bson.M{"_id": nil}
+	for i, w := range q.Windows {
+		windows[fmt.Sprintf("recent_%d", i)] = between(q.Now.Add(-w), q.Now)
+		windows[fmt.Sprintf("previous_%d", i)] = between(q.Now.Add(-2*w), q.Now.Add(-w))
+	}
+
+	// Streak yang diakhiri relapse = relapse_time dikurangi relapse sebelumnya (atau start)
+	streakSeconds := bson.M{"$max": bson.A{0, bson.M{"$toLong": bson.M{"$trunc": bson.M{
+		"$divide": bson.A{bson.M{"$subtract": bson.A{"$relapse_time", "$previous"}}, 1000},
+	}}}}}
+	middle := func(offset int) bson.M {
+		return bson.M{"$arrayElemAt": bson.A{"$streaks", bson.M{"$floor": bson.M{
+			"$divide": bson.A{bson.M{"$add": bson.A{bson.M{"$size": "$streaks"}, offset}}, 2},
+		}}}}
+	}
+
+	pipeline := []bson.M{
+		{"$match": active(bson.M{"habit": q.HabitID})},
+		{"$facet": bson.M{
+			"streaks": bson.A{
+				bson.M{"$setWindowFields": bson.M{
+					"sortBy": bson.M{"relapse_time": 1},
+					"output": bson.M{"previous": bson.M{"$shift": bson.M{"output": "$relapse_time", "by": -1, "default": q.Start}}},
+				}},
+				bson.M{"$project": bson.M{"relapse_time": 1, "seconds": streakSeconds}},
+				bson.M{"$sort": bson.M{"seconds": 1}},
+				bson.M{"$group": bson.M{
+					"_id":     nil,
+					"count":   bson.M{"$sum": 1},
+					"sum":     bson.M{"$sum": "$seconds"},
+					"longest": bson.M{"$max": "$seconds"},
+					"last":    bson.M{"$max": "$relapse_time"},
+					"streaks": bson.M{"$push": "$seconds"},
+				}},
+				bson.M{"$project": bson.M{"count": 1, "sum": 1, "longest": 1, "last": 1, "low": middle(-1), "high": middle(0)}},
+			},
+			"weekly": bson.A{
+				bson.M{"$match": bson.M{"relapse_time": bson.M{"$gte": q.WeeksSince}}},
+				bson.M{"$group": bson.M{"_id": trunc("week"), "count": bson.M{"$sum": 1}}},
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+			"monthly": bson.A{
+				bson.M{"$match": bson.M{"relapse_time": bson.M{"$gte": q.MonthsSince}}},
+				bson.M{"$group": bson.M{"_id": trunc("month"), "count": bson.M{"$sum": 1}}},
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+			"heatmap": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":   bson.M{"day": local("$isoDayOfWeek"), "hour": local("$hour")},
+					"count": bson.M{"$sum": 1},
+				}},
+			},
+			"windows": bson.A{
+				bson.M{"$group": windows},
+				bson.M{"$project": bson.M{"_id": 0}},
+			},
+		}},
+	}
+	cursor, err := s.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Streaks []struct {
+			Count   int       `bson:"count"`
+			Sum     int64     `bson:"sum"`
+			Longest int64     `bson:"longest"`
+			Last    time.Time `bson:"last"`
+			Low     int64     `bson:"low"`
+			High    int64     `bson:"high"`
+		} `bson:"streaks"`
+		Weekly  []PeriodCount `bson:"weekly"`
+		Monthly []PeriodCount `bson:"monthly"`
+		Heatmap []struct {
+			ID struct {
+				Day  int `bson:"day"`
+				Hour int `bson:"hour"`
+			} `bson:"_id"`
+			Count int `bson:"count"`
+		} `bson:"heatmap"`
+		Windows []map[string]int `bson:"windows"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	insights := &RelapseInsights{Windows: make([]WindowCount, len(q.Windows))}
+	for i, w := range q.Windows {
+		insights.Windows[i].Window = w
+	}
+	if len(rows) == 0 {
+		return insights, nil
+	}
+	row := rows[0]
+	if len(row.Streaks) > 0 && row.Streaks[0].Count > 0 {
+		st := row.Streaks[0]
+		insights.Total = st.Count
+		insights.LastRelapse = &st.Last
+		insights.AverageStreak = st.Sum / int64(st.Count)
+		insights.MedianStreak = (st.Low + st.High) / 2
+		insights.LongestStreak = st.Longest
+	}
+	insights.Weekly = row.Weekly
+	insights.Monthly = row.Monthly
+	for _, cell := range row.Heatmap {
+		// $isoDayOfWeek: Senin = 1 ... Minggu = 7
+		if cell.ID.Day >= 1 && cell.ID.Day <= 7 && cell.ID.Hour >= 0 && cell.ID.Hour < 24 {
+			insights.Heatmap[cell.ID.Day-1][cell.ID.Hour] = cell.Count
+		}
+	}
+	if len(row.Windows) > 0 {
+		for i := range insights.Windows {
+			insights.Windows[i].Recent = row.Windows[0][fmt.Sprintf("recent_%d", i)]
+			insights.Windows[i].Previous = row.Windows[0][fmt.Sprintf("previous_%d", i)]
+		}
+	}
+	return insights, nil
+}
+
+func (s *mongoRelapseStore) UpdateNote(ctx context.Context, id, userID primitive.ObjectID, note *string, writtenAt, now time.Time) (*models.RelapseLog, error) {
+	var noteValue interface{}
+	if note != nil {
+		noteValue = *note
+	}
+	return s.update(ctx, id, userID, bson.M{"relapse_note": noteValue, "client_updated_at": writtenAt, "updatedAt": now})
+}
+
+func (s *mongoRelapseStore) UpdateTime(ctx context.Context, id, userID primitive.ObjectID, relapseTime, writtenAt, now time.Time) (*models.RelapseLog, error) {
+	return s.update(ctx, id, userID, bson.M{"relapse_time": relapseTime, "client_updated_at": writtenAt, "updatedAt": now})
+}
+
+func (s *mongoRelapseStore) UpdateDetails(ctx context.Context, id, userID primitive.ObjectID, details models.RelapseDetails, writtenAt, now time.Time) (*models.RelapseLog, error) {
+	set := bson.M{"client_updated_at": writtenAt, "updatedAt": now}
+	unset := bson.M{}
+	fields := bson.M{
+		"triggers":    details.Triggers,
+		"mood_before": details.MoodBefore,
+		"mood_after":  details.MoodAfter,
+		"intensity":   details.Intensity,
+		"location":    details.Location,
+		"tags":        details.Tags,
+	}
+	// Field kosong dihapus dari dokumen, sama seperti omitempty saat insert
+	for field, value := range fields {
+		switch v := value.(type) {
+		case []string:
+			if len(v) == 0 {
+				unset[field] = ""
+				continue
+			}
+		case int:
+			if v == 0 {
+				unset[field] = ""
+				continue
+			}
+		case string:
+			if v == "" {
+				unset[field] = ""
+				continue
+			}
+		}
+		set[field] = value
+	}
+
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated models.RelapseLog
+	err := s.coll.FindOneAndUpdate(ctx, active(bson.M{"_id": id, "user": userID}), update, opts).Decode(&updated)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &updated, nil
+}
+
+// update menerapkan $set pada relapse aktif dan mengembalikan dokumen setelah update.
+func (s *mongoRelapseStore) update(ctx context.Context, id, userID primitive.ObjectID, set bson.M) (*models.RelapseLog, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated models.RelapseLog
+	err := s.coll.FindOneAndUpdate(ctx, active(bson.M{"_id": id, "user": userID}), bson.M{"$set": set}, opts).Decode(&updated)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &updated, nil
+}
+
+// bury mencatat tombstone untuk relapse yang cocok dengan filter lalu menghapusnya.
+func (s *mongoRelapseStore) bury(ctx context.Context, filter bson.M, now time.Time) (int, error) {
+	opts := options.Find().SetProjection(bson.M{"user": 1, "habit": 1, "client_id": 1})
+	cursor, err := s.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return 0, err
+	}
+	var victims []models.RelapseLog
+	if err := cursor.All(ctx, &victims); err != nil {
+		return 0, err
+	}
+	if len(victims) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]primitive.ObjectID, len(victims))
+	tombstones := make([]interface{}, len(victims))
+	for i, r := range victims {
+		ids[i] = r.ID
+		tombstones[i] = models.RelapseTombstone{
+			ID:        r.ID,
+			UserID:    r.UserID,
+			HabitID:   r.HabitID,
+			ClientID:  r.ClientID,
+			DeletedAt: now,
+		}
+	}
+	if _, err := s.tombstones.InsertMany(ctx, tombstones); err != nil {
+		return 0, err
+	}
+
+	if _, err := s.revisions.DeleteMany(ctx, bson.M{"relapse": bson.M{"$in": ids}}); err != nil {
+		return 0, err
+	}
+
+	res, err := s.coll.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+	return int(res.DeletedCount), nil
+}
+
+func (s *mongoRelapseStore) SoftDelete(ctx context.Context, id, userID primitive.ObjectID, now time.Time) (bool, error) {
+	res, err := s.coll.UpdateOne(ctx,
+		active(bson.M{"_id": id, "user": userID}),
+		bson.M{"$set": bson.M{"deleted_at": now, "updatedAt": now}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func (s *mongoRelapseStore) SoftDeleteByHabit(ctx context.Context, habitID, deletionID primitive.ObjectID, now time.Time) (int, error) {
+	res, err := s.coll.UpdateMany(ctx,
+		active(bson.M{"habit": habitID}),
+		bson.M{"$set": bson.M{"deleted_at": now, "deletion_id": deletionID, "updatedAt": now}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return int(res.ModifiedCount), nil
+}
+
+func (s *mongoRelapseStore) SoftDeleteAfter(ctx context.Context, habitID, deletionID primitive.ObjectID, t, now time.Time) (int, error) {
+	res, err := s.coll.UpdateMany(ctx,
+		active(bson.M{"habit": habitID, "relapse_time": bson.M{"$gt": t}}),
+		bson.M{"$set": bson.M{"deleted_at": now, "deletion_id": deletionID, "updatedAt": now}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return int(res.ModifiedCount), nil
+}
+
+func restoreUpdate(now time.Time) bson.M {
+	return bson.M{
+		"$set":   bson.M{"updatedAt": now},
+		"$unset": bson.M{"deleted_at": "", "deletion_id": ""},
+	}
+}
+
+func (s *mongoRelapseStore) Restore(ctx context.Context, id, userID primitive.ObjectID, deletedSince, now time.Time) (*models.RelapseLog, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var restored models.RelapseLog
+	err := s.coll.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "user": userID, "deleted_at": bson.M{"$gte": deletedSince}},
+		restoreUpdate(now),
+		opts,
+	).Decode(&restored)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &restored, nil
+}
+
+func (s *mongoRelapseStore) RestoreDeletion(ctx context.Context, habitID, deletionID primitive.ObjectID, deletedSince, now time.Time) (int, error) {
+	res, err := s.coll.UpdateMany(ctx,
+		bson.M{"habit": habitID, "deletion_id": deletionID, "deleted_at": bson.M{"$gte": deletedSince}},
+		restoreUpdate(now),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return int(res.ModifiedCount), nil
+}
+
+func (s *mongoRelapseStore) LastDeletion(ctx context.Context, habitID primitive.ObjectID, deletedSince time.Time) (primitive.ObjectID, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "deleted_at", Value: -1}})
+	var relapse models.RelapseLog
+	err := s.coll.FindOne(ctx, bson.M{
+		"habit":       habitID,
+		"deletion_id": bson.M{"$exists": true},
+		"deleted_at":  bson.M{"$gte": deletedSince},
+	}, opts).Decode(&relapse)
+	if err != nil {
+		return primitive.NilObjectID, mapErr(err)
+	}
+	return relapse.DeletionID, nil
+}
+
+func (s *mongoRelapseStore) PurgeDeleted(ctx context.Context, before, now time.Time) (int, error) {
+	return s.bury(ctx, bson.M{"deleted_at": bson.M{"$lt": before}}, now)
+}
+
+func (s *mongoRelapseStore) DeleteAllByUser(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	if _, err := s.tombstones.DeleteMany(ctx, bson.M{"user": userID}); err != nil {
+		return 0, err
+	}
+	if _, err := s.revisions.DeleteMany(ctx, bson.M{"user": userID}); err != nil {
+		return 0, err
+	}
+	res, err := s.coll.DeleteMany(ctx, bson.M{"user": userID})
+	if err != nil {
+		return 0, err
+	}
+	return int(res.DeletedCount), nil
+}
+
+func (s *mongoRelapseStore) DeleteAllByHabit(ctx context.Context, habitID primitive.ObjectID, now time.Time) (int, error) {
+	return s.bury(ctx, bson.M{"habit": habitID}, now)
+}
+
+func (s *mongoRelapseStore) FindTombstone(ctx context.Context, userID, id primitive.ObjectID, clientID string) (*models.RelapseTombstone, error) {
+	or := bson.A{bson.M{"_id": id}}
+	if clientID != "" {
+		or = append(or, bson.M{"client_id": clientID})
+	}
+	// Relapse yang masih di-soft delete diperlakukan sama seperti tombstone
+	var relapse models.RelapseLog
+	err := s.coll.FindOne(ctx, bson.M{"user": userID, "deleted_at": bson.M{"$ne": nil}, "$or": or}).Decode(&relapse)
+	if err == nil {
+		return &models.RelapseTombstone{
+			ID:        relapse.ID,
+			UserID:    relapse.UserID,
+			HabitID:   relapse.HabitID,
+			ClientID:  relapse.ClientID,
+			DeletedAt: *relapse.DeletedAt,
+		}, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	var tombstone models.RelapseTombstone
+	if err := s.tombstones.FindOne(ctx, bson.M{"user": userID, "$or": or}).Decode(&tombstone); err != nil {
+		return nil, mapErr(err)
+	}
+	return &tombstone, nil
+}
+
+func (s *mongoRelapseStore) ListTombstonesSince(ctx context.Context, userID primitive.ObjectID, since time.Time, afterID primitive.ObjectID, limit int64) ([]models.RelapseTombstone, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "deleted_at", Value: 1}, {Key: "_id", Value: 1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := s.tombstones.Find(ctx, changedSince(userID, "deleted_at", since, afterID), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tombstones []models.RelapseTombstone
+	if err := cursor.All(ctx, &tombstones); err != nil {
+		return nil, err
+	}
+	return tombstones, nil
+}
+
+func (s *mongoRelapseStore) AddRevision(ctx context.Context, revision *models.RelapseRevision) error {
+	if revision.ID.IsZero() {
+		revision.ID = primitive.NewObjectID()
+	}
+	_, err := s.revisions.InsertOne(ctx, revision)
+	return err
+}
+
+func (s *mongoRelapseStore) ListRevisions(ctx context.Context, relapseID, userID primitive.ObjectID) ([]models.RelapseRevision, error) {
+	return s.listRevisions(ctx, bson.M{"relapse": relapseID, "user": userID})
+}
+
+func (s *mongoRelapseStore) ListRevisionsByUser(ctx context.Context, userID primitive.ObjectID) ([]models.RelapseRevision, error) {
+	return s.listRevisions(ctx, bson.M{"user": userID})
+}
+
+func (s *mongoRelapseStore) listRevisions(ctx context.Context, filter bson.M) ([]models.RelapseRevision, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "relapse", Value: 1}, {Key: "version", Value: 1}})
+	cursor, err := s.revisions.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var revisions []models.RelapseRevision
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (s *mongoRelapseStore) AssignHabit(ctx context.Context, userID, habitID primitive.ObjectID) (int, error) {
+	filter := bson.M{
+		"user": userID,
+		"$or": bson.A{
+			bson.M{"habit": bson.M{"$exists": false}},
+			bson.M{"habit": nil},
+		},
+	}
+	res, err := s.coll.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"habit": habitID}})
+	if err != nil {
+		return 0, err
+	}
+	return int(res.ModifiedCount), nil
+}
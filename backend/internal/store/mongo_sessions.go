@@ -0,0 +1,120 @@
+// internal/store/mongo_sessions.go
+package store
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"solivra-go/backend/internal/models"
+)
+
+type mongoSessionStore struct {
+	coll *mongo.Collection
+}
+
+func (s *mongoSessionStore) Create(ctx context.Context, session *models.UserSession) error {
+	if session.ID.IsZero() {
+		session.ID = primitive.NewObjectID()
+	}
+	_, err := s.coll.InsertOne(ctx, session)
+	return err
+}
+
+func (s *mongoSessionStore) findOne(ctx context.Context, filter bson.M) (*models.UserSession, error) {
+	var session models.UserSession
+	if err := s.coll.FindOne(ctx, filter).Decode(&session); err != nil {
+		return nil, mapErr(err)
+	}
+	return &session, nil
+}
+
+func (s *mongoSessionStore) FindByID(ctx context.Context, id, userID primitive.ObjectID) (*models.UserSession, error) {
+	return s.findOne(ctx, bson.M{"_id": id, "user": userID})
+}
+
+func (s *mongoSessionStore) FindActive(ctx context.Context, userID primitive.ObjectID, tokenHash string) (*models.UserSession, error) {
+	return s.findOne(ctx, bson.M{
+		"user":       userID,
+		"token":      tokenHash,
+		"revoked_at": nil, // Session harus belum revoked
+	})
+}
+
+func (s *mongoSessionStore) ListActive(ctx context.Context, userID primitive.ObjectID) ([]models.UserSession, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "last_active_time", Value: -1}})
+	cursor, err := s.coll.Find(ctx, bson.M{"user": userID, "revoked_at": nil}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []models.UserSession
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (s *mongoSessionStore) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.UserSession, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "login_time", Value: -1}})
+	cursor, err := s.coll.Find(ctx, bson.M{"user": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	sessions := []models.UserSession{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (s *mongoSessionStore) Touch(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	_, err := s.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"last_active_time": at},
+	})
+	return err
+}
+
+func (s *mongoSessionStore) revoke(ctx context.Context, filter bson.M, at time.Time) (int, error) {
+	filter["revoked_at"] = nil
+	res, err := s.coll.UpdateMany(ctx, filter, bson.M{
+		"$set": bson.M{"revoked_at": at, "last_active_time": at},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(res.ModifiedCount), nil
+}
+
+func (s *mongoSessionStore) Revoke(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	_, err := s.revoke(ctx, bson.M{"_id": id}, at)
+	return err
+}
+
+func (s *mongoSessionStore) RevokeByToken(ctx context.Context, tokenHash string, at time.Time) error {
+	_, err := s.revoke(ctx, bson.M{"token": tokenHash}, at)
+	return err
+}
+
+func (s *mongoSessionStore) RevokeAllExcept(ctx context.Context, userID primitive.ObjectID, exceptHash string, at time.Time) (int, error) {
+	return s.revoke(ctx, bson.M{"user": userID, "token": bson.M{"$ne": exceptHash}}, at)
+}
+
+func (s *mongoSessionStore) RevokeAll(ctx context.Context, userID primitive.ObjectID, at time.Time) (int, error) {
+	return s.revoke(ctx, bson.M{"user": userID}, at)
+}
+
+func (s *mongoSessionStore) DeleteRevokedBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.coll.DeleteMany(ctx, bson.M{"revoked_at": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
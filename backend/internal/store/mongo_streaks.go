@@ -0,0 +1,286 @@
+// internal/store/mongo_streaks.go
+package store
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"solivra-go/backend/internal/leaderboard"
+	"solivra-go/backend/internal/models"
+)
+
+type mongoStreakSummaryStore struct {
+	coll *mongo.Collection
+}
+
+func (s *mongoStreakSummaryStore) Get(ctx context.Context, habitID primitive.ObjectID) (*models.StreakSummary, error) {
+	var summary models.StreakSummary
+	if err := s.coll.FindOne(ctx, bson.M{"_id": habitID}).Decode(&summary); err != nil {
+		return nil, mapErr(err)
+	}
+	return &summary, nil
+}
+
+func (s *mongoStreakSummaryStore) Upsert(ctx context.Context, summary *models.StreakSummary) error {
+	_, err := s.coll.ReplaceOne(ctx, bson.M{"_id": summary.HabitID}, summary, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (s *mongoStreakSummaryStore) Delete(ctx context.Context, habitID primitive.ObjectID) error {
+	_, err := s.coll.DeleteOne(ctx, bson.M{"_id": habitID})
+	return err
+}
+
+func (s *mongoStreakSummaryStore) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := s.coll.DeleteMany(ctx, bson.M{"user": userID})
+	return err
+}
+
+func (s *mongoStreakSummaryStore) DeleteLegacy(ctx context.Context) (int64, error) {
+	// Ringkasan lama memakai _id user dan belum punya field "user"
+	res, err := s.coll.DeleteMany(ctx, bson.M{"user": bson.M{"$exists": false}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// rankingQuery menerjemahkan RankingFilter menjadi filter Mongo.
+func rankingQuery(filter RankingFilter) bson.M {
+	query := bson.M{}
+	if filter.HabitKind == "" {
+		query["is_default"] = true
+	} else {
+		query["habit_kind"] = filter.HabitKind
+	}
+	if filter.ExcludeHidden {
+		query["visibility"] = bson.M{"$ne": models.RankingVisibilityHidden}
+	}
+	currentStart := bson.M{}
+	if !filter.CurrentStartFrom.IsZero() {
+		currentStart["$gte"] = filter.CurrentStartFrom
+	}
+	if !filter.CurrentStartBefore.IsZero() {
+		currentStart["$lt"] = filter.CurrentStartBefore
+	}
+	if len(currentStart) > 0 {
+		query["current_streak_start"] = currentStart
+	}
+	return query
+}
+
+// rankedStream adalah bagian ringkasan yang urutan leaderboard-nya bisa dibaca langsung
+// dari satu sort Mongo: field (jika ada) lalu current_streak_start dan _id.
+type rankedStream struct {
+	query bson.M
+	field string
+	desc  bool
+	// running berarti nilai metrik adalah streak berjalan, jadi urutannya cukup
+	// current_streak_start.
+	running bool
+}
+
+func (r rankedStream) sort() bson.D {
+	sort := bson.D{}
+	if r.field != "" {
+		dir := 1
+		if r.desc {
+			dir = -1
+		}
+		sort = append(sort, bson.E{Key: r.field, Value: dir})
+	}
+	return append(sort, bson.E{Key: "current_streak_start", Value: 1}, bson.E{Key: "_id", Value: 1})
+}
+
+// rankedStreams membagi ringkasan sesuai filter.Sort. Streak terpanjang dan rata-rata
+// bergantung pada streak berjalan, jadi ringkasan dibagi dua: yang nilainya saat ini
+// adalah streak berjalan (urut current_streak_start) dan yang nilainya tersimpan
+// (urut record_seconds atau average_completed_seconds).
+func rankedStreams(filter RankingFilter) []rankedStream {
+	with := func(extra bson.M) bson.M {
+		query := rankingQuery(filter)
+		for k, v := range extra {
+			query[k] = v
+		}
+		return query
+	}
+
+	switch filter.Sort {
+	case leaderboard.SortLongest:
+		return []rankedStream{
+			{query: with(bson.M{"record_overtaken_at": bson.M{"$lte": filter.Now}}), running: true},
+			{query: with(bson.M{"record_overtaken_at": bson.M{"$gt": filter.Now}}), field: "record_seconds", desc: true},
+		}
+	case leaderboard.SortAverage:
+		return []rankedStream{
+			{query: with(bson.M{"total_relapses": 0}), running: true},
+			{query: with(bson.M{"total_relapses": bson.M{"$gt": 0}}), field: "average_completed_seconds", desc: true},
+		}
+	case leaderboard.SortFewestRelapses:
+		return []rankedStream{{query: with(nil), field: "total_relapses"}}
+	default:
+		return []rankedStream{{query: with(nil)}}
+	}
+}
+
+// ahead mengembalikan kondisi Mongo untuk ringkasan di stream r yang berada di atas
+// target (lihat leaderboard.Less).
+func (r rankedStream) ahead(target *models.StreakSummary, value int64, now time.Time) bson.A {
+	start := target.CurrentStreakStart
+	tie := bson.M{"current_streak_start": start, "_id": bson.M{"$lt": target.HabitID}}
+	switch {
+	case r.running:
+		// Streak berjalan dihitung dalam detik penuh: nilainya > value jika dimulai paling
+		// lambat longer, dan sama dengan value jika dimulai di (longer, equal].
+		longer := now.Add(-time.Duration(value+1) * time.Second)
+		equal := now.Add(-time.Duration(value) * time.Second)
+		cond := bson.A{
+			bson.M{"current_streak_start": bson.M{"$lte": longer}},
+			bson.M{"current_streak_start": bson.M{"$gt": longer, "$lte": equal, "$lt": start}},
+		}
+		if !start.After(equal) {
+			cond = append(cond, tie)
+		}
+		return cond
+	case r.field != "":
+		op := "$lt"
+		if r.desc {
+			op = "$gt"
+		}
+		return bson.A{
+			bson.M{r.field: bson.M{op: value}},
+			bson.M{r.field: value, "current_streak_start": bson.M{"$lt": start}},
+			bson.M{r.field: value, "current_streak_start": start, "_id": bson.M{"$lt": target.HabitID}},
+		}
+	default:
+		return bson.A{bson.M{"current_streak_start": bson.M{"$lt": start}}, tie}
+	}
+}
+
+// behind mengembalikan kondisi Mongo untuk ringkasan di stream r yang berada di bawah
+// posisi key (kebalikan ahead, tanpa key itu sendiri).
+func (r rankedStream) behind(key leaderboard.Key, now time.Time) bson.A {
+	start := key.CurrentStart
+	tie := bson.M{"current_streak_start": start, "_id": bson.M{"$gt": key.HabitID}}
+	switch {
+	case r.running:
+		// Lihat ahead: nilainya < key.Value jika dimulai setelah equal, dan sama dengan
+		// key.Value jika dimulai di (longer, equal].
+		longer := now.Add(-time.Duration(key.Value+1) * time.Second)
+		equal := now.Add(-time.Duration(key.Value) * time.Second)
+		cond := bson.A{bson.M{"current_streak_start": bson.M{"$gt": equal}}}
+		lower := longer
+		if start.After(lower) {
+			lower = start
+		}
+		if lower.Before(equal) {
+			cond = append(cond, bson.M{"current_streak_start": bson.M{"$gt": lower, "$lte": equal}})
+		}
+		if start.After(longer) && !start.After(equal) {
+			cond = append(cond, tie)
+		}
+		return cond
+	case r.field != "":
+		op := "$gt"
+		if r.desc {
+			op = "$lt"
+		}
+		return bson.A{
+			bson.M{r.field: bson.M{op: key.Value}},
+			bson.M{r.field: key.Value, "current_streak_start": bson.M{"$gt": start}},
+			bson.M{r.field: key.Value, "current_streak_start": start, "_id": bson.M{"$gt": key.HabitID}},
+		}
+	default:
+		return bson.A{bson.M{"current_streak_start": bson.M{"$gt": start}}, tie}
+	}
+}
+
+func (s *mongoStreakSummaryStore) find(ctx context.Context, stream rankedStream, skip, limit int64) ([]models.StreakSummary, error) {
+	opts := options.Find().SetSort(stream.sort()).SetSkip(skip)
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := s.coll.Find(ctx, stream.query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	summaries := []models.StreakSummary{}
+	if err := cursor.All(ctx, &summaries); err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+func (s *mongoStreakSummaryStore) ListRanked(ctx context.Context, filter RankingFilter, skip, limit int64) ([]models.StreakSummary, error) {
+	streams := rankedStreams(filter)
+	if filter.After != nil {
+		for _, stream := range streams {
+			stream.query["$or"] = stream.behind(*filter.After, filter.Now)
+		}
+	}
+	if len(streams) == 1 {
+		return s.find(ctx, streams[0], skip, limit)
+	}
+
+	// Halaman bisa berasal dari stream mana pun, jadi ambil skip+limit teratas dari setiap
+	// stream lalu gabungkan.
+	top := int64(0)
+	if limit > 0 {
+		top = skip + limit
+	}
+	summaries := []models.StreakSummary{}
+	for _, stream := range streams {
+		list, err := s.find(ctx, stream, 0, top)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, list...)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return filter.less(&summaries[i], &summaries[j])
+	})
+
+	if skip >= int64(len(summaries)) {
+		return []models.StreakSummary{}, nil
+	}
+	summaries = summaries[skip:]
+	if limit > 0 && limit < int64(len(summaries)) {
+		summaries = summaries[:limit]
+	}
+	return summaries, nil
+}
+
+func (s *mongoStreakSummaryStore) Rank(ctx context.Context, filter RankingFilter, habitID primitive.ObjectID) (int64, error) {
+	summary, err := s.Get(ctx, habitID)
+	if err != nil {
+		return 0, err
+	}
+	if !filter.matches(summary) {
+		return 0, ErrNotFound
+	}
+	value := filter.entry(summary).Value(filter.Sort)
+
+	var ahead int64
+	for _, stream := range rankedStreams(filter) {
+		query := stream.query
+		query["$or"] = stream.ahead(summary, value, filter.Now)
+		n, err := s.coll.CountDocuments(ctx, query)
+		if err != nil {
+			return 0, err
+		}
+		ahead += n
+	}
+	return ahead + 1, nil
+}
+
+func (s *mongoStreakSummaryStore) Count(ctx context.Context, filter RankingFilter) (int64, error) {
+	return s.coll.CountDocuments(ctx, rankingQuery(filter))
+}
@@ -0,0 +1,250 @@
+// internal/store/mongo_users.go
+package store
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"solivra-go/backend/internal/models"
+)
+
+type mongoUserStore struct {
+	coll *mongo.Collection
+}
+
+func (s *mongoUserStore) findOne(ctx context.Context, filter bson.M) (*models.User, error) {
+	var user models.User
+	if err := s.coll.FindOne(ctx, filter).Decode(&user); err != nil {
+		return nil, mapErr(err)
+	}
+	return &user, nil
+}
+
+func (s *mongoUserStore) FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	return s.findOne(ctx, bson.M{"_id": id})
+}
+
+func (s *mongoUserStore) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	return s.findOne(ctx, bson.M{"username": username})
+}
+
+func (s *mongoUserStore) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.User, error) {
+	if len(ids) == 0 {
+		return []models.User{}, nil
+	}
+	return s.find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+}
+
+func (s *mongoUserStore) UsernameTaken(ctx context.Context, username string, excludeID primitive.ObjectID) (bool, error) {
+	filter := bson.M{"username": username}
+	if !excludeID.IsZero() {
+		filter["_id"] = bson.M{"$ne": excludeID}
+	}
+	count, err := s.coll.CountDocuments(ctx, filter)
+	return count > 0, err
+}
+
+func (s *mongoUserStore) Create(ctx context.Context, user *models.User) error {
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	_, err := s.coll.InsertOne(ctx, user)
+	return err
+}
+
+func (s *mongoUserStore) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	res, err := s.coll.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
+func (s *mongoUserStore) find(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]models.User, error) {
+	cursor, err := s.coll.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (s *mongoUserStore) List(ctx context.Context) ([]models.User, error) {
+	return s.find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+}
+
+func (s *mongoUserStore) Count(ctx context.Context) (int64, error) {
+	return s.coll.CountDocuments(ctx, bson.M{})
+}
+
+func (s *mongoUserStore) CountCreatedSince(ctx context.Context, since time.Time) (int64, error) {
+	return s.coll.CountDocuments(ctx, bson.M{"created_at": bson.M{"$gte": since}})
+}
+
+func (s *mongoUserStore) LanguageDistribution(ctx context.Context) ([]LanguageCount, error) {
+	pipeline := []bson.M{
+		{"$group": bson.M{"_id": "$language_pref", "count": bson.M{"$sum": 1}}},
+		{"$project": bson.M{"language_pref": "$_id", "user_count": "$count", "_id": 0}},
+	}
+	cursor, err := s.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []LanguageCount
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// updateByID menjalankan $set pada satu user dan mengembalikan ErrNotFound jika user tidak ada.
+func (s *mongoUserStore) updateByID(ctx context.Context, id primitive.ObjectID, set bson.M) error {
+	res, err := s.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *mongoUserStore) SetLoginState(ctx context.Context, id primitive.ObjectID, failedAttempts int, lockoutUntil *time.Time) error {
+	return s.updateByID(ctx, id, bson.M{
+		"failed_login_attempts": failedAttempts,
+		"lockout_until":         lockoutUntil,
+	})
+}
+
+func (s *mongoUserStore) UpdateProfile(ctx context.Context, id primitive.ObjectID, update UserProfileUpdate, now time.Time) error {
+	set := bson.M{"updated_at": now}
+	if update.Nickname != nil {
+		set["nickname"] = *update.Nickname
+	}
+	if update.Username != nil {
+		set["username"] = *update.Username
+	}
+	if update.ProfilePicture != nil {
+		set["profile_picture"] = *update.ProfilePicture
+	}
+	if update.ProfilePictureSmall != nil {
+		set["profile_picture_small"] = *update.ProfilePictureSmall
+	}
+	return s.updateByID(ctx, id, set)
+}
+
+func (s *mongoUserStore) SetPassword(ctx context.Context, id primitive.ObjectID, hash string, now time.Time) error {
+	return s.updateByID(ctx, id, bson.M{"password": hash, "updated_at": now})
+}
+
+func (s *mongoUserStore) SetLanguage(ctx context.Context, id primitive.ObjectID, lang string, now time.Time) error {
+	return s.updateByID(ctx, id, bson.M{"language_pref": lang, "updated_at": now})
+}
+
+func (s *mongoUserStore) SetRankingVisibility(ctx context.Context, id primitive.ObjectID, visibility string, now time.Time) error {
+	return s.updateByID(ctx, id, bson.M{"ranking_visibility": visibility, "updated_at": now})
+}
+
+func (s *mongoUserStore) SetTimezone(ctx context.Context, id primitive.ObjectID, timezone string, now time.Time) error {
+	return s.updateByID(ctx, id, bson.M{"timezone": timezone, "updated_at": now})
+}
+
+func (s *mongoUserStore) SetStreakMirror(ctx context.Context, id primitive.ObjectID, start *time.Time, longestSeconds int64) error {
+	return s.updateByID(ctx, id, bson.M{"streak_start_date": start, "longest_streak_seconds": longestSeconds})
+}
+
+func (s *mongoUserStore) AddRefreshToken(ctx context.Context, id primitive.ObjectID, token models.RefreshTokenSchema, now time.Time) error {
+	// User lama menyimpan refreshTokens sebagai null; $push/$pull butuh array.
+	if _, err := s.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "refreshTokens": bson.M{"$not": bson.M{"$type": "array"}}},
+		bson.M{"$set": bson.M{"refreshTokens": bson.A{}}},
+	); err != nil {
+		return err
+	}
+
+	// $pull dan $push tidak boleh menyentuh field yang sama dalam satu update
+	if _, err := s.coll.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$pull": bson.M{"refreshTokens": bson.M{"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$lte": now}},
+			bson.M{"rotated_at": bson.M{"$lte": now.Add(-models.RefreshRotatedRetention)}},
+		}}}},
+	); err != nil {
+		return err
+	}
+
+	res, err := s.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$push": bson.M{"refreshTokens": token}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *mongoUserStore) RotateRefreshToken(ctx context.Context, id primitive.ObjectID, jti string, at time.Time) (bool, error) {
+	res, err := s.coll.UpdateOne(ctx,
+		bson.M{
+			"_id":           id,
+			"refreshTokens": bson.M{"$elemMatch": bson.M{"jti": jti, "rotated_at": nil}},
+		},
+		bson.M{"$set": bson.M{"refreshTokens.$.rotated_at": at}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func (s *mongoUserStore) RemoveSessionRefreshTokens(ctx context.Context, id, sessionID primitive.ObjectID) error {
+	_, err := s.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "refreshTokens": bson.M{"$type": "array"}},
+		bson.M{"$pull": bson.M{"refreshTokens": bson.M{"session_id": sessionID}}},
+	)
+	return err
+}
+
+func (s *mongoUserStore) SetTwoFactor(ctx context.Context, id primitive.ObjectID, settings models.TwoFactorSettings, now time.Time) error {
+	return s.updateByID(ctx, id, bson.M{"two_factor": settings, "updated_at": now})
+}
+
+func (s *mongoUserStore) UseTwoFactorStep(ctx context.Context, id primitive.ObjectID, step int64) (bool, error) {
+	res, err := s.coll.UpdateOne(ctx,
+		bson.M{
+			"_id": id,
+			"$or": bson.A{
+				bson.M{"two_factor.last_used_step": bson.M{"$exists": false}},
+				bson.M{"two_factor.last_used_step": bson.M{"$lt": step}},
+			},
+		},
+		bson.M{"$set": bson.M{"two_factor.last_used_step": step}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func (s *mongoUserStore) ConsumeRecoveryCode(ctx context.Context, id primitive.ObjectID, codeHash string) (bool, error) {
+	res, err := s.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "two_factor.recovery_codes": codeHash},
+		bson.M{"$pull": bson.M{"two_factor.recovery_codes": codeHash}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
@@ -0,0 +1,513 @@
+// internal/store/store.go
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"solivra-go/backend/internal/leaderboard"
+	"solivra-go/backend/internal/models"
+)
+
+// ErrNotFound dikembalikan oleh semua store ketika dokumen yang dicari tidak ada.
+var ErrNotFound = errors.New("store: not found")
+
+// Stores mengelompokkan seluruh store yang dipakai oleh handler dan middleware.
+// Implementasi Mongo dibuat lewat NewMongo, implementasi in-memory lewat NewMemory.
+type Stores struct {
+	Users                UserStore
+	Sessions             SessionStore
+	Relapses             RelapseStore
+	Habits               HabitStore
+	ActivityLogs         ActivityLogStore
+	Honeypots            HoneypotStore
+	LoginAttempts        LoginAttemptStore
+	RegistrationAttempts RegistrationAttemptStore
+	StreakSummaries      StreakSummaryStore
+	DataExports          DataExportStore
+	Achievements         AchievementStore
+	Jobs                 JobStore
+
+	// Tx menjalankan beberapa operasi store secara atomik.
+	Tx Transactor
+}
+
+// Transactor menjalankan fn di dalam transaksi. Store yang dipanggil di dalam fn
+// harus memakai ctx yang diberikan ke fn agar ikut dalam transaksi yang sama.
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// UserProfileUpdate berisi field profil yang boleh diubah. Field nil tidak disentuh.
+type UserProfileUpdate struct {
+	Nickname       *string
+	Username       *string
+	ProfilePicture *string
+	// ProfilePictureSmall adalah thumbnail ProfilePicture; string kosong menghapusnya.
+	ProfilePictureSmall *string
+}
+
+// LanguageCount adalah satu baris distribusi bahasa untuk dashboard admin.
+type LanguageCount struct {
+	LanguagePref interface{} `bson:"language_pref" json:"language_pref"`
+	UserCount    int         `bson:"user_count" json:"user_count"`
+}
+
+// UserStore mengelola koleksi "users".
+type UserStore interface {
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	FindByUsername(ctx context.Context, username string) (*models.User, error)
+	// FindByIDs mengembalikan user untuk setiap ID yang ada (urutan tidak dijamin).
+	FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.User, error)
+	// UsernameTaken memeriksa apakah username dipakai user lain selain excludeID (boleh NilObjectID).
+	UsernameTaken(ctx context.Context, username string, excludeID primitive.ObjectID) (bool, error)
+	Create(ctx context.Context, user *models.User) error
+	Delete(ctx context.Context, id primitive.ObjectID) (bool, error)
+	// List mengembalikan semua user, terbaru lebih dulu.
+	List(ctx context.Context) ([]models.User, error)
+	Count(ctx context.Context) (int64, error)
+	CountCreatedSince(ctx context.Context, since time.Time) (int64, error)
+	LanguageDistribution(ctx context.Context) ([]LanguageCount, error)
+
+	SetLoginState(ctx context.Context, id primitive.ObjectID, failedAttempts int, lockoutUntil *time.Time) error
+	UpdateProfile(ctx context.Context, id primitive.ObjectID, update UserProfileUpdate, now time.Time) error
+	SetPassword(ctx context.Context, id primitive.ObjectID, hash string, now time.Time) error
+	SetLanguage(ctx context.Context, id primitive.ObjectID, lang string, now time.Time) error
+	SetRankingVisibility(ctx context.Context, id primitive.ObjectID, visibility string, now time.Time) error
+	SetTimezone(ctx context.Context, id primitive.ObjectID, timezone string, now time.Time) error
+	// SetStreakMirror menyalin streak habit default ke streak_start_date dan longest_streak_seconds user.
+	SetStreakMirror(ctx context.Context, id primitive.ObjectID, start *time.Time, longestSeconds int64) error
+
+	// AddRefreshToken menyimpan JTI refresh token baru dan membuang entri yang kedaluwarsa
+	// sebelum now serta entri yang sudah di-rotate lebih dari models.RefreshRotatedRetention.
+	AddRefreshToken(ctx context.Context, id primitive.ObjectID, token models.RefreshTokenSchema, now time.Time) error
+	// RotateRefreshToken menandai JTI sebagai sudah dipakai. Mengembalikan false jika JTI
+	// tidak ada atau sudah pernah di-rotate (indikasi reuse).
+	RotateRefreshToken(ctx context.Context, id primitive.ObjectID, jti string, at time.Time) (bool, error)
+	// RemoveSessionRefreshTokens menghapus semua refresh token milik satu sesi.
+	RemoveSessionRefreshTokens(ctx context.Context, id, sessionID primitive.ObjectID) error
+
+	// SetTwoFactor mengganti seluruh pengaturan 2FA user.
+	SetTwoFactor(ctx context.Context, id primitive.ObjectID, settings models.TwoFactorSettings, now time.Time) error
+	// UseTwoFactorStep mencatat langkah TOTP yang dipakai. Mengembalikan false jika
+	// langkah tersebut (atau yang lebih baru) sudah pernah dipakai.
+	UseTwoFactorStep(ctx context.Context, id primitive.ObjectID, step int64) (bool, error)
+	// ConsumeRecoveryCode menghapus hash recovery code. Mengembalikan false jika tidak ada.
+	ConsumeRecoveryCode(ctx context.Context, id primitive.ObjectID, codeHash string) (bool, error)
+}
+
+// SessionStore mengelola koleksi "usersessions".
+type SessionStore interface {
+	Create(ctx context.Context, session *models.UserSession) error
+	FindByID(ctx context.Context, id, userID primitive.ObjectID) (*models.UserSession, error)
+	// FindActive mencari sesi milik user dengan hash token tertentu yang belum di-revoke.
+	FindActive(ctx context.Context, userID primitive.ObjectID, tokenHash string) (*models.UserSession, error)
+	// ListActive mengembalikan sesi aktif, yang paling baru aktif lebih dulu.
+	ListActive(ctx context.Context, userID primitive.ObjectID) ([]models.UserSession, error)
+	// ListByUser mengembalikan semua sesi user termasuk yang sudah di-revoke, terbaru lebih dulu.
+	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.UserSession, error)
+	Touch(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	Revoke(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	RevokeByToken(ctx context.Context, tokenHash string, at time.Time) error
+	// RevokeAllExcept mencabut semua sesi aktif user kecuali sesi dengan hash exceptHash.
+	RevokeAllExcept(ctx context.Context, userID primitive.ObjectID, exceptHash string, at time.Time) (int, error)
+	RevokeAll(ctx context.Context, userID primitive.ObjectID, at time.Time) (int, error)
+	// DeleteRevokedBefore menghapus sesi yang di-revoke sebelum before.
+	DeleteRevokedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// RelapseFilter menyaring relapse berdasarkan metadata (GET /api/relapses). Field bernilai
+// nol tidak menyaring.
+type RelapseFilter struct {
+	Trigger      string
+	Tag          string
+	Location     string
+	MoodBefore   int
+	MoodAfter    int
+	MinIntensity int
+	MaxIntensity int
+}
+
+// Match melaporkan apakah relapse lolos filter.
+func (f RelapseFilter) Match(r *models.RelapseLog) bool {
+	d := r.RelapseDetails
+	switch {
+	case f.Trigger != "" && !hasString(d.Triggers, f.Trigger),
+		f.Tag != "" && !hasString(d.Tags, f.Tag),
+		f.Location != "" && d.Location != f.Location,
+		f.MoodBefore != 0 && d.MoodBefore != f.MoodBefore,
+		f.MoodAfter != 0 && d.MoodAfter != f.MoodAfter,
+		f.MinIntensity != 0 && d.Intensity < f.MinIntensity,
+		f.MaxIntensity != 0 && (d.Intensity == 0 || d.Intensity > f.MaxIntensity):
+		return false
+	}
+	return true
+}
+
+func hasString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// InsightsQuery adalah parameter agregasi RelapseStore.Insights untuk satu habit.
+type InsightsQuery struct {
+	HabitID primitive.ObjectID
+	// Start adalah streak_start_date habit; streak pertama dihitung dari sini.
+	Start time.Time
+	// Location menentukan jam, hari, serta batas minggu (mulai Senin) dan bulan.
+	Location *time.Location
+	// WeeksSince dan MonthsSince membatasi seri mingguan dan bulanan.
+	WeeksSince  time.Time
+	MonthsSince time.Time
+	// Windows adalah panjang jendela tren: relapse pada [Now-w, Now) dibandingkan
+	// dengan [Now-2w, Now-w).
+	Now     time.Time
+	Windows []time.Duration
+}
+
+// PeriodCount adalah jumlah relapse dalam satu minggu atau bulan kalender.
+type PeriodCount struct {
+	Start time.Time `bson:"_id" json:"start"`
+	Count int       `bson:"count" json:"count"`
+}
+
+// WindowCount adalah jumlah relapse pada jendela tren terbaru dan jendela sebelumnya.
+type WindowCount struct {
+	Window   time.Duration
+	Recent   int
+	Previous int
+}
+
+// RelapseInsights adalah hasil agregasi relapse satu habit (GET /api/stats/insights).
+// Setiap relapse mengakhiri satu streak, jadi Total juga jumlah streak yang selesai.
+type RelapseInsights struct {
+	Total int
+	// LastRelapse adalah relapse terbaru, nil jika belum ada.
+	LastRelapse *time.Time
+	// AverageStreak, MedianStreak, dan LongestStreak dalam detik, dari streak yang sudah selesai.
+	AverageStreak int64
+	MedianStreak  int64
+	LongestStreak int64
+	// Weekly dan Monthly hanya memuat periode yang punya relapse, diurutkan naik.
+	Weekly  []PeriodCount
+	Monthly []PeriodCount
+	// Heatmap[hari][jam] dengan hari 0 = Senin.
+	Heatmap [7][24]int
+	// Windows sejajar dengan InsightsQuery.Windows.
+	Windows []WindowCount
+}
+
+// RelapseStore mengelola koleksi "relapselogs".
+// Semua method baca mengabaikan relapse yang di-soft delete (deleted_at terisi), kecuali
+// ListChangedSince dan FindTombstone yang dipakai batch sync.
+type RelapseStore interface {
+	Create(ctx context.Context, relapse *models.RelapseLog) error
+	// CreateMany menyimpan banyak relapse sekaligus (impor riwayat) dan mengembalikan jumlahnya.
+	CreateMany(ctx context.Context, relapses []models.RelapseLog) (int, error)
+	FindByID(ctx context.Context, id, userID primitive.ObjectID) (*models.RelapseLog, error)
+	// ListByUser mengembalikan semua relapse user (semua habit), diurutkan berdasarkan relapse_time.
+	ListByUser(ctx context.Context, userID primitive.ObjectID, ascending bool) ([]models.RelapseLog, error)
+	// ListByHabit mengembalikan relapse satu habit, diurutkan berdasarkan relapse_time.
+	ListByHabit(ctx context.Context, habitID primitive.ObjectID, ascending bool) ([]models.RelapseLog, error)
+	// ListByHabitBetween sama seperti ListByHabit (urut naik), hanya relapse dengan from <= relapse_time < to.
+	ListByHabitBetween(ctx context.Context, habitID primitive.ObjectID, from, to time.Time) ([]models.RelapseLog, error)
+	// ListFiltered sama seperti ListByHabit, hanya relapse yang lolos filter.
+	ListFiltered(ctx context.Context, habitID primitive.ObjectID, filter RelapseFilter, ascending bool) ([]models.RelapseLog, error)
+	CountByHabit(ctx context.Context, habitID primitive.ObjectID) (int64, error)
+	CountSince(ctx context.Context, since time.Time) (int64, error)
+	// ListTimesSince mengembalikan relapse_time (urut naik) per habit untuk habitIDs, hanya
+	// relapse dengan relapse_time >= since. Habit tanpa relapse tidak punya entri.
+	ListTimesSince(ctx context.Context, habitIDs []primitive.ObjectID, since time.Time) (map[primitive.ObjectID][]time.Time, error)
+	// CountNoteDays menghitung tanggal kalender (zona loc) yang punya relapse dengan catatan.
+	CountNoteDays(ctx context.Context, userID primitive.ObjectID, loc *time.Location) (int, error)
+	// ListNoteUserIDs mengembalikan ID setiap user yang punya relapse dengan catatan.
+	ListNoteUserIDs(ctx context.Context) ([]primitive.ObjectID, error)
+	// CountByHour mengembalikan jumlah relapse per jam pada zona loc untuk seluruh user, panjang 24.
+	CountByHour(ctx context.Context, loc *time.Location) ([]int, error)
+	// Insights mengagregasi relapse satu habit: panjang streak, frekuensi per minggu/bulan,
+	// heatmap hari x jam, dan jumlah relapse per jendela tren.
+	Insights(ctx context.Context, q InsightsQuery) (*RelapseInsights, error)
+	// UpdateNote mengganti relapse_note dan mengembalikan dokumen setelah update.
+	// writtenAt disimpan sebagai client_updated_at (waktu tulis untuk last-writer-wins).
+	UpdateNote(ctx context.Context, id, userID primitive.ObjectID, note *string, writtenAt, now time.Time) (*models.RelapseLog, error)
+	// UpdateTime mengoreksi relapse_time, sama seperti UpdateNote.
+	UpdateTime(ctx context.Context, id, userID primitive.ObjectID, relapseTime, writtenAt, now time.Time) (*models.RelapseLog, error)
+	// UpdateDetails mengganti seluruh metadata relapse, sama seperti UpdateNote.
+	UpdateDetails(ctx context.Context, id, userID primitive.ObjectID, details models.RelapseDetails, writtenAt, now time.Time) (*models.RelapseLog, error)
+	// FindByClientID mencari relapse user berdasarkan ID buatan klien.
+	FindByClientID(ctx context.Context, userID primitive.ObjectID, clientID string) (*models.RelapseLog, error)
+	// ListChangedSince mengembalikan relapse user (termasuk yang di-soft delete) yang
+	// updatedAt-nya setelah posisi (since, afterID), diurutkan berdasarkan updatedAt lalu
+	// _id. limit 0 berarti tanpa batas.
+	ListChangedSince(ctx context.Context, userID primitive.ObjectID, since time.Time, afterID primitive.ObjectID, limit int64) ([]models.RelapseLog, error)
+
+	// SoftDelete menandai relapse terhapus (deleted_at = now) tanpa menghapus dokumennya.
+	SoftDelete(ctx context.Context, id, userID primitive.ObjectID, now time.Time) (bool, error)
+	// SoftDeleteByHabit menandai semua relapse aktif habit terhapus dengan deletionID yang sama.
+	SoftDeleteByHabit(ctx context.Context, habitID, deletionID primitive.ObjectID, now time.Time) (int, error)
+	// SoftDeleteAfter sama seperti SoftDeleteByHabit, hanya untuk relapse yang relapse_time-nya setelah t.
+	SoftDeleteAfter(ctx context.Context, habitID, deletionID primitive.ObjectID, t, now time.Time) (int, error)
+	// Restore memulihkan relapse yang di-soft delete pada atau setelah deletedSince.
+	Restore(ctx context.Context, id, userID primitive.ObjectID, deletedSince, now time.Time) (*models.RelapseLog, error)
+	// RestoreDeletion memulihkan semua relapse habit dari satu penghapusan massal.
+	RestoreDeletion(ctx context.Context, habitID, deletionID primitive.ObjectID, deletedSince, now time.Time) (int, error)
+	// LastDeletion mengembalikan deletion_id penghapusan massal terbaru habit sejak deletedSince.
+	LastDeletion(ctx context.Context, habitID primitive.ObjectID, deletedSince time.Time) (primitive.ObjectID, error)
+	// PurgeDeleted menghapus permanen relapse (seluruh user) yang di-soft delete sebelum before.
+	PurgeDeleted(ctx context.Context, before, now time.Time) (int, error)
+
+	// DeleteAllByHabit dan PurgeDeleted menghapus permanen beserta riwayat editnya dan
+	// mencatat tombstone (deleted_at = now) untuk setiap relapse yang dihapus.
+	DeleteAllByHabit(ctx context.Context, habitID primitive.ObjectID, now time.Time) (int, error)
+	// DeleteAllByUser menghapus relapse, riwayat edit, dan tombstone user tanpa mencatat tombstone baru (hapus akun).
+	DeleteAllByUser(ctx context.Context, userID primitive.ObjectID) (int, error)
+	// FindTombstone mencari relapse user yang sudah dihapus (soft delete maupun tombstone
+	// setelah dihapus permanen) berdasarkan ID relapse atau ID buatan klien.
+	FindTombstone(ctx context.Context, userID, id primitive.ObjectID, clientID string) (*models.RelapseTombstone, error)
+	// ListTombstonesSince sama seperti ListChangedSince, berdasarkan deleted_at.
+	ListTombstonesSince(ctx context.Context, userID primitive.ObjectID, since time.Time, afterID primitive.ObjectID, limit int64) ([]models.RelapseTombstone, error)
+	// AddRevision menyimpan satu versi relapse ke riwayat edit.
+	AddRevision(ctx context.Context, revision *models.RelapseRevision) error
+	// ListRevisions mengembalikan riwayat edit relapse milik user, diurutkan naik berdasarkan versi.
+	ListRevisions(ctx context.Context, relapseID, userID primitive.ObjectID) ([]models.RelapseRevision, error)
+	// ListRevisionsByUser mengembalikan seluruh riwayat edit relapse user (ekspor data).
+	ListRevisionsByUser(ctx context.Context, userID primitive.ObjectID) ([]models.RelapseRevision, error)
+	// AssignHabit memasang habitID pada relapse user yang belum punya habit (data sebelum migrasi).
+	AssignHabit(ctx context.Context, userID, habitID primitive.ObjectID) (int, error)
+}
+
+// HabitUpdate berisi field habit yang boleh diubah. Field nil tidak disentuh.
+type HabitUpdate struct {
+	Name           *string
+	Color          *string
+	Icon           *string
+	ShowInRankings *bool
+}
+
+// HabitStore mengelola koleksi "habits".
+type HabitStore interface {
+	Create(ctx context.Context, habit *models.Habit) error
+	// EnsureDefault mengembalikan habit default milik habit.UserID. Jika belum ada, habit
+	// disimpan sebagai default baru dan created bernilai true.
+	EnsureDefault(ctx context.Context, habit *models.Habit) (result *models.Habit, created bool, err error)
+	FindByID(ctx context.Context, id, userID primitive.ObjectID) (*models.Habit, error)
+	FindDefault(ctx context.Context, userID primitive.ObjectID) (*models.Habit, error)
+	// FindByKind mencari habit user dengan jenis tertentu (selain custom, maksimal satu per jenis).
+	FindByKind(ctx context.Context, userID primitive.ObjectID, kind string) (*models.Habit, error)
+	// ListByUser mengembalikan habit user: default lebih dulu, lalu yang paling lama dibuat.
+	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.Habit, error)
+	// ListWithStreak mengembalikan semua habit (seluruh user) yang sudah memulai streak.
+	ListWithStreak(ctx context.Context) ([]models.Habit, error)
+	CountByUser(ctx context.Context, userID primitive.ObjectID) (int64, error)
+	Update(ctx context.Context, id, userID primitive.ObjectID, update HabitUpdate, now time.Time) error
+	Delete(ctx context.Context, id, userID primitive.ObjectID) (bool, error)
+	DeleteAllByUser(ctx context.Context, userID primitive.ObjectID) (int, error)
+
+	// StartStreak hanya mengisi streak_start_date jika belum pernah di-set.
+	StartStreak(ctx context.Context, id primitive.ObjectID, start time.Time) (bool, error)
+	// UpdateStreakStartIfLater memundurkan streak_start_date ke start jika belum ada atau lebih baru dari start.
+	UpdateStreakStartIfLater(ctx context.Context, id primitive.ObjectID, start time.Time) (bool, error)
+	SetLongestStreak(ctx context.Context, id primitive.ObjectID, seconds int64) error
+	// ResetStreak mengosongkan streak_start_date dan longest_streak_seconds.
+	ResetStreak(ctx context.Context, id primitive.ObjectID) error
+}
+
+// RankingFilter membatasi ringkasan yang ikut dalam ranking dan menentukan urutannya.
+type RankingFilter struct {
+	// ExcludeHidden membuang user dengan ranking_visibility "hidden" (ranking publik).
+	ExcludeHidden bool
+	// HabitKind membatasi ranking ke habit dengan jenis tersebut. Kosong berarti
+	// hanya habit default (satu baris per user).
+	HabitKind string
+	// CurrentStartFrom dan CurrentStartBefore (jika tidak nol) membatasi ke streak berjalan
+	// yang dimulai (current_streak_start) pada/sesudah atau sebelum waktu tersebut.
+	CurrentStartFrom   time.Time
+	CurrentStartBefore time.Time
+	// Sort adalah urutan ListRanked dan Rank, sama dengan leaderboard.Less untuk metrik
+	// sepanjang masa. Kosong berarti leaderboard.SortCurrent.
+	Sort leaderboard.Sort
+	// Now adalah waktu acuan metrik yang bergantung pada streak berjalan (SortLongest,
+	// SortAverage).
+	Now time.Time
+	// After (jika ada) membatasi ListRanked ke ringkasan yang berada di bawah posisi
+	// tersebut pada urutan Sort (paginasi keyset). Rank dan Count mengabaikannya.
+	After *leaderboard.Key
+}
+
+// matches melaporkan apakah ringkasan lolos filter.
+func (f RankingFilter) matches(summary *models.StreakSummary) bool {
+	if f.HabitKind == "" && !summary.IsDefault {
+		return false
+	}
+	if f.HabitKind != "" && summary.HabitKind != f.HabitKind {
+		return false
+	}
+	if !f.CurrentStartFrom.IsZero() && summary.CurrentStreakStart.Before(f.CurrentStartFrom) {
+		return false
+	}
+	if !f.CurrentStartBefore.IsZero() && !summary.CurrentStreakStart.Before(f.CurrentStartBefore) {
+		return false
+	}
+	return !f.ExcludeHidden || summary.Visibility != models.RankingVisibilityHidden
+}
+
+// entry mengubah ringkasan menjadi metrik leaderboard pada waktu f.Now.
+func (f RankingFilter) entry(summary *models.StreakSummary) leaderboard.Entry {
+	return leaderboard.FromSummary(*summary, f.Now)
+}
+
+// afterKey melaporkan apakah ringkasan berada di bawah f.After (selalu true tanpa After).
+func (f RankingFilter) afterKey(summary *models.StreakSummary) bool {
+	return f.After == nil || f.After.Before(f.entry(summary), f.Sort)
+}
+
+// less melaporkan apakah a berada di atas b pada urutan f.Sort.
+func (f RankingFilter) less(a, b *models.StreakSummary) bool {
+	return leaderboard.Less(f.entry(a), f.entry(b), f.Sort)
+}
+
+// StreakSummaryStore mengelola koleksi "streaksummaries".
+type StreakSummaryStore interface {
+	Get(ctx context.Context, habitID primitive.ObjectID) (*models.StreakSummary, error)
+	Upsert(ctx context.Context, summary *models.StreakSummary) error
+	Delete(ctx context.Context, habitID primitive.ObjectID) error
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) error
+	// DeleteLegacy menghapus ringkasan format lama (satu per user, sebelum habit) dan mengembalikan jumlahnya.
+	DeleteLegacy(ctx context.Context) (int64, error)
+	// ListRanked mengembalikan ringkasan dalam urutan filter.Sort; limit 0 berarti semua.
+	// Urutan bawaan adalah streak berjalan terpanjang (current_streak_start paling awal),
+	// dengan _id sebagai pemecah seri. Sort lain memakai field urutan dari
+	// StreakSummary.SetSortKeys (ringkasan lama diisi ulang lewat cmd/backfill).
+	ListRanked(ctx context.Context, filter RankingFilter, skip, limit int64) ([]models.StreakSummary, error)
+	// Rank mengembalikan posisi (mulai 1) habit pada urutan ListRanked.
+	// ErrNotFound jika habit tidak punya ringkasan atau tersaring oleh filter.
+	Rank(ctx context.Context, filter RankingFilter, habitID primitive.ObjectID) (int64, error)
+	Count(ctx context.Context, filter RankingFilter) (int64, error)
+}
+
+// ActivityLogQuery adalah filter untuk daftar activity log di panel admin.
+type ActivityLogQuery struct {
+	Action         string // Kosong berarti semua action
+	ExcludeAction  string // Diabaikan jika Action diisi
+	UsernameSearch string // Regex case-insensitive
+	Ascending      bool
+	Skip           int64
+	Limit          int64
+}
+
+// ActivityLogStore mengelola koleksi "activitylogs".
+type ActivityLogStore interface {
+	Insert(ctx context.Context, entry *models.ActivityLog) error
+	// List mengembalikan log sesuai query beserta total dokumen yang cocok (tanpa paging).
+	List(ctx context.Context, q ActivityLogQuery) ([]models.ActivityLog, int64, error)
+	// ListByUser mengembalikan semua log milik user, terlama lebih dulu.
+	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.ActivityLog, error)
+	CountByUser(ctx context.Context, userID primitive.ObjectID) (int64, error)
+	// DeleteBefore menghapus log dengan timestamp sebelum before.
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// HoneypotUsernameCount adalah satu baris top username pada statistik honeypot.
+type HoneypotUsernameCount struct {
+	Username interface{} `bson:"_id" json:"_id"`
+	Count    int         `bson:"count" json:"count"`
+}
+
+// HoneypotStats adalah ringkasan insiden honeypot.
+type HoneypotStats struct {
+	TotalAttempts  int64
+	LoginAttempts  int64
+	AccessAttempts int64
+	RecentAttempts int64
+	UniqueIPs      int
+	TopUsernames   []HoneypotUsernameCount
+}
+
+// HoneypotStore mengelola koleksi "honeypotlogs".
+type HoneypotStore interface {
+	Insert(ctx context.Context, incident *models.HoneypotLog) error
+	// Stats menghitung ringkasan insiden; RecentAttempts dihitung sejak recentSince.
+	Stats(ctx context.Context, recentSince time.Time) (*HoneypotStats, error)
+}
+
+// LoginAttemptStore mengelola koleksi "loginattempts".
+type LoginAttemptStore interface {
+	Insert(ctx context.Context, attempt *models.LoginAttempt) error
+	// FindActiveIPLock mengembalikan lock IP terbaru yang masih berlaku pada waktu now.
+	FindActiveIPLock(ctx context.Context, ip string, now time.Time) (*models.LoginAttempt, error)
+	// CountByIP menghitung percobaan dari ip sejak since yang outcome-nya salah satu dari outcomes.
+	CountByIP(ctx context.Context, ip string, since time.Time, outcomes ...string) (int64, error)
+	// ListByUser mengembalikan percobaan login yang tertaut ke user, terlama lebih dulu.
+	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.LoginAttempt, error)
+	// DeleteExpired menghapus percobaan sebelum before yang lock-nya tidak berlaku lagi pada now.
+	DeleteExpired(ctx context.Context, before, now time.Time) (int64, error)
+}
+
+// RegistrationAttemptStore mengelola koleksi "registrationattempts".
+type RegistrationAttemptStore interface {
+	Insert(ctx context.Context, attempt *models.RegistrationAttempt) error
+	// FindActiveBlock mengembalikan blokir registrasi terbaru yang masih berlaku pada waktu now.
+	FindActiveBlock(ctx context.Context, ip string, now time.Time) (*models.RegistrationAttempt, error)
+	// CountByIP menghitung percobaan dari ip sejak since yang status-nya salah satu dari statuses.
+	CountByIP(ctx context.Context, ip string, since time.Time, statuses ...string) (int64, error)
+	// DeleteExpired menghapus percobaan sebelum before yang blokirnya tidak berlaku lagi pada now.
+	DeleteExpired(ctx context.Context, before, now time.Time) (int64, error)
+}
+
+// DataExportStore mengelola koleksi "dataexports".
+type DataExportStore interface {
+	Create(ctx context.Context, export *models.DataExport) error
+	// Get mencari job tanpa memeriksa pemilik (dipakai link unduhan bertanda tangan).
+	Get(ctx context.Context, id primitive.ObjectID) (*models.DataExport, error)
+	FindByID(ctx context.Context, id, userID primitive.ObjectID) (*models.DataExport, error)
+	// FindPending mengembalikan job user yang masih berstatus pending, jika ada.
+	FindPending(ctx context.Context, userID primitive.ObjectID) (*models.DataExport, error)
+	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.DataExport, error)
+	MarkReady(ctx context.Context, id primitive.ObjectID, filePath string, size int64, expiresAt, now time.Time) error
+	// MarkFailed menandai job gagal; expiresAt menentukan kapan catatannya dibersihkan.
+	MarkFailed(ctx context.Context, id primitive.ObjectID, reason string, expiresAt, now time.Time) error
+	// FailStale menandai gagal semua job pending yang dibuat sebelum createdBefore
+	// (worker-nya mati sebelum selesai) dan mengembalikan jumlahnya.
+	FailStale(ctx context.Context, createdBefore time.Time, reason string, expiresAt, now time.Time) (int, error)
+	// ListExpired mengembalikan job yang ExpiresAt-nya sudah lewat pada waktu now.
+	ListExpired(ctx context.Context, now time.Time) ([]models.DataExport, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) error
+}
+
+// AchievementStore mengelola koleksi "achievements".
+type AchievementStore interface {
+	// Award menyimpan achievement kecuali user sudah punya kode yang sama untuk habit yang
+	// sama. awarded false jika sudah pernah diberikan.
+	Award(ctx context.Context, achievement *models.Achievement) (awarded bool, err error)
+	// ListByUser mengembalikan achievement user, terlama lebih dulu.
+	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.Achievement, error)
+	DeleteByHabit(ctx context.Context, habitID primitive.ObjectID) error
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) error
+}
+
+// JobStore mengelola koleksi "joblocks" (lease pemimpin scheduler) dan "jobruns" (riwayat job).
+type JobStore interface {
+	// AcquireLease mengambil atau memperpanjang lease name untuk owner sampai until.
+	// Mengembalikan false jika lease masih dipegang owner lain pada now.
+	AcquireLease(ctx context.Context, name, owner string, now, until time.Time) (bool, error)
+	// ReleaseLease melepas lease jika masih dipegang owner.
+	ReleaseLease(ctx context.Context, name, owner string) error
+	GetLease(ctx context.Context, name string) (*models.JobLease, error)
+
+	StartRun(ctx context.Context, run *models.JobRun) error
+	FinishRun(ctx context.Context, id primitive.ObjectID, status string, result map[string]interface{}, errMsg string, finishedAt time.Time) error
+	// LastRun mengembalikan eksekusi terbaru job; ErrNotFound jika belum pernah berjalan.
+	LastRun(ctx context.Context, job string) (*models.JobRun, error)
+	// ListRuns mengembalikan eksekusi job, terbaru lebih dulu. limit 0 berarti tanpa batas.
+	ListRuns(ctx context.Context, job string, limit int64) ([]models.JobRun, error)
+	// DeleteRunsBefore menghapus riwayat yang dimulai sebelum before.
+	DeleteRunsBefore(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,130 @@
+// internal/streaks/period.go
+package streaks
+
+import "time"
+
+// Period adalah satuan pengelompokan untuk Breakdown.
+type Period string
+
+const (
+	Day   Period = "day"
+	Week  Period = "week" // Minggu dimulai hari Senin (ISO 8601)
+	Month Period = "month"
+)
+
+// Valid melaporkan apakah p adalah period yang dikenal.
+func (p Period) Valid() bool {
+	return p == Day || p == Week || p == Month
+}
+
+// PeriodStats adalah ringkasan streak dalam satu periode kalender.
+type PeriodStats struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Relapses adalah jumlah relapse yang terjadi di dalam periode.
+	Relapses int `json:"relapses"`
+	// LongestStreak adalah potongan streak terpanjang yang berada di dalam periode.
+	LongestStreak int64 `json:"longest_streak"`
+	// CleanSeconds adalah total waktu di dalam periode yang berada setelah start (terlacak).
+	CleanSeconds int64 `json:"clean_seconds"`
+}
+
+// truncate mengembalikan awal periode yang memuat t pada zona loc.
+func (p Period) truncate(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	y, m, d := t.Date()
+	switch p {
+	case Month:
+		return time.Date(y, m, 1, 0, 0, 0, 0, loc)
+	case Week:
+		offset := (int(t.Weekday()) + 6) % 7 // Senin = 0
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, loc)
+	}
+}
+
+// Start mengembalikan awal periode kalender yang memuat t pada zona loc (nil berarti UTC).
+func (p Period) Start(t time.Time, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return p.truncate(t, loc)
+}
+
+// next mengembalikan awal periode berikutnya setelah start (start harus hasil truncate).
+func (p Period) next(start time.Time) time.Time {
+	switch p {
+	case Month:
+		return start.AddDate(0, 1, 0)
+	case Week:
+		return start.AddDate(0, 0, 7)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// Breakdown membagi rentang [from, now) menjadi periode kalender pada zona loc dan
+// menghitung relapse serta streak per periode. from biasanya tanggal mulai streak
+// atau awal jendela laporan; periode sebelum start tidak punya waktu bersih.
+// loc nil berarti UTC.
+func Breakdown(start time.Time, relapses []time.Time, now, from time.Time, period Period, loc *time.Location) []PeriodStats {
+	if loc == nil {
+		loc = time.UTC
+	}
+	if !period.Valid() {
+		period = Day
+	}
+	if !from.Before(now) {
+		return []PeriodStats{}
+	}
+
+	points := timeline(start, relapses)
+	result := make([]PeriodStats, 0)
+
+	for ps := period.truncate(from, loc); ps.Before(now); ps = period.next(ps) {
+		pe := period.next(ps)
+		stats := PeriodStats{Start: ps, End: pe}
+
+		for _, r := range relapses {
+			if !r.Before(ps) && r.Before(pe) {
+				stats.Relapses++
+			}
+		}
+
+		// Setiap segmen [points[i], points[i+1]) adalah satu streak; segmen terakhir berakhir di now
+		for i := range points {
+			segStart := points[i]
+			segEnd := now
+			if i+1 < len(points) {
+				segEnd = points[i+1]
+			}
+			overlap := seconds(minTime(segEnd, pe, now).Sub(maxTime(segStart, ps)))
+			stats.CleanSeconds += overlap
+			if overlap > stats.LongestStreak {
+				stats.LongestStreak = overlap
+			}
+		}
+
+		result = append(result, stats)
+	}
+
+	return result
+}
+
+func minTime(times ...time.Time) time.Time {
+	m := times[0]
+	for _, t := range times[1:] {
+		if t.Before(m) {
+			m = t
+		}
+	}
+	return m
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
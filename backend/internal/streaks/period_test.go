@@ -0,0 +1,271 @@
+package streaks
+
+import (
+	"testing"
+	"time"
+)
+
+const hour = int64(60 * 60)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestPeriodStart(t *testing.T) {
+	jakarta := mustLocation(t, "Asia/Jakarta")
+	berlin := mustLocation(t, "Europe/Berlin")
+	jkt := func(y int, m time.Month, d, h int) time.Time { return time.Date(y, m, d, h, 0, 0, 0, jakarta) }
+
+	tests := []struct {
+		name   string
+		period Period
+		t      time.Time
+		loc    *time.Location
+		want   time.Time
+	}{
+		{name: "day", period: Day, t: jkt(2024, 3, 6, 23), loc: jakarta, want: jkt(2024, 3, 6, 0)},
+		{name: "day uses zone not UTC", period: Day, t: time.Date(2024, 3, 6, 20, 0, 0, 0, time.UTC), loc: jakarta, want: jkt(2024, 3, 7, 0)},
+		{name: "day nil zone is UTC", period: Day, t: jkt(2024, 3, 7, 3), loc: nil, want: time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC)},
+		{name: "week midweek", period: Week, t: jkt(2024, 3, 6, 10), loc: jakarta, want: jkt(2024, 3, 4, 0)},
+		{name: "week sunday belongs to previous monday", period: Week, t: jkt(2024, 3, 10, 23), loc: jakarta, want: jkt(2024, 3, 4, 0)},
+		{name: "week monday midnight", period: Week, t: jkt(2024, 3, 11, 0), loc: jakarta, want: jkt(2024, 3, 11, 0)},
+		{name: "week sunday in UTC is monday in zone", period: Week, t: time.Date(2024, 3, 10, 18, 0, 0, 0, time.UTC), loc: jakarta, want: jkt(2024, 3, 11, 0)},
+		{name: "week across month", period: Week, t: jkt(2024, 5, 1, 12), loc: jakarta, want: jkt(2024, 4, 29, 0)},
+		{name: "month", period: Month, t: jkt(2024, 2, 29, 23), loc: jakarta, want: jkt(2024, 2, 1, 0)},
+		{name: "month rollover in zone", period: Month, t: time.Date(2024, 2, 29, 20, 0, 0, 0, time.UTC), loc: jakarta, want: jkt(2024, 3, 1, 0)},
+		{name: "day on DST change", period: Day, t: time.Date(2024, 3, 31, 12, 0, 0, 0, berlin), loc: berlin, want: time.Date(2024, 3, 31, 0, 0, 0, 0, berlin)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.period.Start(tt.t, tt.loc); !got.Equal(tt.want) {
+				t.Errorf("Start(%v) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreakdown(t *testing.T) {
+	jakarta := mustLocation(t, "Asia/Jakarta")
+	berlin := mustLocation(t, "Europe/Berlin")
+	jkt := func(y int, m time.Month, d, h int) time.Time { return time.Date(y, m, d, h, 0, 0, 0, jakarta) }
+	ber := func(y int, m time.Month, d, h int) time.Time { return time.Date(y, m, d, h, 0, 0, 0, berlin) }
+
+	tests := []struct {
+		name     string
+		start    time.Time
+		relapses []time.Time
+		now      time.Time
+		from     time.Time
+		period   Period
+		loc      *time.Location
+		want     []PeriodStats
+	}{
+		{
+			name:   "from equals now",
+			start:  jkt(2024, 3, 1, 0),
+			now:    jkt(2024, 3, 5, 0),
+			from:   jkt(2024, 3, 5, 0),
+			period: Day,
+			loc:    jakarta,
+			want:   []PeriodStats{},
+		},
+		{
+			name:   "from after now",
+			start:  jkt(2024, 3, 1, 0),
+			now:    jkt(2024, 3, 5, 0),
+			from:   jkt(2024, 3, 6, 0),
+			period: Day,
+			loc:    jakarta,
+			want:   []PeriodStats{},
+		},
+		{
+			name:     "days with relapse on boundary",
+			start:    jkt(2024, 3, 1, 12),
+			relapses: []time.Time{jkt(2024, 3, 2, 0)},
+			now:      jkt(2024, 3, 3, 6),
+			from:     jkt(2024, 3, 1, 12),
+			period:   Day,
+			loc:      jakarta,
+			want: []PeriodStats{
+				{Start: jkt(2024, 3, 1, 0), End: jkt(2024, 3, 2, 0), CleanSeconds: 12 * hour, LongestStreak: 12 * hour},
+				{Start: jkt(2024, 3, 2, 0), End: jkt(2024, 3, 3, 0), Relapses: 1, CleanSeconds: 24 * hour, LongestStreak: 24 * hour},
+				{Start: jkt(2024, 3, 3, 0), End: jkt(2024, 3, 4, 0), CleanSeconds: 6 * hour, LongestStreak: 6 * hour},
+			},
+		},
+		{
+			name:     "days before start have no clean time",
+			start:    jkt(2024, 3, 2, 0),
+			relapses: []time.Time{jkt(2024, 3, 2, 6)},
+			now:      jkt(2024, 3, 2, 12),
+			from:     jkt(2024, 3, 1, 9),
+			period:   Day,
+			loc:      jakarta,
+			want: []PeriodStats{
+				{Start: jkt(2024, 3, 1, 0), End: jkt(2024, 3, 2, 0)},
+				{Start: jkt(2024, 3, 2, 0), End: jkt(2024, 3, 3, 0), Relapses: 1, CleanSeconds: 12 * hour, LongestStreak: 6 * hour},
+			},
+		},
+		{
+			name:     "relapses before from are not counted",
+			start:    jkt(2024, 3, 1, 0),
+			relapses: []time.Time{jkt(2024, 3, 1, 12)},
+			now:      jkt(2024, 3, 3, 0),
+			from:     jkt(2024, 3, 2, 5),
+			period:   Day,
+			loc:      jakarta,
+			want: []PeriodStats{
+				{Start: jkt(2024, 3, 2, 0), End: jkt(2024, 3, 3, 0), CleanSeconds: 24 * hour, LongestStreak: 24 * hour},
+			},
+		},
+		{
+			name:     "weeks start on monday and streak spans weeks",
+			start:    jkt(2024, 3, 6, 0), // Rabu
+			relapses: []time.Time{jkt(2024, 3, 13, 0)},
+			now:      jkt(2024, 3, 20, 0),
+			from:     jkt(2024, 3, 6, 0),
+			period:   Week,
+			loc:      jakarta,
+			want: []PeriodStats{
+				{Start: jkt(2024, 3, 4, 0), End: jkt(2024, 3, 11, 0), CleanSeconds: 5 * day, LongestStreak: 5 * day},
+				{Start: jkt(2024, 3, 11, 0), End: jkt(2024, 3, 18, 0), Relapses: 1, CleanSeconds: 7 * day, LongestStreak: 5 * day},
+				{Start: jkt(2024, 3, 18, 0), End: jkt(2024, 3, 25, 0), CleanSeconds: 2 * day, LongestStreak: 2 * day},
+			},
+		},
+		{
+			name:     "week relapse on monday midnight",
+			start:    jkt(2024, 3, 4, 0),
+			relapses: []time.Time{jkt(2024, 3, 11, 0)},
+			now:      jkt(2024, 3, 12, 0),
+			from:     jkt(2024, 3, 4, 0),
+			period:   Week,
+			loc:      jakarta,
+			want: []PeriodStats{
+				{Start: jkt(2024, 3, 4, 0), End: jkt(2024, 3, 11, 0), CleanSeconds: 7 * day, LongestStreak: 7 * day},
+				{Start: jkt(2024, 3, 11, 0), End: jkt(2024, 3, 18, 0), Relapses: 1, CleanSeconds: day, LongestStreak: day},
+			},
+		},
+		{
+			name:     "months with relapse on first of month",
+			start:    jkt(2024, 1, 20, 0),
+			relapses: []time.Time{jkt(2024, 2, 1, 0)},
+			now:      jkt(2024, 3, 10, 0),
+			from:     jkt(2024, 1, 25, 0),
+			period:   Month,
+			loc:      jakarta,
+			want: []PeriodStats{
+				{Start: jkt(2024, 1, 1, 0), End: jkt(2024, 2, 1, 0), CleanSeconds: 12 * day, LongestStreak: 12 * day},
+				{Start: jkt(2024, 2, 1, 0), End: jkt(2024, 3, 1, 0), Relapses: 1, CleanSeconds: 29 * day, LongestStreak: 29 * day},
+				{Start: jkt(2024, 3, 1, 0), End: jkt(2024, 4, 1, 0), CleanSeconds: 9 * day, LongestStreak: 9 * day},
+			},
+		},
+		{
+			name:   "month rollover across year",
+			start:  jkt(2023, 12, 31, 12),
+			now:    jkt(2024, 1, 1, 12),
+			from:   jkt(2023, 12, 31, 12),
+			period: Month,
+			loc:    jakarta,
+			want: []PeriodStats{
+				{Start: jkt(2023, 12, 1, 0), End: jkt(2024, 1, 1, 0), CleanSeconds: 12 * hour, LongestStreak: 12 * hour},
+				{Start: jkt(2024, 1, 1, 0), End: jkt(2024, 2, 1, 0), CleanSeconds: 12 * hour, LongestStreak: 12 * hour},
+			},
+		},
+		{
+			name:     "several relapses in one month keep the longest piece",
+			start:    jkt(2024, 4, 1, 0),
+			relapses: []time.Time{jkt(2024, 4, 3, 0), jkt(2024, 4, 20, 0), jkt(2024, 4, 10, 0)},
+			now:      jkt(2024, 4, 25, 0),
+			from:     jkt(2024, 4, 1, 0),
+			period:   Month,
+			loc:      jakarta,
+			want: []PeriodStats{
+				{Start: jkt(2024, 4, 1, 0), End: jkt(2024, 5, 1, 0), Relapses: 3, CleanSeconds: 24 * day, LongestStreak: 10 * day},
+			},
+		},
+		{
+			name:   "spring forward day is 23 hours",
+			start:  ber(2024, 3, 30, 0),
+			now:    ber(2024, 4, 1, 0),
+			from:   ber(2024, 3, 30, 0),
+			period: Day,
+			loc:    berlin,
+			want: []PeriodStats{
+				{Start: ber(2024, 3, 30, 0), End: ber(2024, 3, 31, 0), CleanSeconds: 24 * hour, LongestStreak: 24 * hour},
+				{Start: ber(2024, 3, 31, 0), End: ber(2024, 4, 1, 0), CleanSeconds: 23 * hour, LongestStreak: 23 * hour},
+			},
+		},
+		{
+			name:   "fall back day is 25 hours",
+			start:  ber(2024, 10, 27, 0),
+			now:    ber(2024, 10, 28, 0),
+			from:   ber(2024, 10, 27, 0),
+			period: Day,
+			loc:    berlin,
+			want: []PeriodStats{
+				{Start: ber(2024, 10, 27, 0), End: ber(2024, 10, 28, 0), CleanSeconds: 25 * hour, LongestStreak: 25 * hour},
+			},
+		},
+		{
+			name:     "week across DST change",
+			start:    ber(2024, 3, 25, 0),
+			relapses: []time.Time{ber(2024, 4, 1, 0)},
+			now:      ber(2024, 4, 2, 0),
+			from:     ber(2024, 3, 25, 0),
+			period:   Week,
+			loc:      berlin,
+			want: []PeriodStats{
+				{Start: ber(2024, 3, 25, 0), End: ber(2024, 4, 1, 0), CleanSeconds: 7*day - hour, LongestStreak: 7*day - hour},
+				{Start: ber(2024, 4, 1, 0), End: ber(2024, 4, 8, 0), Relapses: 1, CleanSeconds: day, LongestStreak: day},
+			},
+		},
+		{
+			name:   "invalid period falls back to day",
+			start:  jkt(2024, 3, 1, 0),
+			now:    jkt(2024, 3, 1, 18),
+			from:   jkt(2024, 3, 1, 0),
+			period: Period("year"),
+			loc:    jakarta,
+			want: []PeriodStats{
+				{Start: jkt(2024, 3, 1, 0), End: jkt(2024, 3, 2, 0), CleanSeconds: 18 * hour, LongestStreak: 18 * hour},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Breakdown(tt.start, tt.relapses, tt.now, tt.from, tt.period, tt.loc)
+			if got == nil {
+				t.Fatal("Breakdown returned nil, want a non-nil slice")
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Breakdown returned %d periods, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, want := range tt.want {
+				g := got[i]
+				if !g.Start.Equal(want.Start) || !g.End.Equal(want.End) {
+					t.Errorf("period %d = [%v, %v), want [%v, %v)", i, g.Start, g.End, want.Start, want.End)
+				}
+				if g.Relapses != want.Relapses || g.CleanSeconds != want.CleanSeconds || g.LongestStreak != want.LongestStreak {
+					t.Errorf("period %d stats = {relapses %d clean %d longest %d}, want {relapses %d clean %d longest %d}",
+						i, g.Relapses, g.CleanSeconds, g.LongestStreak, want.Relapses, want.CleanSeconds, want.LongestStreak)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakdownNilLocationIsUTC(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	got := Breakdown(start, nil, start.Add(36*time.Hour), start, Day, nil)
+	if len(got) != 2 {
+		t.Fatalf("Breakdown returned %d periods, want 2", len(got))
+	}
+	if got[1].Start.Location() != time.UTC || got[1].CleanSeconds != 12*hour {
+		t.Errorf("second period = %+v, want a UTC day with 12h clean", got[1])
+	}
+}
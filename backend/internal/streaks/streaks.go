@@ -0,0 +1,116 @@
+// internal/streaks/streaks.go
+// Package streaks menghitung metrik streak dari tanggal mulai dan daftar waktu relapse.
+// Semua durasi dalam detik. Paket ini murni (tanpa DB) sehingga dipakai bersama oleh
+// statistik user, ranking publik, dan ranking admin.
+package streaks
+
+import (
+	"sort"
+	"time"
+)
+
+// Summary adalah hasil perhitungan streak seorang user.
+type Summary struct {
+	// Current adalah durasi sejak relapse terakhir (atau sejak start jika belum pernah relapse).
+	Current int64 `json:"current"`
+	// Longest adalah streak terpanjang, termasuk streak yang sedang berjalan.
+	Longest int64 `json:"longest"`
+	// Average dan Median dihitung dari streak yang sudah selesai (Historical).
+	// Jika belum ada streak yang selesai, keduanya sama dengan Current.
+	Average int64 `json:"average"`
+	Median  int64 `json:"median"`
+	// Historical berisi streak yang sudah selesai (diakhiri relapse), urut kronologis.
+	Historical []int64 `json:"historical"`
+	// TotalRelapses adalah jumlah relapse yang dihitung.
+	TotalRelapses int `json:"total_relapses"`
+	// LastRelapse adalah waktu relapse terbaru, nil jika belum ada.
+	LastRelapse *time.Time `json:"last_relapse,omitempty"`
+}
+
+// timeline mengembalikan start diikuti relapse, diurutkan naik. Input tidak diubah.
+func timeline(start time.Time, relapses []time.Time) []time.Time {
+	points := make([]time.Time, 0, len(relapses)+1)
+	points = append(points, start)
+	points = append(points, relapses...)
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Before(points[j])
+	})
+	return points
+}
+
+// seconds mengubah durasi ke detik dan tidak pernah negatif.
+func seconds(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return int64(d / time.Second)
+}
+
+// Compute menghitung Summary pada waktu now.
+// relapses boleh dalam urutan apa pun; relapse sebelum start tetap dihitung dalam timeline.
+func Compute(start time.Time, relapses []time.Time, now time.Time) Summary {
+	points := timeline(start, relapses)
+
+	summary := Summary{
+		TotalRelapses: len(relapses),
+		Historical:    make([]int64, 0, len(points)-1),
+	}
+
+	for i := 0; i < len(points)-1; i++ {
+		summary.Historical = append(summary.Historical, seconds(points[i+1].Sub(points[i])))
+	}
+
+	// Titik terakhir timeline adalah awal streak yang sedang berjalan. Titik itu bisa start
+	// (jika semua relapse sebelum start), jadi relapse terbaru dicari terpisah.
+	summary.Current = seconds(now.Sub(points[len(points)-1]))
+	for i := range relapses {
+		if summary.LastRelapse == nil || relapses[i].After(*summary.LastRelapse) {
+			last := relapses[i]
+			summary.LastRelapse = &last
+		}
+	}
+
+	summary.Longest = summary.Current
+	for _, s := range summary.Historical {
+		if s > summary.Longest {
+			summary.Longest = s
+		}
+	}
+
+	if len(summary.Historical) == 0 {
+		summary.Average = summary.Current
+		summary.Median = summary.Current
+	} else {
+		summary.Average = Average(summary.Historical)
+		summary.Median = Median(summary.Historical)
+	}
+
+	return summary
+}
+
+// Average mengembalikan rata-rata (dibulatkan ke bawah), 0 untuk slice kosong.
+func Average(values []int64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum int64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / int64(len(values))
+}
+
+// Median mengembalikan median (rata-rata dua nilai tengah untuk jumlah genap), 0 untuk slice kosong.
+func Median(values []int64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
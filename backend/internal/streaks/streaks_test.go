@@ -0,0 +1,116 @@
+package streaks
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const day = int64(24 * 60 * 60)
+
+var t0 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func at(days float64) time.Time {
+	return t0.Add(time.Duration(days * float64(24*time.Hour)))
+}
+
+func TestCompute(t *testing.T) {
+	tests := []struct {
+		name     string
+		relapses []time.Time
+		now      time.Time
+		want     Summary
+		last     *time.Time
+	}{
+		{
+			name: "no relapses",
+			now:  at(10),
+			want: Summary{Current: 10 * day, Longest: 10 * day, Average: 10 * day, Median: 10 * day, Historical: []int64{}},
+		},
+		{
+			name:     "single relapse",
+			relapses: []time.Time{at(4)},
+			now:      at(6),
+			want:     Summary{Current: 2 * day, Longest: 4 * day, Average: 4 * day, Median: 4 * day, Historical: []int64{4 * day}, TotalRelapses: 1},
+			last:     ptr(at(4)),
+		},
+		{
+			name:     "unsorted input",
+			relapses: []time.Time{at(5), at(1), at(3)},
+			now:      at(10),
+			want:     Summary{Current: 5 * day, Longest: 5 * day, Average: 5 * day / 3, Median: 2 * day, Historical: []int64{day, 2 * day, 2 * day}, TotalRelapses: 3},
+			last:     ptr(at(5)),
+		},
+		{
+			name:     "relapses before start",
+			relapses: []time.Time{at(-2), at(-5)},
+			now:      at(1),
+			want:     Summary{Current: day, Longest: 3 * day, Average: 5 * day / 2, Median: 5 * day / 2, Historical: []int64{3 * day, 2 * day}, TotalRelapses: 2},
+			last:     ptr(at(-2)),
+		},
+		{
+			name:     "even median count",
+			relapses: []time.Time{at(1), at(4)},
+			now:      at(4.5),
+			want:     Summary{Current: day / 2, Longest: 3 * day, Average: 2 * day, Median: 2 * day, Historical: []int64{day, 3 * day}, TotalRelapses: 2},
+			last:     ptr(at(4)),
+		},
+		{
+			name:     "odd median count",
+			relapses: []time.Time{at(1), at(4), at(10)},
+			now:      at(10),
+			want:     Summary{Current: 0, Longest: 6 * day, Average: 10 * day / 3, Median: 3 * day, Historical: []int64{day, 3 * day, 6 * day}, TotalRelapses: 3},
+			last:     ptr(at(10)),
+		},
+		{
+			name:     "now before latest relapse",
+			relapses: []time.Time{at(3)},
+			now:      at(2),
+			want:     Summary{Current: 0, Longest: 3 * day, Average: 3 * day, Median: 3 * day, Historical: []int64{3 * day}, TotalRelapses: 1},
+			last:     ptr(at(3)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := append([]time.Time(nil), tt.relapses...)
+			got := Compute(t0, tt.relapses, tt.now)
+
+			if !reflect.DeepEqual(tt.relapses, input) {
+				t.Errorf("Compute mutated relapses: got %v, want %v", tt.relapses, input)
+			}
+			if (got.LastRelapse == nil) != (tt.last == nil) || (got.LastRelapse != nil && !got.LastRelapse.Equal(*tt.last)) {
+				t.Errorf("LastRelapse = %v, want %v", got.LastRelapse, tt.last)
+			}
+			got.LastRelapse = nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compute() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAverageAndMedian(t *testing.T) {
+	tests := []struct {
+		values  []int64
+		average int64
+		median  int64
+	}{
+		{nil, 0, 0},
+		{[]int64{7}, 7, 7},
+		{[]int64{9, 1, 5}, 5, 5},
+		{[]int64{4, 1, 3, 10}, 4, 3},
+	}
+	for _, tt := range tests {
+		if got := Average(tt.values); got != tt.average {
+			t.Errorf("Average(%v) = %d, want %d", tt.values, got, tt.average)
+		}
+		if got := Median(tt.values); got != tt.median {
+			t.Errorf("Median(%v) = %d, want %d", tt.values, got, tt.median)
+		}
+	}
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
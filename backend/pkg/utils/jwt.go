@@ -25,9 +25,11 @@ type UserPayload struct {
 	Username string `json:"username"`
 }
 
-// RefreshClaims represents JWT claims for refresh token
+// RefreshClaims represents JWT claims for refresh token.
+// RegisteredClaims.ID carries the JTI that is tracked in User.RefreshTokens.
 type RefreshClaims struct {
 	UserID     string `json:"userId"`
+	SessionID  string `json:"sid,omitempty"`
 	RememberMe bool   `json:"rememberMe"`
 	jwt.RegisteredClaims
 }
@@ -52,8 +54,9 @@ func GenerateAccessToken(user *models.User) (string, error) {
 	return token.SignedString([]byte(cfg.JWTAccessSecret))
 }
 
-// GenerateRefreshToken generates a new refresh token
-func GenerateRefreshToken(userID primitive.ObjectID, rememberMe bool) (string, error) {
+// GenerateRefreshToken generates a new refresh token bound to a session.
+// The returned entry must be stored in User.RefreshTokens so the token can be rotated.
+func GenerateRefreshToken(userID, sessionID primitive.ObjectID, rememberMe bool) (string, *models.RefreshTokenSchema, error) {
 	cfg := config.Get()
 
 	expiry := 7 * 24 * time.Hour // 7 days
@@ -61,17 +64,36 @@ func GenerateRefreshToken(userID primitive.ObjectID, rememberMe bool) (string, e
 		expiry = 30 * 24 * time.Hour // 30 days
 	}
 
+	jti, err := GenerateRandomToken(16)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	entry := &models.RefreshTokenSchema{
+		JTI:       jti,
+		SessionID: sessionID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(expiry),
+	}
+
 	claims := RefreshClaims{
 		UserID:     userID.Hex(),
+		SessionID:  sessionID.Hex(),
 		RememberMe: rememberMe,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(entry.ExpiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(cfg.JWTRefreshSecret))
+	signed, err := token.SignedString([]byte(cfg.JWTRefreshSecret))
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, entry, nil
 }
 
 // ValidateAccessToken validates and parses an access token
@@ -117,3 +139,97 @@ func ValidateRefreshToken(tokenString string) (*RefreshClaims, error) {
 
 	return nil, fmt.Errorf("invalid token")
 }
+
+// TwoFactorClaims represents the short-lived challenge issued after a correct password
+// when the account has 2FA enabled. It cannot be used as an access token.
+type TwoFactorClaims struct {
+	UserID     string `json:"userId"`
+	RememberMe bool   `json:"rememberMe"`
+	jwt.RegisteredClaims
+}
+
+// twoFactorKey derives a separate signing key so challenge tokens never validate as access tokens
+func twoFactorKey() []byte {
+	return []byte(config.Get().JWTAccessSecret + ":2fa-challenge")
+}
+
+// GenerateTwoFactorChallenge generates a 5-minute challenge token for the second login step
+func GenerateTwoFactorChallenge(userID primitive.ObjectID, rememberMe bool) (string, error) {
+	claims := TwoFactorClaims{
+		UserID:     userID.Hex(),
+		RememberMe: rememberMe,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(5 * time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(twoFactorKey())
+}
+
+// ValidateTwoFactorChallenge validates and parses a 2FA challenge token
+func ValidateTwoFactorChallenge(tokenString string) (*TwoFactorClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &TwoFactorClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return twoFactorKey(), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*TwoFactorClaims); ok && token.Valid {
+		return claims, nil
+	}
+
+	return nil, fmt.Errorf("invalid token")
+}
+
+// ExportDownloadClaims represents a signed download link for a finished data export.
+// The link is bearer-only (no session needed) and expires together with the archive.
+type ExportDownloadClaims struct {
+	ExportID string `json:"exportId"`
+	jwt.RegisteredClaims
+}
+
+// exportDownloadKey derives a separate signing key so download tokens never validate as access tokens
+func exportDownloadKey() []byte {
+	return []byte(config.Get().JWTAccessSecret + ":data-export")
+}
+
+// GenerateExportDownloadToken generates a download token for exportID that is valid until expiresAt
+func GenerateExportDownloadToken(exportID primitive.ObjectID, expiresAt time.Time) (string, error) {
+	claims := ExportDownloadClaims{
+		ExportID: exportID.Hex(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(exportDownloadKey())
+}
+
+// ValidateExportDownloadToken validates and parses a data export download token
+func ValidateExportDownloadToken(tokenString string) (*ExportDownloadClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ExportDownloadClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return exportDownloadKey(), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*ExportDownloadClaims); ok && token.Valid {
+		return claims, nil
+	}
+
+	return nil, fmt.Errorf("invalid token")
+}
@@ -7,14 +7,30 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	
-	"solivra-go/backend/internal/database"
+	"solivra-go/backend/internal/logging"
+	"solivra-go/backend/internal/metrics"
 	"solivra-go/backend/internal/models"
+	"solivra-go/backend/internal/store"
 )
 
+// activityStore adalah tujuan penyimpanan activity log, di-set sekali saat startup.
+var activityStore store.ActivityLogStore
+
+// InitActivityLog menentukan store yang dipakai LogActivity untuk menyimpan log.
+func InitActivityLog(s store.ActivityLogStore) {
+	activityStore = s
+}
+
 // LogActivityInternal is the core function to record activities to the database.
 // This function must run in a separate goroutine and handle its own context.
 func LogActivityInternal(ctx context.Context, action string, details map[string]interface{}, metadata map[string]interface{}) {
-	
+	// Event penting juga dihitung di metrik Prometheus (lihat metrics.RecordEvent)
+	metrics.RecordEvent(action)
+
+	if activityStore == nil {
+		return
+	}
+
 	// Default values
 	username := "system"
 	if uname, ok := metadata["username"].(string); ok && uname != "" {
@@ -45,6 +61,18 @@ func LogActivityInternal(ctx context.Context, action string, details map[string]
 		userAgent = SanitizeString(ua, 512)
 	}
 
+	// Activity dari service yang dipanggil handler membawa request_id lewat ctx
+	if requestID := logging.RequestID(ctx); requestID != "" {
+		if _, ok := details[logging.KeyRequestID]; !ok {
+			withID := make(map[string]interface{}, len(details)+1)
+			for k, v := range details {
+				withID[k] = v
+			}
+			withID[logging.KeyRequestID] = requestID
+			details = withID
+		}
+	}
+
 	// Create log entry
 	logEntry := models.ActivityLog{
 		ID:        primitive.NewObjectID(),
@@ -57,10 +85,8 @@ func LogActivityInternal(ctx context.Context, action string, details map[string]
 		Timestamp: time.Now(),
 	}
 
-	// Insert into MongoDB
-	activityColl := database.GetCollection("activitylogs")
 	// Kita mengabaikan error di sini (swallow logging error) sesuai praktik MERN
-	activityColl.InsertOne(ctx, logEntry)
+	activityStore.Insert(ctx, &logEntry)
 }
 
 // LogActivity is the public wrapper to log activities, usually called from handlers.
@@ -96,16 +122,25 @@ func LogActivity(c *fiber.Ctx, action string, details interface{}, metadata map[
 		}
 	}
 	
-	// 4. Siapkan detail map
+	// 4. Siapkan detail map (disalin agar map milik pemanggil tidak ikut berubah)
 	detailMap := make(map[string]interface{})
 	if d, ok := details.(map[string]interface{}); ok {
-		detailMap = d
+		for k, v := range d {
+			detailMap[k] = v
+		}
 	} else if d, ok := details.(fiber.Map); ok {
 		for k, v := range d {
 			detailMap[k] = v
 		}
 	}
 
+	// 5. Sertakan request_id agar activity log bisa dicocokkan dengan log server
+	if requestID := logging.RequestIDFrom(c); requestID != "" {
+		if _, ok := detailMap[logging.KeyRequestID]; !ok {
+			detailMap[logging.KeyRequestID] = requestID
+		}
+	}
+
 	// --- Jalankan LogActivityInternal di Goroutine ---
 	go func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
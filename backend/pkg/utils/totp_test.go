@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret adalah kunci SHA1 "12345678901234567890" dari RFC 6238 Appendix B dalam base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTPVectors(t *testing.T) {
+	// Vektor RFC 6238 berukuran 8 digit; kode 6 digit adalah 6 digit terakhirnya.
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1111111111, code: "050471"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+		{unix: 20000000000, code: "353130"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			step, ok := ValidateTOTP(rfc6238Secret, tt.code, time.Unix(tt.unix, 0))
+			if !ok {
+				t.Fatalf("ValidateTOTP(%q) at %d rejected a valid code", tt.code, tt.unix)
+			}
+			if want := tt.unix / totpPeriod; step != want {
+				t.Errorf("step = %d, want %d", step, want)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	// "287082" adalah kode untuk langkah 1 (detik 30-59)
+	const code = "287082"
+	tests := []struct {
+		name string
+		unix int64
+		ok   bool
+	}{
+		{name: "one step early", unix: 0, ok: true},
+		{name: "same step start", unix: 30, ok: true},
+		{name: "same step end", unix: 59, ok: true},
+		{name: "one step late", unix: 89, ok: true},
+		{name: "two steps late", unix: 90, ok: false},
+		{name: "far future", unix: 1111111109, ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(rfc6238Secret, code, time.Unix(tt.unix, 0))
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && step != 1 {
+				t.Errorf("step = %d, want 1", step)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPReplayReportsSameStep(t *testing.T) {
+	// Pemanggil menolak replay dengan membandingkan langkah, jadi kode yang sama
+	// harus selalu melaporkan langkah yang sama di dalam jendela toleransi.
+	first, ok := ValidateTOTP(rfc6238Secret, "287082", time.Unix(31, 0))
+	if !ok {
+		t.Fatal("first use rejected")
+	}
+	again, ok := ValidateTOTP(rfc6238Secret, "287082", time.Unix(75, 0))
+	if !ok {
+		t.Fatal("second use rejected by ValidateTOTP itself")
+	}
+	if first != again {
+		t.Errorf("replayed code matched step %d, first use matched %d", again, first)
+	}
+}
+
+func TestValidateTOTPMalformed(t *testing.T) {
+	at := time.Unix(59, 0)
+	tests := []struct {
+		name, secret, code string
+		ok                 bool
+	}{
+		{name: "spaces are ignored", secret: rfc6238Secret, code: " 287 082 ", ok: true},
+		{name: "lowercase secret", secret: "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", code: "287082", ok: true},
+		{name: "too short", secret: rfc6238Secret, code: "28708", ok: false},
+		{name: "too long", secret: rfc6238Secret, code: "2870820", ok: false},
+		{name: "wrong code", secret: rfc6238Secret, code: "000000", ok: false},
+		{name: "invalid secret", secret: "not base32!", code: "287082", ok: false},
+		{name: "empty", secret: rfc6238Secret, code: "", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := ValidateTOTP(tt.secret, tt.code, at); ok != tt.ok {
+				t.Errorf("ok = %v, want %v", ok, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{in: "abcde-12345", want: "abcde12345"},
+		{in: " ABCDE-12345 ", want: "abcde12345"},
+		{in: "abc de-123 45", want: "abcde12345"},
+		{in: "abcde12345", want: "abcde12345"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeRecoveryCode(tt.in); got != tt.want {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("code %q is not in the form xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate code %q", code)
+		}
+		seen[code] = true
+	}
+}
@@ -18,3 +18,21 @@ export const getAdminLogs = async (params) => {
     throw parseError(error, "Gagal memuat log admin");
   }
 };
+
+export const getAdminJobs = async () => {
+  try {
+    const res = await apiClient.get("/admin/jobs");
+    return res.data;
+  } catch (error) {
+    throw parseError(error, "Gagal memuat status job");
+  }
+};
+
+export const getAdminJobRuns = async (name, params) => {
+  try {
+    const res = await apiClient.get(`/admin/jobs/${encodeURIComponent(name)}/runs`, { params });
+    return res.data;
+  } catch (error) {
+    throw parseError(error, "Gagal memuat riwayat job");
+  }
+};
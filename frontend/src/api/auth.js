@@ -10,6 +10,15 @@ export const loginUser = async (credentials) => {
   }
 };
 
+export const verifyTwoFactorLogin = async (payload) => {
+  try {
+    const response = await apiClient.post("/auth/login/2fa", payload);
+    return response.data;
+  } catch (error) {
+    throw parseError(error, "Verifikasi 2FA gagal");
+  }
+};
+
 export const logoutUser = async () => {
   try {
     await apiClient.post("/auth/logout");
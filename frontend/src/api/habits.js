@@ -0,0 +1,78 @@
+// client/src/api/habits.js
+import apiClient, { parseError } from "../utils/apiClient";
+import { debugLog } from "../utils/debugLogger";
+
+export const getHabits = async () => {
+  try {
+    debugLog("habits:list_request");
+    const res = await apiClient.get("/habits");
+    debugLog("habits:list_success", { count: res.data?.habits?.length ?? null });
+    return res.data;
+  } catch (error) {
+    debugLog("habits:list_error", {
+      message: error?.message,
+      status: error?.status,
+    });
+    throw parseError(error, "Failed to get habits");
+  }
+};
+
+export const createHabit = async (habitData) => {
+  try {
+    debugLog("habits:create_request", habitData);
+    const res = await apiClient.post("/habits", habitData);
+    debugLog("habits:create_success", res.data);
+    return res.data;
+  } catch (error) {
+    debugLog("habits:create_error", {
+      message: error?.message,
+      status: error?.status,
+    });
+    throw parseError(error, "Failed to create habit");
+  }
+};
+
+export const updateHabit = async (habitId, habitData) => {
+  try {
+    debugLog("habits:update_request", { habitId, habitData });
+    const res = await apiClient.put(`/habits/${habitId}`, habitData);
+    debugLog("habits:update_success", res.data);
+    return res.data;
+  } catch (error) {
+    debugLog("habits:update_error", {
+      message: error?.message,
+      status: error?.status,
+    });
+    throw parseError(error, "Failed to update habit");
+  }
+};
+
+export const deleteHabit = async (habitId) => {
+  try {
+    debugLog("habits:delete_request", { habitId });
+    const res = await apiClient.delete(`/habits/${habitId}`);
+    debugLog("habits:delete_success", res.data);
+    return res.data;
+  } catch (error) {
+    debugLog("habits:delete_error", {
+      message: error?.message,
+      status: error?.status,
+    });
+    throw parseError(error, "Failed to delete habit");
+  }
+};
+
+export const getHabitStats = async (habitId) => {
+  try {
+    debugLog("habits:stats_request", { habitId });
+    const res = await apiClient.get(`/habits/${habitId}/stats`);
+    debugLog("habits:stats_success", res.data);
+    return res.data;
+  } catch (error) {
+    debugLog("habits:stats_error", {
+      message: error?.message,
+      status: error?.status,
+    });
+    throw parseError(error, "Failed to get habit stats");
+  }
+};
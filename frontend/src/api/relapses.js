@@ -17,10 +17,12 @@ export const addRelapse = async (relapseData) => {
   }
 };
 
-export const getUserRelapses = async () => {
+// filters opsional: habit_id, trigger, tag, location, mood_before, mood_after,
+// min_intensity, max_intensity
+export const getUserRelapses = async (filters = {}) => {
   try {
-    debugLog("relapses:list_request");
-    const res = await apiClient.get("/relapses");
+    debugLog("relapses:list_request", filters);
+    const res = await apiClient.get("/relapses", { params: filters });
     debugLog("relapses:list_success", { count: Array.isArray(res.data) ? res.data.length : null });
     return res.data;
   } catch (error) {
@@ -48,6 +50,25 @@ export const updateRelapse = async (relapseId, relapseData) => {
   }
 };
 
+export const getRelapseHistory = async (relapseId) => {
+  try {
+    debugLog("relapses:history_request", { relapseId });
+    const res = await apiClient.get(`/relapses/${relapseId}/history`);
+    debugLog("relapses:history_success", {
+      relapseId,
+      versions: res.data?.history?.length,
+    });
+    return res.data;
+  } catch (error) {
+    debugLog("relapses:history_error", {
+      relapseId,
+      message: error?.message,
+      status: error?.status,
+    });
+    throw parseError(error, "Failed to load relapse history");
+  }
+};
+
 export const deleteRelapse = async (relapseId) => {
   try {
     debugLog("relapses:delete_request", { relapseId });
@@ -78,3 +99,95 @@ export const deleteAllRelapses = async () => {
     throw parseError(error, "Failed to delete all relapses");
   }
 };
+
+// options: { format, timezone, habitId, sourceHabit, dryRun }
+export const importRelapses = async (file, options = {}) => {
+  const formData = new FormData();
+  formData.append("file", file);
+  const params = {
+    format: options.format || "csv",
+    timezone:
+      options.timezone || Intl.DateTimeFormat().resolvedOptions().timeZone,
+  };
+  if (options.habitId) params.habit_id = options.habitId;
+  if (options.sourceHabit) params.source_habit = options.sourceHabit;
+  if (options.dryRun) params.dry_run = true;
+
+  try {
+    debugLog("relapses:import_request", { ...params, size: file?.size });
+    const res = await apiClient.post("/relapses/import", formData, { params });
+    debugLog("relapses:import_success", {
+      imported: res.data?.imported,
+      to_import: res.data?.to_import,
+    });
+    return res.data;
+  } catch (error) {
+    debugLog("relapses:import_error", {
+      message: error?.message,
+      status: error?.status,
+    });
+    throw parseError(error, "Failed to import relapses");
+  }
+};
+
+// operations: [{ op: "create"|"update_note"|"delete", client_id, id, habit_id,
+//   relapse_time, relapse_note, client_timestamp }]
+// Mengembalikan { results, changes: { relapses, deleted }, cursor, has_more }
+export const syncRelapsesBatch = async (operations = [], cursor = "") => {
+  try {
+    debugLog("relapses:sync_batch_request", {
+      operations: operations.length,
+      hasCursor: Boolean(cursor),
+    });
+    const res = await apiClient.post("/relapses/sync/batch", {
+      operations,
+      cursor,
+    });
+    debugLog("relapses:sync_batch_success", {
+      results: res.data?.results?.length,
+      has_more: res.data?.has_more,
+    });
+    return res.data;
+  } catch (error) {
+    debugLog("relapses:sync_batch_error", {
+      message: error?.message,
+      status: error?.status,
+    });
+    throw parseError(error, "Failed to sync relapses");
+  }
+};
+
+export const restoreRelapse = async (relapseId) => {
+  try {
+    debugLog("relapses:restore_request", { relapseId });
+    const res = await apiClient.post(`/relapses/${relapseId}/restore`);
+    debugLog("relapses:restore_success", { relapseId });
+    return res.data;
+  } catch (error) {
+    debugLog("relapses:restore_error", {
+      relapseId,
+      message: error?.message,
+      status: error?.status,
+    });
+    throw parseError(error, "Failed to restore relapse");
+  }
+};
+
+// deletionId opsional; tanpa deletionId server memulihkan penghapusan massal terakhir
+export const restoreAllRelapses = async (deletionId) => {
+  try {
+    debugLog("relapses:restore_all_request", { deletionId });
+    const res = await apiClient.post(
+      "/relapses/restore",
+      deletionId ? { deletion_id: deletionId } : {},
+    );
+    debugLog("relapses:restore_all_success", res.data);
+    return res.data;
+  } catch (error) {
+    debugLog("relapses:restore_all_error", {
+      message: error?.message,
+      status: error?.status,
+    });
+    throw parseError(error, "Failed to restore relapses");
+  }
+};
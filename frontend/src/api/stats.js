@@ -18,3 +18,12 @@ export const getRankings = async () => {
     throw parseError(error, "Failed to get rankings");
   }
 };
+
+export const getInsights = async (params = {}) => {
+  try {
+    const res = await apiClient.get("/stats/insights", { params });
+    return res.data;
+  } catch (error) {
+    throw parseError(error, "Failed to fetch insights");
+  }
+};
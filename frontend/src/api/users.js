@@ -1,4 +1,5 @@
 import apiClient, { parseError } from "../utils/apiClient";
+import { apiBase } from "./http";
 
 export const getUserData = async () => {
   try {
@@ -84,3 +85,55 @@ export const updateLanguagePreference = async (language) => {
     throw parseError(error, "Gagal memperbarui bahasa");
   }
 };
+
+export const updateRankingVisibility = async (rankingVisibility) => {
+  try {
+    const res = await apiClient.put("/users/privacy", {
+      ranking_visibility: rankingVisibility,
+    });
+    return res.data;
+  } catch (error) {
+    throw parseError(error, "Gagal memperbarui pengaturan privasi");
+  }
+};
+
+export const updateTimezone = async (timezone) => {
+  try {
+    const res = await apiClient.put("/users/timezone", { timezone });
+    return res.data;
+  } catch (error) {
+    throw parseError(error, "Gagal memperbarui zona waktu");
+  }
+};
+
+// Ekspor data selalu diminta secara asinkron dari UI; status dipantau lewat getDataExport
+export const requestDataExport = async () => {
+  try {
+    const res = await apiClient.get("/users/export", { params: { async: true } });
+    return res.data.export;
+  } catch (error) {
+    throw parseError(error, "Gagal meminta ekspor data");
+  }
+};
+
+export const getDataExport = async (exportId) => {
+  try {
+    const res = await apiClient.get(`/users/export/${exportId}`);
+    return res.data.export;
+  } catch (error) {
+    throw parseError(error, "Gagal memuat status ekspor data");
+  }
+};
+
+// download_url dari backend berupa path "/api/exports/..."; ubah menjadi URL absolut
+export const dataExportDownloadUrl = (downloadPath) =>
+  `${apiBase.replace(/\/api$/, "")}${downloadPath}`;
+
+export const getAchievements = async () => {
+  try {
+    const res = await apiClient.get("/users/achievements");
+    return res.data;
+  } catch (error) {
+    throw parseError(error, "Gagal memuat achievement");
+  }
+};
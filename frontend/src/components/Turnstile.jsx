@@ -1,6 +1,11 @@
 // src/components/Turnstile.jsx
 import { useEffect, useRef } from 'react';
 
+// Captcha hanya aktif jika site key diisi. Tanpa site key widget tidak dirender dan form
+// dikirim tanpa token, jadi backend harus memakai CAPTCHA_PROVIDER=none. Provider fake
+// menolak token kosong; pakai hanya untuk pengujian yang mengirim token sendiri.
+export const TURNSTILE_ENABLED = Boolean(import.meta.env.VITE_CLOUDFLARE_TURNSTILE_SITE_KEY);
+
 const Turnstile = ({ onVerify, onFail }) => {
   const ref = useRef(null);
   const widgetIdRef = useRef(null);
@@ -8,7 +8,7 @@ import {
   useMemo,
   useRef,
 } from "react";
-import { loginUser, logoutUser } from "../api/auth";
+import { loginUser, logoutUser, verifyTwoFactorLogin } from "../api/auth";
 import { getUserData } from "../api/users";
 import { getStats } from "../api/stats";
 import { apiBase } from "../api/http";
@@ -317,13 +317,18 @@ export const AuthProvider = ({ children }) => {
   }, []);
 
   const login = useCallback(
-    async ({ username, password, rememberMe }) => {
+    async ({ username, password, rememberMe, cf_turnstile_token }) => {
       debugLog("auth:login_attempt", {
         username,
         rememberMe: Boolean(rememberMe),
       });
       try {
-        const response = await loginUser({ username, password, rememberMe });
+        const response = await loginUser({
+          username,
+          password,
+          rememberMe,
+          cf_turnstile_token,
+        });
         if (!response.ok) {
           debugLog("auth:login_failed_response", {
             username,
@@ -334,6 +339,15 @@ export const AuthProvider = ({ children }) => {
             msg: response.msg || i18n.t("login.errorGeneric"),
           };
         }
+        // Akun dengan 2FA: belum ada token, lanjut ke langkah verifikasi kode
+        if (response.two_factor_required) {
+          debugLog("auth:login_2fa_required", { username });
+          return {
+            ok: false,
+            twoFactorRequired: true,
+            challengeToken: response.challenge_token,
+          };
+        }
         persistAuthTokens({
           accessToken: response.access_token,
           refreshToken: response.refresh_token,
@@ -366,6 +380,41 @@ export const AuthProvider = ({ children }) => {
     [fetchAuthData],
   );
 
+  const verifyTwoFactor = useCallback(
+    async ({ challengeToken, code, recoveryCode }) => {
+      try {
+        const response = await verifyTwoFactorLogin({
+          challenge_token: challengeToken,
+          code: code || undefined,
+          recovery_code: recoveryCode || undefined,
+        });
+        persistAuthTokens({
+          accessToken: response.access_token,
+          refreshToken: response.refresh_token,
+          sessionToken: response.session_token,
+        });
+        await fetchAuthData({ silent: false });
+        debugLog("auth:login_2fa_success", {
+          userId: response.user?.id || response.user?._id,
+        });
+        return { ok: true, user: response.user };
+      } catch (error) {
+        debugLog("auth:login_2fa_error", {
+          message: error?.message,
+          status: error?.status,
+        });
+        return {
+          ok: false,
+          msg: error.message || i18n.t("login.errorGeneric"),
+          status: error?.status,
+          lockout: error.data?.lockout,
+          attemptsRemaining: error.data?.attemptsRemaining,
+        };
+      }
+    },
+    [fetchAuthData],
+  );
+
   const logout = useCallback(async () => {
     const previousKey = getActiveDebugUser();
     debugLog("auth:logout_request", { previousKey });
@@ -402,10 +451,11 @@ export const AuthProvider = ({ children }) => {
       isLoading,
       isAuthenticated: Boolean(userData),
       login,
+      verifyTwoFactor,
       logout,
       refreshData,
     }),
-    [userData, stats, isLoading, login, logout, refreshData],
+    [userData, stats, isLoading, login, verifyTwoFactor, logout, refreshData],
   );
 
   return <AuthContext.Provider value={value}>{children}</AuthContext.Provider>;
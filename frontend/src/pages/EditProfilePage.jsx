@@ -19,6 +19,9 @@ import {
   isStrongPassword,
 } from '../utils/validation';
 
+// Format foto profil yang diterima server
+const ALLOWED_PHOTO_TYPES = ['image/jpeg', 'image/png', 'image/webp'];
+
 const EditProfilePage = () => {
     const { userData, refreshData, logout } = useContext(AuthContext);
     const navigate = useNavigate();
@@ -246,7 +249,7 @@ const EditProfilePage = () => {
         const file = event.target.files?.[0];
         if (!file) return;
 
-        if (!file.type?.startsWith('image/')) {
+        if (!ALLOWED_PHOTO_TYPES.includes(file.type)) {
             toast.error(t('editProfile.photoInvalidType'));
             resetFileInput();
             return;
@@ -459,7 +462,7 @@ const EditProfilePage = () => {
                             </p>
                         </div>
                         {/* File input memicu modal crop sebelum upload */}
-                        <input type="file" ref={fileInputRef} onChange={handleFileChange} className="hidden" accept="image/*" />
+                        <input type="file" ref={fileInputRef} onChange={handleFileChange} className="hidden" accept={ALLOWED_PHOTO_TYPES.join(',')} />
                     </div>
                     <div>
                         <label htmlFor="nickname" className="block text-sm font-medium text-text-secondary mb-2">{t('editProfile.nicknameLabel')}</label>
@@ -6,6 +6,7 @@ import PasswordInput from "../components/PasswordInput";
 import toast from "react-hot-toast"; // Impor toast
 import { useTranslation } from "react-i18next";
 import PublicHeader from "../components/PublicHeader";
+import Turnstile, { TURNSTILE_ENABLED } from "../components/Turnstile";
 
 const LOGIN_LOCKOUT_KEY = "security:login-lockout";
 const REGISTRATION_LOCKOUT_KEY = "security:registration-lockout";
@@ -62,7 +63,12 @@ const LoginPage = () => {
   const [lockoutCountdown, setLockoutCountdown] = useState("");
   const [accountLockInfo, setAccountLockInfo] = useState(null);
   const [accountLockCountdown, setAccountLockCountdown] = useState("");
-  const { login, isAuthenticated } = useContext(AuthContext);
+  const [turnstileToken, setTurnstileToken] = useState(null);
+  const [turnstileKey, setTurnstileKey] = useState(0);
+  const [challengeToken, setChallengeToken] = useState(null);
+  const [twoFactorCode, setTwoFactorCode] = useState("");
+  const [useRecoveryCode, setUseRecoveryCode] = useState(false);
+  const { login, verifyTwoFactor, isAuthenticated } = useContext(AuthContext);
   const navigate = useNavigate();
 
   // Redirect if already authenticated
@@ -122,6 +128,14 @@ const LoginPage = () => {
     return () => clearInterval(timer);
   }, [accountLockInfo, formatCountdown]);
 
+  const handleTurnstileVerify = useCallback((token) => {
+    setTurnstileToken(token);
+  }, []);
+
+  const handleTurnstileFail = useCallback(() => {
+    setTurnstileToken(null);
+  }, []);
+
   // Don't render login form if already authenticated
   if (isAuthenticated) {
     return null;
@@ -158,8 +172,21 @@ const LoginPage = () => {
       setError(t("login.errorMissing"));
       return;
     }
-    const result = await login(formData);
+    const result = await login({
+      ...formData,
+      cf_turnstile_token: turnstileToken,
+    });
+    if (result.twoFactorRequired) {
+      setChallengeToken(result.challengeToken);
+      setTwoFactorCode("");
+      setUseRecoveryCode(false);
+      return;
+    }
     if (!result.ok) {
+      // token turnstile sekali pakai, minta token baru untuk percobaan berikutnya
+      setTurnstileToken(null);
+      setTurnstileKey((key) => key + 1);
+
       if (result.lockout?.type === "user" && result.lockout?.until) {
         setAccountLockInfo({ until: result.lockout.until });
         setError("");
@@ -206,6 +233,10 @@ const LoginPage = () => {
       return;
     }
 
+    handleLoginSuccess();
+  };
+
+  const handleLoginSuccess = () => {
     try {
       localStorage.removeItem(LOGIN_LOCKOUT_KEY);
       localStorage.removeItem(REGISTRATION_LOCKOUT_KEY);
@@ -217,11 +248,60 @@ const LoginPage = () => {
     setLockoutCountdown("");
     setAccountLockInfo(null);
     setAccountLockCountdown("");
+    setChallengeToken(null);
 
     toast.success(t("login.toastWelcome", { username: formData.username }));
     // Navigation will happen automatically via useEffect when isAuthenticated becomes true
   };
 
+  const resetTwoFactor = () => {
+    setChallengeToken(null);
+    setTwoFactorCode("");
+    setUseRecoveryCode(false);
+  };
+
+  const handleTwoFactorSubmit = async (e) => {
+    e.preventDefault();
+    setError("");
+
+    if (!twoFactorCode.trim()) {
+      return;
+    }
+
+    const result = await verifyTwoFactor({
+      challengeToken,
+      code: useRecoveryCode ? undefined : twoFactorCode.trim(),
+      recoveryCode: useRecoveryCode ? twoFactorCode.trim() : undefined,
+    });
+    if (result.ok) {
+      handleLoginSuccess();
+      return;
+    }
+
+    if (result.lockout?.type === "user" && result.lockout?.until) {
+      setAccountLockInfo({ until: result.lockout.until });
+      resetTwoFactor();
+      return;
+    }
+
+    // Challenge token kedaluwarsa: kembali ke form login
+    if (result.status === 401 && typeof result.attemptsRemaining !== "number") {
+      resetTwoFactor();
+      setError(t("login.twoFactorExpired"));
+      return;
+    }
+
+    let message = result.msg || t("login.errorGeneric");
+    if (
+      typeof result.attemptsRemaining === "number" &&
+      result.attemptsRemaining >= 0
+    ) {
+      message = `${message} ${t("login.attemptsRemaining", { count: result.attemptsRemaining })}`;
+    }
+    setTwoFactorCode("");
+    setError(message);
+  };
+
   return (
     <div className="min-h-screen bg-bg text-text-primary">
       <PublicHeader showBackButton={true} />
@@ -229,8 +309,16 @@ const LoginPage = () => {
       <div className="flex items-center justify-center min-h-[calc(100vh-80px)]">
       <div className="w-full max-w-sm p-8 space-y-4">
 
-        <h1 className="text-4xl font-bold">{t("login.title")}</h1>
-        <p className="text-text-secondary">{t("login.subtitle")}</p>
+        <h1 className="text-4xl font-bold">
+          {challengeToken ? t("login.twoFactorTitle") : t("login.title")}
+        </h1>
+        <p className="text-text-secondary">
+          {challengeToken
+            ? useRecoveryCode
+              ? t("login.twoFactorRecoverySubtitle")
+              : t("login.twoFactorSubtitle")
+            : t("login.subtitle")}
+        </p>
 
         {isIpLockActive && (
           <div className="p-3 text-sm bg-secondary border border-border rounded-lg">
@@ -259,6 +347,66 @@ const LoginPage = () => {
           </div>
         )}
 
+        {challengeToken ? (
+          <form onSubmit={handleTwoFactorSubmit} className="space-y-4">
+            <div>
+              <label
+                htmlFor="twoFactorCode"
+                className="block text-sm font-medium text-text-secondary mb-2"
+              >
+                {useRecoveryCode
+                  ? t("login.recoveryCodeLabel")
+                  : t("login.twoFactorCodeLabel")}
+              </label>
+              <input
+                type="text"
+                name="twoFactorCode"
+                id="twoFactorCode"
+                autoComplete="one-time-code"
+                inputMode={useRecoveryCode ? "text" : "numeric"}
+                maxLength={useRecoveryCode ? 16 : 6}
+                value={twoFactorCode}
+                onChange={(event) => setTwoFactorCode(event.target.value)}
+                required
+                autoFocus
+                className="w-full px-4 py-3 text-text-primary bg-surface border border-border rounded-xl focus:outline-none focus:ring-2 focus:ring-primary focus:border-primary transition-colors tracking-widest"
+              />
+            </div>
+
+            <button
+              type="button"
+              onClick={() => {
+                setUseRecoveryCode((prev) => !prev);
+                setTwoFactorCode("");
+                setError("");
+              }}
+              className="text-sm text-primary hover:underline cursor-pointer"
+            >
+              {useRecoveryCode
+                ? t("login.useAuthenticatorCode")
+                : t("login.useRecoveryCode")}
+            </button>
+
+            <button
+              type="submit"
+              disabled={!twoFactorCode.trim()}
+              className="w-full py-4 mt-2 font-bold text-white bg-primary rounded-xl hover:opacity-85 transition disabled:opacity-60 disabled:cursor-not-allowed cursor-pointer"
+            >
+              {t("login.twoFactorSubmit")}
+            </button>
+
+            <button
+              type="button"
+              onClick={() => {
+                resetTwoFactor();
+                setError("");
+              }}
+              className="w-full text-sm text-text-secondary hover:underline cursor-pointer"
+            >
+              {t("login.twoFactorBack")}
+            </button>
+          </form>
+        ) : (
         <form onSubmit={handleSubmit} className="space-y-4">
           <div>
             <label
@@ -309,14 +457,27 @@ const LoginPage = () => {
             </label>
           </div>
 
+          {TURNSTILE_ENABLED && (
+            <div
+              className={`flex justify-center pt-2 ${isFormDisabled ? "opacity-50 pointer-events-none" : ""}`}
+            >
+              <Turnstile
+                key={turnstileKey}
+                onVerify={handleTurnstileVerify}
+                onFail={handleTurnstileFail}
+              />
+            </div>
+          )}
+
           <button
             type="submit"
-            disabled={isFormDisabled}
+            disabled={isFormDisabled || (TURNSTILE_ENABLED && !turnstileToken)}
             className="w-full py-4 mt-2 font-bold text-white bg-primary rounded-xl hover:opacity-85 transition disabled:opacity-60 disabled:cursor-not-allowed cursor-pointer"
           >
             {t("login.submit")}
           </button>
         </form>
+        )}
 
         <div className="text-center pt-4">
           <p className="text-sm text-text-secondary">
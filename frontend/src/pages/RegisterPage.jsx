@@ -5,7 +5,7 @@ import toast from "react-hot-toast";
 import { registerUser } from "../api/auth";
 import axios from "axios";
 import PasswordInput from "../components/PasswordInput";
-import Turnstile from "../components/Turnstile";
+import Turnstile, { TURNSTILE_ENABLED } from "../components/Turnstile";
 import { useTranslation } from "react-i18next";
 import { AuthContext } from "../context/AuthContext";
 import { apiBase } from "../api/http";
@@ -66,6 +66,7 @@ const RegisterPage = () => {
   const [usernameStatus, setUsernameStatus] = useState("idle");
   const [usernameMessage, setUsernameMessage] = useState("");
   const [turnstileToken, setTurnstileToken] = useState(null);
+  const [turnstileKey, setTurnstileKey] = useState(0);
 
   // Redirect if already authenticated
   useEffect(() => {
@@ -226,7 +227,7 @@ const RegisterPage = () => {
       isPasswordStrong &&
       isUsernameAvailable &&
       !isChecking &&
-      !!turnstileToken &&
+      (!TURNSTILE_ENABLED || !!turnstileToken) &&
       !isLockoutActive
     );
   }, [
@@ -277,8 +278,8 @@ const RegisterPage = () => {
         username: canonicalizeUsername(rest.username),
       };
 
-      // kirim token ke server juga kalau endpoint lu verifikasi turnstile
-      // payload.cf_turnstile_token = turnstileToken;
+      // token turnstile diverifikasi server (sekali pakai)
+      payload.cf_turnstile_token = turnstileToken;
 
       await registerUser(payload);
       try {
@@ -294,6 +295,9 @@ const RegisterPage = () => {
       toast.error(err?.message || t("register.errorGeneral"), {
         id: loadingToast,
       });
+      // token sudah terpakai di server, render ulang widget untuk token baru
+      setTurnstileToken(null);
+      setTurnstileKey((key) => key + 1);
       if (err?.data?.lockout?.until) {
         const info = {
           type: err.data.lockout.type,
@@ -410,14 +414,17 @@ const RegisterPage = () => {
             autoComplete="new-password"
           />
 
-          <div
-            className={`flex justify-center pt-2 ${isLockoutActive ? "opacity-50 pointer-events-none" : ""}`}
-          >
-            <Turnstile
-              onVerify={handleTurnstileVerify}
-              onFail={handleTurnstileFail}
-            />
-          </div>
+          {TURNSTILE_ENABLED && (
+            <div
+              className={`flex justify-center pt-2 ${isLockoutActive ? "opacity-50 pointer-events-none" : ""}`}
+            >
+              <Turnstile
+                key={turnstileKey}
+                onVerify={handleTurnstileVerify}
+                onFail={handleTurnstileFail}
+              />
+            </div>
+          )}
 
           <button
             type="submit"
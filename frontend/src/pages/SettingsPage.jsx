@@ -8,7 +8,14 @@ import { usePWAInstall } from "../hooks/usePWAInstall";
 import ConfirmationModal from "../components/ConfirmationModal"; // Impor modal konfirmasi
 import LanguageSelector from "../components/LanguageSelector";
 import { useTranslation } from "react-i18next";
-import { deleteAllRelapses } from "../api/relapses";
+import { deleteAllRelapses, restoreAllRelapses } from "../api/relapses";
+import {
+  dataExportDownloadUrl,
+  getDataExport,
+  requestDataExport,
+  updateRankingVisibility,
+  updateTimezone,
+} from "../api/users";
 import {
   clearDebugLogs,
   debugLog,
@@ -24,6 +31,9 @@ const SettingsPage = () => {
   const [isLogoutModalOpen, setIsLogoutModalOpen] = useState(false); // State untuk modal
   const [isClearRelapseModalOpen, setIsClearRelapseModalOpen] = useState(false);
   const [isClearing, setIsClearing] = useState(false);
+  const [isUpdatingPrivacy, setIsUpdatingPrivacy] = useState(false);
+  const [isUpdatingTimezone, setIsUpdatingTimezone] = useState(false);
+  const [isExporting, setIsExporting] = useState(false);
   const { t } = useTranslation();
   const [debugText, setDebugText] = useState("");
   const [debugCount, setDebugCount] = useState(0);
@@ -61,12 +71,91 @@ const SettingsPage = () => {
     setIsLogoutModalOpen(false);
   };
 
+  // Fungsi untuk mengubah visibilitas di ranking publik
+  const handleRankingVisibilityChange = async (event) => {
+    const visibility = event.target.value;
+    setIsUpdatingPrivacy(true);
+    try {
+      await updateRankingVisibility(visibility);
+      await refreshData({ silent: true });
+      toast.success(t("settings.privacyUpdated"));
+    } catch (error) {
+      toast.error(error.message || t("settings.privacyUpdateFailed"));
+    } finally {
+      setIsUpdatingPrivacy(false);
+    }
+  };
+
+  // Zona waktu browser dipakai sebagai saran bila user belum memilih
+  const browserTimezone = useMemo(
+    () => Intl.DateTimeFormat().resolvedOptions().timeZone || "UTC",
+    []
+  );
+  const timezoneOptions = useMemo(() => {
+    const zones =
+      typeof Intl.supportedValuesOf === "function"
+        ? Intl.supportedValuesOf("timeZone")
+        : [];
+    return Array.from(new Set(["UTC", browserTimezone, ...zones]));
+  }, [browserTimezone]);
+
+  const handleTimezoneChange = async (event) => {
+    const timezone = event.target.value;
+    setIsUpdatingTimezone(true);
+    try {
+      await updateTimezone(timezone);
+      await refreshData({ silent: true });
+      toast.success(t("settings.timezoneUpdated"));
+    } catch (error) {
+      toast.error(error.message || t("settings.timezoneUpdateFailed"));
+    } finally {
+      setIsUpdatingTimezone(false);
+    }
+  };
+
+  // Fungsi untuk meminta ekspor data lalu menunggu arsip siap diunduh
+  const handleExportData = async () => {
+    debugLog("settings:export_requested");
+    setIsExporting(true);
+    const toastId = toast.loading(t("settings.exportPreparing"));
+    try {
+      let job = await requestDataExport();
+      for (let attempt = 0; job.status === "pending" && attempt < 60; attempt++) {
+        await new Promise((resolve) => setTimeout(resolve, 3000));
+        job = await getDataExport(job._id);
+      }
+      if (job.status !== "ready" || !job.download_url) {
+        throw new Error(job.error || t("settings.exportFailed"));
+      }
+      window.location.assign(dataExportDownloadUrl(job.download_url));
+      toast.success(t("settings.exportReady"), { id: toastId });
+    } catch (error) {
+      toast.error(error.message || t("settings.exportFailed"), { id: toastId });
+    } finally {
+      setIsExporting(false);
+    }
+  };
+
   // Fungsi untuk membuka modal clear relapse
   const handleClearRelapseClick = () => {
     debugLog("settings:clear_relapses_modal_open");
     setIsClearRelapseModalOpen(true);
   };
 
+  // Fungsi untuk membatalkan penghapusan semua riwayat relapse
+  const handleRestoreRelapses = async (deletionId, toastId) => {
+    debugLog("settings:restore_relapses", { deletionId });
+    try {
+      await restoreAllRelapses(deletionId);
+      toast.success(t("settings.relapsesRestored"), { id: toastId });
+      await refreshData();
+    } catch (error) {
+      toast.error(error.message || t("settings.restoreRelapsesFailed"), {
+        id: toastId,
+      });
+    }
+  };
+
   // Fungsi untuk menghapus semua riwayat relapse
   const confirmClearRelapses = async () => {
     debugLog("settings:clear_relapses_confirmed");
@@ -78,9 +167,20 @@ const SettingsPage = () => {
       debugLog("settings:clear_relapses_success", {
         deletedCount: result?.deleted_count,
       });
-      toast.success(result.msg || t("settings.relapsesCleared"), {
-        id: loadingToast,
-      });
+      toast.success(
+        (toastItem) => (
+          <span className="flex items-center gap-3">
+            {result.msg || t("settings.relapsesCleared")}
+            <button
+              onClick={() => handleRestoreRelapses(result.deletion_id, toastItem.id)}
+              className="font-semibold text-primary"
+            >
+              {t("settings.restoreRelapses")}
+            </button>
+          </span>
+        ),
+        { id: loadingToast, duration: 8000 },
+      );
       setIsClearRelapseModalOpen(false);
       await refreshData(); // Refresh data untuk update UI
     } catch (error) {
@@ -176,6 +276,72 @@ const SettingsPage = () => {
                 <LanguageSelector />
               </div>
             </div>
+            <div className="p-4 flex flex-col space-y-3">
+              <span className="font-medium">{t("settings.timezoneLabel")}</span>
+              <p className="text-sm text-text-secondary">
+                {t("settings.timezoneDescription", { timezone: browserTimezone })}
+              </p>
+              <select
+                value={userData?.timezone || "UTC"}
+                onChange={handleTimezoneChange}
+                disabled={isUpdatingTimezone}
+                className="px-4 py-2 bg-secondary rounded-lg disabled:opacity-50"
+              >
+                {timezoneOptions.map((zone) => (
+                  <option key={zone} value={zone}>
+                    {zone}
+                  </option>
+                ))}
+              </select>
+            </div>
+          </div>
+        </div>
+
+        {/* Privacy Section */}
+        <div>
+          <h3 className="text-sm uppercase text-text-secondary mb-2">
+            {t("settings.privacy")}
+          </h3>
+          <div className="bg-surface rounded-xl divide-y divide-border">
+            <div className="p-4 flex flex-col space-y-3">
+              <span className="font-medium">
+                {t("settings.rankingVisibilityLabel")}
+              </span>
+              <p className="text-sm text-text-secondary">
+                {t("settings.rankingVisibilityDescription")}
+              </p>
+              <select
+                value={userData?.ranking_visibility || "public"}
+                onChange={handleRankingVisibilityChange}
+                disabled={isUpdatingPrivacy}
+                className="px-4 py-2 bg-secondary rounded-lg disabled:opacity-50"
+              >
+                <option value="public">
+                  {t("settings.rankingVisibilityOptions.public")}
+                </option>
+                <option value="anonymous">
+                  {t("settings.rankingVisibilityOptions.anonymous")}
+                </option>
+                <option value="hidden">
+                  {t("settings.rankingVisibilityOptions.hidden")}
+                </option>
+              </select>
+            </div>
+            <div className="p-4 flex flex-col space-y-3">
+              <span className="font-medium">{t("settings.exportData")}</span>
+              <p className="text-sm text-text-secondary">
+                {t("settings.exportDataDescription")}
+              </p>
+              <button
+                onClick={handleExportData}
+                disabled={isExporting}
+                className="px-4 py-2 bg-secondary rounded-lg font-semibold text-primary disabled:opacity-50"
+              >
+                {isExporting
+                  ? t("settings.exportPreparing")
+                  : t("settings.exportButton")}
+              </button>
+            </div>
           </div>
         </div>
 